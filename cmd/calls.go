@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+)
+
+// printCallSummary writes the number of AWS API calls made, broken down by service operation,
+// so run time can be correlated with API volume
+func printCallSummary(w io.Writer, calls *awsx.CallCounter) {
+	counts := calls.Counts()
+	fmt.Fprintf(w, "AWS API calls: %d\n", calls.Total())
+	for i := range counts {
+		fmt.Fprintf(w, "  %s:%s %d\n", counts[i].Service, counts[i].Operation, counts[i].Count)
+	}
+}
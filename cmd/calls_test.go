@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+)
+
+func TestPrintCallSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls [][2]string
+		want  string
+	}{
+		{
+			name: "no calls",
+			want: "AWS API calls: 0\n",
+		},
+		{
+			name: "sorted by service and operation",
+			calls: [][2]string{
+				{"Lambda", "GetFunction"},
+				{"EC2", "DescribeSubnets"},
+				{"Lambda", "GetFunction"},
+			},
+			want: "AWS API calls: 3\n" +
+				"  EC2:DescribeSubnets 1\n" +
+				"  Lambda:GetFunction 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := awsx.NewCallCounter()
+			for _, call := range tt.calls {
+				counter.Record(call[0], call[1])
+			}
+
+			var buf bytes.Buffer
+			printCallSummary(&buf, counter)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printCallSummary() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+
+	"github.com/pfrederiksen/blast-radius/internal/cost"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// addCostEstimates records an estimated monthly cost on the resources of g. List prices cover
+// RDS instances Cost Explorer has no data for; Cost Explorer, when it answers, takes precedence.
+// A Cost Explorer failure, such as resource-level data not being enabled, only loses its estimates.
+func addCostEstimates(ctx context.Context, client *costexplorer.Client, g *graph.Graph) {
+	estimates := cost.StaticEstimates(g)
+
+	explorerEstimates, err := cost.FromCostExplorer(ctx, client, g, time.Now())
+	if err != nil {
+		slog.Warn("Cost Explorer estimates unavailable; using list prices only", "error", err)
+	}
+	for id, estimate := range explorerEstimates {
+		estimates[id] = estimate
+	}
+
+	cost.Apply(g, estimates)
+	slog.Info("Cost estimates added", "resources", len(estimates))
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/output"
+)
+
+// diffFormat selects how the diff command prints changes
+var diffFormat string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-snapshot> [new-snapshot | resource-identifier]",
+	Short: "Compare a saved snapshot against another snapshot or a fresh discovery",
+	Long: `diff loads a graph snapshot saved with --snapshot-out and prints the nodes and edges
+that were added, removed or changed since.
+
+When the second argument is an existing file it is loaded as the newer snapshot and no AWS
+calls are made. Otherwise a fresh discovery is run, starting from the given resource
+identifier or, by default, the one recorded in the old snapshot.
+
+Nodes are matched by ID and edges by (From, To, RelationType).
+
+Examples:
+  # Save a baseline, then check later whether the blast radius grew
+  blast-radius my-service --snapshot-out baseline.json
+  blast-radius diff baseline.json
+
+  # Compare snapshots taken before and after a deploy, as JSON for automation
+  blast-radius diff before.json after.json --format json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	setupLogging()
+
+	if diffFormat != "text" && diffFormat != "json" {
+		return fmt.Errorf("unknown diff format: %s (must be text or json)", diffFormat)
+	}
+	if dryRun {
+		return fmt.Errorf("--dry-run is not supported by diff")
+	}
+
+	oldGraph, resourceID, err := loadSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+
+	var newGraph *graph.Graph
+	if len(args) > 1 && isSnapshotFile(args[1]) {
+		newGraph, _, err = loadSnapshot(args[1])
+	} else {
+		newGraph, err = discoverForDiff(args, resourceID)
+	}
+	if err != nil {
+		return err
+	}
+
+	diff := graph.Diff(oldGraph, newGraph)
+	slog.Debug("Computed graph diff",
+		"addedNodes", len(diff.AddedNodes),
+		"removedNodes", len(diff.RemovedNodes),
+		"addedEdges", len(diff.AddedEdges),
+		"removedEdges", len(diff.RemovedEdges))
+
+	if diffFormat == "json" {
+		return output.RenderDiffJSON(os.Stdout, &diff)
+	}
+	return output.RenderDiff(os.Stdout, &diff)
+}
+
+// discoverForDiff runs a fresh discovery from the resource given on the command line, falling
+// back to the one recorded in the old snapshot
+func discoverForDiff(args []string, resourceID string) (*graph.Graph, error) {
+	if len(args) > 1 {
+		resourceID = args[1]
+	}
+	if resourceID == "" {
+		return nil, fmt.Errorf("snapshot %s does not record a resource; pass a resource identifier", args[0])
+	}
+
+	ctx, cancel := discoveryContext()
+	defer cancel()
+	return discoverGraph(ctx, resourceID)
+}
+
+// isSnapshotFile reports whether arg names an existing regular file rather than a resource
+func isSnapshotFile(arg string) bool {
+	info, err := os.Stat(arg)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/output"
+)
+
+func TestIsSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "after.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		arg  string
+		want bool
+	}{
+		{name: "Existing file", arg: file, want: true},
+		{name: "Directory", arg: dir, want: false},
+		{name: "Resource identifier", arg: "my-alb", want: false},
+		{name: "ARN", arg: "arn:aws:lambda:us-east-1:123456789012:function:api", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSnapshotFile(tt.arg); got != tt.want {
+				t.Errorf("isSnapshotFile(%q) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+
+	before := graph.New()
+	before.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	before.AddNode(&graph.Node{ID: "tg-old", Type: "TargetGroup", Name: "old-tg"})
+	before.AddEdge(&graph.Edge{From: "lb", To: "tg-old", RelationType: "forwards-to"})
+
+	after := graph.New()
+	after.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	after.AddNode(&graph.Node{ID: "tg-new", Type: "TargetGroup", Name: "new-tg"})
+	after.AddEdge(&graph.Edge{From: "lb", To: "tg-new", RelationType: "forwards-to"})
+
+	beforePath := filepath.Join(dir, "before.json")
+	afterPath := filepath.Join(dir, "after.json")
+	if err := saveSnapshot(beforePath, before, "lb"); err != nil {
+		t.Fatalf("saveSnapshot() error = %v", err)
+	}
+	if err := saveSnapshot(afterPath, after, "lb"); err != nil {
+		t.Fatalf("saveSnapshot() error = %v", err)
+	}
+
+	diffFormat = "json"
+	defer func() { diffFormat = "text" }()
+
+	stdout := captureStdout(t, func() {
+		if err := runDiff(diffCmd, []string{beforePath, afterPath}); err != nil {
+			t.Errorf("runDiff() error = %v", err)
+		}
+	})
+
+	var got output.DiffJSON
+	if err := json.Unmarshal(stdout, &got); err != nil {
+		t.Fatalf("runDiff() printed invalid JSON: %v\n%s", err, stdout)
+	}
+	want := output.DiffSummary{AddedNodes: 1, RemovedNodes: 1, AddedEdges: 1, RemovedEdges: 1, Changed: true}
+	if got.Summary != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary, want)
+	}
+	if len(got.AddedNodes) != 1 || got.AddedNodes[0].ID != "tg-new" {
+		t.Errorf("addedNodes = %v, want [tg-new]", got.AddedNodes)
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+
+	fn()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return <-done
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/output"
+)
+
+// explainFrom and explainTo identify the endpoints of the edges to explain
+var (
+	explainFrom string
+	explainTo   string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <snapshot | resource-identifier> --from <id> --to <id>",
+	Short: "Print the evidence behind the edges between two resources",
+	Long: `explain prints every edge from one resource to another together with the evidence
+that produced it: the AWS API call, each field recorded from the response, and whether the
+edge was inferred heuristically rather than confirmed.
+
+When the argument is an existing file it is loaded as a snapshot saved with --snapshot-out
+and no AWS calls are made. Otherwise a discovery is run from the given resource identifier.
+
+--from and --to match a node's ID or ARN, or failing that its name when exactly one node
+has it.
+
+Examples:
+  # Why does the load balancer depend on this Lambda function?
+  blast-radius explain alb-snapshot.json --from my-alb --to arn:aws:lambda:us-east-1:123456789012:function:api
+
+  # Run a discovery first, then explain an edge found by it
+  blast-radius explain my-service --from my-service --to my-cluster`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().StringVar(&explainFrom, "from", "", "ID, ARN or name of the edge's source resource")
+	explainCmd.Flags().StringVar(&explainTo, "to", "", "ID, ARN or name of the edge's target resource")
+	_ = explainCmd.MarkFlagRequired("from")
+	_ = explainCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	setupLogging()
+
+	if dryRun {
+		return fmt.Errorf("--dry-run is not supported by explain")
+	}
+
+	var g *graph.Graph
+	var err error
+	if isSnapshotFile(args[0]) {
+		g, _, err = loadSnapshot(args[0])
+	} else {
+		ctx, cancel := discoveryContext()
+		defer cancel()
+		g, err = discoverGraph(ctx, args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	return explainEdges(os.Stdout, g, explainFrom, explainTo)
+}
+
+// explainEdges prints the evidence of the edges between the nodes matching from and to
+func explainEdges(w io.Writer, g *graph.Graph, from, to string) error {
+	fromID, err := findNode(g, from)
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	toID, err := findNode(g, to)
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+
+	edges := g.EdgesBetween(fromID, toID)
+	if len(edges) == 0 {
+		if len(g.EdgesBetween(toID, fromID)) > 0 {
+			return fmt.Errorf("no edge from %s to %s, but there is one the other way; swap --from and --to", fromID, toID)
+		}
+		return fmt.Errorf("no edge from %s to %s", fromID, toID)
+	}
+	return output.RenderEdgeEvidence(w, g, edges)
+}
+
+// findNode returns the ID of the node whose ID or ARN is ref, falling back to the only node
+// named ref. Names are not unique, so several matches are reported rather than guessed at.
+func findNode(g *graph.Graph, ref string) (string, error) {
+	if g.HasNode(ref) {
+		return ref, nil
+	}
+
+	var named []string
+	for _, node := range g.SortedNodes() {
+		if node.ARN == ref {
+			return node.ID, nil
+		}
+		if node.Name == ref {
+			named = append(named, node.ID)
+		}
+	}
+
+	switch len(named) {
+	case 0:
+		return "", fmt.Errorf("no resource matches %s", ref)
+	case 1:
+		return named[0], nil
+	default:
+		return "", fmt.Errorf("%d resources are named %s, pass an ID or ARN instead: %s", len(named), ref, strings.Join(named, ", "))
+	}
+}
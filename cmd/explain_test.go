@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestExplainEdges(t *testing.T) {
+	const functionARN = "arn:aws:lambda:us-east-1:123456789012:function:api"
+
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	g.AddNode(&graph.Node{ID: functionARN, ARN: functionARN, Type: "Lambda", Name: "api"})
+	g.AddNode(&graph.Node{ID: "sg-1", Type: "SecurityGroup", Name: "shared"})
+	g.AddNode(&graph.Node{ID: "sg-2", Type: "SecurityGroup", Name: "shared"})
+	g.AddEdge(&graph.Edge{
+		From:         "lb",
+		To:           functionARN,
+		RelationType: "invokes",
+		Evidence: graph.Evidence{
+			APICall: "DescribeTargetHealth",
+			Fields:  map[string]any{"TargetId": functionARN},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    string
+		wantErr string
+	}{
+		{name: "By ID and ARN", from: "lb", to: functionARN, want: "API call:  DescribeTargetHealth"},
+		{name: "By name", from: "my-alb", to: "api", want: "TargetId: " + functionARN},
+		{name: "Reversed", from: "api", to: "my-alb", wantErr: "swap --from and --to"},
+		{name: "No edge", from: "lb", to: "sg-1", wantErr: "no edge from lb to sg-1"},
+		{name: "Unknown resource", from: "missing", to: "lb", wantErr: "--from: no resource matches missing"},
+		{name: "Ambiguous name", from: "lb", to: "shared", wantErr: "2 resources are named shared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := explainEdges(&buf, g, tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("explainEdges() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("explainEdges() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("explainEdges() missing %q in output:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestRunExplainSnapshot(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "api-tg"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "tg", RelationType: "forwards-to", Evidence: graph.Evidence{APICall: "DescribeListeners"}})
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := saveSnapshot(path, g, "lb"); err != nil {
+		t.Fatalf("saveSnapshot() error = %v", err)
+	}
+
+	explainFrom, explainTo = "lb", "tg"
+	defer func() { explainFrom, explainTo = "", "" }()
+
+	stdout := captureStdout(t, func() {
+		if err := runExplain(explainCmd, []string{path}); err != nil {
+			t.Errorf("runExplain() error = %v", err)
+		}
+	})
+	if !strings.Contains(string(stdout), "LoadBalancer my-alb --[forwards-to]--> TargetGroup api-tg") {
+		t.Errorf("runExplain() output:\n%s", stdout)
+	}
+}
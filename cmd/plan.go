@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+	"github.com/pfrederiksen/blast-radius/internal/tfstate"
+)
+
+// printDiscoveryPlan writes the --dry-run plan for resourceID, or for the resources of
+// --from-tfstate, to w. Nodes are only built from ARNs, so the discoverer never calls AWS.
+func printDiscoveryPlan(w io.Writer, resourceID string) error {
+	opts, err := discoveryOptions()
+	if err != nil {
+		return err
+	}
+	discoverer := discover.New(&awsx.Clients{}, opts)
+
+	if fromTFState != "" {
+		resources, loadErr := tfstate.Load(fromTFState)
+		if loadErr != nil {
+			return loadErr
+		}
+		seeds := stateSeeds(discoverer, resources)
+		if len(seeds) == 0 {
+			return fmt.Errorf("no supported AWS resources in %s", fromTFState)
+		}
+		writePlan(w, fmt.Sprintf("%d resources from %s", len(seeds), fromTFState), discoverer.PlanFrom(seeds))
+		return nil
+	}
+
+	plan, err := discoverer.Plan(resourceID)
+	if err != nil {
+		return err
+	}
+	start := resourceID
+	if plan.Start != nil {
+		start = fmt.Sprintf("%s %s (%s)", plan.Start.Type, plan.Start.Name, plan.Start.ARN)
+	}
+	writePlan(w, start, plan)
+	return nil
+}
+
+// writePlan prints the resource types and IAM actions of each level of a plan
+func writePlan(w io.Writer, start string, plan discover.Plan) {
+	fmt.Fprintln(w, "Dry run: no AWS calls were made")
+	if plan.Levels == nil && plan.ResolveActions != nil {
+		fmt.Fprintf(w, "Start: %s is not an ARN, so finding its type needs a resolution call, which may use:\n", start)
+		for _, action := range plan.ResolveActions {
+			fmt.Fprintf(w, "  %s\n", action)
+		}
+		fmt.Fprintln(w, "Pass the resource's ARN to plan the traversal without calling AWS.")
+		return
+	}
+
+	fmt.Fprintf(w, "Start: %s\n", start)
+	actions := make(map[string]bool)
+	for _, level := range plan.Levels {
+		fmt.Fprintf(w, "\nDepth %d: %s\n", level.Depth, strings.Join(level.Types, ", "))
+		for _, action := range level.Actions {
+			fmt.Fprintf(w, "  %s\n", action)
+			actions[action] = true
+		}
+	}
+	fmt.Fprintf(w, "\nUp to %d distinct API actions across %d depths; how often each is called depends on the resources found.\n",
+		len(actions), len(plan.Levels))
+}
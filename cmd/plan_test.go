@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+func TestWritePlanLoadBalancerARN(t *testing.T) {
+	const albARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+	plan, err := discover.New(&awsx.Clients{}, &discover.Options{MaxDepth: 1, SkipRoute53: true}).Plan(albARN)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	writePlan(&buf, "LoadBalancer my-alb ("+albARN+")", plan)
+	got := buf.String()
+
+	for _, want := range []string{
+		"Dry run: no AWS calls were made\nStart: LoadBalancer my-alb (" + albARN + ")\n",
+		"\nDepth 0: LoadBalancer\n  acm:DescribeCertificate\n",
+		"  elasticloadbalancing:DescribeListeners\n",
+		"\nDepth 1: ACMCertificate, Lambda, Subnet, WAFWebACL\n",
+		"  lambda:GetFunction\n",
+		"  wafv2:GetWebACL\n",
+		"across 2 depths",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writePlan() output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "route53:") {
+		t.Errorf("writePlan() lists route53 actions despite SkipRoute53:\n%s", got)
+	}
+}
+
+func TestWritePlanName(t *testing.T) {
+	plan, err := discover.New(&awsx.Clients{}, &discover.Options{MaxDepth: 1}).Plan("my-alb")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	writePlan(&buf, "my-alb", plan)
+	got := buf.String()
+
+	for _, want := range []string{
+		"Start: my-alb is not an ARN, so finding its type needs a resolution call",
+		"  elasticloadbalancing:DescribeLoadBalancers\n",
+		"Pass the resource's ARN",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writePlan() output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Depth") {
+		t.Errorf("writePlan() planned levels for a name:\n%s", got)
+	}
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/spf13/cobra"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+// Preflight command flags
+var (
+	preflightType   string
+	preflightAll    bool
+	preflightProbe  bool
+	preflightFormat string
+)
+
+// Preflight check statuses
+const (
+	preflightUnchecked = "unchecked"
+	preflightAllowed   = "allowed"
+	preflightDenied    = "denied"
+	preflightError     = "error"
+)
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight [resource-identifier]",
+	Short: "List the IAM actions discovery needs and optionally check access",
+	Long: `preflight lists the AWS API actions discovery calls for a starting resource, so a
+read-only policy can be built before a real run. Missing permissions otherwise only show up
+as warnings and a sparser graph.
+
+The actions listed for a resource are those its own discoverer calls, plus the lookups
+needed to resolve a friendly name. Resources found along the way are expanded by their own
+discoverers; use --all for every action a run from any resource may call.
+
+With --probe, preflight calls sts:GetCallerIdentity and one cheap read-only operation per
+service, and reports each action as allowed or denied by its service's probe. A probe only
+shows the service answers; a policy can still deny individual actions.
+
+Examples:
+  # Actions needed to start from a load balancer
+  blast-radius preflight arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/abc
+
+  # Every action, checked against the current credentials, as JSON
+  blast-radius preflight --all --probe --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPreflight,
+}
+
+func init() {
+	preflightCmd.Flags().StringVar(&preflightType, "type", "", "List actions for a resource type (e.g. LoadBalancer) instead of a resource")
+	preflightCmd.Flags().BoolVar(&preflightAll, "all", false, "List every action discovery may call")
+	preflightCmd.Flags().BoolVar(&preflightProbe, "probe", false, "Check access with sts:GetCallerIdentity and one read-only call per service")
+	preflightCmd.Flags().StringVar(&preflightFormat, "format", "text", "Output format: text, json")
+	rootCmd.AddCommand(preflightCmd)
+}
+
+// preflightReport is the result of a preflight run
+type preflightReport struct {
+	ResourceType string           `json:"resourceType,omitempty"`
+	Identity     string           `json:"identity,omitempty"`
+	Checks       []preflightCheck `json:"checks"`
+}
+
+// preflightCheck is the status of one IAM action
+type preflightCheck struct {
+	Action string `json:"action"`
+	Status string `json:"status"`
+	// Probe is the operation called to check the action's service
+	Probe string `json:"probe,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	setupLogging()
+
+	if preflightFormat != "text" && preflightFormat != "json" {
+		return fmt.Errorf("unknown preflight format: %s (must be text or json)", preflightFormat)
+	}
+	selectors := len(args)
+	if preflightType != "" {
+		selectors++
+	}
+	if preflightAll {
+		selectors++
+	}
+	if selectors != 1 {
+		return fmt.Errorf("pass exactly one of a resource identifier, --type or --all")
+	}
+
+	// The arguments are valid; a failure from here on is not a usage error
+	cmd.SilenceUsage = true
+
+	ctx, cancel := discoveryContext()
+	defer cancel()
+
+	// Credentials are only needed to probe or to resolve a friendly name
+	var clients *awsx.Clients
+	var stsClient *sts.Client
+	if preflightProbe || (len(args) == 1 && !strings.HasPrefix(args[0], "arn:")) {
+		cfg, err := awsx.LoadConfig(ctx, profile, region)
+		if err != nil {
+			return fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		clients, err = awsx.NewClientsWithOptions(&cfg, awsx.ClientOptions{CallTimeout: callTimeout})
+		if err != nil {
+			return fmt.Errorf("failed to create AWS clients: %w", err)
+		}
+		stsClient = sts.NewFromConfig(cfg)
+	}
+
+	report, actions, err := preflightActions(ctx, clients, args)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		report.Checks = append(report.Checks, preflightCheck{Action: action, Status: preflightUnchecked})
+	}
+
+	if preflightProbe {
+		report.Checks = append([]preflightCheck{{Action: "sts:GetCallerIdentity", Probe: "GetCallerIdentity"}}, report.Checks...)
+		identity, idErr := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		report.Checks[0].Status, report.Checks[0].Error = probeStatus(idErr)
+		if idErr == nil && identity.Arn != nil {
+			report.Identity = *identity.Arn
+		}
+		probeChecks(report.Checks[1:], func(service string) (string, error) {
+			return clients.Probe(ctx, service)
+		})
+	}
+
+	if preflightFormat == "json" {
+		err = renderPreflightJSON(os.Stdout, report)
+	} else {
+		err = renderPreflight(os.Stdout, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if denied := countStatus(report.Checks, preflightDenied); denied > 0 {
+		return fmt.Errorf("%d of %d actions denied", denied, len(report.Checks))
+	}
+	return nil
+}
+
+// preflightActions returns the actions to check for the selected resource, type or --all
+func preflightActions(ctx context.Context, clients *awsx.Clients, args []string) (*preflightReport, []string, error) {
+	report := &preflightReport{}
+	switch {
+	case preflightAll:
+		return report, discover.AllActions(), nil
+	case preflightType != "":
+		actions := discover.RequiredActions(preflightType)
+		if actions == nil {
+			return nil, nil, fmt.Errorf("no discoverer for resource type %s", preflightType)
+		}
+		report.ResourceType = preflightType
+		return report, actions, nil
+	}
+
+	// ARNs are parsed without calls, so no clients are needed for them
+	resourceID := args[0]
+	if clients == nil {
+		clients = &awsx.Clients{}
+	}
+	node, err := discover.New(clients, &discover.Options{Logger: slog.Default()}).Identify(ctx, resourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.ResourceType = node.Type
+
+	actions := discover.RequiredActions(node.Type)
+	if !strings.HasPrefix(resourceID, "arn:") {
+		actions = append(discover.IdentifyActions(), actions...)
+		slices.Sort(actions)
+		actions = slices.Compact(actions)
+	}
+	return report, actions, nil
+}
+
+// probeChecks sets the status of each check from one probe of its service. Each service is
+// probed once; services without a probe stay unchecked.
+func probeChecks(checks []preflightCheck, probe func(service string) (string, error)) {
+	type result struct {
+		operation string
+		err       error
+	}
+	results := make(map[string]result)
+
+	for i := range checks {
+		service, _, _ := strings.Cut(checks[i].Action, ":")
+		r, ok := results[service]
+		if !ok {
+			r.operation, r.err = probe(service)
+			results[service] = r
+		}
+		if r.operation == "" {
+			continue
+		}
+		checks[i].Probe = r.operation
+		checks[i].Status, checks[i].Error = probeStatus(r.err)
+	}
+}
+
+// probeStatus classifies a probe's error as allowed, denied or another error
+func probeStatus(err error) (status, message string) {
+	switch {
+	case err == nil:
+		return preflightAllowed, ""
+	case awsx.IsAccessDenied(err):
+		return preflightDenied, err.Error()
+	default:
+		return preflightError, err.Error()
+	}
+}
+
+// countStatus returns how many checks have status
+func countStatus(checks []preflightCheck, status string) int {
+	n := 0
+	for i := range checks {
+		if checks[i].Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// renderPreflight writes the report as an aligned action/status table
+func renderPreflight(w io.Writer, report *preflightReport) error {
+	if report.ResourceType != "" {
+		fmt.Fprintf(w, "Resource type: %s\n", report.ResourceType)
+	}
+	if report.Identity != "" {
+		fmt.Fprintf(w, "Caller: %s\n", report.Identity)
+	}
+	if len(report.Checks) == 0 {
+		_, err := fmt.Fprintln(w, "No AWS API calls are made to expand this resource type")
+		return err
+	}
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tSTATUS\tPROBE\tERROR")
+	for i := range report.Checks {
+		check := &report.Checks[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", check.Action, check.Status, check.Probe, check.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Unprobed rows end in empty cells; drop their padding
+	for _, line := range strings.SplitAfter(table.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " \n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderPreflightJSON writes the report as indented JSON
+func renderPreflightJSON(w io.Writer, report *preflightReport) error {
+	if report.Checks == nil {
+		report.Checks = []preflightCheck{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
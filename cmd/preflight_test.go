@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+func TestProbeChecks(t *testing.T) {
+	checks := []preflightCheck{
+		{Action: "lambda:GetFunction", Status: preflightUnchecked},
+		{Action: "lambda:GetPolicy", Status: preflightUnchecked},
+		{Action: "iam:GetRole", Status: preflightUnchecked},
+		{Action: "kms:DescribeKey", Status: preflightUnchecked},
+		{Action: "sqs:GetQueueAttributes", Status: preflightUnchecked},
+	}
+
+	probed := make(map[string]int)
+	probeChecks(checks, func(service string) (string, error) {
+		probed[service]++
+		switch service {
+		case "lambda":
+			return "ListFunctions", nil
+		case "iam":
+			return "ListRoles", &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"}
+		case "kms":
+			return "ListKeys", errors.New("connection reset")
+		default:
+			return "", errors.New("no probe")
+		}
+	})
+
+	want := []struct{ status, probe string }{
+		{preflightAllowed, "ListFunctions"},
+		{preflightAllowed, "ListFunctions"},
+		{preflightDenied, "ListRoles"},
+		{preflightError, "ListKeys"},
+		{preflightUnchecked, ""},
+	}
+	for i, w := range want {
+		if checks[i].Status != w.status || checks[i].Probe != w.probe {
+			t.Errorf("%s = %s via %q, want %s via %q", checks[i].Action, checks[i].Status, checks[i].Probe, w.status, w.probe)
+		}
+	}
+	if probed["lambda"] != 1 {
+		t.Errorf("lambda probed %d times, want once per service", probed["lambda"])
+	}
+	if checks[2].Error == "" {
+		t.Error("denied check has no error message")
+	}
+}
+
+func TestPreflightActionsForARN(t *testing.T) {
+	previousType, previousAll := preflightType, preflightAll
+	preflightType, preflightAll = "", false
+	defer func() { preflightType, preflightAll = previousType, previousAll }()
+
+	// ARNs are identified offline, so no clients are needed
+	report, actions, err := preflightActions(context.Background(), nil, []string{"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"})
+	if err != nil {
+		t.Fatalf("preflightActions() error = %v", err)
+	}
+	if report.ResourceType != discover.ResourceTypeKMSKey {
+		t.Errorf("ResourceType = %q, want %s", report.ResourceType, discover.ResourceTypeKMSKey)
+	}
+	if want := discover.RequiredActions(discover.ResourceTypeKMSKey); strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+}
+
+func TestRenderPreflight(t *testing.T) {
+	report := &preflightReport{
+		ResourceType: "Lambda",
+		Identity:     "arn:aws:sts::123456789012:assumed-role/ReadOnly/me",
+		Checks: []preflightCheck{
+			{Action: "lambda:GetFunction", Status: preflightAllowed, Probe: "ListFunctions"},
+			{Action: "events:ListRules", Status: preflightUnchecked},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := renderPreflight(&buf, report); err != nil {
+		t.Fatalf("renderPreflight() error = %v", err)
+	}
+
+	want := `Resource type: Lambda
+Caller: arn:aws:sts::123456789012:assumed-role/ReadOnly/me
+ACTION              STATUS     PROBE          ERROR
+lambda:GetFunction  allowed    ListFunctions
+events:ListRules    unchecked
+`
+	if buf.String() != want {
+		t.Errorf("renderPreflight() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+// progressReporter rewrites a single status line on a terminal as discovery progresses
+type progressReporter struct {
+	w       io.Writer
+	written bool
+}
+
+// newProgressReporter returns a reporter writing to f, or nil when f or stdout is not a
+// terminal (output is piped or redirected), debug logging would interleave with it or --quiet is set
+func newProgressReporter(f *os.File) *progressReporter {
+	if debug || quiet || !isTerminal(os.Stdout) || !isTerminal(f) {
+		return nil
+	}
+	return &progressReporter{w: f}
+}
+
+// update replaces the status line with the current graph size and depth
+func (p *progressReporter) update(event discover.ProgressEvent) {
+	fmt.Fprintf(p.w, "\r\033[KDiscovered %d nodes, %d edges at depth %d...", event.Nodes, event.Edges, event.Depth)
+	p.written = true
+}
+
+// done clears the status line so later output starts on a clean line. It is safe on a nil reporter.
+func (p *progressReporter) done() {
+	if p != nil && p.written {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+}
+
+// isTerminal reports whether f is attached to a character device such as a TTY
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressReporter{w: &buf}
+
+	p.done()
+	if buf.Len() != 0 {
+		t.Errorf("done() before any update wrote %q, want nothing", buf.String())
+	}
+
+	p.update(discover.ProgressEvent{Nodes: 87, Edges: 142, Depth: 2})
+	if !strings.Contains(buf.String(), "Discovered 87 nodes, 142 edges at depth 2...") {
+		t.Errorf("update() wrote %q", buf.String())
+	}
+
+	buf.Reset()
+	p.done()
+	if buf.String() != "\r\033[K" {
+		t.Errorf("done() wrote %q, want line clear", buf.String())
+	}
+
+	// A nil reporter (non-terminal output) is a no-op
+	var none *progressReporter
+	none.done()
+}
+
+func TestNewProgressReporterNotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "progress")
+	if err != nil {
+		t.Fatalf("CreateTemp() error = %v", err)
+	}
+	defer f.Close()
+
+	if newProgressReporter(f) != nil {
+		t.Error("newProgressReporter() expected nil for a regular file")
+	}
+}
@@ -3,26 +3,81 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/discover"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 	"github.com/pfrederiksen/blast-radius/internal/output"
+	"github.com/pfrederiksen/blast-radius/internal/tfstate"
 )
 
 var (
 	// Global flags
-	profile    string
-	region     string
-	depth      int
-	format     string
-	maxNodes   int
-	debug      bool
-	heuristics []string
+	profile      string
+	region       string
+	depth        int
+	format       string
+	maxNodes     int
+	maxEdges     int
+	debug        bool
+	quiet        bool
+	heuristics   []string
+	excludeTypes []string
+	includeTypes []string
+	excludeARNs  []string
+	excludeNames []string
+	depthFor     []string
+	timeout      time.Duration
+	callTimeout  time.Duration
+	outputFile   string
+	snapshotIn   string
+	snapshotOut  string
+	assumeRoles  []string
+	externalID   string
+	accountID    string
+	showEvidence bool
+	treeStyle    string
+
+	filterRegion  string
+	filterAccount string
+
+	includeRuntime bool
+	regionFilters  []string
+	skipRoute53    bool
+	skipIAM        bool
+
+	dryRun bool
+
+	fromTFState string
+
+	withCost bool
+
+	highlightExposure bool
+	confirmedOnly     bool
+	pruneLeaves       []string
+	pruneIterative    bool
+	bundleEdges       bool
+	jsonLevels        bool
+	groupBy           string
+	dotStyles         []string
+	validate          bool
+	labelTemplate     labelTemplateFlag
+	labelTags         []string
 )
 
 var rootCmd = &cobra.Command{
@@ -53,8 +108,15 @@ Examples:
   blast-radius my-rds-instance --depth 3
 
   # Enable heuristics for RDS endpoint discovery
-  blast-radius my-rds --heuristics rds-endpoint`,
-	Args: cobra.ExactArgs(1),
+  blast-radius my-rds --heuristics rds-endpoint
+
+  # Discover from every resource a Terraform state manages
+  blast-radius --from-tfstate terraform.tfstate --format json
+
+  # Save a snapshot, then re-render it offline
+  blast-radius my-alb --snapshot-out alb.json
+  blast-radius --snapshot-in alb.json --format dot`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runGraph,
 }
 
@@ -65,78 +127,635 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringVar(&profile, "profile", "", "AWS profile to use")
-	rootCmd.Flags().StringVar(&region, "region", "", "AWS region (default: from config/environment)")
-	rootCmd.Flags().IntVar(&depth, "depth", 2, "Maximum traversal depth")
-	rootCmd.Flags().StringVar(&format, "format", "tree", "Output format: tree, dot, json")
-	rootCmd.Flags().IntVar(&maxNodes, "max-nodes", 250, "Maximum nodes to discover")
-	rootCmd.Flags().BoolVar(&debug, "debug", false, "Enable debug logging")
-	rootCmd.Flags().StringSliceVar(&heuristics, "heuristics", []string{}, "Enable heuristics: env-arn, rds-endpoint")
+	// Discovery flags are shared with subcommands that run discovery
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "AWS profile to use")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "AWS region (default: from config/environment)")
+	rootCmd.PersistentFlags().IntVar(&depth, "depth", 2, "Maximum traversal depth")
+	rootCmd.PersistentFlags().IntVar(&maxNodes, "max-nodes", 250, "Maximum nodes to discover")
+	rootCmd.PersistentFlags().IntVar(&maxEdges, "max-edges", 2000, "Maximum edges to discover, 0 for no limit")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug logging")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log errors, and skip the progress line and API call summary on stderr")
+	rootCmd.PersistentFlags().StringSliceVar(&heuristics, "heuristics", []string{}, "Enable heuristics: env-arn, rds-endpoint, iam-policy")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeTypes, "exclude-types", []string{}, "Resource types to leave out of the graph (e.g. IAMRole,ScalingPolicy)")
+	rootCmd.PersistentFlags().StringSliceVar(&includeTypes, "include-types", []string{}, "Only add these resource types to the graph (the starting resource is always included)")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeARNs, "exclude-arn", []string{}, "Glob of resource ARNs (or IDs) to leave out and not traverse through; repeatable")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeNames, "exclude-name", []string{}, "Glob of resource names to leave out and not traverse through; repeatable")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 5*time.Minute, "Stop discovery after this long and render what was found (0 disables)")
+	rootCmd.PersistentFlags().DurationVar(&callTimeout, "call-timeout", awsx.DefaultCallTimeout, "Fail a single AWS API call, including its retries, after this long and move on (0 disables)")
+	rootCmd.PersistentFlags().StringArrayVar(&depthFor, "depth-for", []string{}, "Lower the traversal depth for one resource type, as Type=N (repeatable, e.g. IAMRole=0)")
+	rootCmd.PersistentFlags().StringArrayVar(&regionFilters, "region-filter", []string{}, "Only follow resources into this region during discovery (repeatable); others are shown but not expanded")
+	rootCmd.PersistentFlags().BoolVar(&includeRuntime, "include-runtime", false, "Add running ECS tasks and the container instances or network interfaces they run on")
+	rootCmd.PersistentFlags().BoolVar(&skipRoute53, "skip-route53", false, "Skip looking up Route 53 records that point at load balancers, which scans every hosted zone")
+	rootCmd.PersistentFlags().BoolVar(&skipIAM, "skip-iam", false, "Keep IAM roles as leaves instead of reading their trust and permission policies")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the resource types and AWS API calls discovery would use at each depth, without calling AWS")
+
+	rootCmd.PersistentFlags().StringArrayVar(&assumeRoles, "assume-role", []string{}, "IAM role ARN to assume for discovery in its account (repeatable, one per account)")
+	rootCmd.PersistentFlags().StringVar(&externalID, "external-id", "", "External ID to pass when assuming roles")
+	rootCmd.PersistentFlags().StringVar(&accountID, "account-id", "", "Account the starting resource lives in, selecting which --assume-role to start with")
+
+	rootCmd.Flags().StringVar(&snapshotIn, "snapshot-in", "", "Load a previously saved graph snapshot instead of calling AWS")
+	rootCmd.Flags().StringVar(&fromTFState, "from-tfstate", "", "Start discovery from the AWS resources managed in a Terraform state file (terraform.tfstate or terraform show -json)")
+	rootCmd.Flags().BoolVar(&withCost, "with-cost", false, "Estimate the monthly cost of each resource from Cost Explorer and list prices (Cost Explorer charges per request)")
+	addRenderFlags(rootCmd.Flags())
+
+	rootCmd.MarkFlagsMutuallyExclusive("debug", "quiet")
+	rootCmd.SetGlobalNormalizationFunc(normalizeFlagName)
+}
+
+// addRenderFlags registers the output flags shared by commands that render a graph
+func addRenderFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&format, "format", "tree", "Output format: tree, dot, json, json-tree, jsonl, csv, plantuml")
+	flags.BoolVar(&showEvidence, "show-evidence", false, "Show the API call and fields behind each relationship in tree output")
+	flags.StringVar(&treeStyle, "tree-style", output.TreeStyleLevels, "Tree output style: levels, nested")
+	flags.StringVarP(&outputFile, "output", "o", "", "Write output to a file instead of stdout")
+	flags.StringVar(&snapshotOut, "snapshot-out", "", "Save the full discovered graph to a snapshot file")
+	flags.StringVar(&filterRegion, "filter-region", "", "Only show resources in this region (the starting resource is always shown)")
+	flags.StringVar(&filterAccount, "filter-account", "", "Only show resources in this account (the starting resource is always shown)")
+	flags.BoolVar(&highlightExposure, "highlight-exposure", false, "Flag resources reachable from internet-facing entry points in tree and dot output")
+	flags.BoolVar(&confirmedOnly, "confirmed-only", false, "Hide heuristic relationships and the resources only they connect")
+	flags.StringSliceVar(&pruneLeaves, "prune-leaves", []string{}, "Hide resources of these types that have no dependencies, counting them on their parents instead (e.g. Subnet,SecurityGroup)")
+	flags.BoolVar(&pruneIterative, "prune-leaves-iterative", false, "Keep pruning with --prune-leaves while removing leaves exposes new ones")
+	flags.BoolVar(&jsonLevels, "json-levels", false, "Group json output into BFS levels from the starting resource, as in tree output")
+	flags.StringVar(&groupBy, "group-by", output.DOTGroupByVPC, "Cluster dot output by: vpc, region, account")
+	flags.StringArrayVar(&dotStyles, "dot-style", []string{}, "Override how one resource type is drawn in dot output, as Type=shape[:fillcolor[:outline]] (repeatable, e.g. Lambda=hexagon:orange)")
+	flags.BoolVar(&bundleEdges, "bundle-edges", false, "Merge fan-in and fan-out edges of the same relation into one labeled edge in dot output")
+	flags.Var(&labelTemplate, "label-template", `Go text/template for node labels in tree, dot and plantuml output, e.g. '{{.Name}} ({{.Type}})'; fields are ID, Type, Name, ARN, Region, Account and {{meta . "key"}} reads metadata`)
+	flags.StringSliceVar(&labelTags, "label-tags", []string{}, "Append the values of these tags to node labels in tree and dot output (e.g. team,owner)")
+	flags.BoolVar(&validate, "validate", false, "Check the graph for edges to missing resources, duplicate edges and self-loops, printing any found to stderr before rendering")
 }
 
-func runGraph(cmd *cobra.Command, args []string) error {
-	// Setup logging
-	logLevel := slog.LevelInfo
-	if debug {
-		logLevel = slog.LevelDebug
+// normalizeFlagName maps flag aliases to the flag they stand for
+func normalizeFlagName(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "max-depth-type":
+		name = "depth-for"
 	}
+	return pflag.NormalizedName(name)
+}
+
+// setupLogging configures the default logger on stderr, honoring --debug and --quiet
+func setupLogging() {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logLevel,
+		Level: logLevel(),
 	}))
 	slog.SetDefault(logger)
+}
+
+// logLevel returns the slog level selected by --debug and --quiet
+func logLevel() slog.Level {
+	switch {
+	case debug:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
 
-	resourceID := args[0]
-	ctx := context.Background()
+func runGraph(cmd *cobra.Command, args []string) (err error) {
+	setupLogging()
+
+	if fromTFState != "" && (len(args) > 0 || snapshotIn != "") {
+		return fmt.Errorf("--from-tfstate cannot be combined with a resource identifier or --snapshot-in")
+	}
+	if len(args) == 0 && snapshotIn == "" && fromTFState == "" {
+		return fmt.Errorf("a resource identifier is required unless --snapshot-in or --from-tfstate is set")
+	}
+
+	// A state file stands in for the identifier, e.g. in saved snapshots
+	resourceID := fromTFState
+	if len(args) > 0 {
+		resourceID = args[0]
+	}
+	// A dry run plans discovery without calling AWS and renders no graph
+	if dryRun {
+		if snapshotIn != "" {
+			return fmt.Errorf("--dry-run cannot be combined with --snapshot-in")
+		}
+		return printDiscoveryPlan(os.Stdout, resourceID)
+	}
+
+	var g *graph.Graph
+	if snapshotIn != "" {
+		g, resourceID, err = loadSnapshot(snapshotIn)
+	} else {
+		ctx, cancel := discoveryContext()
+		g, err = discoverGraph(ctx, resourceID)
+		cancel()
+	}
+	if err != nil {
+		return err
+	}
+
+	return outputGraph(g, resourceID)
+}
+
+// outputGraph saves a graph snapshot if requested, applies the render-time filters and writes
+// the graph in the selected format. resourceID is the start node when the graph has no root.
+func outputGraph(g *graph.Graph, resourceID string) (err error) {
+	if validate {
+		printValidationIssues(os.Stderr, g.Validate())
+	}
+
+	if snapshotOut != "" {
+		if err = saveSnapshot(snapshotOut, g, resourceID); err != nil {
+			return err
+		}
+		slog.Info("Snapshot saved", "path", snapshotOut)
+	}
+
+	// Render the tree from the discovered root when known
+	startID := resourceID
+	if root := g.Root(); root != "" {
+		startID = root
+	}
+
+	// Heuristic edges are dropped at render time so one snapshot can be rendered both ways
+	if confirmedOnly {
+		confirmed := g.ConfirmedOnly()
+		slog.Debug("Dropped heuristic relationships",
+			"edges", g.EdgeCount()-confirmed.EdgeCount(),
+			"nodes", g.NodeCount()-confirmed.NodeCount())
+		g = confirmed
+	}
 
+	// Exposure is computed before filtering so paths through hidden resources still count
+	var exposed map[string]bool
+	if highlightExposure {
+		exposed = g.ReachableFrom(discover.IsInternetEntryPoint)
+		slog.Debug("Computed internet exposure", "reachable", len(exposed))
+	}
+
+	// Low-signal leaves are folded into counts on their parents
+	if len(pruneLeaves) > 0 {
+		removed := g.PruneLeaves(pruneIterative, pruneLeaves...)
+		slog.Debug("Pruned leaf resources", "types", pruneLeaves, "nodes", removed)
+	}
+
+	// Snapshots keep the full graph; filters only narrow what is rendered
+	g = filterGraph(g, startID)
+
+	// A CSV export to a directory is split into node and edge files
+	if format == "csv" && isOutputDir(outputFile) {
+		return writeCSVFiles(outputFile, g)
+	}
+
+	// Output results
+	var w io.Writer = os.Stdout
+	if outputFile != "" {
+		f, openErr := openOutputFile(outputFile)
+		if openErr != nil {
+			return openErr
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to close output file %s: %w", outputFile, closeErr)
+			}
+		}()
+		w = f
+	}
+
+	return render(w, g, startID, exposed)
+}
+
+// printValidationIssues writes the structural problems --validate found in a graph
+func printValidationIssues(w io.Writer, issues []graph.ValidationIssue) {
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "graph validation: no issues found")
+		return
+	}
+	fmt.Fprintf(w, "graph validation: %d issues found\n", len(issues))
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  %s: %s\n", issue.Kind, issue.Message)
+	}
+}
+
+// filterGraph prunes resources outside --filter-region and --filter-account, keeping the start node
+func filterGraph(g *graph.Graph, startID string) *graph.Graph {
+	if filterRegion == "" && filterAccount == "" {
+		return g
+	}
+
+	filtered := g.Filter(func(node *graph.Node) bool {
+		if node.ID == startID {
+			return true
+		}
+		return (filterRegion == "" || node.Region == filterRegion) &&
+			(filterAccount == "" || node.Account == filterAccount)
+	})
+	slog.Debug("Filtered graph",
+		"region", filterRegion,
+		"account", filterAccount,
+		"nodes", filtered.NodeCount(),
+		"pruned", g.NodeCount()-filtered.NodeCount())
+
+	return filtered
+}
+
+// discoveryContext returns the context live discovery runs under, bounded by --timeout and
+// canceled on Ctrl-C or SIGTERM so what was found so far is still rendered. Calling the
+// returned cancel func restores default signal handling, so a second Ctrl-C exits at once.
+func discoveryContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// discoveryOptions builds the discovery options selected by the command-line flags
+func discoveryOptions() (*discover.Options, error) {
+	depthOverrides, err := parseDepthOverrides(depthFor)
+	if err != nil {
+		return nil, err
+	}
+	return &discover.Options{
+		MaxDepth:       depth,
+		MaxNodes:       maxNodes,
+		MaxEdges:       maxEdges,
+		Heuristics:     heuristics,
+		ExcludeTypes:   excludeTypes,
+		IncludeTypes:   includeTypes,
+		ExcludeARNs:    excludeARNs,
+		ExcludeNames:   excludeNames,
+		DepthOverrides: depthOverrides,
+		IncludeRuntime: includeRuntime,
+		RegionFilters:  regionFilters,
+		SkipRoute53:    skipRoute53,
+		SkipIAM:        skipIAM,
+		Logger:         slog.Default(),
+	}, nil
+}
+
+// discoverGraph runs live discovery against AWS starting from resourceID
+func discoverGraph(ctx context.Context, resourceID string) (*graph.Graph, error) {
 	slog.Info("Starting blast-radius discovery",
 		"resource", resourceID,
 		"depth", depth,
 		"maxNodes", maxNodes,
+		"maxEdges", maxEdges,
 		"format", format)
 
+	// A state file is read before any AWS call so a bad file fails fast
+	var stateResources []tfstate.Resource
+	if fromTFState != "" {
+		var stateErr error
+		stateResources, stateErr = tfstate.Load(fromTFState)
+		if stateErr != nil {
+			return nil, stateErr
+		}
+	}
+
 	// Load AWS config
 	cfg, err := awsx.LoadConfig(ctx, profile, region)
 	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
 	slog.Debug("AWS config loaded",
 		"region", cfg.Region,
 		"profile", profile)
 
-	// Initialize clients
-	clients, err := awsx.NewClients(&cfg)
+	// Assume roles for cross-account discovery
+	accountConfigs, err := awsx.RoleConfigs(&cfg, sts.NewFromConfig(cfg), assumeRoles, externalID)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS clients: %w", err)
+		return nil, fmt.Errorf("failed to configure assumed roles: %w", err)
+	}
+	primaryCfg, err := primaryConfig(&cfg, accountConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize clients; clients for other regions are created as discovery reaches them.
+	// Each call gets its own --call-timeout so one hung call cannot use up the whole --timeout.
+	// Every call is counted and summarized on stderr once the run ends.
+	calls := awsx.NewCallCounter()
+	if !quiet {
+		defer printCallSummary(os.Stderr, calls)
+	}
+	clientOptions := awsx.ClientOptions{CallTimeout: callTimeout, Calls: calls}
+	provider, err := awsx.NewClientProvider(primaryCfg, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS clients: %w", err)
+	}
+	clients, err := provider.ForRegion("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS clients: %w", err)
+	}
+
+	accountClients := make(map[string]*awsx.ClientProvider, len(accountConfigs))
+	for account := range accountConfigs {
+		accountCfg := accountConfigs[account]
+		accountClients[account], err = awsx.NewClientProvider(&accountCfg, clientOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS clients for account %s: %w", account, err)
+		}
+	}
+
+	opts, err := discoveryOptions()
+	if err != nil {
+		return nil, err
 	}
 
 	// Create graph
 	g := graph.New()
 
-	// Discover dependencies
-	discoverer := discover.New(clients, &discover.Options{
-		MaxDepth:   depth,
-		MaxNodes:   maxNodes,
-		Heuristics: heuristics,
-	})
+	// Show live progress on an interactive terminal
+	progress := newProgressReporter(os.Stderr)
+	if progress != nil {
+		opts.OnProgress = progress.update
+	}
+
+	discoverer := discover.New(clients, opts)
+	discoverer.SetRegionalClients(provider)
+	discoverer.SetAccountClients(accountClients)
+
+	var seeds []*graph.Node
+	if fromTFState != "" {
+		seeds = stateSeeds(discoverer, stateResources)
+		if len(seeds) == 0 {
+			return nil, fmt.Errorf("no supported AWS resources in %s", fromTFState)
+		}
+	}
+
+	var result discover.Result
+	if seeds != nil {
+		result, err = discoverer.DiscoverFrom(ctx, seeds, g)
+	} else {
+		result, err = discoverer.Discover(ctx, resourceID, g)
+	}
+	progress.done()
+	if err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
 
-	if err := discoverer.Discover(ctx, resourceID, g); err != nil {
-		return fmt.Errorf("discovery failed: %w", err)
+	// Failures were logged as they happened; summarize so incomplete graphs are not mistaken for complete ones
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(os.Stderr, "partial results: %d errors during discovery\n", len(result.Errors))
 	}
 
 	slog.Info("Discovery complete",
-		"nodes", len(g.Nodes()),
-		"edges", len(g.Edges()))
+		"nodes", result.Nodes,
+		"edges", result.Edges)
 
-	// Output results
+	if withCost {
+		addCostEstimates(ctx, clients.CostExplorer, g)
+	}
+
+	return g, nil
+}
+
+// parseDepthOverrides parses --depth-for values of the form Type=N into per-type depth limits
+func parseDepthOverrides(values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]int, len(values))
+	for _, value := range values {
+		resourceType, limit, ok := strings.Cut(value, "=")
+		resourceType = strings.TrimSpace(resourceType)
+		if !ok || resourceType == "" {
+			return nil, fmt.Errorf("invalid --depth-for %q: expected Type=N", value)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid --depth-for %q: depth must be a non-negative integer", value)
+		}
+		overrides[resourceType] = n
+	}
+	return overrides, nil
+}
+
+// labelTemplateFlag is the --label-template value. The template is parsed as the flag is set, so
+// a bad template is reported with the other flag errors before any discovery.
+type labelTemplateFlag struct {
+	text string
+	tmpl *template.Template
+}
+
+func (f *labelTemplateFlag) String() string { return f.text }
+
+func (f *labelTemplateFlag) Type() string { return "template" }
+
+// Set parses text as a label template; an empty template restores the default labels
+func (f *labelTemplateFlag) Set(text string) error {
+	if text == "" {
+		f.text, f.tmpl = "", nil
+		return nil
+	}
+	tmpl, err := output.ParseLabelTemplate(text)
+	if err != nil {
+		return err
+	}
+	f.text, f.tmpl = text, tmpl
+	return nil
+}
+
+// parseDOTStyles parses --dot-style values of the form Type=shape[:fillcolor[:outline]] into
+// per-type node styles replacing the defaults
+func parseDOTStyles(values []string) (map[string]output.DOTNodeStyle, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	styles := make(map[string]output.DOTNodeStyle, len(values))
+	for _, value := range values {
+		resourceType, spec, ok := strings.Cut(value, "=")
+		resourceType = strings.TrimSpace(resourceType)
+		if !ok || resourceType == "" {
+			return nil, fmt.Errorf("invalid --dot-style %q: expected Type=shape[:fillcolor[:outline]]", value)
+		}
+		parts := strings.Split(spec, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid --dot-style %q: expected at most shape, fill color and outline color", value)
+		}
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+		style := output.DOTNodeStyle{
+			Shape:     strings.TrimSpace(parts[0]),
+			FillColor: strings.TrimSpace(parts[1]),
+			Color:     strings.TrimSpace(parts[2]),
+		}
+		if style == (output.DOTNodeStyle{}) {
+			return nil, fmt.Errorf("invalid --dot-style %q: no shape or color given", value)
+		}
+		styles[resourceType] = style
+	}
+	return styles, nil
+}
+
+// primaryConfig picks the config used for the starting resource: the role for --account-id,
+// the only assumed role when just one is given, or the caller's own credentials
+func primaryConfig(cfg *aws.Config, accountConfigs map[string]aws.Config) (*aws.Config, error) {
+	if accountID != "" {
+		accountCfg, ok := accountConfigs[accountID]
+		if !ok {
+			return nil, fmt.Errorf("no --assume-role given for account %s", accountID)
+		}
+		return &accountCfg, nil
+	}
+	if len(accountConfigs) == 1 {
+		for account := range accountConfigs {
+			accountCfg := accountConfigs[account]
+			return &accountCfg, nil
+		}
+	}
+	return cfg, nil
+}
+
+// loadSnapshot reads a previously saved graph instead of calling AWS
+func loadSnapshot(path string) (*graph.Graph, string, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is supplied by the user via --snapshot-in
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open snapshot %s: %w", path, err)
+	}
+	defer f.Close()
+
+	snapshot, g, err := output.ReadSnapshot(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load snapshot %s: %w", path, err)
+	}
+
+	slog.Info("Loaded snapshot",
+		"path", path,
+		"resource", snapshot.Resource,
+		"createdAt", snapshot.CreatedAt,
+		"nodes", g.NodeCount(),
+		"edges", g.EdgeCount())
+
+	return g, snapshot.Resource, nil
+}
+
+// saveSnapshot writes the full graph to path so it can be re-rendered offline
+func saveSnapshot(path string, g *graph.Graph, resourceID string) (err error) {
+	f, err := openOutputFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close snapshot %s: %w", path, closeErr)
+		}
+	}()
+
+	if err = output.WriteSnapshot(f, g, resourceID); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return nil
+}
+
+// render writes the graph to w in the selected output format. exposed holds the nodes to
+// flag as internet-reachable, if --highlight-exposure is set.
+func render(w io.Writer, g *graph.Graph, resourceID string, exposed map[string]bool) error {
 	switch format {
 	case "tree":
-		return output.RenderTree(os.Stdout, g, resourceID)
+		return output.RenderTreeWithOptions(w, g, resourceID, output.TreeOptions{
+			ShowEvidence:      showEvidence,
+			Style:             treeStyle,
+			InternetReachable: exposed,
+			LabelTemplate:     labelTemplate.tmpl,
+			LabelTags:         labelTags,
+		})
 	case "dot":
-		return output.RenderDOT(os.Stdout, g)
+		nodeStyles, err := parseDOTStyles(dotStyles)
+		if err != nil {
+			return err
+		}
+		return output.RenderDOTWithOptions(w, g, output.DOTOptions{
+			InternetReachable: exposed,
+			BundleEdges:       bundleEdges,
+			GroupBy:           groupBy,
+			Root:              resourceID,
+			GeneratedAt:       time.Now(),
+			NodeStyles:        nodeStyles,
+			LabelTemplate:     labelTemplate.tmpl,
+			LabelTags:         labelTags,
+		})
 	case "json":
-		return output.RenderJSON(os.Stdout, g)
+		if jsonLevels {
+			return output.RenderJSONLevels(w, g, resourceID)
+		}
+		return output.RenderJSON(w, g)
+	case "json-tree":
+		return output.RenderJSONTree(w, g, resourceID)
+	case "jsonl":
+		return output.RenderJSONL(w, g)
+	case "csv":
+		return output.RenderCSV(w, g)
+	case "plantuml":
+		return output.RenderPlantUMLWithOptions(w, g, output.PlantUMLOptions{LabelTemplate: labelTemplate.tmpl})
 	default:
-		return fmt.Errorf("unknown format: %s (must be tree, dot, or json)", format)
+		return fmt.Errorf("unknown format: %s (must be tree, dot, json, json-tree, jsonl, csv, or plantuml)", format)
+	}
+}
+
+// isOutputDir reports whether path names a directory: one that already exists or a path ending
+// in a separator. Any other path is written as a single file.
+func isOutputDir(path string) bool {
+	if path == "" {
+		return false
+	}
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// writeCSVFiles writes nodes.csv and edges.csv into dir, creating it if needed
+func writeCSVFiles(dir string, g *graph.Graph) error {
+	files := []struct {
+		name   string
+		render func(io.Writer, *graph.Graph) error
+	}{
+		{"nodes.csv", output.RenderNodesCSV},
+		{"edges.csv", output.RenderCSV},
+	}
+	for _, file := range files {
+		if err := writeOutputFile(filepath.Join(dir, file.name), g, file.render); err != nil {
+			return err
+		}
+	}
+	slog.Info("CSV export written", "dir", dir)
+	return nil
+}
+
+// writeOutputFile renders g into a new file at path
+func writeOutputFile(path string, g *graph.Graph, render func(io.Writer, *graph.Graph) error) (err error) {
+	f, err := openOutputFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close output file %s: %w", path, closeErr)
+		}
+	}()
+
+	if err = render(f, g); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// openOutputFile creates (or truncates) the output file, creating parent directories as needed
+func openOutputFile(path string) (*os.File, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create output directory %s: %w", dir, err)
+		}
+	}
+
+	f, err := os.Create(path) // #nosec G304 -- path is supplied by the user via --output
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file %s: %w", path, err)
 	}
+	return f, nil
 }
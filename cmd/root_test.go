@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/output"
+)
+
+func TestParseDepthOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:   "No overrides",
+			values: nil,
+			want:   nil,
+		},
+		{
+			name:   "Multiple types",
+			values: []string{"IAMRole=0", " SecurityGroup = 4 "},
+			want:   map[string]int{"IAMRole": 0, "SecurityGroup": 4},
+		},
+		{
+			name:   "Later value wins",
+			values: []string{"IAMRole=2", "IAMRole=1"},
+			want:   map[string]int{"IAMRole": 1},
+		},
+		{
+			name:    "Missing depth",
+			values:  []string{"IAMRole"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing type",
+			values:  []string{"=2"},
+			wantErr: true,
+		},
+		{
+			name:    "Negative depth",
+			values:  []string{"IAMRole=-1"},
+			wantErr: true,
+		},
+		{
+			name:    "Non-numeric depth",
+			values:  []string{"IAMRole=deep"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDepthOverrides(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDepthOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDepthOverrides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDOTStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]output.DOTNodeStyle
+		wantErr bool
+	}{
+		{
+			name:   "No styles",
+			values: nil,
+			want:   nil,
+		},
+		{
+			name:   "Shape, fill and outline",
+			values: []string{"Lambda=hexagon", " RDSInstance = cylinder:orange ", "SecurityGroup=box:white:red"},
+			want: map[string]output.DOTNodeStyle{
+				"Lambda":        {Shape: "hexagon"},
+				"RDSInstance":   {Shape: "cylinder", FillColor: "orange"},
+				"SecurityGroup": {Shape: "box", FillColor: "white", Color: "red"},
+			},
+		},
+		{
+			name:   "Color without shape",
+			values: []string{"IAMRole=:pink"},
+			want:   map[string]output.DOTNodeStyle{"IAMRole": {FillColor: "pink"}},
+		},
+		{
+			name:    "Missing style",
+			values:  []string{"Lambda"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty style",
+			values:  []string{"Lambda=::"},
+			wantErr: true,
+		},
+		{
+			name:    "Too many parts",
+			values:  []string{"Lambda=box:red:blue:green"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDOTStyles(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDOTStyles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDOTStyles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFlagName(t *testing.T) {
+	var values []string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArrayVar(&values, "depth-for", nil, "")
+	flags.SetNormalizeFunc(normalizeFlagName)
+
+	if err := flags.Parse([]string{"--max-depth-type", "SecurityGroup=1", "--depth-for", "IAMRole=0"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []string{"SecurityGroup=1", "IAMRole=0"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("--max-depth-type and --depth-for values = %v, want %v", values, want)
+	}
+}
+
+func TestWriteCSVFiles(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "a", Type: "LoadBalancer", Name: "alb"})
+	g.AddNode(&graph.Node{ID: "b", Type: "TargetGroup", Name: "tg"})
+	g.AddEdge(&graph.Edge{From: "a", To: "b", RelationType: "forwards-to"})
+
+	dir := filepath.Join(t.TempDir(), "export")
+	if err := writeCSVFiles(dir, g); err != nil {
+		t.Fatalf("writeCSVFiles() error = %v", err)
+	}
+
+	for name, wantRows := range map[string]int{"nodes.csv": 3, "edges.csv": 2} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("writeCSVFiles() did not write %s: %v", name, err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s is not valid CSV: %v", name, err)
+		}
+		if len(records) != wantRows {
+			t.Errorf("%s has %d rows, want %d", name, len(records), wantRows)
+		}
+	}
+}
+
+func TestIsOutputDir(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"", false},
+		{dir, true},
+		{filepath.Join(dir, "export") + "/", true},
+		{filepath.Join(dir, "edges.csv"), false},
+		{filepath.Join(dir, "edges.txt"), false},
+		{filepath.Join(dir, "out"), false},
+	}
+	for _, tt := range tests {
+		if got := isOutputDir(tt.path); got != tt.want {
+			t.Errorf("isOutputDir(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPrintValidationIssues(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "a"})
+	g.AddEdge(&graph.Edge{From: "a", To: "missing", RelationType: "uses"})
+	g.AddEdge(&graph.Edge{From: "a", To: "a", RelationType: "uses"})
+
+	var buf bytes.Buffer
+	printValidationIssues(&buf, g.Validate())
+	want := "graph validation: 2 issues found\n" +
+		"  self-loop: edge a -[uses]-> a points at its own source\n" +
+		"  dangling-edge: edge a -[uses]-> missing references missing node missing\n"
+	if buf.String() != want {
+		t.Errorf("printValidationIssues() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printValidationIssues(&buf, nil)
+	if buf.String() != "graph validation: no issues found\n" {
+		t.Errorf("printValidationIssues(nil) = %q", buf.String())
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		name         string
+		debug, quiet bool
+		want         slog.Level
+	}{
+		{name: "default", want: slog.LevelInfo},
+		{name: "debug", debug: true, want: slog.LevelDebug},
+		{name: "quiet", quiet: true, want: slog.LevelError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDebug, oldQuiet := debug, quiet
+			defer func() { debug, quiet = oldDebug, oldQuiet }()
+			debug, quiet = tt.debug, tt.quiet
+
+			if got := logLevel(); got != tt.want {
+				t.Errorf("logLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietConflictsWithDebug(t *testing.T) {
+	defer func() {
+		for _, name := range []string{"debug", "quiet"} {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			_ = flag.Value.Set("false")
+			flag.Changed = false
+		}
+	}()
+	if err := rootCmd.ParseFlags([]string{"--debug", "--quiet"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := rootCmd.ValidateFlagGroups(); err == nil {
+		t.Error("ValidateFlagGroups() error = nil, want --debug and --quiet rejected together")
+	}
+}
+
+func TestCallTimeoutFlag(t *testing.T) {
+	flag := rootCmd.PersistentFlags().Lookup("call-timeout")
+	if flag == nil {
+		t.Fatal("--call-timeout is not registered")
+	}
+	if flag.DefValue != awsx.DefaultCallTimeout.String() {
+		t.Errorf("--call-timeout default = %s, want %s", flag.DefValue, awsx.DefaultCallTimeout)
+	}
+}
+
+func TestLabelTemplateFlag(t *testing.T) {
+	var flag labelTemplateFlag
+	if err := flag.Set("{{.Name}} ({{.Type}})"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if flag.tmpl == nil || flag.String() != "{{.Name}} ({{.Type}})" {
+		t.Fatalf("Set() left flag = %+v", flag)
+	}
+
+	for _, bad := range []string{"{{.Name", "{{.Bogus}}"} {
+		if err := flag.Set(bad); err == nil {
+			t.Errorf("Set(%q) error = nil, want error", bad)
+		}
+	}
+	if flag.String() != "{{.Name}} ({{.Type}})" {
+		t.Errorf("failed Set() changed flag to %q", flag.String())
+	}
+
+	if err := flag.Set(""); err != nil || flag.tmpl != nil {
+		t.Errorf("Set(\"\") = %v, tmpl %v, want default labels", err, flag.tmpl)
+	}
+}
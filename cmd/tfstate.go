@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/tfstate"
+)
+
+var importTFStateCmd = &cobra.Command{
+	Use:   "import-tfstate <state-file>",
+	Short: "Build a graph from a Terraform state or plan without calling AWS",
+	Long: `import-tfstate reads a terraform.tfstate file or the output of terraform show -json, for
+a state or a saved plan, and renders the resources it manages with the dependencies Terraform
+recorded between them as "dependency" edges. No AWS calls are made.
+
+Resources are keyed the way discovery keys them, so the resources in a snapshot of the declared
+topology can be compared with a live one using diff. Resource types blast-radius does not discover are shown as
+AWSResource nodes, and resources a plan has yet to create are keyed by their address.
+
+Examples:
+  # Render the declared topology of a configuration
+  terraform show -json > state.json
+  blast-radius import-tfstate state.json --format dot
+
+  # Compare it with what is running
+  blast-radius import-tfstate state.json --snapshot-out declared.json
+  blast-radius --from-tfstate state.json --snapshot-out live.json
+  blast-radius diff declared.json live.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportTFState,
+}
+
+func init() {
+	addRenderFlags(importTFStateCmd.Flags())
+	rootCmd.AddCommand(importTFStateCmd)
+}
+
+func runImportTFState(cmd *cobra.Command, args []string) error {
+	setupLogging()
+
+	if dryRun {
+		return fmt.Errorf("--dry-run is not supported by import-tfstate")
+	}
+
+	resources, err := tfstate.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Nodes are only built from ARNs and IDs, so the discoverer never calls AWS
+	discoverer := discover.New(&awsx.Clients{}, &discover.Options{})
+	g := tfstate.Graph(resources, discoverer.SeedNode)
+	if g.NodeCount() == 0 {
+		return fmt.Errorf("no AWS resources found in %s", args[0])
+	}
+	slog.Info("Imported Terraform state", "resources", len(resources), "nodes", g.NodeCount(), "edges", g.EdgeCount())
+
+	return outputGraph(g, args[0])
+}
+
+// stateSeeds builds the starting nodes for the resources of a Terraform state, in address
+// order. Resources without a node type or identifier, and repeats of one resource, are skipped.
+func stateSeeds(discoverer *discover.Discoverer, resources []tfstate.Resource) []*graph.Node {
+	var seeds []*graph.Node
+	seen := make(map[string]bool)
+	for i := range resources {
+		resource := &resources[i]
+		if resource.NodeType == "" {
+			slog.Debug("Skipping Terraform resource without a node type", "address", resource.Address, "type", resource.Type)
+			continue
+		}
+
+		node := discoverer.SeedNode(resource.NodeType, resource.ARN, resource.ID, resource.Name)
+		if node == nil || seen[node.ID] {
+			continue
+		}
+		seen[node.ID] = true
+		node.Metadata["terraformAddress"] = resource.Address
+		seeds = append(seeds, node)
+	}
+
+	slog.Info("Read Terraform state", "resources", len(resources), "seeds", len(seeds))
+	return seeds
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+	"github.com/pfrederiksen/blast-radius/internal/tfstate"
+)
+
+func TestStateSeeds(t *testing.T) {
+	const lambdaARN = "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	resources := []tfstate.Resource{
+		{Address: "aws_lambda_function.orders", Type: "aws_lambda_function", NodeType: discover.ResourceTypeLambda, ARN: lambdaARN, ID: "orders"},
+		{Address: "aws_iam_role_policy_attachment.logs", Type: "aws_iam_role_policy_attachment", ID: "logs-attachment"},
+		{Address: "aws_subnet.private", Type: "aws_subnet", NodeType: discover.ResourceTypeSubnet, ID: "subnet-1"},
+		// The same function imported twice under two addresses
+		{Address: "aws_lambda_function.orders_alias", Type: "aws_lambda_function", NodeType: discover.ResourceTypeLambda, ARN: lambdaARN, ID: "orders"},
+	}
+
+	seeds := stateSeeds(discover.New(&awsx.Clients{}, &discover.Options{}), resources)
+	if len(seeds) != 2 {
+		t.Fatalf("stateSeeds() returned %d seeds, want 2: %v", len(seeds), seeds)
+	}
+	if seeds[0].ID != lambdaARN || seeds[0].Type != discover.ResourceTypeLambda || seeds[0].Name != "orders" {
+		t.Errorf("seeds[0] = %s %s %q, want the Lambda function", seeds[0].Type, seeds[0].ID, seeds[0].Name)
+	}
+	if seeds[1].ID != "subnet-1" {
+		t.Errorf("seeds[1].ID = %s, want subnet-1", seeds[1].ID)
+	}
+	if got := seeds[0].Metadata["terraformAddress"]; got != "aws_lambda_function.orders" {
+		t.Errorf("terraformAddress = %v, want aws_lambda_function.orders", got)
+	}
+}
+
+func TestRunImportTFState(t *testing.T) {
+	const state = `{
+  "version": 4,
+  "resources": [
+    {"mode": "managed", "type": "aws_lambda_function", "name": "orders",
+     "instances": [{"attributes": {"arn": "arn:aws:lambda:us-east-1:123456789012:function:orders", "id": "orders"},
+                    "dependencies": ["aws_sqs_queue.jobs"]}]},
+    {"mode": "managed", "type": "aws_sqs_queue", "name": "jobs",
+     "instances": [{"attributes": {"arn": "arn:aws:sqs:us-east-1:123456789012:jobs", "id": "https://sqs.us-east-1.amazonaws.com/123456789012/jobs"}}]}
+  ]
+}`
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	if err := os.WriteFile(statePath, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFormat, oldOutput := format, outputFile
+	t.Cleanup(func() { format, outputFile = oldFormat, oldOutput })
+	format, outputFile = "json", filepath.Join(dir, "graph.json")
+
+	if err := runImportTFState(importTFStateCmd, []string{statePath}); err != nil {
+		t.Fatalf("runImportTFState() error = %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"dependency"`, "arn:aws:lambda:us-east-1:123456789012:function:orders", "arn:aws:sqs:us-east-1:123456789012:jobs"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("output missing %s:\n%s", want, data)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.tfstate")
+	if err := os.WriteFile(empty, []byte(`{"version": 4, "resources": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runImportTFState(importTFStateCmd, []string{empty}); err == nil || !strings.Contains(err.Error(), "no AWS resources") {
+		t.Errorf("runImportTFState() on an empty state error = %v, want no AWS resources", err)
+	}
+}
@@ -0,0 +1,58 @@
+package awsx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+// roleSessionName identifies blast-radius sessions in CloudTrail
+const roleSessionName = "blast-radius"
+
+// WithAssumedRole returns a copy of cfg whose credentials come from assuming roleARN via stsClient
+func WithAssumedRole(cfg *aws.Config, stsClient stscreds.AssumeRoleAPIClient, roleARN, externalID string) aws.Config {
+	c := cfg.Copy()
+	provider := stscreds.NewAssumeRoleProvider(stsClient, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = roleSessionName
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	c.Credentials = aws.NewCredentialsCache(provider)
+	return c
+}
+
+// RoleConfigs builds one config per account from a list of role ARNs, keyed by the
+// account ID each role lives in. At most one role may be given per account.
+func RoleConfigs(cfg *aws.Config, stsClient stscreds.AssumeRoleAPIClient, roleARNs []string, externalID string) (map[string]aws.Config, error) {
+	if cfg == nil {
+		return nil, errors.New("aws config is required")
+	}
+
+	configs := make(map[string]aws.Config, len(roleARNs))
+	for _, roleARN := range roleARNs {
+		accountID, err := RoleAccountID(roleARN)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := configs[accountID]; exists {
+			return nil, fmt.Errorf("multiple roles given for account %s", accountID)
+		}
+		configs[accountID] = WithAssumedRole(cfg, stsClient, roleARN, externalID)
+	}
+
+	return configs, nil
+}
+
+// RoleAccountID returns the account ID of an IAM role ARN
+func RoleAccountID(roleARN string) (string, error) {
+	// ARN format: arn:partition:iam::account:role/role-name
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") || parts[4] == "" {
+		return "", fmt.Errorf("invalid IAM role ARN: %s", roleARN)
+	}
+	return parts[4], nil
+}
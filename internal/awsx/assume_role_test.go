@@ -0,0 +1,124 @@
+package awsx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// fakeSTS records AssumeRole calls and returns fixed credentials
+type fakeSTS struct {
+	inputs []*sts.AssumeRoleInput
+}
+
+func (f *fakeSTS) AssumeRole(_ context.Context, params *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	f.inputs = append(f.inputs, params)
+	return &sts.AssumeRoleOutput{
+		Credentials: &ststypes.Credentials{
+			AccessKeyId:     aws.String("AKIDASSUMED"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func TestWithAssumedRole(t *testing.T) {
+	fake := &fakeSTS{}
+	cfg := aws.Config{Region: "us-east-1"}
+
+	assumed := WithAssumedRole(&cfg, fake, "arn:aws:iam::210987654321:role/blast-radius-read", "ext-123")
+
+	creds, err := assumed.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if creds.AccessKeyID != "AKIDASSUMED" {
+		t.Errorf("Retrieve() AccessKeyID = %q, want AKIDASSUMED", creds.AccessKeyID)
+	}
+
+	if len(fake.inputs) != 1 {
+		t.Fatalf("expected 1 AssumeRole call, got %d", len(fake.inputs))
+	}
+	input := fake.inputs[0]
+	if aws.ToString(input.RoleArn) != "arn:aws:iam::210987654321:role/blast-radius-read" {
+		t.Errorf("AssumeRole RoleArn = %q", aws.ToString(input.RoleArn))
+	}
+	if aws.ToString(input.ExternalId) != "ext-123" {
+		t.Errorf("AssumeRole ExternalId = %q, want ext-123", aws.ToString(input.ExternalId))
+	}
+	if aws.ToString(input.RoleSessionName) != roleSessionName {
+		t.Errorf("AssumeRole RoleSessionName = %q, want %q", aws.ToString(input.RoleSessionName), roleSessionName)
+	}
+
+	// The caller's config must not be modified
+	if cfg.Credentials != nil {
+		t.Error("expected original config credentials to be untouched")
+	}
+}
+
+func TestRoleConfigs(t *testing.T) {
+	fake := &fakeSTS{}
+	cfg := aws.Config{Region: "us-east-1"}
+
+	configs, err := RoleConfigs(&cfg, fake, []string{
+		"arn:aws:iam::111111111111:role/reader",
+		"arn:aws:iam::222222222222:role/reader",
+	}, "")
+	if err != nil {
+		t.Fatalf("RoleConfigs() error = %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("RoleConfigs() returned %d configs, want 2", len(configs))
+	}
+	for _, account := range []string{"111111111111", "222222222222"} {
+		c, ok := configs[account]
+		if !ok {
+			t.Errorf("RoleConfigs() missing config for account %s", account)
+			continue
+		}
+		if c.Region != "us-east-1" {
+			t.Errorf("RoleConfigs() region = %q, want us-east-1", c.Region)
+		}
+	}
+
+	// Credentials are only fetched when first used
+	if len(fake.inputs) != 0 {
+		t.Errorf("expected no AssumeRole calls before use, got %d", len(fake.inputs))
+	}
+	if _, err := configs["222222222222"].Credentials.Retrieve(context.Background()); err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if len(fake.inputs) != 1 || aws.ToString(fake.inputs[0].RoleArn) != "arn:aws:iam::222222222222:role/reader" {
+		t.Errorf("expected AssumeRole for account 222222222222, got %v", fake.inputs)
+	}
+	if fake.inputs[0].ExternalId != nil {
+		t.Errorf("expected no ExternalId, got %q", aws.ToString(fake.inputs[0].ExternalId))
+	}
+}
+
+func TestRoleConfigsErrors(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+
+	tests := []struct {
+		name  string
+		roles []string
+	}{
+		{name: "not a role ARN", roles: []string{"arn:aws:iam::111111111111:user/alice"}},
+		{name: "not an ARN", roles: []string{"reader"}},
+		{name: "duplicate account", roles: []string{"arn:aws:iam::111111111111:role/a", "arn:aws:iam::111111111111:role/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RoleConfigs(&cfg, &fakeSTS{}, tt.roles, ""); err == nil {
+				t.Error("RoleConfigs() expected error, got nil")
+			}
+		})
+	}
+}
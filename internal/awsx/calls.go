@@ -0,0 +1,84 @@
+package awsx
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// APICallCount is the number of calls made to one service operation
+type APICallCount struct {
+	Service   string
+	Operation string
+	Count     int
+}
+
+// CallCounter tallies AWS API calls per service operation. It is safe for concurrent use.
+type CallCounter struct {
+	mu     sync.Mutex
+	counts map[callKey]int
+}
+
+// callKey identifies one service operation
+type callKey struct {
+	service   string
+	operation string
+}
+
+// NewCallCounter creates an empty call counter
+func NewCallCounter() *CallCounter {
+	return &CallCounter{counts: make(map[callKey]int)}
+}
+
+// Record counts one call to service operation
+func (c *CallCounter) Record(service, operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[callKey{service: service, operation: operation}]++
+}
+
+// Counts returns the per-operation tallies sorted by service, then operation
+func (c *CallCounter) Counts() []APICallCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make([]APICallCount, 0, len(c.counts))
+	for key, n := range c.counts {
+		counts = append(counts, APICallCount{Service: key.service, Operation: key.operation, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Service != counts[j].Service {
+			return counts[i].Service < counts[j].Service
+		}
+		return counts[i].Operation < counts[j].Operation
+	})
+	return counts
+}
+
+// Total returns the number of calls recorded across all operations
+func (c *CallCounter) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, n := range c.counts {
+		total += n
+	}
+	return total
+}
+
+// withCallCounter returns an API option that records every operation in counter.
+// It runs after the SDK registers service metadata and before retries, so each
+// operation (or paginator page) counts once regardless of how many attempts it takes.
+func withCallCounter(counter *CallCounter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CallCounter",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				counter.Record(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx))
+				return next.HandleInitialize(ctx, in)
+			}), middleware.After)
+	}
+}
@@ -0,0 +1,73 @@
+package awsx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestCallCounterCounts(t *testing.T) {
+	counter := NewCallCounter()
+	counter.Record("Lambda", "GetFunction")
+	counter.Record("EC2", "DescribeSubnets")
+	counter.Record("Lambda", "GetFunction")
+	counter.Record("EC2", "DescribeSecurityGroups")
+
+	want := []APICallCount{
+		{Service: "EC2", Operation: "DescribeSecurityGroups", Count: 1},
+		{Service: "EC2", Operation: "DescribeSubnets", Count: 1},
+		{Service: "Lambda", Operation: "GetFunction", Count: 2},
+	}
+	got := counter.Counts()
+	if len(got) != len(want) {
+		t.Fatalf("Counts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Counts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if total := counter.Total(); total != 4 {
+		t.Errorf("Total() = %d, want 4", total)
+	}
+}
+
+func TestNewClientsWithOptionsCallCounter(t *testing.T) {
+	// Answer every call once it is built so the test never reaches AWS
+	errStub := errors.New("stubbed")
+	stub := func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("stub",
+			func(context.Context, middleware.BuildInput, middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+				return middleware.BuildOutput{}, middleware.Metadata{}, errStub
+			}), middleware.After)
+	}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		APIOptions: []func(*middleware.Stack) error{stub},
+	}
+
+	counter := NewCallCounter()
+	clients, err := NewClientsWithOptions(&cfg, ClientOptions{Calls: counter})
+	if err != nil {
+		t.Fatalf("NewClientsWithOptions() error = %v", err)
+	}
+
+	for range 2 {
+		_, err = clients.Lambda.GetFunction(context.Background(), &lambda.GetFunctionInput{
+			FunctionName: aws.String("api"),
+		})
+		if !errors.Is(err, errStub) {
+			t.Fatalf("GetFunction() error = %v, want stubbed error", err)
+		}
+	}
+
+	got := counter.Counts()
+	want := APICallCount{Service: "Lambda", Operation: "GetFunction", Count: 2}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Counts() = %v, want [%v]", got, want)
+	}
+}
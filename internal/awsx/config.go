@@ -2,28 +2,69 @@ package awsx
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
 	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	"github.com/aws/smithy-go/middleware"
 )
 
 // Clients holds all AWS service clients
 type Clients struct {
 	ELBv2                  *elasticloadbalancingv2.Client
 	ECS                    *ecs.Client
+	EFS                    *efs.Client
 	Lambda                 *lambda.Client
 	RDS                    *rds.Client
 	Route53                *route53.Client
 	EC2                    *ec2.Client
+	ECR                    *ecr.Client
 	ApplicationAutoScaling *applicationautoscaling.Client
+	AutoScaling            *autoscaling.Client
+	APIGateway             *apigateway.Client
+	APIGatewayV2           *apigatewayv2.Client
+	EventBridge            *eventbridge.Client
+	Kinesis                *kinesis.Client
+	Firehose               *firehose.Client
+	Kafka                  *kafka.Client
+	KMS                    *kms.Client
+	SecretsManager         *secretsmanager.Client
+	SESv2                  *sesv2.Client
+	SSM                    *ssm.Client
+	ServiceDiscovery       *servicediscovery.Client
+	ACM                    *acm.Client
+	IAM                    *iam.Client
+	WAFv2                  *wafv2.Client
+	Tagging                *resourcegroupstaggingapi.Client
+	CostExplorer           *costexplorer.Client
 }
 
 // LoadConfig loads AWS configuration with optional profile and region overrides
@@ -46,15 +87,78 @@ func LoadConfig(ctx context.Context, profile, region string) (aws.Config, error)
 	return cfg, nil
 }
 
+// ClientOptions customizes how service clients are constructed
+type ClientOptions struct {
+	// BaseEndpoint overrides the endpoint for every service client (e.g. LocalStack)
+	BaseEndpoint string
+	// Retryer, if set, provides the retryer shared by every service client
+	Retryer func() aws.Retryer
+	// CallTimeout, if positive, bounds each API call including its retries, so one hung
+	// call fails on its own instead of consuming the whole discovery deadline
+	CallTimeout time.Duration
+	// Calls, if set, records every API call made through the clients
+	Calls *CallCounter
+}
+
 // NewClients creates all AWS service clients from config
 func NewClients(cfg *aws.Config) (*Clients, error) {
+	return NewClientsWithOptions(cfg, ClientOptions{})
+}
+
+// NewClientsWithOptions creates all AWS service clients from config, applying opts
+func NewClientsWithOptions(cfg *aws.Config, opts ClientOptions) (*Clients, error) {
+	if cfg == nil {
+		return nil, errors.New("aws config is required")
+	}
+
+	// Apply options to a copy so the caller's config is left untouched
+	c := cfg.Copy()
+	if opts.BaseEndpoint != "" {
+		c.BaseEndpoint = aws.String(opts.BaseEndpoint)
+	}
+	if opts.Retryer != nil {
+		c.Retryer = opts.Retryer
+	}
+	if opts.CallTimeout > 0 || opts.Calls != nil {
+		// Copy the slice so appending never writes into the caller's backing array
+		c.APIOptions = append([]func(*middleware.Stack) error(nil), c.APIOptions...)
+	}
+	if opts.CallTimeout > 0 {
+		c.APIOptions = append(c.APIOptions, withCallTimeout(opts.CallTimeout))
+	}
+	if opts.Calls != nil {
+		c.APIOptions = append(c.APIOptions, withCallCounter(opts.Calls))
+	}
+
 	return &Clients{
-		ELBv2:                  elasticloadbalancingv2.NewFromConfig(*cfg),
-		ECS:                    ecs.NewFromConfig(*cfg),
-		Lambda:                 lambda.NewFromConfig(*cfg),
-		RDS:                    rds.NewFromConfig(*cfg),
-		Route53:                route53.NewFromConfig(*cfg),
-		EC2:                    ec2.NewFromConfig(*cfg),
-		ApplicationAutoScaling: applicationautoscaling.NewFromConfig(*cfg),
+		ELBv2:                  elasticloadbalancingv2.NewFromConfig(c),
+		ECS:                    ecs.NewFromConfig(c),
+		EFS:                    efs.NewFromConfig(c),
+		Lambda:                 lambda.NewFromConfig(c),
+		RDS:                    rds.NewFromConfig(c),
+		Route53:                route53.NewFromConfig(c),
+		EC2:                    ec2.NewFromConfig(c),
+		ECR:                    ecr.NewFromConfig(c),
+		ApplicationAutoScaling: applicationautoscaling.NewFromConfig(c),
+		AutoScaling:            autoscaling.NewFromConfig(c),
+		APIGateway:             apigateway.NewFromConfig(c),
+		APIGatewayV2:           apigatewayv2.NewFromConfig(c),
+		EventBridge:            eventbridge.NewFromConfig(c),
+		Kinesis:                kinesis.NewFromConfig(c),
+		Firehose:               firehose.NewFromConfig(c),
+		Kafka:                  kafka.NewFromConfig(c),
+		KMS:                    kms.NewFromConfig(c),
+		SecretsManager:         secretsmanager.NewFromConfig(c),
+		SESv2:                  sesv2.NewFromConfig(c),
+		SSM:                    ssm.NewFromConfig(c),
+		ServiceDiscovery:       servicediscovery.NewFromConfig(c),
+		ACM:                    acm.NewFromConfig(c),
+		IAM:                    iam.NewFromConfig(c),
+		WAFv2:                  wafv2.NewFromConfig(c),
+		Tagging:                resourcegroupstaggingapi.NewFromConfig(c),
+		// Cost Explorer is only served from us-east-1, whatever region is scanned
+		CostExplorer: costexplorer.NewFromConfig(c, func(o *costexplorer.Options) {
+			o.Region = "us-east-1"
+		}),
 	}, nil
 }
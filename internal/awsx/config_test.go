@@ -0,0 +1,71 @@
+package awsx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+func TestNewClients(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+
+	clients, err := NewClients(&cfg)
+	if err != nil {
+		t.Fatalf("NewClients() error = %v", err)
+	}
+
+	assertAllClientsSet(t, clients)
+}
+
+func TestNewClientsWithOptions(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+	retryerCalls := 0
+
+	clients, err := NewClientsWithOptions(&cfg, ClientOptions{
+		BaseEndpoint: "http://localhost:4566",
+		Retryer: func() aws.Retryer {
+			retryerCalls++
+			return retry.NewStandard()
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClientsWithOptions() error = %v", err)
+	}
+
+	assertAllClientsSet(t, clients)
+
+	if got := clients.Lambda.Options().BaseEndpoint; got == nil || *got != "http://localhost:4566" {
+		t.Errorf("expected Lambda BaseEndpoint to be overridden, got %v", got)
+	}
+	if retryerCalls == 0 {
+		t.Error("expected shared retryer to be used by clients")
+	}
+
+	// The caller's config must not be modified
+	if cfg.BaseEndpoint != nil {
+		t.Errorf("expected original config BaseEndpoint to be nil, got %v", *cfg.BaseEndpoint)
+	}
+}
+
+func TestNewClientsWithOptionsNilConfig(t *testing.T) {
+	if _, err := NewClientsWithOptions(nil, ClientOptions{}); err == nil {
+		t.Error("expected error for nil config, got nil")
+	}
+}
+
+func assertAllClientsSet(t *testing.T, clients *Clients) {
+	t.Helper()
+
+	if clients == nil {
+		t.Fatal("expected clients, got nil")
+	}
+
+	v := reflect.ValueOf(clients).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			t.Errorf("client %s is nil", v.Type().Field(i).Name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package awsx
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// PageRetries is how many times EachPage requests a page again after a transient error
+const PageRetries = 3
+
+// pageRetryDelay is the wait before the first page retry; it doubles with each further retry
+var pageRetryDelay = time.Second
+
+// Pager is the part of an SDK paginator EachPage uses; every generated paginator satisfies it
+type Pager[T, O any] interface {
+	HasMorePages() bool
+	NextPage(ctx context.Context, optFns ...func(*O)) (T, error)
+}
+
+// EachPage calls fn with each page from p. A page that fails with a transient error, such as
+// throttling that outlasted the client's own retries, is requested again up to PageRetries
+// times, so pages already handled are not thrown away by one throttle late in a long scan.
+// When a page still fails, or fn returns an error, EachPage stops and returns that error;
+// every earlier page has already been passed to fn.
+func EachPage[T, O any](ctx context.Context, p Pager[T, O], fn func(T) error) error {
+	for p.HasMorePages() {
+		page, err := nextPage(ctx, p)
+		if err != nil {
+			return err
+		}
+		if fnErr := fn(page); fnErr != nil {
+			return fnErr
+		}
+	}
+	return nil
+}
+
+// nextPage fetches the next page, retrying transient failures. SDK paginators only advance
+// their token after a successful call, so calling NextPage again requests the same page.
+func nextPage[T, O any](ctx context.Context, p Pager[T, O]) (T, error) {
+	delay := pageRetryDelay
+	for attempt := 1; ; attempt++ {
+		page, err := p.NextPage(ctx)
+		if err == nil || attempt > PageRetries || !isTransient(ctx, err) {
+			return page, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return page, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isTransient reports whether err is a throttle, timeout or server error worth retrying,
+// using the SDK's own classification. Nothing is retried once ctx is done.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
@@ -0,0 +1,136 @@
+package awsx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// fakePager serves pages in order, failing a page with the queued errors before serving it
+type fakePager struct {
+	pages  []string
+	errs   map[int][]error // Page index -> errors returned before the page succeeds
+	next   int
+	called int
+}
+
+type fakeOptions struct{}
+
+func (p *fakePager) HasMorePages() bool {
+	return p.next < len(p.pages)
+}
+
+func (p *fakePager) NextPage(context.Context, ...func(*fakeOptions)) (string, error) {
+	p.called++
+	if errs := p.errs[p.next]; len(errs) > 0 {
+		p.errs[p.next] = errs[1:]
+		return "", errs[0]
+	}
+	page := p.pages[p.next]
+	p.next++
+	return page, nil
+}
+
+func TestEachPage(t *testing.T) {
+	previous := pageRetryDelay
+	pageRetryDelay = time.Millisecond
+	defer func() { pageRetryDelay = previous }()
+
+	throttled := &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}
+	denied := &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"}
+
+	tests := []struct {
+		name      string
+		errs      map[int][]error
+		wantPages []string
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "All pages",
+			wantPages: []string{"a", "b", "c"},
+			wantCalls: 3,
+		},
+		{
+			name:      "Throttled page is retried",
+			errs:      map[int][]error{1: {throttled, throttled}},
+			wantPages: []string{"a", "b", "c"},
+			wantCalls: 5,
+		},
+		{
+			name:      "Retries exhausted keeps earlier pages",
+			errs:      map[int][]error{2: {throttled, throttled, throttled, throttled}},
+			wantPages: []string{"a", "b"},
+			wantCalls: 2 + 1 + PageRetries,
+			wantErr:   throttled,
+		},
+		{
+			name:      "Permanent error is not retried",
+			errs:      map[int][]error{1: {denied}},
+			wantPages: []string{"a"},
+			wantCalls: 2,
+			wantErr:   denied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pager := &fakePager{pages: []string{"a", "b", "c"}, errs: tt.errs}
+
+			var got []string
+			err := EachPage(context.Background(), pager, func(page string) error {
+				got = append(got, page)
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EachPage() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantPages) {
+				t.Errorf("EachPage() handled pages %v, want %v", got, tt.wantPages)
+			}
+			if pager.called != tt.wantCalls {
+				t.Errorf("NextPage() called %d times, want %d", pager.called, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestEachPageStopsOnCallbackError(t *testing.T) {
+	pager := &fakePager{pages: []string{"a", "b", "c"}}
+	stop := errors.New("stop")
+
+	var got []string
+	err := EachPage(context.Background(), pager, func(page string) error {
+		got = append(got, page)
+		if page == "b" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("EachPage() error = %v, want %v", err, stop)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EachPage() handled pages %v, want %v", got, want)
+	}
+}
+
+func TestEachPageCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	throttled := &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}
+	pager := &fakePager{pages: []string{"a"}, errs: map[int][]error{0: {throttled, throttled}}}
+
+	err := EachPage(ctx, pager, func(string) error { return nil })
+	if !errors.Is(err, throttled) {
+		t.Errorf("EachPage() error = %v, want %v", err, throttled)
+	}
+	if pager.called != 1 {
+		t.Errorf("NextPage() called %d times after cancellation, want 1", pager.called)
+	}
+}
@@ -0,0 +1,173 @@
+package awsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	aastypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	wafv2types "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
+	"github.com/aws/smithy-go"
+)
+
+// serviceProbe is a cheap read-only call that shows whether a service can be reached
+type serviceProbe struct {
+	operation string
+	call      func(ctx context.Context, c *Clients) error
+}
+
+// serviceProbes maps IAM service prefixes to their probe. Each lists at most one item.
+var serviceProbes = map[string]serviceProbe{
+	"acm": {"ListCertificates", func(ctx context.Context, c *Clients) error {
+		_, err := c.ACM.ListCertificates(ctx, &acm.ListCertificatesInput{MaxItems: aws.Int32(1)})
+		return err
+	}},
+	"apigateway": {"GetRestApis", func(ctx context.Context, c *Clients) error {
+		_, err := c.APIGateway.GetRestApis(ctx, &apigateway.GetRestApisInput{Limit: aws.Int32(1)})
+		return err
+	}},
+	"application-autoscaling": {"DescribeScalableTargets", func(ctx context.Context, c *Clients) error {
+		_, err := c.ApplicationAutoScaling.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{
+			ServiceNamespace: aastypes.ServiceNamespaceEcs,
+			MaxResults:       aws.Int32(1),
+		})
+		return err
+	}},
+	"autoscaling": {"DescribeAutoScalingGroups", func(ctx context.Context, c *Clients) error {
+		_, err := c.AutoScaling.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{MaxRecords: aws.Int32(1)})
+		return err
+	}},
+	"ec2": {"DescribeRouteTables", func(ctx context.Context, c *Clients) error {
+		_, err := c.EC2.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{MaxResults: aws.Int32(5)})
+		return err
+	}},
+	"ecr": {"DescribeRepositories", func(ctx context.Context, c *Clients) error {
+		_, err := c.ECR.DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"ecs": {"ListClusters", func(ctx context.Context, c *Clients) error {
+		_, err := c.ECS.ListClusters(ctx, &ecs.ListClustersInput{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"elasticfilesystem": {"DescribeFileSystems", func(ctx context.Context, c *Clients) error {
+		_, err := c.EFS.DescribeFileSystems(ctx, &efs.DescribeFileSystemsInput{MaxItems: aws.Int32(1)})
+		return err
+	}},
+	"elasticloadbalancing": {"DescribeLoadBalancers", func(ctx context.Context, c *Clients) error {
+		_, err := c.ELBv2.DescribeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{PageSize: aws.Int32(1)})
+		return err
+	}},
+	"events": {"ListEventBuses", func(ctx context.Context, c *Clients) error {
+		_, err := c.EventBridge.ListEventBuses(ctx, &eventbridge.ListEventBusesInput{Limit: aws.Int32(1)})
+		return err
+	}},
+	"firehose": {"ListDeliveryStreams", func(ctx context.Context, c *Clients) error {
+		_, err := c.Firehose.ListDeliveryStreams(ctx, &firehose.ListDeliveryStreamsInput{Limit: aws.Int32(1)})
+		return err
+	}},
+	"iam": {"ListRoles", func(ctx context.Context, c *Clients) error {
+		_, err := c.IAM.ListRoles(ctx, &iam.ListRolesInput{MaxItems: aws.Int32(1)})
+		return err
+	}},
+	"kafka": {"ListClustersV2", func(ctx context.Context, c *Clients) error {
+		_, err := c.Kafka.ListClustersV2(ctx, &kafka.ListClustersV2Input{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"kinesis": {"ListStreams", func(ctx context.Context, c *Clients) error {
+		_, err := c.Kinesis.ListStreams(ctx, &kinesis.ListStreamsInput{Limit: aws.Int32(1)})
+		return err
+	}},
+	"kms": {"ListKeys", func(ctx context.Context, c *Clients) error {
+		_, err := c.KMS.ListKeys(ctx, &kms.ListKeysInput{Limit: aws.Int32(1)})
+		return err
+	}},
+	"lambda": {"ListFunctions", func(ctx context.Context, c *Clients) error {
+		_, err := c.Lambda.ListFunctions(ctx, &lambda.ListFunctionsInput{MaxItems: aws.Int32(1)})
+		return err
+	}},
+	"rds": {"DescribeDBInstances", func(ctx context.Context, c *Clients) error {
+		// 20 is the smallest page RDS accepts
+		_, err := c.RDS.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{MaxRecords: aws.Int32(20)})
+		return err
+	}},
+	"route53": {"ListHostedZones", func(ctx context.Context, c *Clients) error {
+		_, err := c.Route53.ListHostedZones(ctx, &route53.ListHostedZonesInput{MaxItems: aws.Int32(1)})
+		return err
+	}},
+	"secretsmanager": {"ListSecrets", func(ctx context.Context, c *Clients) error {
+		_, err := c.SecretsManager.ListSecrets(ctx, &secretsmanager.ListSecretsInput{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"servicediscovery": {"ListNamespaces", func(ctx context.Context, c *Clients) error {
+		_, err := c.ServiceDiscovery.ListNamespaces(ctx, &servicediscovery.ListNamespacesInput{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"ses": {"ListConfigurationSets", func(ctx context.Context, c *Clients) error {
+		_, err := c.SESv2.ListConfigurationSets(ctx, &sesv2.ListConfigurationSetsInput{PageSize: aws.Int32(1)})
+		return err
+	}},
+	"ssm": {"DescribeParameters", func(ctx context.Context, c *Clients) error {
+		_, err := c.SSM.DescribeParameters(ctx, &ssm.DescribeParametersInput{MaxResults: aws.Int32(1)})
+		return err
+	}},
+	"tag": {"GetResources", func(ctx context.Context, c *Clients) error {
+		_, err := c.Tagging.GetResources(ctx, &resourcegroupstaggingapi.GetResourcesInput{ResourcesPerPage: aws.Int32(1)})
+		return err
+	}},
+	"wafv2": {"ListWebACLs", func(ctx context.Context, c *Clients) error {
+		_, err := c.WAFv2.ListWebACLs(ctx, &wafv2.ListWebACLsInput{Scope: wafv2types.ScopeRegional, Limit: aws.Int32(1)})
+		return err
+	}},
+}
+
+// Probe makes one cheap read-only call to the service with the given IAM prefix (e.g. "lambda")
+// and returns the operation it called. A nil error means the service answered; use
+// IsAccessDenied to tell a missing permission from other failures. The probe only shows the
+// service is reachable with some permission, not that every action discovery needs is allowed.
+func (c *Clients) Probe(ctx context.Context, service string) (string, error) {
+	probe, ok := serviceProbes[service]
+	if !ok {
+		return "", fmt.Errorf("no probe for service %s", service)
+	}
+	return probe.operation, probe.call(ctx, c)
+}
+
+// IsAccessDenied reports whether err is an authorization failure from AWS
+func IsAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "UnauthorizedException",
+		"AuthorizationError", "AuthorizationErrorException":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+package awsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestIsAccessDenied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "AccessDeniedException", err: &smithy.GenericAPIError{Code: "AccessDeniedException"}, want: true},
+		{name: "Wrapped AccessDenied", err: fmt.Errorf("failed: %w", &smithy.GenericAPIError{Code: "AccessDenied"}), want: true},
+		{name: "EC2 UnauthorizedOperation", err: &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, want: true},
+		{name: "Throttling", err: &smithy.GenericAPIError{Code: "Throttling"}, want: false},
+		{name: "Not an API error", err: errors.New("connection refused"), want: false},
+		{name: "Nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAccessDenied(tt.err); got != tt.want {
+				t.Errorf("IsAccessDenied(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	// Deny every call once it is built so the test never reaches AWS
+	denied := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	deny := func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("deny",
+			func(context.Context, middleware.BuildInput, middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+				return middleware.BuildOutput{}, middleware.Metadata{}, denied
+			}), middleware.After)
+	}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		APIOptions: []func(*middleware.Stack) error{deny},
+	}
+	clients, err := NewClientsWithOptions(&cfg, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClientsWithOptions() error = %v", err)
+	}
+
+	for service, probe := range serviceProbes {
+		operation, probeErr := clients.Probe(context.Background(), service)
+		if operation != probe.operation {
+			t.Errorf("Probe(%s) operation = %q, want %q", service, operation, probe.operation)
+		}
+		if !IsAccessDenied(probeErr) {
+			t.Errorf("Probe(%s) error = %v, want access denied", service, probeErr)
+		}
+	}
+
+	if _, err := clients.Probe(context.Background(), "sqs"); err == nil {
+		t.Error("Probe(sqs) error = nil, want an error for a service without a probe")
+	}
+}
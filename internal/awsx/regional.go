@@ -0,0 +1,59 @@
+package awsx
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// ClientProvider lazily creates one set of service clients per region from a base config,
+// so discovery can follow references into regions other than the configured one
+type ClientProvider struct {
+	cfg  aws.Config
+	opts ClientOptions
+
+	mu      sync.Mutex
+	clients map[string]*Clients // Region -> clients
+}
+
+// NewClientProvider creates a provider whose home region is cfg.Region
+func NewClientProvider(cfg *aws.Config, opts ClientOptions) (*ClientProvider, error) {
+	if cfg == nil {
+		return nil, errors.New("aws config is required")
+	}
+	return &ClientProvider{
+		cfg:     cfg.Copy(),
+		opts:    opts,
+		clients: make(map[string]*Clients),
+	}, nil
+}
+
+// Region returns the provider's home region
+func (p *ClientProvider) Region() string {
+	return p.cfg.Region
+}
+
+// ForRegion returns the clients for region, creating them on first use. An empty region
+// returns the home region's clients.
+func (p *ClientProvider) ForRegion(region string) (*Clients, error) {
+	if region == "" {
+		region = p.cfg.Region
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if clients, ok := p.clients[region]; ok {
+		return clients, nil
+	}
+
+	cfg := p.cfg.Copy()
+	cfg.Region = region
+	clients, err := NewClientsWithOptions(&cfg, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[region] = clients
+	return clients, nil
+}
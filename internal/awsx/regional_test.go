@@ -0,0 +1,54 @@
+package awsx
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClientProvider(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1"}
+
+	provider, err := NewClientProvider(&cfg, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClientProvider() error = %v", err)
+	}
+	if provider.Region() != "us-east-1" {
+		t.Errorf("Region() = %q, want us-east-1", provider.Region())
+	}
+
+	home, err := provider.ForRegion("")
+	if err != nil {
+		t.Fatalf("ForRegion(\"\") error = %v", err)
+	}
+	assertAllClientsSet(t, home)
+
+	if again, _ := provider.ForRegion("us-east-1"); again != home {
+		t.Error("ForRegion(home) expected the same clients as ForRegion(\"\")")
+	}
+
+	west, err := provider.ForRegion("eu-west-1")
+	if err != nil {
+		t.Fatalf("ForRegion(eu-west-1) error = %v", err)
+	}
+	if west == home {
+		t.Error("ForRegion(eu-west-1) expected separate clients")
+	}
+	if got := west.Lambda.Options().Region; got != "eu-west-1" {
+		t.Errorf("ForRegion(eu-west-1) Lambda region = %q, want eu-west-1", got)
+	}
+	if again, _ := provider.ForRegion("eu-west-1"); again != west {
+		t.Error("ForRegion(eu-west-1) expected cached clients on second call")
+	}
+
+	// The caller's config must not be modified
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected original config region to be unchanged, got %q", cfg.Region)
+	}
+}
+
+func TestNewClientProviderNilConfig(t *testing.T) {
+	if _, err := NewClientProvider(nil, ClientOptions{}); err == nil {
+		t.Error("expected error for nil config, got nil")
+	}
+}
@@ -0,0 +1,24 @@
+package awsx
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// DefaultCallTimeout bounds a single API call, including its retries
+const DefaultCallTimeout = 30 * time.Second
+
+// withCallTimeout returns an API option that runs each operation under its own deadline.
+// It is added ahead of every other initialize middleware so retries share the deadline.
+func withCallTimeout(timeout time.Duration) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CallTimeout",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				ctx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	}
+}
@@ -0,0 +1,46 @@
+package awsx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestNewClientsWithOptionsCallTimeout(t *testing.T) {
+	// A handler that never answers, standing in for a hung connection
+	block := func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("block",
+			func(ctx context.Context, _ middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				<-ctx.Done()
+				return middleware.InitializeOutput{}, middleware.Metadata{}, ctx.Err()
+			}), middleware.After)
+	}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		APIOptions: []func(*middleware.Stack) error{block},
+	}
+
+	clients, err := NewClientsWithOptions(&cfg, ClientOptions{CallTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClientsWithOptions() error = %v", err)
+	}
+	if len(cfg.APIOptions) != 1 {
+		t.Errorf("caller's APIOptions modified: got %d options, want 1", len(cfg.APIOptions))
+	}
+
+	start := time.Now()
+	_, err = clients.Lambda.GetFunction(context.Background(), &lambda.GetFunctionInput{
+		FunctionName: aws.String("api"),
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetFunction() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetFunction() returned after %s, want it bounded by the call timeout", elapsed)
+	}
+}
@@ -0,0 +1,201 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// Metadata keys set on resources with a cost estimate
+const (
+	// MetadataKey holds the estimated monthly cost in US dollars
+	MetadataKey = "estimatedMonthlyUsd"
+	// BasisKey describes how the estimate was made, since every source is approximate
+	BasisKey = "costBasis"
+)
+
+// costExplorerDays is how far back resource-level costs are read; Cost Explorer only keeps
+// resource-level data for the last 14 days
+const costExplorerDays = 14
+
+// hoursPerMonth is the average number of hours in a month, as AWS uses for monthly pricing
+const hoursPerMonth = 730
+
+// costMetric is the Cost Explorer metric estimates are based on
+const costMetric = "UnblendedCost"
+
+// Estimate is an approximate monthly cost of one resource
+type Estimate struct {
+	MonthlyUSD float64
+	Basis      string
+}
+
+// rdsHourlyUSD holds single-AZ on-demand prices of common RDS instance classes for MySQL and
+// PostgreSQL in us-east-1, used when Cost Explorer has no data for an instance
+var rdsHourlyUSD = map[string]float64{
+	"db.t3.micro":   0.017,
+	"db.t3.small":   0.034,
+	"db.t3.medium":  0.068,
+	"db.t3.large":   0.136,
+	"db.t4g.micro":  0.016,
+	"db.t4g.small":  0.032,
+	"db.t4g.medium": 0.065,
+	"db.t4g.large":  0.129,
+	"db.m5.large":   0.171,
+	"db.m5.xlarge":  0.342,
+	"db.m6g.large":  0.152,
+	"db.m6g.xlarge": 0.304,
+	"db.r5.large":   0.24,
+	"db.r5.xlarge":  0.48,
+	"db.r6g.large":  0.215,
+	"db.r6g.xlarge": 0.43,
+}
+
+// FromCostExplorer estimates the monthly cost of every resource in g that Cost Explorer reports
+// resource-level costs for, keyed by node ID. The unblended cost of the last 14 days before now
+// is scaled to a month, so recently created or resized resources are approximate. Resource-level
+// data must be enabled in the Cost Explorer settings of the account.
+func FromCostExplorer(ctx context.Context, client *costexplorer.Client, g *graph.Graph, now time.Time) (map[string]Estimate, error) {
+	// Cost Explorer identifies resources by ARN for most services and by bare ID for EC2, so
+	// nodes scoped by account and region are looked up by their local ID
+	nodeIDs := make(map[string]string)
+	for _, node := range g.Nodes() {
+		nodeIDs[node.LocalID()] = node.ID
+		if node.ARN != "" {
+			nodeIDs[node.ARN] = node.ID
+		}
+	}
+	if len(nodeIDs) == 0 {
+		return nil, nil
+	}
+	resourceIDs := make([]string, 0, len(nodeIDs))
+	for id := range nodeIDs {
+		resourceIDs = append(resourceIDs, id)
+	}
+	sort.Strings(resourceIDs)
+
+	end := now.UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -costExplorerDays)
+	input := &costexplorer.GetCostAndUsageWithResourcesInput{
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(start.Format(time.DateOnly)),
+			End:   aws.String(end.Format(time.DateOnly)),
+		},
+		Granularity: cetypes.GranularityDaily,
+		Metrics:     []string{costMetric},
+		Filter: &cetypes.Expression{
+			Dimensions: &cetypes.DimensionValues{
+				Key:    cetypes.DimensionResourceId,
+				Values: resourceIDs,
+			},
+		},
+		GroupBy: []cetypes.GroupDefinition{{
+			Type: cetypes.GroupDefinitionTypeDimension,
+			Key:  aws.String(string(cetypes.DimensionResourceId)),
+		}},
+	}
+
+	var results []cetypes.ResultByTime
+	for {
+		output, err := client.GetCostAndUsageWithResources(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cost and usage: %w", err)
+		}
+		results = append(results, output.ResultsByTime...)
+		if output.NextPageToken == nil {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	totals, err := aggregate(results)
+	if err != nil {
+		return nil, err
+	}
+
+	basis := fmt.Sprintf("Cost Explorer unblended cost from %s to %s, scaled to a month", start.Format(time.DateOnly), end.Format(time.DateOnly))
+	estimates := make(map[string]Estimate)
+	for resourceID, total := range totals {
+		nodeID, ok := nodeIDs[resourceID]
+		if !ok {
+			continue
+		}
+		// A resource reported under both its ID and ARN is summed
+		estimate := estimates[nodeID]
+		estimate.MonthlyUSD += total / costExplorerDays * hoursPerMonth / 24
+		estimate.Basis = basis
+		estimates[nodeID] = estimate
+	}
+	return estimates, nil
+}
+
+// aggregate sums the cost of each resource across the daily results of a grouped query
+func aggregate(results []cetypes.ResultByTime) (map[string]float64, error) {
+	totals := make(map[string]float64)
+	for i := range results {
+		for _, group := range results[i].Groups {
+			if len(group.Keys) == 0 {
+				continue
+			}
+			metric, ok := group.Metrics[costMetric]
+			if !ok || metric.Amount == nil {
+				continue
+			}
+			amount, err := strconv.ParseFloat(*metric.Amount, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cost amount %q for %s: %w", *metric.Amount, group.Keys[0], err)
+			}
+			totals[group.Keys[0]] += amount
+		}
+	}
+	return totals, nil
+}
+
+// StaticEstimates estimates the monthly cost of RDS instances from the on-demand price of their
+// instance class, keyed by node ID. Multi-AZ instances are priced twice. Prices are us-east-1
+// list prices, so instances in other regions or with other engines are approximate.
+func StaticEstimates(g *graph.Graph) map[string]Estimate {
+	estimates := make(map[string]Estimate)
+	for _, node := range g.Nodes() {
+		instanceClass, ok := node.MetaString("instanceClass")
+		if !ok {
+			continue
+		}
+		hourly, ok := rdsHourlyUSD[instanceClass]
+		if !ok {
+			continue
+		}
+
+		basis := "us-east-1 on-demand price of " + instanceClass
+		if multiAZ, _ := node.MetaBool("multiAZ"); multiAZ {
+			hourly *= 2
+			basis += ", multi-AZ"
+		}
+		estimates[node.ID] = Estimate{MonthlyUSD: hourly * hoursPerMonth, Basis: basis}
+	}
+	return estimates
+}
+
+// Apply records estimates on the nodes of g, rounded to cents, with the basis of each
+func Apply(g *graph.Graph, estimates map[string]Estimate) {
+	for id, estimate := range estimates {
+		node, ok := g.GetNode(id)
+		if !ok {
+			continue
+		}
+		if node.Metadata == nil {
+			node.Metadata = make(map[string]any)
+		}
+		node.Metadata[MetadataKey] = math.Round(estimate.MonthlyUSD*100) / 100
+		node.Metadata[BasisKey] = estimate.Basis
+	}
+}
@@ -0,0 +1,184 @@
+package cost
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const (
+	testLambdaARN = "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	testDBARN     = "arn:aws:rds:us-east-1:123456789012:db:orders"
+)
+
+// costGroup returns a Cost Explorer group with the unblended cost of one resource
+func costGroup(resourceID, amount string) cetypes.Group {
+	return cetypes.Group{
+		Keys:    []string{resourceID},
+		Metrics: map[string]cetypes.MetricValue{costMetric: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	results := []cetypes.ResultByTime{
+		{Groups: []cetypes.Group{costGroup(testLambdaARN, "1.50"), costGroup("i-0123456789abcdef0", "2.25")}},
+		{Groups: []cetypes.Group{costGroup(testLambdaARN, "0.50"), {Keys: []string{"no-metric"}}}},
+	}
+
+	totals, err := aggregate(results)
+	if err != nil {
+		t.Fatalf("aggregate() error = %v", err)
+	}
+	if len(totals) != 2 || totals[testLambdaARN] != 2 || totals["i-0123456789abcdef0"] != 2.25 {
+		t.Errorf("aggregate() = %v, want the Lambda at 2 and the instance at 2.25", totals)
+	}
+
+	_, err = aggregate([]cetypes.ResultByTime{{Groups: []cetypes.Group{costGroup(testLambdaARN, "n/a")}}})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse cost amount") {
+		t.Errorf("aggregate() error = %v, want a parse error", err)
+	}
+}
+
+func TestFromCostExplorer(t *testing.T) {
+	pages := []*costexplorer.GetCostAndUsageWithResourcesOutput{
+		{
+			ResultsByTime: []cetypes.ResultByTime{{Groups: []cetypes.Group{costGroup(testLambdaARN, "7")}}},
+			NextPageToken: aws.String("page-2"),
+		},
+		{
+			ResultsByTime: []cetypes.ResultByTime{{Groups: []cetypes.Group{
+				costGroup(testLambdaARN, "7"),
+				// Resources outside the graph are ignored
+				costGroup("arn:aws:lambda:us-east-1:123456789012:function:other", "100"),
+			}}},
+		},
+	}
+	var inputs []*costexplorer.GetCostAndUsageWithResourcesInput
+	client := costexplorer.New(costexplorer.Options{
+		Region: "us-east-1",
+		APIOptions: []func(*middleware.Stack) error{func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("stubCostExplorer",
+				func(_ context.Context, in middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					input := *in.Parameters.(*costexplorer.GetCostAndUsageWithResourcesInput)
+					inputs = append(inputs, &input)
+					page := pages[0]
+					pages = pages[1:]
+					return middleware.InitializeOutput{Result: page}, middleware.Metadata{}, nil
+				}), middleware.After)
+		}},
+	})
+
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: testLambdaARN, ARN: testLambdaARN, Type: "Lambda"})
+	g.AddNode(&graph.Node{ID: "sg-1", Type: "SecurityGroup"})
+
+	now := time.Date(2026, 3, 15, 18, 30, 0, 0, time.UTC)
+	estimates, err := FromCostExplorer(context.Background(), client, g, now)
+	if err != nil {
+		t.Fatalf("FromCostExplorer() error = %v", err)
+	}
+
+	if len(inputs) != 2 || aws.ToString(inputs[1].NextPageToken) != "page-2" {
+		t.Fatalf("GetCostAndUsageWithResources called with %d inputs, want 2 pages", len(inputs))
+	}
+	if start, end := aws.ToString(inputs[0].TimePeriod.Start), aws.ToString(inputs[0].TimePeriod.End); start != "2026-03-01" || end != "2026-03-15" {
+		t.Errorf("TimePeriod = %s to %s, want the 14 days before today", start, end)
+	}
+	if values := inputs[0].Filter.Dimensions.Values; strings.Join(values, ",") != testLambdaARN+",sg-1" {
+		t.Errorf("Filter values = %v, want the graph's IDs and ARNs", values)
+	}
+
+	// $14 over 14 days is $1 a day, or 730/24 a month
+	estimate, ok := estimates[testLambdaARN]
+	if len(estimates) != 1 || !ok || math.Abs(estimate.MonthlyUSD-730.0/24) > 1e-9 {
+		t.Errorf("FromCostExplorer() = %+v, want only the Lambda at %.2f", estimates, 730.0/24)
+	}
+	if !strings.Contains(estimate.Basis, "2026-03-01 to 2026-03-15") {
+		t.Errorf("Basis = %q, want the period recorded", estimate.Basis)
+	}
+}
+
+func TestFromCostExplorerScopedEC2(t *testing.T) {
+	const (
+		instanceID = "i-0123456789abcdef0"
+		nodeID     = "EC2Instance:123456789012:us-east-1:" + instanceID
+	)
+	var values []string
+	client := costexplorer.New(costexplorer.Options{
+		Region: "us-east-1",
+		APIOptions: []func(*middleware.Stack) error{func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("stubCostExplorer",
+				func(_ context.Context, in middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					values = in.Parameters.(*costexplorer.GetCostAndUsageWithResourcesInput).Filter.Dimensions.Values
+					return middleware.InitializeOutput{Result: &costexplorer.GetCostAndUsageWithResourcesOutput{
+						ResultsByTime: []cetypes.ResultByTime{{Groups: []cetypes.Group{costGroup(instanceID, "14")}}},
+					}}, middleware.Metadata{}, nil
+				}), middleware.After)
+		}},
+	})
+
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: nodeID, Type: "EC2Instance", Region: "us-east-1", Account: "123456789012"})
+
+	estimates, err := FromCostExplorer(context.Background(), client, g, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FromCostExplorer() error = %v", err)
+	}
+
+	// Cost Explorer is asked for the bare instance ID and its cost lands on the scoped node
+	if strings.Join(values, ",") != instanceID {
+		t.Errorf("Filter values = %v, want the bare instance ID", values)
+	}
+	if estimate, ok := estimates[nodeID]; !ok || math.Abs(estimate.MonthlyUSD-730.0/24) > 1e-9 {
+		t.Errorf("FromCostExplorer() = %+v, want the instance at %.2f", estimates, 730.0/24)
+	}
+}
+
+func TestStaticEstimates(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: testDBARN, Type: "RDSInstance", Metadata: map[string]any{"instanceClass": "db.t3.medium"}})
+	g.AddNode(&graph.Node{ID: "replica", Type: "RDSInstance", Metadata: map[string]any{"instanceClass": "db.r6g.large", "multiAZ": true}})
+	g.AddNode(&graph.Node{ID: "unknown-class", Type: "RDSInstance", Metadata: map[string]any{"instanceClass": "db.x2g.16xlarge"}})
+	g.AddNode(&graph.Node{ID: "fn", Type: "Lambda"})
+
+	estimates := StaticEstimates(g)
+	if len(estimates) != 2 {
+		t.Fatalf("StaticEstimates() = %+v, want the two known classes", estimates)
+	}
+	if got := estimates[testDBARN]; math.Abs(got.MonthlyUSD-0.068*730) > 1e-9 || got.Basis != "us-east-1 on-demand price of db.t3.medium" {
+		t.Errorf("single-AZ estimate = %+v", got)
+	}
+	if got := estimates["replica"]; math.Abs(got.MonthlyUSD-0.215*2*730) > 1e-9 || !strings.HasSuffix(got.Basis, "multi-AZ") {
+		t.Errorf("multi-AZ estimate = %+v", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: testLambdaARN, Type: "Lambda"})
+	g.AddNode(&graph.Node{ID: testDBARN, Type: "RDSInstance"})
+
+	Apply(g, map[string]Estimate{
+		testLambdaARN: {MonthlyUSD: 30.416666, Basis: "Cost Explorer"},
+		testDBARN:     {MonthlyUSD: 49.64, Basis: "static"},
+		"missing":     {MonthlyUSD: 1000, Basis: "static"},
+	})
+
+	lambda, _ := g.GetNode(testLambdaARN)
+	if lambda.Metadata[MetadataKey] != 30.42 || lambda.Metadata[BasisKey] != "Cost Explorer" {
+		t.Errorf("Lambda metadata = %v, want the estimate rounded to cents", lambda.Metadata)
+	}
+
+	if _, ok := g.GetNode("missing"); ok {
+		t.Error("Apply() added a node for an estimate outside the graph")
+	}
+}
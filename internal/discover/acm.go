@@ -0,0 +1,132 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// discoverListenerCertificate links a listener to a certificate it serves, sharing one node per
+// certificate. Certificates are described up front so expiry is known even at the depth limit.
+func (d *Discoverer) discoverListenerCertificate(ctx context.Context, certARN string, listenerNode *graph.Node, g *graph.Graph) string {
+	certNode := acmCertToNode(certARN, listenerNode.Region, listenerNode.Account)
+	if !g.HasNode(certNode.ID) {
+		if err := d.describeACMCertificate(ctx, certNode); err != nil {
+			d.warn(certNode.ID, "Failed to describe certificate", err, "arn", certARN)
+		}
+		g.AddNode(certNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         listenerNode.ID,
+		To:           certNode.ID,
+		RelationType: "uses-certificate",
+		Evidence: graph.Evidence{
+			APICall: "DescribeListeners",
+			Fields: map[string]any{
+				"CertificateArn": certARN,
+			},
+		},
+	})
+
+	return certNode.ID
+}
+
+// discoverACMCertificate fills in domains, expiry and validation status for a certificate.
+// Certificates are leaves in the graph, so no neighbors are returned.
+func (d *Discoverer) discoverACMCertificate(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	// Certificates found through a listener are already described
+	if _, ok := node.Metadata["status"]; ok {
+		return nil, nil
+	}
+	return nil, d.describeACMCertificate(ctx, node)
+}
+
+// describeACMCertificate records certificate details on node
+func (d *Discoverer) describeACMCertificate(ctx context.Context, node *graph.Node) error {
+	d.log.Debug("Describing ACM certificate", "arn", node.ARN)
+
+	// Certificates are regional and may live outside the configured region
+	// (CloudFront certificates are always in us-east-1), so call ACM in the ARN's region
+	output, err := d.clients.ACM.DescribeCertificate(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: &node.ARN,
+	}, func(o *acm.Options) {
+		if node.Region != "" {
+			o.Region = node.Region
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe certificate: %w", err)
+	}
+	if output.Certificate != nil {
+		setACMCertificateMetadata(node, output.Certificate)
+	}
+
+	return nil
+}
+
+// isACMCertificateARN reports whether arn is an ACM certificate rather than an IAM server certificate
+func isACMCertificateARN(arn string) bool {
+	return strings.Contains(arn, ":acm:") && strings.Contains(arn, ":certificate/")
+}
+
+// acmCertToNode creates an ACM certificate node from its ARN. Region and account come from
+// the ARN, falling back to the referencing resource's for malformed ARNs.
+func acmCertToNode(certARN, region, account string) *graph.Node {
+	node := &graph.Node{
+		ID:       certARN,
+		Type:     ResourceTypeACMCertificate,
+		ARN:      certARN,
+		Name:     extractNameFromARN(certARN),
+		Region:   region,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:aws:acm:region:account:certificate/certificate-id
+	if parts := strings.SplitN(certARN, ":", 6); len(parts) == 6 && parts[0] == "arn" {
+		node.Region = parts[3]
+		node.Account = parts[4]
+	}
+
+	return node
+}
+
+// setACMCertificateMetadata records domains, expiry and validation status from DescribeCertificate
+func setACMCertificateMetadata(node *graph.Node, cert *acmtypes.CertificateDetail) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	if cert.DomainName != nil {
+		node.Name = *cert.DomainName
+		node.Metadata["domainName"] = *cert.DomainName
+	}
+	if len(cert.SubjectAlternativeNames) > 0 {
+		node.Metadata["subjectAlternativeNames"] = cert.SubjectAlternativeNames
+	}
+	if cert.NotAfter != nil {
+		node.Metadata["notAfter"] = *cert.NotAfter
+	}
+	node.Metadata["status"] = cert.Status
+	node.Metadata["type"] = cert.Type
+	if cert.RenewalEligibility != "" {
+		node.Metadata["renewalEligibility"] = cert.RenewalEligibility
+	}
+
+	// Report the primary domain's validation status, or the first domain still pending
+	for i := range cert.DomainValidationOptions {
+		option := &cert.DomainValidationOptions[i]
+		if option.ValidationStatus != acmtypes.DomainStatusSuccess {
+			node.Metadata["validationStatus"] = option.ValidationStatus
+			break
+		}
+		if option.DomainName != nil && cert.DomainName != nil && *option.DomainName == *cert.DomainName {
+			node.Metadata["validationStatus"] = option.ValidationStatus
+		}
+	}
+}
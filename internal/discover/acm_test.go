@@ -0,0 +1,122 @@
+package discover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestACMCertToNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		certARN     string
+		region      string
+		account     string
+		wantName    string
+		wantRegion  string
+		wantAccount string
+	}{
+		{
+			name:        "Same region as listener",
+			certARN:     "arn:aws:acm:us-west-2:123456789012:certificate/12345678-1234-1234-1234-123456789012",
+			region:      "us-west-2",
+			account:     "123456789012",
+			wantName:    "12345678-1234-1234-1234-123456789012",
+			wantRegion:  "us-west-2",
+			wantAccount: "123456789012",
+		},
+		{
+			// CloudFront certificates always live in us-east-1
+			name:        "Different region from referencing resource",
+			certARN:     "arn:aws:acm:us-east-1:123456789012:certificate/abcdefab-1234-1234-1234-123456789012",
+			region:      "eu-west-1",
+			account:     "123456789012",
+			wantName:    "abcdefab-1234-1234-1234-123456789012",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+		},
+		{
+			name:        "Malformed ARN falls back to referencing resource",
+			certARN:     "certificate/abc",
+			region:      "eu-west-1",
+			account:     "123456789012",
+			wantName:    "abc",
+			wantRegion:  "eu-west-1",
+			wantAccount: "123456789012",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := acmCertToNode(tt.certARN, tt.region, tt.account)
+
+			if node.Type != ResourceTypeACMCertificate {
+				t.Errorf("acmCertToNode() Type = %v, want %v", node.Type, ResourceTypeACMCertificate)
+			}
+			if node.ID != tt.certARN {
+				t.Errorf("acmCertToNode() ID = %v, want %v", node.ID, tt.certARN)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("acmCertToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+			if node.Region != tt.wantRegion {
+				t.Errorf("acmCertToNode() Region = %v, want %v", node.Region, tt.wantRegion)
+			}
+			if node.Account != tt.wantAccount {
+				t.Errorf("acmCertToNode() Account = %v, want %v", node.Account, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestSetACMCertificateMetadata(t *testing.T) {
+	expiry := time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)
+	node := acmCertToNode("arn:aws:acm:us-east-1:123456789012:certificate/abc", "us-east-1", "123456789012")
+
+	setACMCertificateMetadata(node, &acmtypes.CertificateDetail{
+		DomainName:              aws.String("example.com"),
+		SubjectAlternativeNames: []string{"example.com", "www.example.com"},
+		NotAfter:                &expiry,
+		Status:                  acmtypes.CertificateStatusIssued,
+		DomainValidationOptions: []acmtypes.DomainValidation{
+			{DomainName: aws.String("example.com"), ValidationStatus: acmtypes.DomainStatusSuccess},
+			{DomainName: aws.String("www.example.com"), ValidationStatus: acmtypes.DomainStatusPendingValidation},
+		},
+	})
+
+	if node.Name != "example.com" {
+		t.Errorf("Name = %v, want example.com", node.Name)
+	}
+	if node.Metadata["notAfter"] != expiry {
+		t.Errorf("notAfter = %v, want %v", node.Metadata["notAfter"], expiry)
+	}
+	if node.Metadata["validationStatus"] != acmtypes.DomainStatusPendingValidation {
+		t.Errorf("validationStatus = %v, want %v", node.Metadata["validationStatus"], acmtypes.DomainStatusPendingValidation)
+	}
+}
+
+func TestIsACMCertificateARN(t *testing.T) {
+	if !isACMCertificateARN("arn:aws:acm:us-east-1:123456789012:certificate/abc") {
+		t.Error("isACMCertificateARN() = false for ACM certificate")
+	}
+	if isACMCertificateARN("arn:aws:iam::123456789012:server-certificate/legacy") {
+		t.Error("isACMCertificateARN() = true for IAM server certificate")
+	}
+}
+
+func TestDiscoverACMCertificateAlreadyDescribed(t *testing.T) {
+	d := New(nil, &Options{})
+	node := acmCertToNode("arn:aws:acm:us-east-1:123456789012:certificate/abc", "", "")
+	node.Metadata["status"] = acmtypes.CertificateStatusIssued
+
+	// No clients are configured, so this would panic if ACM were called
+	neighbors, err := d.discoverACMCertificate(context.Background(), node, graph.New())
+	if err != nil || neighbors != nil {
+		t.Errorf("discoverACMCertificate() = %v, %v; want nil, nil", neighbors, err)
+	}
+}
@@ -2,43 +2,80 @@ package discover
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
 	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
+// loadBalancerNamePattern matches a valid ELBv2 load balancer name
+var loadBalancerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,30}[a-zA-Z0-9])?$`)
+
+// gwlbGenevePort is the port every Gateway Load Balancer listener uses for GENEVE traffic
+const gwlbGenevePort = 6081
+
 // resolveLoadBalancerByName resolves a load balancer by name
 func (d *Discoverer) resolveLoadBalancerByName(ctx context.Context, name string) (*graph.Node, error) {
-	slog.Debug("Resolving load balancer by name", "name", name)
+	d.log.Debug("Resolving load balancer by name", "name", name)
 
-	// List all load balancers and filter by name
-	paginator := elasticloadbalancingv2.NewDescribeLoadBalancersPaginator(d.clients.ELBv2, &elasticloadbalancingv2.DescribeLoadBalancersInput{})
+	if lb, ok := d.lbNameCache[name]; ok {
+		if lb == nil {
+			return nil, fmt.Errorf("load balancer not found: %s", name)
+		}
+		return d.loadBalancerToNode(lb), nil
+	}
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	// Strings that cannot be load balancer names never need an API call
+	if !loadBalancerNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("load balancer not found: %s", name)
+	}
+
+	// Look the name up directly rather than paginating through every load balancer
+	output, err := d.clients.ELBv2.DescribeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{
+		Names: []string{name},
+	})
+	if err != nil {
+		var notFoundErr *elbv2types.LoadBalancerNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.cacheLoadBalancerName(name, nil)
+			return nil, fmt.Errorf("load balancer not found: %s", name)
 		}
+		return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	}
 
-		for i := range output.LoadBalancers {
-			lb := &output.LoadBalancers[i]
-			if lb.LoadBalancerName != nil && *lb.LoadBalancerName == name {
-				return d.loadBalancerToNode(lb), nil
-			}
+	for i := range output.LoadBalancers {
+		lb := &output.LoadBalancers[i]
+		if lb.LoadBalancerName != nil && *lb.LoadBalancerName == name {
+			d.cacheLoadBalancerName(name, lb)
+			return d.loadBalancerToNode(lb), nil
 		}
 	}
 
+	d.cacheLoadBalancerName(name, nil)
 	return nil, fmt.Errorf("load balancer not found: %s", name)
 }
 
+// cacheLoadBalancerName records a name lookup result; a nil lb records that the name does not exist
+func (d *Discoverer) cacheLoadBalancerName(name string, lb *elbv2types.LoadBalancer) {
+	if d.lbNameCache == nil {
+		d.lbNameCache = make(map[string]*elbv2types.LoadBalancer)
+	}
+	d.lbNameCache[name] = lb
+}
+
 // discoverLoadBalancer discovers dependencies for a load balancer
 func (d *Discoverer) discoverLoadBalancer(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering load balancer dependencies", "arn", node.ARN)
+	d.log.Debug("Discovering load balancer dependencies", "arn", node.ARN)
 
 	var neighbors []string
 
@@ -56,10 +93,16 @@ func (d *Discoverer) discoverLoadBalancer(ctx context.Context, node *graph.Node,
 
 	lb := &output.LoadBalancers[0]
 
+	// Nodes parsed from an ARN do not know yet whether the load balancer is dualstack
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	node.Metadata["ipAddressType"] = string(lb.IpAddressType)
+
 	// Add security groups
 	for _, sgID := range lb.SecurityGroups {
 		sgNode := &graph.Node{
-			ID:      sgID,
+			ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, sgID),
 			Type:    "SecurityGroup",
 			Name:    sgID,
 			Region:  node.Region,
@@ -87,7 +130,7 @@ func (d *Discoverer) discoverLoadBalancer(ctx context.Context, node *graph.Node,
 	for _, subnet := range lb.AvailabilityZones {
 		if subnet.SubnetId != nil {
 			subnetNode := &graph.Node{
-				ID:      *subnet.SubnetId,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSubnet, *subnet.SubnetId),
 				Type:    "Subnet",
 				Name:    *subnet.SubnetId,
 				Region:  node.Region,
@@ -112,19 +155,33 @@ func (d *Discoverer) discoverLoadBalancer(ctx context.Context, node *graph.Node,
 		}
 	}
 
-	// Discover listeners
-	listenerNeighbors, err := d.discoverListeners(ctx, node, g)
+	// Add the Elastic IPs of NLBs with static addresses, and record dualstack IPv6 addresses
+	neighbors = append(neighbors, d.addLoadBalancerAddresses(g, node, lb)...)
+
+	// Discover listeners, keeping those from pages read before a failure
+	listenerNeighbors, err := d.discoverListeners(ctx, node, lb.Type, g)
 	if err != nil {
-		slog.Warn("Failed to discover listeners", "error", err)
-	} else {
-		neighbors = append(neighbors, listenerNeighbors...)
+		d.warn(node.ID, "Failed to discover listeners", err)
+	}
+	neighbors = append(neighbors, listenerNeighbors...)
+
+	// Discover the WAF Web ACL protecting this LB (only ALBs can be associated)
+	if lb.Type == elbv2types.LoadBalancerTypeEnumApplication {
+		aclID, wafErr := d.discoverWebACLForResource(ctx, node, g)
+		if wafErr != nil {
+			d.warn(node.ID, "Failed to discover web ACL", wafErr)
+		} else if aclID != "" {
+			neighbors = append(neighbors, aclID)
+		}
 	}
 
 	// Discover Route53 upstream (records that alias to this LB)
-	if lb.DNSName != nil {
+	if d.opts.SkipRoute53 {
+		d.log.Debug("Skipping Route53 alias discovery", "loadBalancer", node.ID)
+	} else if lb.DNSName != nil {
 		route53Neighbors, err := d.discoverRoute53Aliases(ctx, *lb.DNSName, node, g)
 		if err != nil {
-			slog.Warn("Failed to discover Route53 aliases", "error", err)
+			d.warn(node.ID, "Failed to discover Route53 aliases", err)
 		} else {
 			neighbors = append(neighbors, route53Neighbors...)
 		}
@@ -133,9 +190,66 @@ func (d *Discoverer) discoverLoadBalancer(ctx context.Context, node *graph.Node,
 	return neighbors, nil
 }
 
-// discoverListeners discovers listeners for a load balancer
-func (d *Discoverer) discoverListeners(ctx context.Context, lbNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering listeners", "loadBalancer", lbNode.ARN)
+// addLoadBalancerAddresses links a load balancer to the Elastic IPs allocated to it, one per
+// subnet for internet-facing NLBs with static addresses, and returns their IDs. The IPv6
+// addresses of dualstack load balancers have no resource of their own, so they are recorded
+// in metadata.
+func (d *Discoverer) addLoadBalancerAddresses(g *graph.Graph, node *graph.Node, lb *elbv2types.LoadBalancer) []string {
+	var neighbors []string
+	var ipv6Addresses []string
+	for _, zone := range lb.AvailabilityZones {
+		for _, address := range zone.LoadBalancerAddresses {
+			if address.IPv6Address != nil {
+				ipv6Addresses = append(ipv6Addresses, *address.IPv6Address)
+			}
+			if address.AllocationId == nil {
+				continue
+			}
+
+			eipNode := elasticIPNode(node, *address.AllocationId, address.IpAddress)
+			if address.PrivateIPv4Address != nil {
+				eipNode.Metadata["privateIpv4Address"] = *address.PrivateIPv4Address
+			}
+			if zone.SubnetId != nil {
+				eipNode.Metadata["subnetId"] = *zone.SubnetId
+			}
+			if zone.ZoneName != nil {
+				eipNode.Metadata["availabilityZone"] = *zone.ZoneName
+			}
+			if !g.HasNode(eipNode.ID) {
+				g.AddNode(eipNode)
+			}
+			fields := map[string]any{
+				"AllocationId": *address.AllocationId,
+			}
+			if address.IpAddress != nil {
+				fields["IpAddress"] = *address.IpAddress
+			}
+			if zone.SubnetId != nil {
+				fields["SubnetId"] = *zone.SubnetId
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           eipNode.ID,
+				RelationType: "has-address",
+				Evidence: graph.Evidence{
+					APICall: "DescribeLoadBalancers",
+					Fields:  fields,
+				},
+			})
+			neighbors = append(neighbors, eipNode.ID)
+		}
+	}
+	if len(ipv6Addresses) > 0 {
+		node.Metadata["ipv6Addresses"] = ipv6Addresses
+	}
+	return neighbors
+}
+
+// discoverListeners discovers listeners for a load balancer. Only ALB listeners have rules;
+// NLB and GWLB listeners route through their default actions alone.
+func (d *Discoverer) discoverListeners(ctx context.Context, lbNode *graph.Node, lbType elbv2types.LoadBalancerTypeEnum, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering listeners", "loadBalancer", lbNode.ARN)
 
 	var neighbors []string
 
@@ -143,37 +257,42 @@ func (d *Discoverer) discoverListeners(ctx context.Context, lbNode *graph.Node,
 		LoadBalancerArn: &lbNode.ARN,
 	})
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe listeners: %w", err)
-		}
-
+	pageErr := awsx.EachPage(ctx, paginator, func(output *elasticloadbalancingv2.DescribeListenersOutput) error {
 		for i := range output.Listeners {
 			listener := &output.Listeners[i]
-			listenerNode := d.listenerToNode(listener, lbNode.Region, lbNode.Account)
+			listenerNode := d.listenerToNode(listener, lbType, lbNode.Region, lbNode.Account)
 			g.AddNode(listenerNode)
+			fields := map[string]any{
+				"ListenerArn": *listener.ListenerArn,
+				"Protocol":    listener.Protocol,
+			}
+			if listener.Port != nil {
+				fields["Port"] = *listener.Port
+			}
 			g.AddEdge(&graph.Edge{
 				From:         lbNode.ID,
 				To:           listenerNode.ID,
 				RelationType: "has-listener",
 				Evidence: graph.Evidence{
 					APICall: "DescribeListeners",
-					Fields: map[string]any{
-						"ListenerArn": *listener.ListenerArn,
-						"Port":        *listener.Port,
-						"Protocol":    listener.Protocol,
-					},
+					Fields:  fields,
 				},
 			})
 			neighbors = append(neighbors, listenerNode.ID)
 
+			// Discover certificates served by HTTPS/TLS listeners
+			for _, cert := range listener.Certificates {
+				if cert.CertificateArn != nil && isACMCertificateARN(*cert.CertificateArn) {
+					neighbors = append(neighbors, d.discoverListenerCertificate(ctx, *cert.CertificateArn, listenerNode, g))
+				}
+			}
+
 			// Discover default actions (target groups)
 			for _, action := range listener.DefaultActions {
 				if action.TargetGroupArn != nil {
 					tgNeighbors, err := d.discoverTargetGroup(ctx, *action.TargetGroupArn, listenerNode, g)
 					if err != nil {
-						slog.Warn("Failed to discover target group", "arn", *action.TargetGroupArn, "error", err)
+						d.warn(listenerNode.ID, "Failed to discover target group", err, "arn", *action.TargetGroupArn)
 					} else {
 						neighbors = append(neighbors, tgNeighbors...)
 					}
@@ -181,58 +300,152 @@ func (d *Discoverer) discoverListeners(ctx context.Context, lbNode *graph.Node,
 			}
 
 			// Discover listener rules
+			if lbType != elbv2types.LoadBalancerTypeEnumApplication {
+				continue
+			}
 			ruleNeighbors, err := d.discoverListenerRules(ctx, listener, listenerNode, g)
 			if err != nil {
-				slog.Warn("Failed to discover listener rules", "error", err)
-			} else {
-				neighbors = append(neighbors, ruleNeighbors...)
+				d.warn(listenerNode.ID, "Failed to discover listener rules", err)
 			}
+			neighbors = append(neighbors, ruleNeighbors...)
 		}
+		return nil
+	})
+	if pageErr != nil {
+		return neighbors, fmt.Errorf("failed to describe listeners: %w", pageErr)
 	}
 
 	return neighbors, nil
 }
 
-// discoverListenerRules discovers rules for a listener
+// discoverListenerRules adds a ListenerRule node for each of a listener's non-default rules, in
+// priority order, between the listener and the target groups the rule forwards to, so
+// path-based routing shows which paths reach which targets
 func (d *Discoverer) discoverListenerRules(ctx context.Context, listener *elbv2types.Listener, listenerNode *graph.Node, g *graph.Graph) ([]string, error) {
-	var neighbors []string
-
+	var rules []elbv2types.Rule
 	paginator := elasticloadbalancingv2.NewDescribeRulesPaginator(d.clients.ELBv2, &elasticloadbalancingv2.DescribeRulesInput{
 		ListenerArn: listener.ListenerArn,
 	})
-
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe rules: %w", err)
-		}
-
+	pageErr := awsx.EachPage(ctx, paginator, func(output *elasticloadbalancingv2.DescribeRulesOutput) error {
 		for _, rule := range output.Rules {
-			// Skip default rule (already handled)
-			if rule.IsDefault != nil && *rule.IsDefault {
+			// The default rule carries the listener's default actions, already handled
+			if aws.ToBool(rule.IsDefault) || rule.RuleArn == nil {
 				continue
 			}
+			rules = append(rules, rule)
+		}
+		return nil
+	})
+	// Rules listed before a failure are still added
+	sort.SliceStable(rules, func(i, j int) bool {
+		return listenerRulePriority(&rules[i]) < listenerRulePriority(&rules[j])
+	})
 
-			// Process forward actions to target groups
-			for _, action := range rule.Actions {
-				if action.TargetGroupArn != nil {
-					tgNeighbors, err := d.discoverTargetGroup(ctx, *action.TargetGroupArn, listenerNode, g)
-					if err != nil {
-						slog.Warn("Failed to discover target group from rule", "arn", *action.TargetGroupArn, "error", err)
-					} else {
-						neighbors = append(neighbors, tgNeighbors...)
-					}
+	var neighbors []string
+	for i := range rules {
+		rule := &rules[i]
+		ruleNode := listenerRuleToNode(rule, listenerNode)
+		if !g.HasNode(ruleNode.ID) {
+			g.AddNode(ruleNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         listenerNode.ID,
+			To:           ruleNode.ID,
+			RelationType: "has-rule",
+			Evidence: graph.Evidence{
+				APICall: "DescribeRules",
+				Fields: map[string]any{
+					"RuleArn":  *rule.RuleArn,
+					"Priority": aws.ToString(rule.Priority),
+				},
+			},
+		})
+		neighbors = append(neighbors, ruleNode.ID)
+
+		// Process forward actions to target groups
+		for _, action := range rule.Actions {
+			if action.TargetGroupArn != nil {
+				tgNeighbors, err := d.discoverTargetGroup(ctx, *action.TargetGroupArn, ruleNode, g)
+				if err != nil {
+					d.warn(listenerNode.ID, "Failed to discover target group from rule", err, "arn", *action.TargetGroupArn)
+				} else {
+					neighbors = append(neighbors, tgNeighbors...)
 				}
 			}
 		}
 	}
 
+	if pageErr != nil {
+		return neighbors, fmt.Errorf("failed to describe rules: %w", pageErr)
+	}
 	return neighbors, nil
 }
 
+// listenerRulePriority returns a rule's priority as a number, ordering rules without a numeric
+// priority last
+func listenerRulePriority(rule *elbv2types.Rule) int {
+	priority, err := strconv.Atoi(aws.ToString(rule.Priority))
+	if err != nil {
+		return math.MaxInt
+	}
+	return priority
+}
+
+// listenerRuleToNode creates a node for a listener rule, named by its priority and the hosts and
+// paths it matches, e.g. "10 api.example.com /v1/*"
+func listenerRuleToNode(rule *elbv2types.Rule, listenerNode *graph.Node) *graph.Node {
+	priority := aws.ToString(rule.Priority)
+	node := &graph.Node{
+		ID:       *rule.RuleArn,
+		Type:     ResourceTypeListenerRule,
+		ARN:      *rule.RuleArn,
+		Name:     priority,
+		Region:   listenerNode.Region,
+		Account:  listenerNode.Account,
+		Metadata: map[string]any{},
+	}
+	if n, err := strconv.Atoi(priority); err == nil {
+		node.Metadata["priority"] = n
+	}
+
+	hosts, paths := listenerRuleConditions(rule.Conditions)
+	if len(hosts) > 0 {
+		node.Metadata["hostHeaders"] = hosts
+		node.Name += " " + strings.Join(hosts, ",")
+	}
+	if len(paths) > 0 {
+		node.Metadata["pathPatterns"] = paths
+		node.Name += " " + strings.Join(paths, ",")
+	}
+	return node
+}
+
+// listenerRuleConditions returns the host-header and path-pattern values of a rule's conditions.
+// Values are read from the condition's config, falling back to the legacy Values field.
+func listenerRuleConditions(conditions []elbv2types.RuleCondition) (hosts, paths []string) {
+	for i := range conditions {
+		condition := &conditions[i]
+		switch aws.ToString(condition.Field) {
+		case "host-header":
+			if condition.HostHeaderConfig != nil {
+				hosts = append(hosts, condition.HostHeaderConfig.Values...)
+			} else {
+				hosts = append(hosts, condition.Values...)
+			}
+		case "path-pattern":
+			if condition.PathPatternConfig != nil {
+				paths = append(paths, condition.PathPatternConfig.Values...)
+			} else {
+				paths = append(paths, condition.Values...)
+			}
+		}
+	}
+	return hosts, paths
+}
+
 // discoverTargetGroup discovers a target group and its targets
 func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sourceNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering target group", "arn", tgARN)
+	d.log.Debug("Discovering target group", "arn", tgARN)
 
 	var neighbors []string
 
@@ -267,6 +480,17 @@ func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sour
 
 	tg := &output.TargetGroups[0]
 	tgNode := d.targetGroupToNode(tg)
+
+	// Target health is summarized on the target group before it is added to the graph
+	healthOutput, healthErr := d.clients.ELBv2.DescribeTargetHealth(ctx, &elasticloadbalancingv2.DescribeTargetHealthInput{
+		TargetGroupArn: &tgARN,
+	})
+	if healthErr != nil {
+		tgNode.Metadata["targetHealth"] = "unavailable"
+	} else {
+		addTargetHealthSummary(tgNode.Metadata, healthOutput.TargetHealthDescriptions)
+	}
+
 	g.AddNode(tgNode)
 	g.AddEdge(&graph.Edge{
 		From:         sourceNode.ID,
@@ -281,16 +505,13 @@ func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sour
 	})
 	neighbors = append(neighbors, tgNode.ID)
 
-	// Discover target health
-	healthOutput, err := d.clients.ELBv2.DescribeTargetHealth(ctx, &elasticloadbalancingv2.DescribeTargetHealthInput{
-		TargetGroupArn: &tgARN,
-	})
-	if err != nil {
-		slog.Warn("Failed to describe target health", "error", err)
+	if healthErr != nil {
+		d.warn(tgNode.ID, "Failed to describe target health", healthErr)
 		return neighbors, nil
 	}
 
 	// Add targets
+	var instanceIDs []string
 	for _, targetHealth := range healthOutput.TargetHealthDescriptions {
 		if targetHealth.Target == nil {
 			continue
@@ -302,8 +523,8 @@ func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sour
 		switch tg.TargetType {
 		case elbv2types.TargetTypeEnumInstance:
 			targetNode = &graph.Node{
-				ID:      *target.Id,
-				Type:    "EC2Instance",
+				ID:      canonicalID(tgNode.Region, tgNode.Account, ResourceTypeEC2Instance, *target.Id),
+				Type:    ResourceTypeEC2Instance,
 				Name:    *target.Id,
 				Region:  tgNode.Region,
 				Account: tgNode.Account,
@@ -311,6 +532,7 @@ func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sour
 					"port": target.Port,
 				},
 			}
+			instanceIDs = append(instanceIDs, *target.Id)
 		case elbv2types.TargetTypeEnumIp:
 			targetNode = &graph.Node{
 				ID:      *target.Id,
@@ -351,9 +573,49 @@ func (d *Discoverer) discoverTargetGroup(ctx context.Context, tgARN string, sour
 		neighbors = append(neighbors, targetNode.ID)
 	}
 
+	// Instance targets are usually ephemeral members of an Auto Scaling Group
+	asgNeighbors, asgErr := d.discoverASG(ctx, instanceIDs, tgNode, g)
+	if asgErr != nil {
+		d.warn(tgNode.ID, "Failed to discover Auto Scaling Groups", asgErr)
+	}
+	neighbors = append(neighbors, asgNeighbors...)
+
 	return neighbors, nil
 }
 
+// addTargetHealthSummary records how many of a target group's targets are healthy, unhealthy,
+// draining or in another state (initial, unused, unavailable or not reported), and flags the
+// target group as unhealthy when any target is
+func addTargetHealthSummary(metadata map[string]any, descriptions []elbv2types.TargetHealthDescription) {
+	healthy, unhealthy, draining, other := 0, 0, 0, 0
+	for i := range descriptions {
+		if descriptions[i].TargetHealth == nil {
+			other++
+			continue
+		}
+		switch descriptions[i].TargetHealth.State {
+		case elbv2types.TargetHealthStateEnumHealthy:
+			healthy++
+		case elbv2types.TargetHealthStateEnumUnhealthy:
+			unhealthy++
+		case elbv2types.TargetHealthStateEnumDraining, elbv2types.TargetHealthStateEnumUnhealthyDraining:
+			draining++
+		default:
+			other++
+		}
+	}
+
+	metadata["healthyTargets"] = healthy
+	metadata["unhealthyTargets"] = unhealthy
+	metadata["drainingTargets"] = draining
+	if other > 0 {
+		metadata["otherTargets"] = other
+	}
+	if unhealthy > 0 {
+		metadata["unhealthy"] = true
+	}
+}
+
 // Helper functions to convert AWS types to graph nodes
 
 func (d *Discoverer) loadBalancerToNode(lb *elbv2types.LoadBalancer) *graph.Node {
@@ -373,9 +635,10 @@ func (d *Discoverer) loadBalancerToNode(lb *elbv2types.LoadBalancer) *graph.Node
 	}
 
 	metadata := map[string]any{
-		"type":   lb.Type,
-		"scheme": lb.Scheme,
-		"state":  lb.State,
+		"type":          lb.Type,
+		"scheme":        lb.Scheme,
+		"state":         lb.State,
+		"ipAddressType": string(lb.IpAddressType),
 	}
 	if lb.DNSName != nil {
 		metadata["dnsName"] = *lb.DNSName
@@ -399,19 +662,41 @@ func (d *Discoverer) loadBalancerToNode(lb *elbv2types.LoadBalancer) *graph.Node
 	}
 }
 
-func (d *Discoverer) listenerToNode(listener *elbv2types.Listener, region, account string) *graph.Node {
+// listenerToNode creates a listener node. Gateway Load Balancer listeners report no protocol
+// or port, so they are recorded as GENEVE on 6081, the only combination GWLB supports.
+func (d *Discoverer) listenerToNode(listener *elbv2types.Listener, lbType elbv2types.LoadBalancerTypeEnum, region, account string) *graph.Node {
+	protocol := listener.Protocol
+	port := listener.Port
+	if lbType == elbv2types.LoadBalancerTypeEnumGateway {
+		if protocol == "" {
+			protocol = elbv2types.ProtocolEnumGeneve
+		}
+		if port == nil {
+			port = aws.Int32(gwlbGenevePort)
+		}
+	}
+
 	var name string
-	if listener.Port != nil && listener.Protocol != "" {
-		name = fmt.Sprintf("%s:%d", listener.Protocol, *listener.Port)
+	if port != nil && protocol != "" {
+		name = fmt.Sprintf("%s:%d", protocol, *port)
 	}
 
 	metadata := map[string]any{
-		"port":     listener.Port,
-		"protocol": listener.Protocol,
+		"port":     port,
+		"protocol": protocol,
+	}
+	if lbType != "" {
+		metadata["loadBalancerType"] = lbType
 	}
 	if len(listener.Certificates) > 0 {
 		metadata["certificateArn"] = listener.Certificates[0].CertificateArn
 	}
+	if listener.SslPolicy != nil {
+		metadata["sslPolicy"] = *listener.SslPolicy
+	}
+	if len(listener.AlpnPolicy) > 0 {
+		metadata["alpnPolicy"] = listener.AlpnPolicy
+	}
 
 	return &graph.Node{
 		ID:       *listener.ListenerArn,
@@ -448,6 +733,13 @@ func (d *Discoverer) targetGroupToNode(tg *elbv2types.TargetGroup) *graph.Node {
 	if tg.VpcId != nil {
 		metadata["vpcId"] = *tg.VpcId
 	}
+	// Protocol versions (HTTP1, HTTP2, GRPC) only apply to ALB target groups
+	if tg.ProtocolVersion != nil {
+		metadata["protocolVersion"] = *tg.ProtocolVersion
+	}
+	if tg.HealthCheckProtocol != "" {
+		metadata["healthCheckProtocol"] = tg.HealthCheckProtocol
+	}
 
 	return &graph.Node{
 		ID:       *tg.TargetGroupArn,
@@ -0,0 +1,592 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestLoadBalancerNamePattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "simple name", input: "my-alb", want: true},
+		{name: "single character", input: "a", want: true},
+		{name: "32 characters", input: "abcdefghijklmnopqrstuvwxyz012345", want: true},
+		{name: "33 characters", input: "abcdefghijklmnopqrstuvwxyz0123456", want: false},
+		{name: "leading hyphen", input: "-my-alb", want: false},
+		{name: "trailing hyphen", input: "my-alb-", want: false},
+		{name: "ECS service path", input: "cluster/service", want: false},
+		{name: "underscore", input: "my_function", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loadBalancerNamePattern.MatchString(tt.input); got != tt.want {
+				t.Errorf("loadBalancerNamePattern.MatchString(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLoadBalancerByNameCached(t *testing.T) {
+	// The cache is consulted before any API call, so empty clients are safe here
+	d := New(&awsx.Clients{}, &Options{})
+
+	arn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/abc123"
+	name := "my-alb"
+	d.cacheLoadBalancerName(name, &elbv2types.LoadBalancer{
+		LoadBalancerArn:  &arn,
+		LoadBalancerName: &name,
+		Type:             elbv2types.LoadBalancerTypeEnumApplication,
+	})
+	d.cacheLoadBalancerName("missing-alb", nil)
+
+	node, err := d.resolveLoadBalancerByName(context.Background(), name)
+	if err != nil {
+		t.Fatalf("resolveLoadBalancerByName() error = %v", err)
+	}
+	if node.ID != arn || node.Name != name {
+		t.Errorf("resolveLoadBalancerByName() = %s (%s), want %s (%s)", node.ID, node.Name, arn, name)
+	}
+
+	if _, err := d.resolveLoadBalancerByName(context.Background(), "missing-alb"); err == nil {
+		t.Error("resolveLoadBalancerByName() expected error for cached missing name")
+	}
+
+	// Invalid names are rejected without an API call
+	if _, err := d.resolveLoadBalancerByName(context.Background(), "cluster/service"); err == nil {
+		t.Error("resolveLoadBalancerByName() expected error for invalid name")
+	}
+}
+
+func TestDiscoverLoadBalancerByType(t *testing.T) {
+	const (
+		account = "123456789012"
+		certARN = "arn:aws:acm:us-east-1:123456789012:certificate/abc-123"
+		tgARN   = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/1111"
+	)
+
+	tests := []struct {
+		name         string
+		lbType       elbv2types.LoadBalancerTypeEnum
+		listener     elbv2types.Listener
+		tgProtocol   elbv2types.ProtocolEnum
+		wantListener string
+		wantRules    bool
+		wantWAF      bool
+		wantCert     bool
+	}{
+		{
+			name:   "application",
+			lbType: elbv2types.LoadBalancerTypeEnumApplication,
+			listener: elbv2types.Listener{
+				Port:     aws.Int32(80),
+				Protocol: elbv2types.ProtocolEnumHttp,
+			},
+			tgProtocol:   elbv2types.ProtocolEnumHttp,
+			wantListener: "HTTP:80",
+			wantRules:    true,
+			wantWAF:      true,
+		},
+		{
+			name:   "network with TLS listener",
+			lbType: elbv2types.LoadBalancerTypeEnumNetwork,
+			listener: elbv2types.Listener{
+				Port:         aws.Int32(443),
+				Protocol:     elbv2types.ProtocolEnumTls,
+				SslPolicy:    aws.String("ELBSecurityPolicy-TLS13-1-2-2021-06"),
+				Certificates: []elbv2types.Certificate{{CertificateArn: aws.String(certARN)}},
+			},
+			tgProtocol:   elbv2types.ProtocolEnumTcp,
+			wantListener: "TLS:443",
+			wantCert:     true,
+		},
+		{
+			name:         "gateway",
+			lbType:       elbv2types.LoadBalancerTypeEnumGateway,
+			listener:     elbv2types.Listener{},
+			tgProtocol:   elbv2types.ProtocolEnumGeneve,
+			wantListener: "GENEVE:6081",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lbARN := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/" + string(tt.lbType) + "/my-lb/abc"
+			listenerARN := "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/" + string(tt.lbType) + "/my-lb/abc/def"
+
+			listener := tt.listener
+			listener.ListenerArn = aws.String(listenerARN)
+			listener.DefaultActions = []elbv2types.Action{{
+				Type:           elbv2types.ActionTypeEnumForward,
+				TargetGroupArn: aws.String(tgARN),
+			}}
+
+			stub := newStubAPI(map[string]any{
+				"DescribeLoadBalancers": &elasticloadbalancingv2.DescribeLoadBalancersOutput{
+					LoadBalancers: []elbv2types.LoadBalancer{{
+						LoadBalancerArn:  aws.String(lbARN),
+						LoadBalancerName: aws.String("my-lb"),
+						Type:             tt.lbType,
+					}},
+				},
+				"DescribeListeners": &elasticloadbalancingv2.DescribeListenersOutput{
+					Listeners: []elbv2types.Listener{listener},
+				},
+				"DescribeRules": &elasticloadbalancingv2.DescribeRulesOutput{
+					Rules: []elbv2types.Rule{{IsDefault: aws.Bool(true)}},
+				},
+				"DescribeTargetGroups": &elasticloadbalancingv2.DescribeTargetGroupsOutput{
+					TargetGroups: []elbv2types.TargetGroup{{
+						TargetGroupArn:  aws.String(tgARN),
+						TargetGroupName: aws.String("backend"),
+						Protocol:        tt.tgProtocol,
+						TargetType:      elbv2types.TargetTypeEnumInstance,
+					}},
+				},
+				"DescribeTargetHealth": &elasticloadbalancingv2.DescribeTargetHealthOutput{},
+				"GetWebACLForResource": &wafv2.GetWebACLForResourceOutput{},
+				"DescribeCertificate": &acm.DescribeCertificateOutput{
+					Certificate: &acmtypes.CertificateDetail{
+						CertificateArn: aws.String(certARN),
+						DomainName:     aws.String("api.example.com"),
+						Status:         acmtypes.CertificateStatusIssued,
+					},
+				},
+			})
+
+			d := New(stubClients(stub), &Options{})
+			g := graph.New()
+			lbNode := &graph.Node{ID: lbARN, ARN: lbARN, Type: ResourceTypeLoadBalancer, Region: "us-east-1", Account: account}
+			g.AddNode(lbNode)
+
+			if _, err := d.discoverLoadBalancer(context.Background(), lbNode, g); err != nil {
+				t.Fatalf("discoverLoadBalancer() error = %v", err)
+			}
+
+			if got := stub.called("DescribeRules"); got != tt.wantRules {
+				t.Errorf("DescribeRules called = %v, want %v", got, tt.wantRules)
+			}
+			if got := stub.called("GetWebACLForResource"); got != tt.wantWAF {
+				t.Errorf("GetWebACLForResource called = %v, want %v", got, tt.wantWAF)
+			}
+
+			listenerNode, ok := g.GetNode(listenerARN)
+			if !ok {
+				t.Fatal("expected listener node")
+			}
+			if listenerNode.Name != tt.wantListener {
+				t.Errorf("listener name = %q, want %q", listenerNode.Name, tt.wantListener)
+			}
+			if listenerNode.Metadata["loadBalancerType"] != tt.lbType {
+				t.Errorf("listener loadBalancerType = %v, want %v", listenerNode.Metadata["loadBalancerType"], tt.lbType)
+			}
+
+			if _, ok := g.GetNode(certARN); ok != tt.wantCert {
+				t.Errorf("certificate node present = %v, want %v", ok, tt.wantCert)
+			}
+
+			var forwards bool
+			for _, edge := range g.EdgesFrom(listenerARN) {
+				if edge.To == tgARN && edge.RelationType == "forwards-to" {
+					forwards = true
+				}
+			}
+			if !forwards {
+				t.Error("expected forwards-to edge from listener to target group")
+			}
+		})
+	}
+}
+
+func TestDiscoverTargetGroupRecordsErrors(t *testing.T) {
+	const (
+		listenerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/abc/def"
+		tgARN       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/1111"
+	)
+
+	healthErr := errors.New("access denied")
+	stub := newStubAPI(map[string]any{
+		"DescribeTargetGroups": &elasticloadbalancingv2.DescribeTargetGroupsOutput{
+			TargetGroups: []elbv2types.TargetGroup{{
+				TargetGroupArn:  aws.String(tgARN),
+				TargetGroupName: aws.String("backend"),
+				TargetType:      elbv2types.TargetTypeEnumInstance,
+			}},
+		},
+		"DescribeTargetHealth": healthErr,
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	listenerNode := &graph.Node{ID: listenerARN, Type: ResourceTypeListener}
+	g.AddNode(listenerNode)
+
+	neighbors, err := d.discoverTargetGroup(context.Background(), tgARN, listenerNode, g)
+	if err != nil {
+		t.Fatalf("discoverTargetGroup() error = %v", err)
+	}
+	if len(neighbors) != 1 || neighbors[0] != tgARN {
+		t.Errorf("discoverTargetGroup() neighbors = %v, want the target group only", neighbors)
+	}
+
+	errs := d.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("Errors() returned %d errors, want 1: %v", len(errs), errs)
+	}
+	var discoveryErr *DiscoveryError
+	if !errors.As(errs[0], &discoveryErr) {
+		t.Fatalf("Errors()[0] = %T, want *DiscoveryError", errs[0])
+	}
+	if discoveryErr.NodeID != tgARN {
+		t.Errorf("NodeID = %q, want %q", discoveryErr.NodeID, tgARN)
+	}
+	if discoveryErr.APICall != "DescribeTargetHealth" {
+		t.Errorf("APICall = %q, want DescribeTargetHealth", discoveryErr.APICall)
+	}
+	if !errors.Is(errs[0], healthErr) {
+		t.Errorf("expected Errors()[0] to wrap the API error, got %v", errs[0])
+	}
+
+	tgNode, _ := g.GetNode(tgARN)
+	if got := tgNode.Metadata["targetHealth"]; got != "unavailable" {
+		t.Errorf("targetHealth = %v, want unavailable", got)
+	}
+	if _, ok := tgNode.Metadata["unhealthyTargets"]; ok {
+		t.Error("unhealthyTargets recorded although target health could not be described")
+	}
+}
+
+func TestDiscoverTargetGroupSummarizesTargetHealth(t *testing.T) {
+	const (
+		listenerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/abc/def"
+		tgARN       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/1111"
+	)
+
+	target := func(id string, state elbv2types.TargetHealthStateEnum) elbv2types.TargetHealthDescription {
+		return elbv2types.TargetHealthDescription{
+			Target:       &elbv2types.TargetDescription{Id: aws.String(id), Port: aws.Int32(80)},
+			TargetHealth: &elbv2types.TargetHealth{State: state},
+		}
+	}
+	stub := newStubAPI(map[string]any{
+		"DescribeTargetGroups": &elasticloadbalancingv2.DescribeTargetGroupsOutput{
+			TargetGroups: []elbv2types.TargetGroup{{
+				TargetGroupArn:  aws.String(tgARN),
+				TargetGroupName: aws.String("backend"),
+				TargetType:      elbv2types.TargetTypeEnumInstance,
+			}},
+		},
+		"DescribeTargetHealth": &elasticloadbalancingv2.DescribeTargetHealthOutput{
+			TargetHealthDescriptions: []elbv2types.TargetHealthDescription{
+				target("i-1", elbv2types.TargetHealthStateEnumHealthy),
+				target("i-2", elbv2types.TargetHealthStateEnumHealthy),
+				target("i-3", elbv2types.TargetHealthStateEnumUnhealthy),
+				target("i-4", elbv2types.TargetHealthStateEnumDraining),
+				target("i-5", elbv2types.TargetHealthStateEnumUnhealthyDraining),
+				target("i-6", elbv2types.TargetHealthStateEnumInitial),
+				// Health can be missing for a target, e.g. while it registers
+				{Target: &elbv2types.TargetDescription{Id: aws.String("i-7")}},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	listenerNode := &graph.Node{ID: listenerARN, Type: ResourceTypeListener}
+	g.AddNode(listenerNode)
+
+	neighbors, err := d.discoverTargetGroup(context.Background(), tgARN, listenerNode, g)
+	if err != nil {
+		t.Fatalf("discoverTargetGroup() error = %v", err)
+	}
+	if len(neighbors) != 8 {
+		t.Errorf("discoverTargetGroup() returned %d neighbors, want the target group and 7 targets", len(neighbors))
+	}
+
+	tgNode, ok := g.GetNode(tgARN)
+	if !ok {
+		t.Fatal("target group node not added")
+	}
+	want := map[string]any{
+		"healthyTargets":   2,
+		"unhealthyTargets": 1,
+		"drainingTargets":  2,
+		"otherTargets":     2,
+		"unhealthy":        true,
+	}
+	for key, value := range want {
+		if got := tgNode.Metadata[key]; got != value {
+			t.Errorf("Metadata[%q] = %v, want %v", key, got, value)
+		}
+	}
+}
+
+func TestDiscoverListenerRules(t *testing.T) {
+	const (
+		listenerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/abc/def"
+		apiRuleARN  = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener-rule/app/my-lb/abc/def/r10"
+		adminRule   = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener-rule/app/my-lb/abc/def/r20"
+		apiTG       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/api/111"
+		adminTG     = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/admin/222"
+	)
+	forward := func(tgARN string) []elbv2types.Action {
+		return []elbv2types.Action{{Type: elbv2types.ActionTypeEnumForward, TargetGroupArn: aws.String(tgARN)}}
+	}
+	targetGroup := func(arn, name string) *elasticloadbalancingv2.DescribeTargetGroupsOutput {
+		return &elasticloadbalancingv2.DescribeTargetGroupsOutput{
+			TargetGroups: []elbv2types.TargetGroup{{TargetGroupArn: aws.String(arn), TargetGroupName: aws.String(name)}},
+		}
+	}
+	stub := newStubAPI(map[string]any{
+		// Listed out of priority order
+		"DescribeRules": &elasticloadbalancingv2.DescribeRulesOutput{
+			Rules: []elbv2types.Rule{
+				{
+					RuleArn:  aws.String(adminRule),
+					Priority: aws.String("20"),
+					Conditions: []elbv2types.RuleCondition{
+						// Older rules only set the legacy Values field
+						{Field: aws.String("path-pattern"), Values: []string{"/admin/*"}},
+					},
+					Actions: forward(adminTG),
+				},
+				{
+					RuleArn:  aws.String(apiRuleARN),
+					Priority: aws.String("10"),
+					Conditions: []elbv2types.RuleCondition{
+						{Field: aws.String("host-header"), HostHeaderConfig: &elbv2types.HostHeaderConditionConfig{Values: []string{"example.com"}}},
+						{Field: aws.String("path-pattern"), PathPatternConfig: &elbv2types.PathPatternConditionConfig{Values: []string{"/api/*"}}},
+					},
+					Actions: forward(apiTG),
+				},
+				{RuleArn: aws.String("default-rule"), Priority: aws.String("default"), IsDefault: aws.Bool(true), Actions: forward(apiTG)},
+			},
+		},
+		"DescribeTargetGroups": []any{targetGroup(apiTG, "api"), targetGroup(adminTG, "admin")},
+		"DescribeTargetHealth": &elasticloadbalancingv2.DescribeTargetHealthOutput{},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	listenerNode := &graph.Node{ID: listenerARN, Type: ResourceTypeListener, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(listenerNode)
+
+	neighbors, err := d.discoverListenerRules(context.Background(), &elbv2types.Listener{ListenerArn: aws.String(listenerARN)}, listenerNode, g)
+	if err != nil {
+		t.Fatalf("discoverListenerRules() error = %v", err)
+	}
+	if want := []string{apiRuleARN, apiTG, adminRule, adminTG}; !slices.Equal(neighbors, want) {
+		t.Errorf("discoverListenerRules() neighbors = %v, want %v in priority order", neighbors, want)
+	}
+	if g.HasNode("default-rule") {
+		t.Error("default rule was added as a node")
+	}
+
+	tests := []struct {
+		ruleARN      string
+		tgARN        string
+		wantName     string
+		wantPriority int64
+		wantHosts    []string
+		wantPaths    []string
+	}{
+		{ruleARN: apiRuleARN, tgARN: apiTG, wantName: "10 example.com /api/*", wantPriority: 10, wantHosts: []string{"example.com"}, wantPaths: []string{"/api/*"}},
+		{ruleARN: adminRule, tgARN: adminTG, wantName: "20 /admin/*", wantPriority: 20, wantPaths: []string{"/admin/*"}},
+	}
+	for _, tt := range tests {
+		rule, ok := g.GetNode(tt.ruleARN)
+		if !ok || rule.Type != ResourceTypeListenerRule || rule.Name != tt.wantName {
+			t.Errorf("rule node = %+v, want ListenerRule %q", rule, tt.wantName)
+			continue
+		}
+		if priority, _ := rule.MetaInt("priority"); priority != tt.wantPriority {
+			t.Errorf("%s priority = %d, want %d", tt.wantName, priority, tt.wantPriority)
+		}
+		if hosts := metadataStrings(rule, "hostHeaders"); !slices.Equal(hosts, tt.wantHosts) {
+			t.Errorf("%s hostHeaders = %v, want %v", tt.wantName, hosts, tt.wantHosts)
+		}
+		if paths := metadataStrings(rule, "pathPatterns"); !slices.Equal(paths, tt.wantPaths) {
+			t.Errorf("%s pathPatterns = %v, want %v", tt.wantName, paths, tt.wantPaths)
+		}
+
+		// listener -has-rule-> rule -forwards-to-> target group
+		if edges := g.EdgesBetween(listenerARN, tt.ruleARN); len(edges) != 1 || edges[0].RelationType != "has-rule" {
+			t.Errorf("edges listener -> %s = %+v, want one has-rule", tt.wantName, edges)
+		}
+		if edges := g.EdgesBetween(tt.ruleARN, tt.tgARN); len(edges) != 1 || edges[0].RelationType != "forwards-to" {
+			t.Errorf("edges %s -> target group = %+v, want one forwards-to", tt.wantName, edges)
+		}
+		if edges := g.EdgesBetween(listenerARN, tt.tgARN); len(edges) != 0 {
+			t.Errorf("edges listener -> %s target group = %+v, want none", tt.wantName, edges)
+		}
+	}
+}
+
+func TestDiscoverLoadBalancerSkipRoute53(t *testing.T) {
+	const lbARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-lb/abc"
+
+	for _, skip := range []bool{false, true} {
+		stub := newStubAPI(map[string]any{
+			"DescribeLoadBalancers": &elasticloadbalancingv2.DescribeLoadBalancersOutput{
+				LoadBalancers: []elbv2types.LoadBalancer{{
+					LoadBalancerArn:  aws.String(lbARN),
+					LoadBalancerName: aws.String("my-lb"),
+					DNSName:          aws.String("my-lb-123.us-east-1.elb.amazonaws.com"),
+					Type:             elbv2types.LoadBalancerTypeEnumApplication,
+				}},
+			},
+			"DescribeListeners":    &elasticloadbalancingv2.DescribeListenersOutput{},
+			"GetWebACLForResource": &wafv2.GetWebACLForResourceOutput{},
+			"ListHostedZones":      &route53.ListHostedZonesOutput{},
+		})
+
+		d := New(stubClients(stub), &Options{SkipRoute53: skip})
+		g := graph.New()
+		lbNode := &graph.Node{ID: lbARN, ARN: lbARN, Type: ResourceTypeLoadBalancer, Region: "us-east-1", Account: "123456789012"}
+		g.AddNode(lbNode)
+
+		if _, err := d.discoverLoadBalancer(context.Background(), lbNode, g); err != nil {
+			t.Fatalf("discoverLoadBalancer() error = %v", err)
+		}
+		if got := stub.called("ListHostedZones"); got == skip {
+			t.Errorf("SkipRoute53 = %v: ListHostedZones called = %v", skip, got)
+		}
+		if errs := d.Errors(); len(errs) != 0 {
+			t.Errorf("SkipRoute53 = %v: Errors() = %v, want none", skip, errs)
+		}
+	}
+}
+
+func TestSecurityGroupScopedByAccount(t *testing.T) {
+	const (
+		prodARN    = "arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/app/web/abc"
+		stagingARN = "arn:aws:elasticloadbalancing:us-east-1:222222222222:loadbalancer/app/web/def"
+	)
+	// Both accounts happen to have a security group sg-123
+	stub := newStubAPI(map[string]any{
+		"DescribeLoadBalancers": &elasticloadbalancingv2.DescribeLoadBalancersOutput{
+			LoadBalancers: []elbv2types.LoadBalancer{{
+				LoadBalancerName: aws.String("web"),
+				SecurityGroups:   []string{"sg-123"},
+			}},
+		},
+		"DescribeListeners": &elasticloadbalancingv2.DescribeListenersOutput{},
+	})
+	d := New(stubClients(stub), &Options{SkipRoute53: true})
+	g := graph.New()
+
+	for _, arn := range []string{prodARN, stagingARN} {
+		lbNode, err := d.parseARN(arn)
+		if err != nil {
+			t.Fatalf("parseARN() error = %v", err)
+		}
+		g.AddNode(lbNode)
+		if _, err = d.discoverLoadBalancer(context.Background(), lbNode, g); err != nil {
+			t.Fatalf("discoverLoadBalancer(%s) error = %v", arn, err)
+		}
+	}
+
+	if count := g.CountByType()[ResourceTypeSecurityGroup]; count != 2 {
+		t.Fatalf("SecurityGroup nodes = %d, want one per account", count)
+	}
+	for lbARN, sgID := range map[string]string{
+		prodARN:    "SecurityGroup:111111111111:us-east-1:sg-123",
+		stagingARN: "SecurityGroup:222222222222:us-east-1:sg-123",
+	} {
+		sg, ok := g.GetNode(sgID)
+		if !ok || sg.Name != "sg-123" {
+			t.Errorf("security group %s = %+v, want a node named sg-123", sgID, sg)
+		}
+		if edges := g.EdgesBetween(lbARN, sgID); len(edges) != 1 {
+			t.Errorf("edges %s -> %s = %v, want one uses-security-group", lbARN, sgID, edges)
+		}
+	}
+}
+
+func TestDiscoverLoadBalancerElasticIPs(t *testing.T) {
+	const lbARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/my-nlb/abc"
+
+	stub := newStubAPI(map[string]any{
+		"DescribeLoadBalancers": &elasticloadbalancingv2.DescribeLoadBalancersOutput{
+			LoadBalancers: []elbv2types.LoadBalancer{{
+				LoadBalancerArn:  aws.String(lbARN),
+				LoadBalancerName: aws.String("my-nlb"),
+				Type:             elbv2types.LoadBalancerTypeEnumNetwork,
+				IpAddressType:    elbv2types.IpAddressTypeDualstack,
+				AvailabilityZones: []elbv2types.AvailabilityZone{
+					{
+						SubnetId: aws.String("subnet-a"),
+						ZoneName: aws.String("us-east-1a"),
+						LoadBalancerAddresses: []elbv2types.LoadBalancerAddress{{
+							AllocationId: aws.String("eipalloc-0a"),
+							IpAddress:    aws.String("203.0.113.10"),
+							IPv6Address:  aws.String("2600:1f18::10"),
+						}},
+					},
+					{
+						// Without an allocation the address is assigned by AWS, not an Elastic IP
+						SubnetId: aws.String("subnet-b"),
+						ZoneName: aws.String("us-east-1b"),
+						LoadBalancerAddresses: []elbv2types.LoadBalancerAddress{{
+							IpAddress:   aws.String("198.51.100.20"),
+							IPv6Address: aws.String("2600:1f18::20"),
+						}},
+					},
+				},
+			}},
+		},
+		"DescribeListeners": &elasticloadbalancingv2.DescribeListenersOutput{},
+	})
+
+	d := New(stubClients(stub), &Options{SkipRoute53: true})
+	g := graph.New()
+	lbNode := &graph.Node{ID: lbARN, ARN: lbARN, Type: ResourceTypeLoadBalancer, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(lbNode)
+
+	neighbors, err := d.discoverLoadBalancer(context.Background(), lbNode, g)
+	if err != nil {
+		t.Fatalf("discoverLoadBalancer() error = %v", err)
+	}
+	const eipID = "ElasticIP:123456789012:us-east-1:eipalloc-0a"
+	if !slices.Contains(neighbors, eipID) {
+		t.Errorf("neighbors = %v, want the Elastic IP", neighbors)
+	}
+
+	eip, ok := g.GetNode(eipID)
+	if !ok {
+		t.Fatal("expected Elastic IP node")
+	}
+	if eip.Type != ResourceTypeElasticIP || eip.Name != "203.0.113.10" || eip.Metadata["subnetId"] != "subnet-a" {
+		t.Errorf("Elastic IP node = %+v, want ElasticIP 203.0.113.10 in subnet-a", eip)
+	}
+	edges := g.EdgesBetween(lbARN, eipID)
+	if len(edges) != 1 || edges[0].RelationType != "has-address" || edges[0].Evidence.Fields["IpAddress"] != "203.0.113.10" {
+		t.Errorf("edges to Elastic IP = %+v, want one has-address", edges)
+	}
+	if count := g.CountByType()[ResourceTypeElasticIP]; count != 1 {
+		t.Errorf("Elastic IP nodes = %d, want 1", count)
+	}
+
+	if lbNode.Metadata["ipAddressType"] != "dualstack" {
+		t.Errorf("ipAddressType = %v, want dualstack", lbNode.Metadata["ipAddressType"])
+	}
+	if got := metadataStrings(lbNode, "ipv6Addresses"); !slices.Equal(got, []string{"2600:1f18::10", "2600:1f18::20"}) {
+		t.Errorf("ipv6Addresses = %v, want both zones' addresses", got)
+	}
+}
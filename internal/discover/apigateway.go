@@ -0,0 +1,426 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	apigwtypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+	apigwv2types "github.com/aws/aws-sdk-go-v2/service/apigatewayv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// lambdaFunctionARNPattern matches a Lambda function ARN embedded in an integration URI
+var lambdaFunctionARNPattern = regexp.MustCompile(`arn:aws[a-zA-Z-]*:lambda:[a-z0-9-]+:\d{12}:function:[A-Za-z0-9_-]+(:[A-Za-z0-9_$-]+)?`)
+
+// apiGatewayIntegration is a single API integration pointing at a backend resource
+type apiGatewayIntegration struct {
+	api       *graph.Node
+	backendID string
+	apiCall   string
+	fields    map[string]any
+}
+
+// discoverAPIGateway discovers the backends integrated with a REST or HTTP API
+func (d *Discoverer) discoverAPIGateway(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering API Gateway dependencies", "type", node.Type, "arn", node.ARN)
+
+	apiID, _ := node.Metadata["apiId"].(string)
+	if apiID == "" {
+		return nil, fmt.Errorf("cannot determine API ID for API Gateway: %s", node.ARN)
+	}
+
+	var integrations []apiGatewayIntegration
+	var err error
+	switch node.Type {
+	case ResourceTypeAPIGatewayRestAPI:
+		integrations, err = d.listRestAPIIntegrations(ctx, apiID, node)
+	case ResourceTypeAPIGatewayHTTPAPI:
+		integrations, err = d.listHTTPAPIIntegrations(ctx, apiID, node)
+	default:
+		return nil, fmt.Errorf("unknown API Gateway type: %s", node.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+
+	// Discover the Web ACLs protecting REST API stages (HTTP APIs cannot be associated)
+	if node.Type == ResourceTypeAPIGatewayRestAPI {
+		aclNeighbors, wafErr := d.discoverRestAPIWebACLs(ctx, apiID, node, g)
+		if wafErr != nil {
+			d.warn(node.ID, "Failed to discover web ACLs", wafErr)
+		} else {
+			neighbors = append(neighbors, aclNeighbors...)
+		}
+	}
+
+	for i := range integrations {
+		integration := &integrations[i]
+		backendNode, parseErr := d.parseARN(integration.backendID)
+		if parseErr != nil {
+			d.log.Debug("Skipping unsupported API Gateway backend", "backend", integration.backendID, "error", parseErr)
+			continue
+		}
+
+		if !g.HasNode(backendNode.ID) {
+			g.AddNode(backendNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           backendNode.ID,
+			RelationType: "integrates-with",
+			Evidence: graph.Evidence{
+				APICall: integration.apiCall,
+				Fields:  integration.fields,
+			},
+		})
+		neighbors = append(neighbors, backendNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// discoverAPIGatewayUpstream discovers REST and HTTP APIs whose integrations invoke a Lambda function
+func (d *Discoverer) discoverAPIGatewayUpstream(ctx context.Context, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering API Gateway integrations for function", "arn", lambdaNode.ARN)
+
+	index, err := d.apiGatewayIndex(ctx, lambdaNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for _, integration := range index[normalizeLambdaARN(lambdaNode.ARN)] {
+		if !g.HasNode(integration.api.ID) {
+			g.AddNode(integration.api)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         integration.api.ID,
+			To:           lambdaNode.ID,
+			RelationType: "integrates-with",
+			Evidence: graph.Evidence{
+				APICall: integration.apiCall,
+				Fields:  integration.fields,
+			},
+		})
+		neighbors = append(neighbors, integration.api.ID)
+	}
+
+	return neighbors, nil
+}
+
+// apiGatewayIndex lazily builds an index of every API integration in the source node's account
+// and region, keyed by backend Lambda ARN
+func (d *Discoverer) apiGatewayIndex(ctx context.Context, sourceNode *graph.Node) (map[string][]apiGatewayIntegration, error) {
+	if index, ok := d.apiIntegrations[scopeKey(sourceNode)]; ok {
+		return index, nil
+	}
+
+	index := make(map[string][]apiGatewayIntegration)
+
+	restPaginator := apigateway.NewGetRestApisPaginator(d.clients.APIGateway, &apigateway.GetRestApisInput{})
+	pageErr := awsx.EachPage(ctx, restPaginator, func(output *apigateway.GetRestApisOutput) error {
+		for i := range output.Items {
+			api := &output.Items[i]
+			if api.Id == nil {
+				continue
+			}
+			apiNode := d.restAPIToNode(api, sourceNode.Region, sourceNode.Account)
+			integrations, listErr := d.listRestAPIIntegrations(ctx, *api.Id, apiNode)
+			if listErr != nil {
+				d.warn(sourceNode.ID, "Failed to list REST API integrations", listErr, "apiId", *api.Id)
+				continue
+			}
+			addToAPIGatewayIndex(index, integrations)
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to list REST APIs: %w", pageErr)
+	}
+
+	var nextToken *string
+	for {
+		output, err := d.clients.APIGatewayV2.GetApis(ctx, &apigatewayv2.GetApisInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list HTTP APIs: %w", err)
+		}
+
+		for i := range output.Items {
+			api := &output.Items[i]
+			if api.ApiId == nil {
+				continue
+			}
+			apiNode := d.httpAPIToNode(api, sourceNode.Region, sourceNode.Account)
+			integrations, listErr := d.listHTTPAPIIntegrations(ctx, *api.ApiId, apiNode)
+			if listErr != nil {
+				d.warn(sourceNode.ID, "Failed to list HTTP API integrations", listErr, "apiId", *api.ApiId)
+				continue
+			}
+			addToAPIGatewayIndex(index, integrations)
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	if d.apiIntegrations == nil {
+		d.apiIntegrations = make(map[string]map[string][]apiGatewayIntegration)
+	}
+	d.apiIntegrations[scopeKey(sourceNode)] = index
+	return index, nil
+}
+
+func addToAPIGatewayIndex(index map[string][]apiGatewayIntegration, integrations []apiGatewayIntegration) {
+	for _, integration := range integrations {
+		if !strings.Contains(integration.backendID, ":lambda:") {
+			continue
+		}
+		index[integration.backendID] = append(index[integration.backendID], integration)
+	}
+}
+
+// listRestAPIIntegrations lists the backend integrations of every method in a REST API
+func (d *Discoverer) listRestAPIIntegrations(ctx context.Context, apiID string, apiNode *graph.Node) ([]apiGatewayIntegration, error) {
+	var integrations []apiGatewayIntegration
+	vpcLinkTargets := make(map[string][]string)
+
+	paginator := apigateway.NewGetResourcesPaginator(d.clients.APIGateway, &apigateway.GetResourcesInput{
+		RestApiId: &apiID,
+		Embed:     []string{"methods"},
+	})
+
+	pageErr := awsx.EachPage(ctx, paginator, func(output *apigateway.GetResourcesOutput) error {
+		for i := range output.Items {
+			resource := &output.Items[i]
+			for httpMethod, method := range resource.ResourceMethods {
+				integration := method.MethodIntegration
+				if integration == nil {
+					continue
+				}
+
+				fields := map[string]any{
+					"ResourcePath":    resource.Path,
+					"HttpMethod":      httpMethod,
+					"IntegrationType": integration.Type,
+				}
+
+				// Private integrations reach NLBs through a VPC link
+				if integration.ConnectionType == apigwtypes.ConnectionTypeVpcLink && integration.ConnectionId != nil {
+					targets, linkErr := d.restAPIVPCLinkTargets(ctx, *integration.ConnectionId, vpcLinkTargets)
+					if linkErr != nil {
+						d.warn(apiNode.ID, "Failed to get VPC link", linkErr, "vpcLinkId", *integration.ConnectionId)
+						continue
+					}
+					for _, target := range targets {
+						integrations = append(integrations, apiGatewayIntegration{
+							api:       apiNode,
+							backendID: target,
+							apiCall:   "GetResources/GetVpcLink",
+							fields:    withField(fields, "VpcLinkId", *integration.ConnectionId),
+						})
+					}
+					continue
+				}
+
+				if integration.Uri == nil {
+					continue
+				}
+				if lambdaARN := lambdaFunctionARNPattern.FindString(*integration.Uri); lambdaARN != "" {
+					integrations = append(integrations, apiGatewayIntegration{
+						api:       apiNode,
+						backendID: normalizeLambdaARN(lambdaARN),
+						apiCall:   "GetResources",
+						fields:    withField(fields, "Uri", *integration.Uri),
+					})
+				}
+			}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to get resources: %w", pageErr)
+	}
+
+	return integrations, nil
+}
+
+// restAPIVPCLinkTargets returns the NLB ARNs behind a REST API VPC link, using cache to avoid repeat lookups
+func (d *Discoverer) restAPIVPCLinkTargets(ctx context.Context, vpcLinkID string, cache map[string][]string) ([]string, error) {
+	if targets, ok := cache[vpcLinkID]; ok {
+		return targets, nil
+	}
+
+	output, err := d.clients.APIGateway.GetVpcLink(ctx, &apigateway.GetVpcLinkInput{
+		VpcLinkId: &vpcLinkID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VPC link: %w", err)
+	}
+
+	cache[vpcLinkID] = output.TargetArns
+	return output.TargetArns, nil
+}
+
+// listHTTPAPIIntegrations lists the backend integrations of an HTTP API
+func (d *Discoverer) listHTTPAPIIntegrations(ctx context.Context, apiID string, apiNode *graph.Node) ([]apiGatewayIntegration, error) {
+	var integrations []apiGatewayIntegration
+
+	var nextToken *string
+	for {
+		output, err := d.clients.APIGatewayV2.GetIntegrations(ctx, &apigatewayv2.GetIntegrationsInput{
+			ApiId:     &apiID,
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get integrations: %w", err)
+		}
+
+		for i := range output.Items {
+			integration := &output.Items[i]
+			if integration.IntegrationUri == nil {
+				continue
+			}
+
+			uri := *integration.IntegrationUri
+			fields := map[string]any{
+				"IntegrationId":   integration.IntegrationId,
+				"IntegrationType": integration.IntegrationType,
+				"IntegrationUri":  uri,
+			}
+
+			var backendID string
+			switch {
+			case integration.ConnectionType == apigwv2types.ConnectionTypeVpcLink && strings.Contains(uri, ":listener/"):
+				// Private integrations target an ALB/NLB listener through a VPC link
+				backendID = loadBalancerARNFromListenerARN(uri)
+				if integration.ConnectionId != nil {
+					fields["VpcLinkId"] = *integration.ConnectionId
+				}
+			default:
+				if lambdaARN := lambdaFunctionARNPattern.FindString(uri); lambdaARN != "" {
+					backendID = normalizeLambdaARN(lambdaARN)
+				}
+			}
+
+			if backendID == "" {
+				continue
+			}
+			integrations = append(integrations, apiGatewayIntegration{
+				api:       apiNode,
+				backendID: backendID,
+				apiCall:   "GetIntegrations",
+				fields:    fields,
+			})
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	return integrations, nil
+}
+
+// Helper functions to convert API Gateway types to graph nodes
+
+func (d *Discoverer) restAPIToNode(api *apigwtypes.RestApi, region, account string) *graph.Node {
+	arn := fmt.Sprintf("arn:aws:apigateway:%s::/restapis/%s", region, *api.Id)
+
+	var name string
+	if api.Name != nil {
+		name = *api.Name
+	}
+
+	metadata := map[string]any{
+		"apiId": *api.Id,
+	}
+	if api.EndpointConfiguration != nil {
+		metadata["endpointTypes"] = api.EndpointConfiguration.Types
+	}
+
+	return &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeAPIGatewayRestAPI,
+		ARN:      arn,
+		Name:     name,
+		Region:   region,
+		Account:  account,
+		Tags:     api.Tags,
+		Metadata: metadata,
+	}
+}
+
+func (d *Discoverer) httpAPIToNode(api *apigwv2types.Api, region, account string) *graph.Node {
+	arn := fmt.Sprintf("arn:aws:apigateway:%s::/apis/%s", region, *api.ApiId)
+
+	var name string
+	if api.Name != nil {
+		name = *api.Name
+	}
+
+	metadata := map[string]any{
+		"apiId":        *api.ApiId,
+		"protocolType": api.ProtocolType,
+	}
+	if api.ApiEndpoint != nil {
+		metadata["apiEndpoint"] = *api.ApiEndpoint
+	}
+
+	return &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeAPIGatewayHTTPAPI,
+		ARN:      arn,
+		Name:     name,
+		Region:   region,
+		Account:  account,
+		Tags:     api.Tags,
+		Metadata: metadata,
+	}
+}
+
+// normalizeLambdaARN strips any version or alias qualifier from a Lambda function ARN
+func normalizeLambdaARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) > 7 && parts[5] == "function" {
+		return strings.Join(parts[:7], ":")
+	}
+	return arn
+}
+
+// loadBalancerARNFromListenerARN derives the load balancer ARN from a listener ARN
+func loadBalancerARNFromListenerARN(listenerARN string) string {
+	// Listener: arn:aws:elasticloadbalancing:region:account:listener/app/name/lb-id/listener-id
+	// LB:       arn:aws:elasticloadbalancing:region:account:loadbalancer/app/name/lb-id
+	idx := strings.Index(listenerARN, ":listener/")
+	if idx < 0 {
+		return ""
+	}
+	parts := strings.Split(listenerARN[idx+len(":listener/"):], "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	return listenerARN[:idx] + ":loadbalancer/" + strings.Join(parts[:3], "/")
+}
+
+// withField returns a copy of fields with key set to value
+func withField(fields map[string]any, key string, value any) map[string]any {
+	result := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
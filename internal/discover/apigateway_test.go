@@ -0,0 +1,112 @@
+package discover
+
+import (
+	"testing"
+)
+
+func TestLambdaFunctionARNPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		wantARN string
+	}{
+		{
+			name:    "REST API Lambda proxy integration URI",
+			uri:     "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:my-function/invocations",
+			wantARN: "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		},
+		{
+			name:    "Qualified function in integration URI",
+			uri:     "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:my-function:prod/invocations",
+			wantARN: "arn:aws:lambda:us-east-1:123456789012:function:my-function:prod",
+		},
+		{
+			name:    "HTTP API bare function ARN",
+			uri:     "arn:aws:lambda:eu-west-1:123456789012:function:http-handler",
+			wantARN: "arn:aws:lambda:eu-west-1:123456789012:function:http-handler",
+		},
+		{
+			name:    "Stage variable function name",
+			uri:     "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:${stageVariables.fn}/invocations",
+			wantARN: "",
+		},
+		{
+			name:    "HTTP proxy integration",
+			uri:     "https://example.com/api",
+			wantARN: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lambdaFunctionARNPattern.FindString(tt.uri)
+			if got != tt.wantARN {
+				t.Errorf("lambdaFunctionARNPattern.FindString() = %q, want %q", got, tt.wantARN)
+			}
+		})
+	}
+}
+
+func TestNormalizeLambdaARN(t *testing.T) {
+	tests := []struct {
+		name string
+		arn  string
+		want string
+	}{
+		{
+			name: "Unqualified ARN",
+			arn:  "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+			want: "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		},
+		{
+			name: "Alias qualifier",
+			arn:  "arn:aws:lambda:us-east-1:123456789012:function:my-function:prod",
+			want: "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		},
+		{
+			name: "Non-Lambda ARN",
+			arn:  "arn:aws:sqs:us-east-1:123456789012:my-queue",
+			want: "arn:aws:sqs:us-east-1:123456789012:my-queue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLambdaARN(tt.arn); got != tt.want {
+				t.Errorf("normalizeLambdaARN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBalancerARNFromListenerARN(t *testing.T) {
+	tests := []struct {
+		name     string
+		listener string
+		want     string
+	}{
+		{
+			name:     "ALB listener",
+			listener: "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-alb/50dc6c495c0c9188/f2f7dc8efc522ab2",
+			want:     "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188",
+		},
+		{
+			name:     "NLB listener",
+			listener: "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/net/my-nlb/50dc6c495c0c9188/f2f7dc8efc522ab2",
+			want:     "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/my-nlb/50dc6c495c0c9188",
+		},
+		{
+			name:     "Cloud Map service",
+			listener: "arn:aws:servicediscovery:us-east-1:123456789012:service/srv-abc",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loadBalancerARNFromListenerARN(tt.listener); got != tt.want {
+				t.Errorf("loadBalancerARNFromListenerARN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// maxAutoScalingInstanceIDs is the most instance IDs DescribeAutoScalingInstances accepts per call
+const maxAutoScalingInstanceIDs = 50
+
+// discoverASG links a target group's instance targets to the Auto Scaling Groups that own
+// them, and each group to its launch template and subnets. Instances outside any group are
+// left as they are.
+func (d *Discoverer) discoverASG(ctx context.Context, instanceIDs []string, tgNode *graph.Node, g *graph.Graph) ([]string, error) {
+	if len(instanceIDs) == 0 {
+		return nil, nil
+	}
+	d.log.Debug("Discovering Auto Scaling Groups", "targetGroup", tgNode.ID, "instances", len(instanceIDs))
+
+	// Group the instances by their owning Auto Scaling Group
+	members := make(map[string][]astypes.AutoScalingInstanceDetails)
+	for start := 0; start < len(instanceIDs); start += maxAutoScalingInstanceIDs {
+		batch := instanceIDs[start:min(start+maxAutoScalingInstanceIDs, len(instanceIDs))]
+		output, err := d.clients.AutoScaling.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe auto scaling instances: %w", err)
+		}
+		for _, instance := range output.AutoScalingInstances {
+			if instance.AutoScalingGroupName == nil || instance.InstanceId == nil {
+				continue
+			}
+			members[*instance.AutoScalingGroupName] = append(members[*instance.AutoScalingGroupName], instance)
+		}
+	}
+	if len(members) == 0 {
+		d.log.Debug("No Auto Scaling Groups found for targets", "targetGroup", tgNode.ID)
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var groups []astypes.AutoScalingGroup
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: names,
+	}
+	for {
+		output, err := d.clients.AutoScaling.DescribeAutoScalingGroups(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe auto scaling groups: %w", err)
+		}
+		groups = append(groups, output.AutoScalingGroups...)
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	var neighbors []string
+	for i := range groups {
+		group := &groups[i]
+		if group.AutoScalingGroupName == nil {
+			continue
+		}
+		neighbors = append(neighbors, d.addASG(group, members[*group.AutoScalingGroupName], tgNode, g)...)
+	}
+
+	return neighbors, nil
+}
+
+// addASG adds an Auto Scaling Group with edges from the target group and to its member
+// instances, launch template and subnets, and returns the new neighbors
+func (d *Discoverer) addASG(group *astypes.AutoScalingGroup, instances []astypes.AutoScalingInstanceDetails, tgNode *graph.Node, g *graph.Graph) []string {
+	asgNode := d.asgToNode(group, tgNode.Region, tgNode.Account)
+	if !g.HasNode(asgNode.ID) {
+		g.AddNode(asgNode)
+	}
+
+	instanceIDs := make([]string, 0, len(instances))
+	for i := range instances {
+		instanceIDs = append(instanceIDs, *instances[i].InstanceId)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         tgNode.ID,
+		To:           asgNode.ID,
+		RelationType: "backed-by-asg",
+		Evidence: graph.Evidence{
+			APICall: "DescribeAutoScalingInstances",
+			Fields: map[string]any{
+				"AutoScalingGroupName": *group.AutoScalingGroupName,
+				"InstanceIds":          instanceIDs,
+			},
+		},
+	})
+	neighbors := []string{asgNode.ID}
+
+	// The instances are already in the graph as targets of the target group
+	for i := range instances {
+		instance := &instances[i]
+		g.AddEdge(&graph.Edge{
+			From:         asgNode.ID,
+			To:           canonicalID(asgNode.Region, asgNode.Account, ResourceTypeEC2Instance, *instance.InstanceId),
+			RelationType: "manages",
+			Evidence: graph.Evidence{
+				APICall: "DescribeAutoScalingInstances",
+				Fields: map[string]any{
+					"InstanceId":     *instance.InstanceId,
+					"LifecycleState": aws.ToString(instance.LifecycleState),
+					"HealthStatus":   aws.ToString(instance.HealthStatus),
+				},
+			},
+		})
+	}
+
+	if template := launchTemplateSpec(group); template != nil {
+		templateNode := &graph.Node{
+			ID:      aws.ToString(template.LaunchTemplateId),
+			Type:    ResourceTypeLaunchTemplate,
+			Name:    aws.ToString(template.LaunchTemplateName),
+			Region:  asgNode.Region,
+			Account: asgNode.Account,
+			Metadata: map[string]any{
+				"version": aws.ToString(template.Version),
+			},
+		}
+		if templateNode.ID == "" {
+			// Groups may reference a template by name only
+			templateNode.ID = templateNode.Name
+		}
+		if templateNode.Name == "" {
+			templateNode.Name = templateNode.ID
+		}
+		if templateNode.ID != "" {
+			templateNode.ID = canonicalID(asgNode.Region, asgNode.Account, ResourceTypeLaunchTemplate, templateNode.ID)
+			if !g.HasNode(templateNode.ID) {
+				g.AddNode(templateNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         asgNode.ID,
+				To:           templateNode.ID,
+				RelationType: "launches-from",
+				Evidence: graph.Evidence{
+					APICall: "DescribeAutoScalingGroups",
+					Fields: map[string]any{
+						"LaunchTemplateId":   aws.ToString(template.LaunchTemplateId),
+						"LaunchTemplateName": aws.ToString(template.LaunchTemplateName),
+						"Version":            aws.ToString(template.Version),
+					},
+				},
+			})
+			neighbors = append(neighbors, templateNode.ID)
+		}
+	}
+
+	// VPCZoneIdentifier is a comma-separated list of subnet IDs
+	for _, subnetID := range strings.Split(aws.ToString(group.VPCZoneIdentifier), ",") {
+		subnetID = strings.TrimSpace(subnetID)
+		if subnetID == "" {
+			continue
+		}
+		subnetNode := &graph.Node{
+			ID:      canonicalID(asgNode.Region, asgNode.Account, ResourceTypeSubnet, subnetID),
+			Type:    ResourceTypeSubnet,
+			Name:    subnetID,
+			Region:  asgNode.Region,
+			Account: asgNode.Account,
+		}
+		if !g.HasNode(subnetNode.ID) {
+			g.AddNode(subnetNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         asgNode.ID,
+			To:           subnetNode.ID,
+			RelationType: "runs-in-subnet",
+			Evidence: graph.Evidence{
+				APICall: "DescribeAutoScalingGroups",
+				Fields: map[string]any{
+					"VPCZoneIdentifier": *group.VPCZoneIdentifier,
+				},
+			},
+		})
+		neighbors = append(neighbors, subnetNode.ID)
+	}
+
+	return neighbors
+}
+
+// launchTemplateSpec returns the launch template a group launches instances from, whether set
+// directly or through a mixed instances policy. Groups using a launch configuration return nil.
+func launchTemplateSpec(group *astypes.AutoScalingGroup) *astypes.LaunchTemplateSpecification {
+	if group.LaunchTemplate != nil {
+		return group.LaunchTemplate
+	}
+	if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.LaunchTemplate != nil {
+		return group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	return nil
+}
+
+// asgToNode converts an Auto Scaling Group to a graph node
+func (d *Discoverer) asgToNode(group *astypes.AutoScalingGroup, region, account string) *graph.Node {
+	node := &graph.Node{
+		ID:      aws.ToString(group.AutoScalingGroupARN),
+		Type:    ResourceTypeAutoScalingGroup,
+		ARN:     aws.ToString(group.AutoScalingGroupARN),
+		Name:    *group.AutoScalingGroupName,
+		Region:  region,
+		Account: account,
+		Metadata: map[string]any{
+			"minSize":         aws.ToInt32(group.MinSize),
+			"maxSize":         aws.ToInt32(group.MaxSize),
+			"desiredCapacity": aws.ToInt32(group.DesiredCapacity),
+			"healthCheckType": aws.ToString(group.HealthCheckType),
+		},
+	}
+	if node.ID == "" {
+		node.ID = node.Name
+	}
+	if group.LaunchConfigurationName != nil {
+		node.Metadata["launchConfiguration"] = *group.LaunchConfigurationName
+	}
+	return node
+}
@@ -0,0 +1,196 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const (
+	asgListenerARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/my-lb/abc/def"
+	asgTGARN       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/1111"
+	asgARN         = "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:uuid:autoScalingGroupName/web"
+)
+
+// instanceTargetGroupResponses answers the target group calls with instance targets
+func instanceTargetGroupResponses(instanceIDs ...string) map[string]any {
+	descriptions := make([]elbv2types.TargetHealthDescription, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		descriptions = append(descriptions, elbv2types.TargetHealthDescription{
+			Target:       &elbv2types.TargetDescription{Id: aws.String(id), Port: aws.Int32(80)},
+			TargetHealth: &elbv2types.TargetHealth{State: elbv2types.TargetHealthStateEnumHealthy},
+		})
+	}
+	return map[string]any{
+		"DescribeTargetGroups": &elasticloadbalancingv2.DescribeTargetGroupsOutput{
+			TargetGroups: []elbv2types.TargetGroup{{
+				TargetGroupArn:  aws.String(asgTGARN),
+				TargetGroupName: aws.String("backend"),
+				TargetType:      elbv2types.TargetTypeEnumInstance,
+			}},
+		},
+		"DescribeTargetHealth": &elasticloadbalancingv2.DescribeTargetHealthOutput{
+			TargetHealthDescriptions: descriptions,
+		},
+	}
+}
+
+func TestDiscoverTargetGroupFindsASG(t *testing.T) {
+	responses := instanceTargetGroupResponses("i-1", "i-2", "i-3")
+	responses["DescribeAutoScalingInstances"] = &autoscaling.DescribeAutoScalingInstancesOutput{
+		AutoScalingInstances: []astypes.AutoScalingInstanceDetails{
+			{InstanceId: aws.String("i-1"), AutoScalingGroupName: aws.String("web"), LifecycleState: aws.String("InService")},
+			{InstanceId: aws.String("i-2"), AutoScalingGroupName: aws.String("web"), LifecycleState: aws.String("InService")},
+			// i-3 was registered by hand and belongs to no group
+		},
+	}
+	responses["DescribeAutoScalingGroups"] = &autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []astypes.AutoScalingGroup{{
+			AutoScalingGroupARN:  aws.String(asgARN),
+			AutoScalingGroupName: aws.String("web"),
+			MinSize:              aws.Int32(2),
+			MaxSize:              aws.Int32(6),
+			DesiredCapacity:      aws.Int32(2),
+			HealthCheckType:      aws.String("ELB"),
+			LaunchTemplate: &astypes.LaunchTemplateSpecification{
+				LaunchTemplateId:   aws.String("lt-0abc"),
+				LaunchTemplateName: aws.String("web-template"),
+				Version:            aws.String("$Latest"),
+			},
+			VPCZoneIdentifier: aws.String("subnet-a,subnet-b"),
+		}},
+	}
+	stub := newStubAPI(responses)
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	listenerNode := &graph.Node{ID: asgListenerARN, Type: ResourceTypeListener}
+	g.AddNode(listenerNode)
+
+	neighbors, err := d.discoverTargetGroup(context.Background(), asgTGARN, listenerNode, g)
+	if err != nil {
+		t.Fatalf("discoverTargetGroup() error = %v", err)
+	}
+	if errs := d.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors() = %v, want none", errs)
+	}
+	const (
+		templateID = "LaunchTemplate:123456789012:us-east-1:lt-0abc"
+		subnetA    = "Subnet:123456789012:us-east-1:subnet-a"
+		subnetB    = "Subnet:123456789012:us-east-1:subnet-b"
+	)
+	for _, want := range []string{asgARN, templateID, subnetA, subnetB} {
+		if !slices.Contains(neighbors, want) {
+			t.Errorf("neighbors = %v, missing %s", neighbors, want)
+		}
+	}
+
+	asgNode, ok := g.GetNode(asgARN)
+	if !ok {
+		t.Fatal("Auto Scaling Group node not added")
+	}
+	if asgNode.Type != ResourceTypeAutoScalingGroup || asgNode.Name != "web" {
+		t.Errorf("ASG node = %s %q, want AutoScalingGroup web", asgNode.Type, asgNode.Name)
+	}
+	if got := asgNode.Metadata["maxSize"]; got != int32(6) {
+		t.Errorf("maxSize = %v, want 6", got)
+	}
+	if templateNode, _ := g.GetNode(templateID); templateNode == nil || templateNode.Type != ResourceTypeLaunchTemplate {
+		t.Errorf("launch template node = %v, want a LaunchTemplate", templateNode)
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesFrom(asgARN) {
+		relations[edge.To] = edge.RelationType
+	}
+	want := map[string]string{
+		"EC2Instance:123456789012:us-east-1:i-1": "manages",
+		"EC2Instance:123456789012:us-east-1:i-2": "manages",
+		templateID:                               "launches-from",
+		subnetA:                                  "runs-in-subnet",
+		subnetB:                                  "runs-in-subnet",
+	}
+	for to, relation := range want {
+		if relations[to] != relation {
+			t.Errorf("edge to %s = %q, want %q", to, relations[to], relation)
+		}
+	}
+	if _, ok := relations["EC2Instance:123456789012:us-east-1:i-3"]; ok {
+		t.Error("ASG manages i-3, which is in no group")
+	}
+
+	var backed bool
+	for _, edge := range g.EdgesFrom(asgTGARN) {
+		if edge.To == asgARN && edge.RelationType == "backed-by-asg" {
+			backed = true
+		}
+	}
+	if !backed {
+		t.Error("expected backed-by-asg edge from target group to ASG")
+	}
+}
+
+func TestDiscoverTargetGroupRecordsASGErrors(t *testing.T) {
+	asgErr := errors.New("access denied")
+	responses := instanceTargetGroupResponses("i-1")
+	responses["DescribeAutoScalingInstances"] = asgErr
+	stub := newStubAPI(responses)
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	listenerNode := &graph.Node{ID: asgListenerARN, Type: ResourceTypeListener}
+	g.AddNode(listenerNode)
+
+	neighbors, err := d.discoverTargetGroup(context.Background(), asgTGARN, listenerNode, g)
+	if err != nil {
+		t.Fatalf("discoverTargetGroup() error = %v", err)
+	}
+	if len(neighbors) != 2 {
+		t.Errorf("neighbors = %v, want the target group and its instance", neighbors)
+	}
+	errs := d.Errors()
+	if len(errs) != 1 || !errors.Is(errs[0], asgErr) {
+		t.Errorf("Errors() = %v, want the DescribeAutoScalingInstances error", errs)
+	}
+	if stub.called("DescribeAutoScalingGroups") {
+		t.Error("DescribeAutoScalingGroups called although no groups were found")
+	}
+}
+
+func TestLaunchTemplateSpec(t *testing.T) {
+	direct := &astypes.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-direct")}
+	mixed := &astypes.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-mixed")}
+
+	tests := []struct {
+		name  string
+		group *astypes.AutoScalingGroup
+		want  *astypes.LaunchTemplateSpecification
+	}{
+		{name: "Launch template", group: &astypes.AutoScalingGroup{LaunchTemplate: direct}, want: direct},
+		{
+			name: "Mixed instances policy",
+			group: &astypes.AutoScalingGroup{MixedInstancesPolicy: &astypes.MixedInstancesPolicy{
+				LaunchTemplate: &astypes.LaunchTemplate{LaunchTemplateSpecification: mixed},
+			}},
+			want: mixed,
+		},
+		{name: "Launch configuration", group: &astypes.AutoScalingGroup{LaunchConfigurationName: aws.String("legacy")}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := launchTemplateSpec(tt.group); got != tt.want {
+				t.Errorf("launchTemplateSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	sdtypes "github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// Attributes ECS sets on the Cloud Map instances it registers for a service's tasks
+const (
+	cloudMapECSClusterAttribute = "ECS_CLUSTER_NAME"
+	cloudMapECSServiceAttribute = "ECS_SERVICE_NAME"
+)
+
+// discoverECSServiceCloudMap links an ECS service to the Cloud Map services it registers its
+// tasks in, through service registries or Service Connect, and to the Cloud Map services it can
+// reach through its Service Connect namespace
+func (d *Discoverer) discoverECSServiceCloudMap(ctx context.Context, svc *ecstypes.Service, node *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	registered := make(map[string]bool)
+
+	for i := range svc.ServiceRegistries {
+		registry := &svc.ServiceRegistries[i]
+		if registry.RegistryArn == nil {
+			continue
+		}
+		cmNode := addCloudMapService(*registry.RegistryArn, "", node, g)
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           cmNode.ID,
+			RelationType: "registered-in",
+			Evidence: graph.Evidence{
+				APICall: "DescribeServices",
+				Fields: map[string]any{
+					"RegistryArn":   *registry.RegistryArn,
+					"ContainerName": aws.ToString(registry.ContainerName),
+					"ContainerPort": aws.ToInt32(registry.ContainerPort),
+				},
+			},
+		})
+		registered[cmNode.ID] = true
+		neighbors = append(neighbors, cmNode.ID)
+	}
+
+	deployment := primaryDeployment(svc)
+	if deployment == nil || deployment.ServiceConnectConfiguration == nil || !deployment.ServiceConnectConfiguration.Enabled {
+		return neighbors
+	}
+
+	// Services exposing endpoints through Service Connect register them as Cloud Map services
+	for _, resource := range deployment.ServiceConnectResources {
+		if resource.DiscoveryArn == nil {
+			continue
+		}
+		cmNode := addCloudMapService(*resource.DiscoveryArn, aws.ToString(resource.DiscoveryName), node, g)
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           cmNode.ID,
+			RelationType: "registered-in",
+			Evidence: graph.Evidence{
+				APICall: "DescribeServices",
+				Fields: map[string]any{
+					"DiscoveryArn":  *resource.DiscoveryArn,
+					"DiscoveryName": aws.ToString(resource.DiscoveryName),
+				},
+			},
+		})
+		registered[cmNode.ID] = true
+		neighbors = append(neighbors, cmNode.ID)
+	}
+
+	// Every Service Connect client can reach the other services in its namespace
+	namespace := aws.ToString(deployment.ServiceConnectConfiguration.Namespace)
+	namespaceID := cloudMapNamespaceID(namespace)
+	if namespaceID == "" {
+		d.log.Debug("Skipping Service Connect namespace without an ARN", "service", node.ID, "namespace", namespace)
+		return neighbors
+	}
+
+	paginator := servicediscovery.NewListServicesPaginator(d.clients.ServiceDiscovery, &servicediscovery.ListServicesInput{
+		Filters: []sdtypes.ServiceFilter{{
+			Name:      sdtypes.ServiceFilterNameNamespaceId,
+			Values:    []string{namespaceID},
+			Condition: sdtypes.FilterConditionEq,
+		}},
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *servicediscovery.ListServicesOutput) error {
+		for _, summary := range output.Services {
+			if summary.Arn == nil || registered[*summary.Arn] {
+				continue
+			}
+			cmNode := addCloudMapService(*summary.Arn, aws.ToString(summary.Name), node, g)
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           cmNode.ID,
+				RelationType: "discovers",
+				Evidence: graph.Evidence{
+					APICall: "ListServices",
+					Fields: map[string]any{
+						"Namespace": namespace,
+					},
+				},
+			})
+			neighbors = append(neighbors, cmNode.ID)
+		}
+		return nil
+	})
+	if pageErr != nil {
+		d.warn(node.ID, "Failed to list Service Connect namespace services", pageErr, "namespace", namespace)
+	}
+
+	return neighbors
+}
+
+// discoverCloudMap links a Cloud Map service to the ECS services whose tasks are registered in
+// it, which makes the producers behind a Service Connect or DNS name reachable from consumers
+func (d *Discoverer) discoverCloudMap(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Cloud Map service", "arn", node.ARN)
+
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	serviceID := cloudMapServiceID(node.ARN)
+	if serviceID == "" {
+		return nil, fmt.Errorf("cannot determine Cloud Map service ID: %s", node.ID)
+	}
+
+	output, err := d.clients.ServiceDiscovery.GetService(ctx, &servicediscovery.GetServiceInput{
+		Id: aws.String(serviceID),
+	})
+	if err != nil {
+		d.warn(node.ID, "Failed to get Cloud Map service", err)
+	} else if output.Service != nil {
+		if output.Service.Name != nil {
+			node.Name = *output.Service.Name
+		}
+		if output.Service.NamespaceId != nil {
+			node.Metadata["namespaceId"] = *output.Service.NamespaceId
+		}
+	}
+
+	// Tasks of one service register one instance each, so producers are collected once
+	producers := make(map[string][2]string)
+	instances := 0
+	paginator := servicediscovery.NewListInstancesPaginator(d.clients.ServiceDiscovery, &servicediscovery.ListInstancesInput{
+		ServiceId: aws.String(serviceID),
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(page *servicediscovery.ListInstancesOutput) error {
+		for _, instance := range page.Instances {
+			instances++
+			cluster := instance.Attributes[cloudMapECSClusterAttribute]
+			service := instance.Attributes[cloudMapECSServiceAttribute]
+			if cluster == "" || service == "" {
+				continue
+			}
+			producers[cluster+"/"+service] = [2]string{cluster, service}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to list Cloud Map instances: %w", pageErr)
+	}
+	node.Metadata["instanceCount"] = instances
+
+	keys := make([]string, 0, len(producers))
+	for key := range producers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var neighbors []string
+	for _, key := range keys {
+		cluster, service := producers[key][0], producers[key][1]
+		svcNode := cloudMapProducerNode(node, cluster, service)
+		if !g.HasNode(svcNode.ID) {
+			g.AddNode(svcNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         svcNode.ID,
+			To:           node.ID,
+			RelationType: "registered-in",
+			Evidence: graph.Evidence{
+				APICall: "ListInstances",
+				Fields: map[string]any{
+					cloudMapECSClusterAttribute: cluster,
+					cloudMapECSServiceAttribute: service,
+				},
+			},
+		})
+		neighbors = append(neighbors, svcNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// addCloudMapService adds a Cloud Map service node unless it is already in the graph, returning
+// the node in the graph. The service ID stands in for the name when none is known.
+func addCloudMapService(arn, name string, source *graph.Node, g *graph.Graph) *graph.Node {
+	if existing, ok := g.GetNode(arn); ok {
+		return existing
+	}
+	if name == "" {
+		name = cloudMapServiceID(arn)
+	}
+	region, account := source.Region, source.Account
+	if parts := strings.Split(arn, ":"); len(parts) >= 6 {
+		region, account = parts[3], parts[4]
+	}
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeCloudMapService,
+		ARN:      arn,
+		Name:     name,
+		Region:   region,
+		Account:  account,
+		Metadata: map[string]any{},
+	}
+	g.AddNode(node)
+	return node
+}
+
+// cloudMapProducerNode returns a node for the ECS service that registered instances in a Cloud
+// Map service, with an ARN built from the cluster and service names in the instance attributes
+func cloudMapProducerNode(cmNode *graph.Node, cluster, service string) *graph.Node {
+	partition := "aws"
+	if parts := strings.Split(cmNode.ARN, ":"); len(parts) >= 2 && parts[1] != "" {
+		partition = parts[1]
+	}
+	arn := fmt.Sprintf("arn:%s:ecs:%s:%s:service/%s/%s", partition, cmNode.Region, cmNode.Account, cluster, service)
+	return &graph.Node{
+		ID:      arn,
+		Type:    ResourceTypeECSService,
+		ARN:     arn,
+		Name:    service,
+		Region:  cmNode.Region,
+		Account: cmNode.Account,
+		Metadata: map[string]any{
+			"cluster": cluster,
+		},
+	}
+}
+
+// primaryDeployment returns the deployment an ECS service is rolling out or running, or nil
+func primaryDeployment(svc *ecstypes.Service) *ecstypes.Deployment {
+	for i := range svc.Deployments {
+		if aws.ToString(svc.Deployments[i].Status) == "PRIMARY" {
+			return &svc.Deployments[i]
+		}
+	}
+	return nil
+}
+
+// cloudMapServiceID returns the srv- ID from a Cloud Map service ARN, or an empty string
+func cloudMapServiceID(arn string) string {
+	if !strings.Contains(arn, ":servicediscovery:") {
+		return ""
+	}
+	_, id, ok := strings.Cut(arn, ":service/")
+	if !ok || strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
+
+// cloudMapNamespaceID returns the ns- ID from a Cloud Map namespace ARN, or an empty string for
+// namespaces given by name
+func cloudMapNamespaceID(arn string) string {
+	if !strings.Contains(arn, ":servicediscovery:") {
+		return ""
+	}
+	_, id, ok := strings.Cut(arn, ":namespace/")
+	if !ok || strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
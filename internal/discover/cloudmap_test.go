@@ -0,0 +1,165 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	sdtypes "github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const (
+	testCloudMapRegistryARN = "arn:aws:servicediscovery:us-east-1:123456789012:service/srv-registry"
+	testCloudMapOrdersARN   = "arn:aws:servicediscovery:us-east-1:123456789012:service/srv-orders"
+	testCloudMapPaymentsARN = "arn:aws:servicediscovery:us-east-1:123456789012:service/srv-payments"
+	testCloudMapNamespace   = "arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-internal"
+)
+
+func TestDiscoverECSServiceCloudMap(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"ListServices": &servicediscovery.ListServicesOutput{
+			Services: []sdtypes.ServiceSummary{
+				// The service's own endpoint is not something it discovers
+				{Arn: aws.String(testCloudMapOrdersARN), Name: aws.String("orders")},
+				{Arn: aws.String(testCloudMapPaymentsARN), Name: aws.String("payments")},
+			},
+		},
+	})
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := &graph.Node{ID: "arn:aws:ecs:us-east-1:123456789012:service/prod/orders", Type: ResourceTypeECSService, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(node)
+
+	svc := &ecstypes.Service{
+		ServiceRegistries: []ecstypes.ServiceRegistry{{
+			RegistryArn:   aws.String(testCloudMapRegistryARN),
+			ContainerName: aws.String("app"),
+			ContainerPort: aws.Int32(8080),
+		}},
+		Deployments: []ecstypes.Deployment{
+			{
+				// Connections made by a previous deployment are ignored
+				Status: aws.String("ACTIVE"),
+				ServiceConnectResources: []ecstypes.ServiceConnectServiceResource{
+					{DiscoveryArn: aws.String("arn:aws:servicediscovery:us-east-1:123456789012:service/srv-old")},
+				},
+			},
+			{
+				Status: aws.String("PRIMARY"),
+				ServiceConnectConfiguration: &ecstypes.ServiceConnectConfiguration{
+					Enabled:   true,
+					Namespace: aws.String(testCloudMapNamespace),
+				},
+				ServiceConnectResources: []ecstypes.ServiceConnectServiceResource{
+					{DiscoveryArn: aws.String(testCloudMapOrdersARN), DiscoveryName: aws.String("orders")},
+				},
+			},
+		},
+	}
+
+	neighbors := d.discoverECSServiceCloudMap(context.Background(), svc, node, g)
+
+	want := []string{testCloudMapRegistryARN, testCloudMapOrdersARN, testCloudMapPaymentsARN}
+	if len(neighbors) != len(want) {
+		t.Fatalf("neighbors = %v, want %v", neighbors, want)
+	}
+	for i := range want {
+		if neighbors[i] != want[i] {
+			t.Errorf("neighbors[%d] = %s, want %s", i, neighbors[i], want[i])
+		}
+	}
+
+	edges := []struct {
+		to       string
+		relation string
+	}{
+		{testCloudMapRegistryARN, "registered-in"},
+		{testCloudMapOrdersARN, "registered-in"},
+		{testCloudMapPaymentsARN, "discovers"},
+	}
+	for _, e := range edges {
+		if found := g.EdgesBetween(node.ID, e.to); len(found) != 1 || found[0].RelationType != e.relation {
+			t.Errorf("edges to %s = %v, want one %s edge", e.to, found, e.relation)
+		}
+	}
+
+	payments, ok := g.GetNode(testCloudMapPaymentsARN)
+	if !ok || payments.Type != ResourceTypeCloudMapService || payments.Name != "payments" {
+		t.Errorf("payments node = %+v, want a CloudMapService named payments", payments)
+	}
+	registry, _ := g.GetNode(testCloudMapRegistryARN)
+	if registry == nil || registry.Name != "srv-registry" {
+		t.Errorf("registry node = %+v, want it named by service ID", registry)
+	}
+	if g.HasNode("arn:aws:servicediscovery:us-east-1:123456789012:service/srv-old") {
+		t.Error("Cloud Map service of an inactive deployment added")
+	}
+	if errs := d.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v, want none", errs)
+	}
+}
+
+func TestDiscoverCloudMap(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"GetService": &servicediscovery.GetServiceOutput{
+			Service: &sdtypes.Service{Name: aws.String("payments"), NamespaceId: aws.String("ns-internal")},
+		},
+		"ListInstances": &servicediscovery.ListInstancesOutput{
+			Instances: []sdtypes.InstanceSummary{
+				{Id: aws.String("task-1"), Attributes: map[string]string{"ECS_CLUSTER_NAME": "prod", "ECS_SERVICE_NAME": "payments"}},
+				{Id: aws.String("task-2"), Attributes: map[string]string{"ECS_CLUSTER_NAME": "prod", "ECS_SERVICE_NAME": "payments"}},
+				// Instances registered outside ECS have no producer to link
+				{Id: aws.String("i-0123456789abcdef0"), Attributes: map[string]string{"AWS_INSTANCE_IPV4": "10.0.1.15"}},
+			},
+		},
+	})
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := &graph.Node{ID: testCloudMapPaymentsARN, ARN: testCloudMapPaymentsARN, Type: ResourceTypeCloudMapService, Name: "srv-payments", Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverCloudMap(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverCloudMap() error = %v", err)
+	}
+
+	const producerARN = "arn:aws:ecs:us-east-1:123456789012:service/prod/payments"
+	if len(neighbors) != 1 || neighbors[0] != producerARN {
+		t.Fatalf("neighbors = %v, want [%s]", neighbors, producerARN)
+	}
+	producer, ok := g.GetNode(producerARN)
+	if !ok || producer.Type != ResourceTypeECSService || producer.Metadata["cluster"] != "prod" {
+		t.Errorf("producer node = %+v, want an ECSService in cluster prod", producer)
+	}
+	if edges := g.EdgesBetween(producerARN, node.ID); len(edges) != 1 || edges[0].RelationType != "registered-in" {
+		t.Errorf("producer edges = %v, want registered-in", edges)
+	}
+	if node.Name != "payments" || node.Metadata["namespaceId"] != "ns-internal" || node.Metadata["instanceCount"] != 3 {
+		t.Errorf("Cloud Map node = %+v, want name, namespace and instance count recorded", node)
+	}
+}
+
+func TestCloudMapIDs(t *testing.T) {
+	tests := []struct {
+		arn           string
+		wantService   string
+		wantNamespace string
+	}{
+		{testCloudMapPaymentsARN, "srv-payments", ""},
+		{testCloudMapNamespace, "", "ns-internal"},
+		{"internal.local", "", ""},
+		{"arn:aws:ecs:us-east-1:123456789012:service/prod/payments", "", ""},
+	}
+	for _, tt := range tests {
+		if got := cloudMapServiceID(tt.arn); got != tt.wantService {
+			t.Errorf("cloudMapServiceID(%q) = %q, want %q", tt.arn, got, tt.wantService)
+		}
+		if got := cloudMapNamespaceID(tt.arn); got != tt.wantNamespace {
+			t.Errorf("cloudMapNamespaceID(%q) = %q, want %q", tt.arn, got, tt.wantNamespace)
+		}
+	}
+}
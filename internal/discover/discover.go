@@ -2,10 +2,15 @@ package discover
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
 	"strings"
 
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+
 	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
@@ -15,55 +20,296 @@ type Options struct {
 	MaxDepth   int
 	MaxNodes   int
 	Heuristics []string
+
+	// MaxEdges, if positive, stops discovery once the graph holds this many edges, as MaxNodes
+	// does for nodes. Edges and nodes found past the limit are dropped, so a single resource
+	// with thousands of relationships cannot grow the graph without bound.
+	MaxEdges int
+
+	// ExcludeTypes lists resource types that are never added to the graph
+	ExcludeTypes []string
+	// IncludeTypes, if set, is an allowlist of resource types added to the graph
+	IncludeTypes []string
+
+	// ExcludeARNs and ExcludeNames are glob patterns for specific resources, such as a shared
+	// logging role, that are never added to the graph, so traversal does not pass through them.
+	// ExcludeARNs matches a node's ARN, or its ID if it has none, and ExcludeNames its name.
+	// In patterns * matches any run of characters, including /, and ? matches one character.
+	ExcludeARNs  []string
+	ExcludeNames []string
+
+	// DepthOverrides caps the depth at which nodes of a type are expanded, keyed by
+	// resource type. Nodes beyond their type's limit are kept as leaves. Overrides
+	// tighten MaxDepth and cannot extend it.
+	DepthOverrides map[string]int
+
+	// RegionFilters, if set, is an allowlist of regions to traverse. Resources found in other
+	// regions are added to the graph but not expanded. Resources without a region, such as
+	// IAM roles, are not affected.
+	RegionFilters []string
+
+	// IncludeRuntime adds the running tasks of ECS services and the capacity they run on.
+	// Tasks come and go with deployments, so they are left out by default.
+	IncludeRuntime bool
+
+	// SkipRoute53 skips the upstream Route 53 record lookup for load balancers, which lists
+	// every hosted zone in the account
+	SkipRoute53 bool
+	// SkipIAM leaves IAM roles as leaves instead of reading their trust and permission policies
+	SkipIAM bool
+
+	// OnProgress, if set, is called after each node is expanded with the current graph size
+	// and BFS depth, so callers can render progress without this package doing I/O
+	OnProgress func(ProgressEvent)
+
+	// Logger receives discovery logs. If nil, nothing is logged; the CLI passes slog.Default().
+	Logger *slog.Logger
+}
+
+// Result summarizes a Discover call, so callers need not inspect the graph to learn what happened
+type Result struct {
+	// Nodes and Edges are the final graph size
+	Nodes int
+	Edges int
+	// Errors are the failures that left the graph incomplete, as returned by Errors
+	Errors []error
+	// TruncationReason is set when discovery stopped early, e.g. TruncatedMaxNodes
+	TruncationReason string
+}
+
+// Complete reports whether discovery finished without errors or truncation
+func (r Result) Complete() bool {
+	return len(r.Errors) == 0 && r.TruncationReason == ""
+}
+
+// ProgressEvent reports discovery progress after a node has been expanded
+type ProgressEvent struct {
+	// Nodes and Edges are the current graph size
+	Nodes int
+	Edges int
+	// Depth is the BFS depth of Node
+	Depth int
+	// Node is the node just processed
+	Node *graph.Node
 }
 
 // Discoverer orchestrates resource discovery
 type Discoverer struct {
 	clients *awsx.Clients
 	opts    *Options
+	log     *slog.Logger
+
+	// regionalClients provides clients for other regions of the default account
+	regionalClients *awsx.ClientProvider
+	// accountClients provides clients for other accounts, keyed by account ID
+	accountClients map[string]*awsx.ClientProvider
+
+	// discoverNodeFunc discovers a single node's neighbors; tests replace it to avoid AWS calls
+	discoverNodeFunc func(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error)
+
+	// apiIntegrations indexes API Gateway integrations by backend Lambda ARN, built lazily
+	// per account and region (see scopeKey)
+	apiIntegrations map[string]map[string][]apiGatewayIntegration
+	// lbNameCache maps load balancer names to their description; nil marks a name known not to exist
+	lbNameCache map[string]*elbv2types.LoadBalancer
+	// eventRuleTargets indexes EventBridge rule targets by target ARN, built lazily per account and region
+	eventRuleTargets map[string]map[string][]eventBridgeRuleTarget
+	// route53Aliases indexes alias and CNAME records by target DNS name, built lazily per
+	// account and reset by each Discover call
+	route53Aliases map[string]map[string][]route53Target
+	// layerFunctions indexes Lambda functions by the layer version ARNs they use, built lazily
+	// per account and region
+	layerFunctions map[string]map[string][]*graph.Node
+
+	// errs collects failures during Discover that left the graph incomplete (see Errors)
+	errs []*DiscoveryError
 }
 
 // New creates a new Discoverer
 func New(clients *awsx.Clients, opts *Options) *Discoverer {
-	return &Discoverer{
+	d := &Discoverer{
 		clients: clients,
 		opts:    opts,
+		log:     opts.Logger,
+	}
+	if d.log == nil {
+		d.log = slog.New(discardHandler{})
 	}
+	d.discoverNodeFunc = d.discoverNode
+	return d
 }
 
-// Discover starts the discovery process from a resource identifier
-func (d *Discoverer) Discover(ctx context.Context, resourceID string, g *graph.Graph) error {
-	slog.Debug("Starting discovery", "resourceID", resourceID)
+// discardHandler is a slog.Handler that drops every record, used when Options.Logger is nil
+type discardHandler struct{}
 
-	// Parse resource identifier to determine type
-	startNode, err := d.identifyResource(ctx, resourceID)
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// SetRegionalClients registers the provider used to reach regions other than the default
+// clients' region. Without one, every node is discovered with the default clients.
+func (d *Discoverer) SetRegionalClients(provider *awsx.ClientProvider) {
+	d.regionalClients = provider
+}
+
+// SetAccountClients registers client providers for nodes in other accounts, keyed by account ID.
+// Nodes in accounts without an entry are discovered with the default account's clients.
+func (d *Discoverer) SetAccountClients(providers map[string]*awsx.ClientProvider) {
+	d.accountClients = providers
+}
+
+// useNodeClients switches to the clients for a node's account and region and returns a func
+// restoring the previous clients
+func (d *Discoverer) useNodeClients(node *graph.Node) func() {
+	provider, ok := d.accountClients[node.Account]
+	if !ok {
+		provider = d.regionalClients
+	}
+	if provider == nil {
+		return func() {}
+	}
+
+	clients, err := provider.ForRegion(node.Region)
 	if err != nil {
-		return fmt.Errorf("failed to identify resource: %w", err)
+		d.log.Warn("Failed to create clients for region, using defaults",
+			"account", node.Account,
+			"region", node.Region,
+			"error", err)
+		return func() {}
 	}
 
-	g.AddNode(startNode)
-	slog.Info("Identified starting resource",
+	previous := d.clients
+	d.clients = clients
+	return func() {
+		d.clients = previous
+	}
+}
+
+// scopeKey identifies the account and region a lazily built index was listed from
+func scopeKey(node *graph.Node) string {
+	return node.Account + "/" + node.Region
+}
+
+// canonicalID returns the node ID of a resource known by a local ID rather than an ARN, such
+// as sg-123 or an ECS cluster name. Local IDs only identify a resource within an account and
+// region, so the ID is scoped by both, e.g. SecurityGroup:123456789012:us-east-1:sg-123, and
+// the same ID in two accounts stays two nodes. Without either the local ID is used as is.
+func canonicalID(region, account, resourceType, localID string) string {
+	if region == "" && account == "" {
+		return localID
+	}
+	return resourceType + ":" + account + ":" + region + ":" + localID
+}
+
+// Discover starts the discovery process from a resource identifier and summarizes the
+// outcome. An error means the starting resource could not be resolved; failures after that
+// are reported in the Result and g holds whatever was found.
+func (d *Discoverer) Discover(ctx context.Context, resourceID string, g *graph.Graph) (Result, error) {
+	if err := d.discover(ctx, resourceID, g); err != nil {
+		return Result{}, err
+	}
+	return d.result(g), nil
+}
+
+// DiscoverFrom traverses from several starting nodes at once, e.g. the resources of a
+// Terraform state built with SeedNode. The first seed becomes the graph's root.
+func (d *Discoverer) DiscoverFrom(ctx context.Context, seeds []*graph.Node, g *graph.Graph) (Result, error) {
+	if len(seeds) == 0 {
+		return Result{}, errors.New("no resources to discover from")
+	}
+	d.log.Debug("Starting discovery", "seeds", len(seeds))
+	d.traverse(ctx, seeds, g)
+	return d.result(g), nil
+}
+
+// result summarizes the graph and errors of the last traversal
+func (d *Discoverer) result(g *graph.Graph) Result {
+	return Result{
+		Nodes:            g.NodeCount(),
+		Edges:            g.EdgeCount(),
+		Errors:           d.Errors(),
+		TruncationReason: g.TruncationReason(),
+	}
+}
+
+// discover runs the breadth-first traversal from resourceID into g
+func (d *Discoverer) discover(ctx context.Context, resourceID string, g *graph.Graph) error {
+	d.log.Debug("Starting discovery", "resourceID", resourceID)
+
+	// Parse resource identifier to determine type
+	startNode, err := d.Identify(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	d.log.Info("Identified starting resource",
 		"type", startNode.Type,
 		"id", startNode.ID,
 		"name", startNode.Name)
 
+	d.traverse(ctx, []*graph.Node{startNode}, g)
+	return nil
+}
+
+// traverse adds the seeds to g, the first as its root, and expands them breadth-first up to
+// MaxDepth, MaxNodes, MaxEdges or the context's deadline
+func (d *Discoverer) traverse(ctx context.Context, seeds []*graph.Node, g *graph.Graph) {
+	d.errs = nil
+	d.route53Aliases = nil
+
+	for _, seed := range seeds {
+		if !g.HasNode(seed.ID) {
+			g.AddNode(seed)
+		}
+	}
+	g.SetRoot(seeds[0].ID)
+
+	// The seeds are already in the graph, so type filters and exclusions never drop them
+	if filter := d.nodeFilter(); filter != nil {
+		g.SetNodeFilter(filter)
+	}
+	defer g.SetNodeFilter(nil)
+
+	// The graph enforces MaxEdges itself, so one node's discoverer cannot overshoot it
+	if d.opts.MaxEdges > 0 {
+		g.SetEdgeLimit(d.opts.MaxEdges)
+		defer g.SetEdgeLimit(0)
+	}
+
 	// BFS traversal
 	visited := make(map[string]bool)
-	queue := []string{startNode.ID}
-	visited[startNode.ID] = true
+	var queue []string
+	for _, seed := range seeds {
+		if !visited[seed.ID] {
+			visited[seed.ID] = true
+			queue = append(queue, seed.ID)
+		}
+	}
 	currentDepth := 0
 
 	for len(queue) > 0 && currentDepth <= d.opts.MaxDepth {
 		levelSize := len(queue)
-		slog.Debug("Processing BFS level",
+		d.log.Debug("Processing BFS level",
 			"depth", currentDepth,
 			"queueSize", levelSize,
 			"totalNodes", g.NodeCount())
 
 		for i := 0; i < levelSize; i++ {
+			// Stop at the deadline or on cancellation and keep what was found; linking the
+			// remaining nodes would only issue more calls that fail immediately
+			if ctx.Err() != nil {
+				d.markStopped(ctx, g)
+				return
+			}
+
 			if g.NodeCount() >= d.opts.MaxNodes {
-				slog.Warn("Reached max nodes limit", "maxNodes", d.opts.MaxNodes)
-				return nil
+				d.log.Warn("Reached max nodes limit", "maxNodes", d.opts.MaxNodes)
+				d.linkRemaining(ctx, queue, g)
+				g.MarkTruncated(TruncatedMaxNodes)
+				return
+			}
+			if d.reachedMaxEdges(g) {
+				return
 			}
 
 			nodeID := queue[0]
@@ -75,32 +321,205 @@ func (d *Discoverer) Discover(ctx context.Context, resourceID string, g *graph.G
 			}
 
 			// Discover dependencies for this node
-			neighbors, err := d.discoverNode(ctx, node, g)
+			neighbors, err := d.discoverNodeFunc(ctx, node, g)
 			if err != nil {
-				slog.Warn("Discovery error for node",
-					"nodeID", nodeID,
-					"error", err)
+				d.warn(nodeID, "Discovery error for node", err, "nodeID", nodeID)
 				// Continue despite errors
 			}
 
-			// Add new neighbors to queue
+			// Add new neighbors to queue, skipping any dropped by filters or exclusions,
+			// beyond their type's depth override or outside the region filter
 			for _, neighborID := range neighbors {
-				if !visited[neighborID] {
-					visited[neighborID] = true
-					queue = append(queue, neighborID)
+				if visited[neighborID] || !g.HasNode(neighborID) {
+					continue
+				}
+				visited[neighborID] = true
+				if !d.withinDepthOverride(g, neighborID, currentDepth+1) || !d.withinRegionFilter(g, neighborID) {
+					continue
 				}
+				queue = append(queue, neighborID)
+			}
+
+			if d.opts.OnProgress != nil {
+				d.opts.OnProgress(ProgressEvent{
+					Nodes: g.NodeCount(),
+					Edges: g.EdgeCount(),
+					Depth: currentDepth,
+					Node:  node,
+				})
 			}
 		}
 
 		currentDepth++
 	}
 
-	slog.Info("Discovery complete",
+	// The last node's calls may have been cut short by the deadline or cancellation
+	if ctx.Err() != nil {
+		d.markStopped(ctx, g)
+		return
+	}
+
+	// The last node expanded may have filled the graph's edges
+	if d.reachedMaxEdges(g) {
+		return
+	}
+
+	// Nodes still queued were found but never expanded
+	if len(queue) > 0 {
+		d.log.Info("Reached max depth with unexpanded nodes", "maxDepth", d.opts.MaxDepth, "unexpanded", len(queue))
+		g.MarkTruncated(TruncatedMaxDepth)
+	}
+
+	d.log.Info("Discovery complete",
 		"finalDepth", currentDepth,
 		"nodes", g.NodeCount(),
 		"edges", g.EdgeCount())
+}
 
-	return nil
+// reachedMaxEdges reports whether the graph holds MaxEdges edges, logging a warning and
+// recording the truncation if so
+func (d *Discoverer) reachedMaxEdges(g *graph.Graph) bool {
+	if d.opts.MaxEdges <= 0 || g.EdgeCount() < d.opts.MaxEdges {
+		return false
+	}
+	d.log.Warn("Reached max edges limit", "maxEdges", d.opts.MaxEdges)
+	g.MarkTruncated(TruncatedMaxEdges)
+	return true
+}
+
+// markStopped records that discovery stopped because ctx expired or was canceled
+func (d *Discoverer) markStopped(ctx context.Context, g *graph.Graph) {
+	d.log.Warn("Discovery stopped before completing", "reason", ctx.Err(), "nodes", g.NodeCount())
+	if errors.Is(ctx.Err(), context.Canceled) {
+		g.MarkTruncated(TruncatedInterrupted)
+		return
+	}
+	g.MarkTruncated(TruncatedTimeout)
+}
+
+// withinDepthOverride reports whether a node found at depth may be expanded under the
+// depth override for its type. Types without an override are limited only by MaxDepth.
+func (d *Discoverer) withinDepthOverride(g *graph.Graph, nodeID string, depth int) bool {
+	if len(d.opts.DepthOverrides) == 0 {
+		return true
+	}
+	node, ok := g.GetNode(nodeID)
+	if !ok {
+		return true
+	}
+	limit, ok := d.opts.DepthOverrides[node.Type]
+	return !ok || depth <= limit
+}
+
+// withinRegionFilter reports whether a node may be expanded under RegionFilters. Nodes without
+// a region are always expanded.
+func (d *Discoverer) withinRegionFilter(g *graph.Graph, nodeID string) bool {
+	if len(d.opts.RegionFilters) == 0 {
+		return true
+	}
+	node, ok := g.GetNode(nodeID)
+	if !ok || node.Region == "" {
+		return true
+	}
+	return slices.Contains(d.opts.RegionFilters, node.Region)
+}
+
+// linkRemaining discovers edges between nodes already in the graph for nodes that were
+// queued but will not be expanded, so a truncated graph is not missing known relationships.
+// No further nodes are added.
+func (d *Discoverer) linkRemaining(ctx context.Context, nodeIDs []string, g *graph.Graph) {
+	g.SetNodeFilter(func(*graph.Node) bool { return false })
+
+	for _, nodeID := range nodeIDs {
+		node, ok := g.GetNode(nodeID)
+		if !ok {
+			continue
+		}
+		if _, err := d.discoverNodeFunc(ctx, node, g); err != nil {
+			d.log.Debug("Discovery error while linking remaining node", "nodeID", nodeID, "error", err)
+		}
+	}
+}
+
+// typeFilter returns a node predicate built from ExcludeTypes and IncludeTypes, or nil if neither is set
+func (d *Discoverer) typeFilter() func(*graph.Node) bool {
+	if len(d.opts.ExcludeTypes) == 0 && len(d.opts.IncludeTypes) == 0 {
+		return nil
+	}
+
+	excluded := make(map[string]bool, len(d.opts.ExcludeTypes))
+	for _, t := range d.opts.ExcludeTypes {
+		excluded[t] = true
+	}
+	included := make(map[string]bool, len(d.opts.IncludeTypes))
+	for _, t := range d.opts.IncludeTypes {
+		included[t] = true
+	}
+
+	return func(node *graph.Node) bool {
+		if excluded[node.Type] {
+			return false
+		}
+		return len(included) == 0 || included[node.Type]
+	}
+}
+
+// nodeFilter combines typeFilter with the ExcludeARNs and ExcludeNames patterns, or returns nil
+// if none are set
+func (d *Discoverer) nodeFilter() func(*graph.Node) bool {
+	typeFilter := d.typeFilter()
+	excludedARN := globPattern(d.opts.ExcludeARNs)
+	excludedName := globPattern(d.opts.ExcludeNames)
+	if excludedARN == nil && excludedName == nil {
+		return typeFilter
+	}
+
+	return func(node *graph.Node) bool {
+		arn := node.ARN
+		if arn == "" {
+			arn = node.LocalID()
+		}
+		if excludedARN != nil && excludedARN.MatchString(arn) {
+			return false
+		}
+		if excludedName != nil && node.Name != "" && excludedName.MatchString(node.Name) {
+			return false
+		}
+		return typeFilter == nil || typeFilter(node)
+	}
+}
+
+// globPattern compiles glob patterns into one anchored regexp matching any of them, or returns
+// nil if there are none. Only * and ? are special, so compiling cannot fail.
+func globPattern(patterns []string) *regexp.Regexp {
+	if len(patterns) == 0 {
+		return nil
+	}
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		var b strings.Builder
+		for _, r := range pattern {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		alternatives[i] = b.String()
+	}
+	return regexp.MustCompile("^(?:" + strings.Join(alternatives, "|") + ")$")
+}
+
+// Identify resolves a resource identifier to its node without traversing any dependencies
+func (d *Discoverer) Identify(ctx context.Context, resourceID string) (*graph.Node, error) {
+	node, err := d.identifyResource(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify resource: %w", err)
+	}
+	return node, nil
 }
 
 // identifyResource determines the resource type and creates initial node
@@ -110,6 +529,14 @@ func (d *Discoverer) identifyResource(ctx context.Context, resourceID string) (*
 		return d.parseARN(resourceID)
 	}
 
+	// KMS key IDs, alias names and EFS file system IDs are unambiguous, so resolve them directly
+	if kmsKeyIDPattern.MatchString(resourceID) || strings.HasPrefix(resourceID, "alias/") {
+		return d.resolveKMSKey(ctx, resourceID)
+	}
+	if efsFileSystemIDPattern.MatchString(resourceID) {
+		return d.resolveEFSFileSystem(ctx, resourceID)
+	}
+
 	// Try to resolve as a friendly name
 	// For MVP, try common patterns
 
@@ -143,24 +570,74 @@ func (d *Discoverer) identifyResource(ctx context.Context, resourceID string) (*
 		return node, nil
 	}
 
-	return nil, fmt.Errorf("unable to identify resource: %s", resourceID)
+	// Fall back to the Name tag shown in the console
+	node, err := d.resolveByNameTag(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to identify resource: %s: %w", resourceID, err)
+	}
+	return node, nil
 }
 
 // discoverNode discovers dependencies for a specific node
 func (d *Discoverer) discoverNode(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering dependencies", "nodeType", node.Type, "nodeID", node.ID)
+	d.log.Debug("Discovering dependencies", "nodeType", node.Type, "nodeID", node.ID)
+
+	// Discovery is sequential, so nodes in other accounts or regions can swap in their clients
+	defer d.useNodeClients(node)()
 
 	switch node.Type {
 	case ResourceTypeLoadBalancer:
 		return d.discoverLoadBalancer(ctx, node, g)
 	case ResourceTypeECSService:
 		return d.discoverECSService(ctx, node, g)
+	case ResourceTypeECSCluster:
+		return d.discoverECSCluster(ctx, node, g)
 	case ResourceTypeLambda:
 		return d.discoverLambda(ctx, node, g)
-	case ResourceTypeRDSInstance, ResourceTypeRDSCluster:
+	case ResourceTypeLambdaLayer:
+		return d.discoverLambdaLayer(ctx, node, g)
+	case ResourceTypeRDSInstance, ResourceTypeRDSCluster, ResourceTypeRDSGlobalCluster:
 		return d.discoverRDS(ctx, node, g)
+	case ResourceTypeAPIGatewayRestAPI, ResourceTypeAPIGatewayHTTPAPI:
+		return d.discoverAPIGateway(ctx, node, g)
+	case ResourceTypeEventBridgeRule:
+		return d.discoverEventBridgeRule(ctx, node, g)
+	case ResourceTypeKMSKey:
+		return d.discoverKMSKey(ctx, node, g)
+	case ResourceTypeSecretsManagerSecret:
+		return d.discoverSecret(ctx, node, g)
+	case ResourceTypeSSMParameter:
+		return d.discoverSSMParameter(ctx, node, g)
+	case ResourceTypeACMCertificate:
+		return d.discoverACMCertificate(ctx, node, g)
+	case ResourceTypeIAMRole:
+		if d.opts.SkipIAM {
+			d.log.Debug("Skipping IAM role discovery", "role", node.ID)
+			return nil, nil
+		}
+		return d.discoverIAMRole(ctx, node, g)
+	case ResourceTypeWAFWebACL:
+		return d.discoverWAF(ctx, node, g)
+	case ResourceTypeECRRepository:
+		return d.discoverECR(ctx, node, g)
+	case ResourceTypeEFSFileSystem, ResourceTypeEFSAccessPoint:
+		return d.discoverEFS(ctx, node, g)
+	case ResourceTypeKinesisStream:
+		return d.discoverKinesis(ctx, node, g)
+	case ResourceTypeCloudMapService:
+		return d.discoverCloudMap(ctx, node, g)
+	case ResourceTypeFirehoseDeliveryStream:
+		return d.discoverFirehose(ctx, node, g)
+	case ResourceTypeKafkaCluster:
+		return d.discoverMSK(ctx, node, g)
+	case ResourceTypeSESConfigurationSet:
+		return d.discoverSESConfigurationSet(ctx, node, g)
+	case ResourceTypeSubnet:
+		return d.discoverSubnet(ctx, node, g)
+	case ResourceTypeNATGateway:
+		return d.discoverNATGateway(ctx, node, g)
 	default:
-		slog.Debug("No discovery handler for node type", "type", node.Type)
+		d.log.Debug("No discovery handler for node type", "type", node.Type)
 		return nil, nil
 	}
 }
@@ -204,8 +681,18 @@ func (d *Discoverer) parseARN(arn string) (*graph.Node, error) {
 				node.Name = parts[len(parts)-1]
 				node.Metadata["cluster"] = parts[1]
 			}
+		} else if strings.HasPrefix(resource, "cluster/") {
+			node.Type = ResourceTypeECSCluster
+			node.Name = strings.TrimPrefix(resource, "cluster/")
 		}
 	case "lambda":
+		if strings.HasPrefix(resource, "layer:") {
+			layer := lambdaLayerToNode(arn)
+			node.Type = ResourceTypeLambdaLayer
+			node.Name = layer.Name
+			node.Metadata = layer.Metadata
+			break
+		}
 		node.Type = ResourceTypeLambda
 		if strings.HasPrefix(resource, "function:") {
 			node.Name = strings.TrimPrefix(resource, "function:")
@@ -218,7 +705,133 @@ func (d *Discoverer) parseARN(arn string) (*graph.Node, error) {
 		case strings.HasPrefix(resource, "cluster:"):
 			node.Type = ResourceTypeRDSCluster
 			node.Name = strings.TrimPrefix(resource, "cluster:")
+		case strings.HasPrefix(resource, "global-cluster:"):
+			node.Type = ResourceTypeRDSGlobalCluster
+			node.Name = strings.TrimPrefix(resource, "global-cluster:")
+		}
+	case "apigateway":
+		// REST APIs: /restapis/{id}, HTTP APIs: /apis/{id}
+		switch {
+		case strings.HasPrefix(resource, "/restapis/"):
+			node.Type = ResourceTypeAPIGatewayRestAPI
+		case strings.HasPrefix(resource, "/apis/"):
+			node.Type = ResourceTypeAPIGatewayHTTPAPI
+		}
+		if node.Type != "" {
+			parts := strings.Split(resource, "/")
+			if len(parts) >= 3 {
+				node.Name = parts[2]
+				node.Metadata["apiId"] = parts[2]
+			}
+		}
+	case "events":
+		// Rules on the default bus: rule/{name}, on custom buses: rule/{bus}/{name}
+		if strings.HasPrefix(resource, "rule/") {
+			node.Type = ResourceTypeEventBridgeRule
+			parts := strings.Split(resource, "/")
+			node.Name = parts[len(parts)-1]
+			if len(parts) == 3 {
+				node.Metadata["eventBusName"] = parts[1]
+			}
+		}
+	case "iam":
+		switch {
+		case strings.HasPrefix(resource, "role/"):
+			node.Type = ResourceTypeIAMRole
+			node.Name = extractRoleNameFromARN(arn)
+		case strings.HasPrefix(resource, "policy/"):
+			policyNode := iamManagedPolicyToNode(arn)
+			node.Type = policyNode.Type
+			node.Name = policyNode.Name
+			node.Metadata = policyNode.Metadata
+		default:
+			return nil, fmt.Errorf("unsupported iam resource in ARN: %s", arn)
+		}
+	case "kms":
+		keyNode := kmsKeyToNode(arn)
+		node.Type = keyNode.Type
+		node.Name = keyNode.Name
+		node.Metadata = keyNode.Metadata
+	case "secretsmanager", "ssm":
+		if !strings.HasPrefix(resource, "secret:") && !strings.HasPrefix(resource, "parameter/") {
+			return nil, fmt.Errorf("unsupported %s resource in ARN: %s", service, arn)
+		}
+		refNode := secretReferenceToNode(arn, region, account)
+		node.Type = refNode.Type
+		node.Name = refNode.Name
+	case "acm":
+		if !strings.HasPrefix(resource, "certificate/") {
+			return nil, fmt.Errorf("unsupported acm resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeACMCertificate
+		node.Name = strings.TrimPrefix(resource, "certificate/")
+	case "wafv2":
+		// Resources: regional/webacl/name/id, global/rulegroup/name/id, ...
+		segments := strings.Split(resource, "/")
+		if len(segments) == 4 {
+			node.Name = segments[2]
+			switch segments[1] {
+			case "webacl":
+				node.Type = ResourceTypeWAFWebACL
+			case "rulegroup":
+				node.Type = ResourceTypeWAFRuleGroup
+			case "ipset":
+				node.Type = ResourceTypeWAFIPSet
+			}
+		}
+	case "ecr":
+		if !strings.HasPrefix(resource, "repository/") {
+			return nil, fmt.Errorf("unsupported ecr resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeECRRepository
+		node.Name = strings.TrimPrefix(resource, "repository/")
+	case "elasticfilesystem":
+		switch {
+		case strings.HasPrefix(resource, "file-system/"):
+			node.Type = ResourceTypeEFSFileSystem
+			node.Name = strings.TrimPrefix(resource, "file-system/")
+		case strings.HasPrefix(resource, "access-point/"):
+			node.Type = ResourceTypeEFSAccessPoint
+			node.Name = strings.TrimPrefix(resource, "access-point/")
+		default:
+			return nil, fmt.Errorf("unsupported elasticfilesystem resource in ARN: %s", arn)
+		}
+	case "servicediscovery":
+		// Namespaces are not expanded on their own
+		id := cloudMapServiceID(arn)
+		if id == "" {
+			return nil, fmt.Errorf("unsupported servicediscovery resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeCloudMapService
+		node.Name = id
+	case "kinesis":
+		// Consumer ARNs (stream/name/consumer/...) are not expanded on their own
+		name, ok := strings.CutPrefix(resource, "stream/")
+		if !ok || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("unsupported kinesis resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeKinesisStream
+		node.Name = name
+	case "firehose":
+		if !strings.HasPrefix(resource, "deliverystream/") {
+			return nil, fmt.Errorf("unsupported firehose resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeFirehoseDeliveryStream
+		node.Name = strings.TrimPrefix(resource, "deliverystream/")
+	case "kafka":
+		// Configurations are not expanded on their own
+		if !strings.HasPrefix(resource, "cluster/") {
+			return nil, fmt.Errorf("unsupported kafka resource in ARN: %s", arn)
+		}
+		node.Type = ResourceTypeKafkaCluster
+		node.Name = mskResourceName(arn)
+	case "ses":
+		// Identities and other SES resources are not expanded
+		if !strings.HasPrefix(resource, "configuration-set/") {
+			return nil, fmt.Errorf("unsupported ses resource in ARN: %s", arn)
 		}
+		node.Type = ResourceTypeSESConfigurationSet
+		node.Name = strings.TrimPrefix(resource, "configuration-set/")
 	default:
 		return nil, fmt.Errorf("unsupported service in ARN: %s", service)
 	}
@@ -1,7 +1,22 @@
 package discover
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+	"github.com/pfrederiksen/blast-radius/internal/output"
 )
 
 func TestParseARN(t *testing.T) {
@@ -41,6 +56,24 @@ func TestParseARN(t *testing.T) {
 			wantAccount: "123456789012",
 			wantErr:     false,
 		},
+		{
+			name:        "ECS Cluster ARN",
+			arn:         "arn:aws:ecs:us-east-1:123456789012:cluster/my-cluster",
+			wantType:    "ECSCluster",
+			wantName:    "my-cluster",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "IAM Role ARN with path",
+			arn:         "arn:aws:iam::123456789012:role/service-role/my-role",
+			wantType:    "IAMRole",
+			wantName:    "my-role",
+			wantRegion:  "",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
 		{
 			name:        "Lambda Function ARN",
 			arn:         "arn:aws:lambda:us-east-1:123456789012:function:my-function",
@@ -68,6 +101,169 @@ func TestParseARN(t *testing.T) {
 			wantAccount: "123456789012",
 			wantErr:     false,
 		},
+		{
+			name:        "WAF Web ACL ARN",
+			arn:         "arn:aws:wafv2:us-east-1:123456789012:regional/webacl/my-acl/a1b2c3d4-5678-90ab-cdef-EXAMPLE11111",
+			wantType:    "WAFWebACL",
+			wantName:    "my-acl",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "ECR repository ARN",
+			arn:         "arn:aws:ecr:us-west-2:123456789012:repository/team/api",
+			wantType:    "ECRRepository",
+			wantName:    "team/api",
+			wantRegion:  "us-west-2",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "EFS access point ARN",
+			arn:         "arn:aws:elasticfilesystem:us-east-1:123456789012:access-point/fsap-0123456789abcdef0",
+			wantType:    "EFSAccessPoint",
+			wantName:    "fsap-0123456789abcdef0",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "Kinesis stream ARN",
+			arn:         "arn:aws:kinesis:us-east-1:123456789012:stream/orders",
+			wantType:    "KinesisStream",
+			wantName:    "orders",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:    "Kinesis consumer ARN is unsupported",
+			arn:     "arn:aws:kinesis:us-east-1:123456789012:stream/orders/consumer/analytics:1700000000",
+			wantErr: true,
+		},
+		{
+			name:        "Firehose delivery stream ARN",
+			arn:         "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders-to-s3",
+			wantType:    "FirehoseDeliveryStream",
+			wantName:    "orders-to-s3",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "Lambda layer version ARN",
+			arn:         "arn:aws:lambda:us-east-1:123456789012:layer:common-deps:7",
+			wantType:    "LambdaLayer",
+			wantName:    "common-deps:7",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "Cloud Map service ARN",
+			arn:         "arn:aws:servicediscovery:us-east-1:123456789012:service/srv-abc123",
+			wantType:    "CloudMapService",
+			wantName:    "srv-abc123",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:    "Cloud Map namespace ARN is unsupported",
+			arn:     "arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-abc123",
+			wantErr: true,
+		},
+		{
+			name:        "MSK cluster ARN",
+			arn:         "arn:aws:kafka:us-east-1:123456789012:cluster/orders-events/0b8d4c2a-1f3e-4a5b-9c6d-7e8f9a0b1c2d-2",
+			wantType:    "KafkaCluster",
+			wantName:    "orders-events",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "SES configuration set ARN",
+			arn:         "arn:aws:ses:us-east-1:123456789012:configuration-set/transactional",
+			wantType:    "SESConfigurationSet",
+			wantName:    "transactional",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+		},
+		{
+			name:    "SES identity ARN is unsupported",
+			arn:     "arn:aws:ses:us-east-1:123456789012:identity/example.com",
+			wantErr: true,
+		},
+		{
+			name:    "MSK configuration ARN is unsupported",
+			arn:     "arn:aws:kafka:us-east-1:123456789012:configuration/tuned/5f1c7e2a-3b4d-4c6e-8f9a-0b1c2d3e4f5a-1",
+			wantErr: true,
+		},
+		{
+			name:        "API Gateway REST API ARN",
+			arn:         "arn:aws:apigateway:us-east-1::/restapis/a1b2c3d4e5",
+			wantType:    "APIGatewayRestAPI",
+			wantName:    "a1b2c3d4e5",
+			wantRegion:  "us-east-1",
+			wantAccount: "",
+			wantErr:     false,
+		},
+		{
+			name:        "API Gateway HTTP API ARN",
+			arn:         "arn:aws:apigateway:us-east-1::/apis/f6g7h8i9j0",
+			wantType:    "APIGatewayHTTPAPI",
+			wantName:    "f6g7h8i9j0",
+			wantRegion:  "us-east-1",
+			wantAccount: "",
+			wantErr:     false,
+		},
+		{
+			name:        "EventBridge rule ARN on default bus",
+			arn:         "arn:aws:events:us-east-1:123456789012:rule/nightly-cleanup",
+			wantType:    "EventBridgeRule",
+			wantName:    "nightly-cleanup",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "EventBridge rule ARN on custom bus",
+			arn:         "arn:aws:events:us-east-1:123456789012:rule/orders/order-created",
+			wantType:    "EventBridgeRule",
+			wantName:    "order-created",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "KMS key ARN",
+			arn:         "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantType:    "KMSKey",
+			wantName:    "1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "Secrets Manager secret ARN",
+			arn:         "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+			wantType:    "SecretsManagerSecret",
+			wantName:    "prod/db-AbCdEf",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
+		{
+			name:        "SSM parameter ARN",
+			arn:         "arn:aws:ssm:us-east-1:123456789012:parameter/app/prod/api-key",
+			wantType:    "SSMParameter",
+			wantName:    "/app/prod/api-key",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantErr:     false,
+		},
 		{
 			name:    "Invalid ARN - too short",
 			arn:     "arn:aws:service",
@@ -157,3 +353,927 @@ func TestExtractLambdaNameFromARN(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonicalID(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		account string
+		localID string
+		want    string
+	}{
+		{
+			name:    "Scoped by account and region",
+			region:  "us-east-1",
+			account: "123456789012",
+			localID: "sg-123",
+			want:    "SecurityGroup:123456789012:us-east-1:sg-123",
+		},
+		{
+			name:    "Account unknown",
+			region:  "eu-west-1",
+			localID: "sg-123",
+			want:    "SecurityGroup::eu-west-1:sg-123",
+		},
+		{
+			name:    "Scope unknown",
+			localID: "sg-123",
+			want:    "sg-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := canonicalID(tt.region, tt.account, ResourceTypeSecurityGroup, tt.localID)
+			if id != tt.want {
+				t.Errorf("canonicalID() = %s, want %s", id, tt.want)
+			}
+			node := &graph.Node{ID: id, Type: ResourceTypeSecurityGroup}
+			if got := node.LocalID(); got != tt.localID {
+				t.Errorf("LocalID() = %s, want %s", got, tt.localID)
+			}
+		})
+	}
+
+	// ARN-keyed nodes are left alone
+	arn := "arn:aws:lambda:us-east-1:123456789012:function:api"
+	if got := (&graph.Node{ID: arn, Type: ResourceTypeLambda}).LocalID(); got != arn {
+		t.Errorf("LocalID() = %s, want the ARN", got)
+	}
+}
+
+func TestDiscoverNodeUnhandledType(t *testing.T) {
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 2, MaxNodes: 250})
+	g := graph.New()
+
+	// Types without a handler are leaves and must not touch any AWS client
+	for _, nodeType := range []string{ResourceTypeSecurityGroup, ResourceTypeVPC, ResourceTypeIAMPolicy, "Unknown"} {
+		node := &graph.Node{ID: "node-" + nodeType, Type: nodeType}
+		g.AddNode(node)
+
+		neighbors, err := d.discoverNode(context.Background(), node, g)
+		if err != nil {
+			t.Errorf("discoverNode(%s) unexpected error: %v", nodeType, err)
+		}
+		if len(neighbors) != 0 {
+			t.Errorf("discoverNode(%s) returned %d neighbors, want 0", nodeType, len(neighbors))
+		}
+	}
+}
+
+func TestDiscoverNodeSkipIAM(t *testing.T) {
+	// Without IAM clients, expanding the role would panic
+	d := New(&awsx.Clients{}, &Options{SkipIAM: true})
+	g := graph.New()
+	node := &graph.Node{ID: "arn:aws:iam::123456789012:role/app", ARN: "arn:aws:iam::123456789012:role/app", Type: ResourceTypeIAMRole, Name: "app"}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverNode(context.Background(), node, g)
+	if err != nil || len(neighbors) != 0 {
+		t.Errorf("discoverNode() = %v, %v, want no neighbors and no error", neighbors, err)
+	}
+}
+
+func TestDiscoverNodeMissingIdentifiers(t *testing.T) {
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 2, MaxNodes: 250})
+	g := graph.New()
+
+	// Handlers validate their inputs before calling AWS
+	tests := []struct {
+		name string
+		node *graph.Node
+	}{
+		{
+			name: "API Gateway without apiId",
+			node: &graph.Node{ID: "api", Type: ResourceTypeAPIGatewayRestAPI, Metadata: map[string]any{}},
+		},
+		{
+			name: "ECS service without cluster",
+			node: &graph.Node{ID: "svc", Type: ResourceTypeECSService, ARN: "my-service", Metadata: map[string]any{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g.AddNode(tt.node)
+			if _, err := d.discoverNode(context.Background(), tt.node, g); err == nil {
+				t.Error("discoverNode() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTypeFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		allowed map[string]bool
+	}{
+		{
+			name:    "no filters",
+			opts:    Options{},
+			allowed: nil,
+		},
+		{
+			name: "exclude types",
+			opts: Options{ExcludeTypes: []string{ResourceTypeIAMRole, ResourceTypeScalingPolicy}},
+			allowed: map[string]bool{
+				ResourceTypeIAMRole:       false,
+				ResourceTypeScalingPolicy: false,
+				ResourceTypeTargetGroup:   true,
+			},
+		},
+		{
+			name: "include types",
+			opts: Options{IncludeTypes: []string{ResourceTypeTargetGroup, ResourceTypeECSService}},
+			allowed: map[string]bool{
+				ResourceTypeTargetGroup:   true,
+				ResourceTypeECSService:    true,
+				ResourceTypeSecurityGroup: false,
+			},
+		},
+		{
+			name: "exclude wins over include",
+			opts: Options{
+				IncludeTypes: []string{ResourceTypeTargetGroup, ResourceTypeIAMRole},
+				ExcludeTypes: []string{ResourceTypeIAMRole},
+			},
+			allowed: map[string]bool{
+				ResourceTypeTargetGroup: true,
+				ResourceTypeIAMRole:     false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New(&awsx.Clients{}, &tt.opts)
+			filter := d.typeFilter()
+
+			if tt.allowed == nil {
+				if filter != nil {
+					t.Error("typeFilter() expected nil filter when no types are set")
+				}
+				return
+			}
+
+			for nodeType, want := range tt.allowed {
+				if got := filter(&graph.Node{Type: nodeType}); got != want {
+					t.Errorf("typeFilter()(%s) = %v, want %v", nodeType, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeFilterExclusions(t *testing.T) {
+	d := New(&awsx.Clients{}, &Options{
+		ExcludeTypes: []string{ResourceTypeScalingPolicy},
+		ExcludeARNs:  []string{"arn:aws:iam::*:role/logging-*", "sg-0default"},
+		ExcludeNames: []string{"shared-?"},
+	})
+	filter := d.nodeFilter()
+
+	tests := []struct {
+		name string
+		node *graph.Node
+		want bool
+	}{
+		{
+			name: "ARN glob across slashes",
+			node: &graph.Node{ID: "arn:aws:iam::123456789012:role/logging-writer", ARN: "arn:aws:iam::123456789012:role/logging-writer", Type: ResourceTypeIAMRole},
+			want: false,
+		},
+		{
+			name: "ID without ARN",
+			node: &graph.Node{ID: "sg-0default", Type: ResourceTypeSecurityGroup},
+			want: false,
+		},
+		{
+			name: "Name glob",
+			node: &graph.Node{ID: "sg-1", Type: ResourceTypeSecurityGroup, Name: "shared-a"},
+			want: false,
+		},
+		{
+			name: "Name glob matches one character",
+			node: &graph.Node{ID: "sg-2", Type: ResourceTypeSecurityGroup, Name: "shared-ab"},
+			want: true,
+		},
+		{
+			name: "Pattern is anchored",
+			node: &graph.Node{ID: "arn:aws:iam::123456789012:role/app-logging-writer", ARN: "arn:aws:iam::123456789012:role/app-logging-writer", Type: ResourceTypeIAMRole},
+			want: true,
+		},
+		{
+			name: "Excluded type",
+			node: &graph.Node{ID: "policy", Type: ResourceTypeScalingPolicy},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter(tt.node); got != tt.want {
+				t.Errorf("nodeFilter()(%s) = %v, want %v", tt.node.ID, got, tt.want)
+			}
+		})
+	}
+
+	if New(&awsx.Clients{}, &Options{}).nodeFilter() != nil {
+		t.Error("nodeFilter() expected nil filter when nothing is excluded")
+	}
+}
+
+func TestDiscoverExcludedResource(t *testing.T) {
+	const (
+		root      = "arn:aws:lambda:us-east-1:123456789012:function:root"
+		sharedARN = "arn:aws:iam::123456789012:role/logging"
+	)
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 100, ExcludeARNs: []string{"*:role/logging"}})
+
+	// The root links to a shared role and a queue; the role would link to everything else
+	var expanded []string
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded = append(expanded, node.ID)
+		var children []*graph.Node
+		switch node.ID {
+		case root:
+			children = []*graph.Node{
+				{ID: sharedARN, ARN: sharedARN, Type: ResourceTypeIAMRole, Name: "logging"},
+				{ID: "queue", Type: "Test", Name: "queue"},
+			}
+		case sharedARN:
+			children = []*graph.Node{{ID: "behind-role", Type: "Test"}}
+		}
+		var neighbors []string
+		for _, child := range children {
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "uses"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if g.HasNode(sharedARN) || g.HasNode("behind-role") {
+		t.Errorf("Discover() nodes = %v, want the excluded role and what is behind it left out", g.SortedNodes())
+	}
+	if edges := g.EdgesTo(sharedARN); len(edges) != 0 {
+		t.Errorf("edges to the excluded role = %+v, want none", edges)
+	}
+	if !slices.Equal(expanded, []string{root, "queue"}) {
+		t.Errorf("expanded = %v, want [%s queue]", expanded, root)
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("EdgeCount() = %d, want only the edge to the queue", g.EdgeCount())
+	}
+}
+
+func TestUseNodeClients(t *testing.T) {
+	home, err := awsx.NewClientProvider(&aws.Config{Region: "us-east-1"}, awsx.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClientProvider() error = %v", err)
+	}
+	other, err := awsx.NewClientProvider(&aws.Config{Region: "us-east-1"}, awsx.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClientProvider() error = %v", err)
+	}
+	defaultClients, _ := home.ForRegion("")
+
+	d := New(defaultClients, &Options{})
+	d.SetRegionalClients(home)
+	d.SetAccountClients(map[string]*awsx.ClientProvider{
+		"210987654321": other,
+	})
+
+	// Nodes in other accounts use that account's clients
+	restore := d.useNodeClients(&graph.Node{Account: "210987654321", Region: "us-east-1"})
+	if want, _ := other.ForRegion("us-east-1"); d.clients != want {
+		t.Error("useNodeClients() expected clients for the node's account")
+	}
+	restore()
+	if d.clients != defaultClients {
+		t.Error("useNodeClients() restore expected default clients")
+	}
+
+	// Nodes in other regions of the default account use clients for that region
+	restore = d.useNodeClients(&graph.Node{Account: "123456789012", Region: "eu-west-1"})
+	if got := d.clients.Lambda.Options().Region; got != "eu-west-1" {
+		t.Errorf("useNodeClients() region = %q, want eu-west-1", got)
+	}
+	restore()
+
+	// Nodes in the home region keep the defaults
+	restore = d.useNodeClients(&graph.Node{Account: "123456789012", Region: "us-east-1"})
+	if d.clients != defaultClients {
+		t.Error("useNodeClients() expected default clients for the home region")
+	}
+	restore()
+
+	// Without providers every node uses the defaults
+	plain := New(defaultClients, &Options{})
+	restore = plain.useNodeClients(&graph.Node{Account: "210987654321", Region: "eu-west-1"})
+	if plain.clients != defaultClients {
+		t.Error("useNodeClients() expected default clients without providers")
+	}
+	restore()
+}
+
+func TestDiscoverMaxEdgesTruncation(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 100, MaxEdges: 3})
+
+	// Every node fans out to five new children
+	expanded := 0
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded++
+		var neighbors []string
+		for _, suffix := range []string{"-a", "-b", "-c", "-d", "-e"} {
+			child := &graph.Node{ID: node.ID + suffix, Type: "Test"}
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	result, err := d.Discover(context.Background(), root, g)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	// Children past the limit are dropped rather than left unconnected
+	if result.Edges != 3 || result.Nodes != 4 {
+		t.Errorf("Discover() = %d nodes, %d edges, want 4 nodes, 3 edges", result.Nodes, result.Edges)
+	}
+	if expanded != 1 {
+		t.Errorf("Discover() expanded %d nodes, want only the root", expanded)
+	}
+	if result.TruncationReason != TruncatedMaxEdges {
+		t.Errorf("Discover() truncation = %q, want %q", result.TruncationReason, TruncatedMaxEdges)
+	}
+
+	// The limit only applies during discovery
+	g.AddEdge(&graph.Edge{From: root, To: root + "-a", RelationType: "owns"})
+	if g.EdgeCount() != 4 {
+		t.Errorf("EdgeCount() after Discover() = %d, want the limit removed", g.EdgeCount())
+	}
+}
+
+func TestDiscoverMaxNodesTruncation(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 3})
+
+	// Every node links back to the root and fans out to two new children
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		var neighbors []string
+		if node.ID != root {
+			g.AddEdge(&graph.Edge{From: node.ID, To: root, RelationType: "calls"})
+			neighbors = append(neighbors, root)
+		}
+		for _, suffix := range []string{"-a", "-b"} {
+			child := &graph.Node{ID: node.ID + suffix, Type: "Test"}
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if g.NodeCount() != 3 {
+		t.Errorf("Discover() node count = %d, want 3", g.NodeCount())
+	}
+	if !g.Truncated() || g.TruncationReason() != TruncatedMaxNodes {
+		t.Errorf("Discover() truncation = %q, want %q", g.TruncationReason(), TruncatedMaxNodes)
+	}
+
+	// Queued nodes that were not expanded still get their edges to existing nodes
+	for _, id := range []string{root + "-a", root + "-b"} {
+		if len(g.EdgesFrom(id)) != 1 {
+			t.Errorf("Discover() edges from %s = %d, want 1", id, len(g.EdgesFrom(id)))
+		}
+	}
+}
+
+func TestDiscoverMaxNodesLinksNextLevel(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 4})
+
+	// The root fans out to two children, the first of which has a child of its own;
+	// every node links back to the root
+	children := map[string][]string{
+		root:        {root + "-a", root + "-b"},
+		root + "-a": {root + "-a-x"},
+	}
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		var neighbors []string
+		if node.ID != root {
+			g.AddEdge(&graph.Edge{From: node.ID, To: root, RelationType: "calls"})
+			neighbors = append(neighbors, root)
+		}
+		for _, child := range children[node.ID] {
+			g.AddNode(&graph.Node{ID: child, Type: "Test"})
+			g.AddEdge(&graph.Edge{From: node.ID, To: child, RelationType: "calls"})
+			neighbors = append(neighbors, child)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if g.TruncationReason() != TruncatedMaxNodes {
+		t.Errorf("Discover() truncation = %q, want %q", g.TruncationReason(), TruncatedMaxNodes)
+	}
+
+	// The limit is hit before -b is expanded, with -a-x already queued for the next level
+	for _, id := range []string{root + "-b", root + "-a-x"} {
+		if len(g.EdgesFrom(id)) != 1 {
+			t.Errorf("Discover() edges from %s = %d, want 1", id, len(g.EdgesFrom(id)))
+		}
+	}
+}
+
+func TestDiscoverMaxDepthTruncation(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 0, MaxNodes: 100})
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		child := &graph.Node{ID: node.ID + "-child", Type: "Test"}
+		g.AddNode(child)
+		g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+		return []string{child.ID}, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if g.TruncationReason() != TruncatedMaxDepth {
+		t.Errorf("Discover() truncation = %q, want %q", g.TruncationReason(), TruncatedMaxDepth)
+	}
+}
+
+func TestDiscoverDepthOverrides(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{
+		MaxDepth:       3,
+		MaxNodes:       100,
+		DepthOverrides: map[string]int{"IAMRole": 0},
+	})
+
+	// Every node fans out to a role and a network child
+	var expanded []string
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded = append(expanded, node.ID)
+		var neighbors []string
+		for _, child := range []*graph.Node{
+			{ID: node.ID + "-role", Type: "IAMRole"},
+			{ID: node.ID + "-net", Type: "SecurityGroup"},
+		} {
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "uses"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	// Network nodes are followed to MaxDepth; roles are never expanded but stay in the graph
+	want := []string{root, root + "-net", root + "-net-net", root + "-net-net-net"}
+	if len(expanded) != len(want) {
+		t.Fatalf("Discover() expanded %v, want %v", expanded, want)
+	}
+	for i := range want {
+		if expanded[i] != want[i] {
+			t.Errorf("Discover() expanded[%d] = %s, want %s", i, expanded[i], want[i])
+		}
+	}
+	if !g.HasNode(root + "-role") {
+		t.Error("Discover() dropped a role beyond its depth override, want it kept as a leaf")
+	}
+}
+
+func TestDiscoverDepthOverrideBelowGlobalDepth(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{
+		MaxDepth:       4,
+		MaxNodes:       100,
+		DepthOverrides: map[string]int{"SecurityGroup": 1},
+	})
+
+	// Every node fans out to a security group and a service, so both types appear at each depth
+	depths := map[string]int{root: 0}
+	var expanded []string
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded = append(expanded, node.ID)
+		var neighbors []string
+		for _, child := range []*graph.Node{
+			{ID: node.ID + "-sg", Type: "SecurityGroup"},
+			{ID: node.ID + "-svc", Type: "ECSService"},
+		} {
+			depths[child.ID] = depths[node.ID] + 1
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "uses"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	var sgExpanded, svcMaxDepth int
+	for _, id := range expanded {
+		node, _ := g.GetNode(id)
+		switch node.Type {
+		case "SecurityGroup":
+			sgExpanded++
+			if depths[id] > 1 {
+				t.Errorf("Discover() expanded security group %s at depth %d, want at most 1", id, depths[id])
+			}
+		case "ECSService":
+			svcMaxDepth = max(svcMaxDepth, depths[id])
+		}
+	}
+	if sgExpanded != 1 {
+		t.Errorf("Discover() expanded %d security groups, want only the one at depth 1", sgExpanded)
+	}
+	if svcMaxDepth != 4 {
+		t.Errorf("Discover() expanded services to depth %d, want the global depth 4", svcMaxDepth)
+	}
+	if !g.HasNode(root + "-svc-sg") {
+		t.Error("Discover() dropped a security group beyond its depth override, want it kept as a leaf")
+	}
+}
+
+func TestDiscoverRegionFilters(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{
+		MaxDepth:      3,
+		MaxNodes:      100,
+		RegionFilters: []string{"us-east-1", "us-west-2"},
+	})
+
+	// The root references one resource in each region plus a global one; only the root expands
+	expanded := make(map[string]bool)
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded[node.ID] = true
+		if node.ID != root {
+			return nil, nil
+		}
+		var neighbors []string
+		for _, child := range []*graph.Node{
+			{ID: "east", Type: "Test", Region: "us-east-1"},
+			{ID: "west", Type: "Test", Region: "us-west-2"},
+			{ID: "europe", Type: "Test", Region: "eu-west-1"},
+			{ID: "role", Type: "IAMRole"},
+		} {
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "uses"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(context.Background(), root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	tests := []struct {
+		id           string
+		wantExpanded bool
+	}{
+		{id: "east", wantExpanded: true},
+		{id: "west", wantExpanded: true},
+		{id: "europe", wantExpanded: false},
+		{id: "role", wantExpanded: true},
+	}
+	for _, tt := range tests {
+		if !g.HasNode(tt.id) {
+			t.Errorf("Discover() dropped %s, want it in the graph", tt.id)
+		}
+		if expanded[tt.id] != tt.wantExpanded {
+			t.Errorf("Discover() expanded %s = %v, want %v", tt.id, expanded[tt.id], tt.wantExpanded)
+		}
+	}
+}
+
+func TestDiscoverTimeout(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 100})
+
+	// The root fans out to two children; expanding the first one hangs like a stalled API call
+	d.discoverNodeFunc = func(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		if node.ID != root {
+			<-ctx.Done()
+			return nil, fmt.Errorf("failed to get function: %w", ctx.Err())
+		}
+		var neighbors []string
+		for _, suffix := range []string{"-a", "-b"} {
+			child := &graph.Node{ID: node.ID + suffix, Type: "Test", Name: "child" + suffix}
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	g := graph.New()
+	done := make(chan error, 1)
+	go func() {
+		_, err := d.Discover(ctx, root, g)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Discover() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Discover() did not return after the context deadline")
+	}
+
+	if g.TruncationReason() != TruncatedTimeout {
+		t.Errorf("Discover() truncation = %q, want %q", g.TruncationReason(), TruncatedTimeout)
+	}
+	if g.NodeCount() != 3 {
+		t.Errorf("Discover() node count = %d, want the root and both children", g.NodeCount())
+	}
+
+	// What was found before the deadline still renders
+	var buf bytes.Buffer
+	if err := output.RenderTree(&buf, g, root); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	for _, want := range []string{"child-a", "child-b", "results truncated (timeout)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("rendered tree missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDiscoverInterrupted(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 5, MaxNodes: 100})
+
+	// The root fans out to two children; the user interrupts while the first one is expanded
+	var expanded []string
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded = append(expanded, node.ID)
+		if node.ID != root {
+			cancel()
+			return nil, nil
+		}
+		var neighbors []string
+		for _, suffix := range []string{"-a", "-b"} {
+			child := &graph.Node{ID: node.ID + suffix, Type: "Test", Name: "child" + suffix}
+			g.AddNode(child)
+			g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+			neighbors = append(neighbors, child.ID)
+		}
+		return neighbors, nil
+	}
+
+	g := graph.New()
+	if _, err := d.Discover(ctx, root, g); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if len(expanded) != 2 {
+		t.Errorf("Discover() expanded %v, want it to stop after the interrupted node", expanded)
+	}
+	if g.TruncationReason() != TruncatedInterrupted {
+		t.Errorf("Discover() truncation = %q, want %q", g.TruncationReason(), TruncatedInterrupted)
+	}
+
+	// What was found before the interrupt still renders
+	var buf bytes.Buffer
+	if err := output.RenderTree(&buf, g, root); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	for _, want := range []string{"child-a", "child-b", "results truncated (interrupted)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("rendered tree missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDiscoverErrors(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	apiErr := &smithy.OperationError{ServiceID: "Lambda", OperationName: "GetFunction", Err: errors.New("throttled")}
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 1, MaxNodes: 100})
+	d.discoverNodeFunc = func(context.Context, *graph.Node, *graph.Graph) ([]string, error) {
+		return nil, fmt.Errorf("failed to get function: %w", apiErr)
+	}
+
+	for run := 0; run < 2; run++ {
+		if _, err := d.Discover(context.Background(), root, graph.New()); err != nil {
+			t.Fatalf("Discover() error = %v", err)
+		}
+
+		// Errors are reset on each run rather than accumulating
+		errs := d.Errors()
+		if len(errs) != 1 {
+			t.Fatalf("run %d: Errors() returned %d errors, want 1: %v", run, len(errs), errs)
+		}
+		var discoveryErr *DiscoveryError
+		if !errors.As(errs[0], &discoveryErr) {
+			t.Fatalf("Errors()[0] = %T, want *DiscoveryError", errs[0])
+		}
+		if discoveryErr.NodeID != root || discoveryErr.APICall != "GetFunction" {
+			t.Errorf("Errors()[0] = %+v, want node %s and API call GetFunction", discoveryErr, root)
+		}
+		if !errors.Is(errs[0], apiErr) {
+			t.Errorf("Errors()[0] does not wrap the API error")
+		}
+	}
+}
+
+func TestDiscoverResult(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	var logs bytes.Buffer
+	d := New(&awsx.Clients{}, &Options{
+		MaxDepth: 1,
+		MaxNodes: 100,
+		Logger:   slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		if node.ID != root {
+			return nil, errors.New("access denied")
+		}
+		child := &graph.Node{ID: "child", Type: "Test"}
+		g.AddNode(child)
+		g.AddEdge(&graph.Edge{From: root, To: child.ID, RelationType: "calls"})
+		return []string{child.ID}, nil
+	}
+
+	result, err := d.Discover(context.Background(), root, graph.New())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if result.Nodes != 2 || result.Edges != 1 || len(result.Errors) != 1 || result.TruncationReason != "" {
+		t.Errorf("Discover() = %+v, want 2 nodes, 1 edge, 1 error and no truncation", result)
+	}
+	if result.Complete() {
+		t.Error("Complete() = true for a result with errors")
+	}
+	for _, want := range []string{"Discovery complete", "access denied"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs missing %q:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestDiscoverWithoutLoggerIsQuiet(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 1, MaxNodes: 100})
+	d.discoverNodeFunc = func(context.Context, *graph.Node, *graph.Graph) ([]string, error) {
+		return nil, errors.New("access denied")
+	}
+
+	result, err := d.Discover(context.Background(), root, graph.New())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("Discover() errors = %v, want 1", result.Errors)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Discover() without a Logger wrote to the default logger:\n%s", logs.String())
+	}
+}
+
+func TestDiscoverOnProgress(t *testing.T) {
+	const root = "arn:aws:lambda:us-east-1:123456789012:function:root"
+
+	var events []ProgressEvent
+	d := New(&awsx.Clients{}, &Options{
+		MaxDepth: 1,
+		MaxNodes: 100,
+		OnProgress: func(event ProgressEvent) {
+			events = append(events, event)
+		},
+	})
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		child := &graph.Node{ID: node.ID + "-child", Type: "Test"}
+		g.AddNode(child)
+		g.AddEdge(&graph.Edge{From: node.ID, To: child.ID, RelationType: "calls"})
+		return []string{child.ID}, nil
+	}
+
+	if _, err := d.Discover(context.Background(), root, graph.New()); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	want := []struct {
+		nodes, edges, depth int
+		nodeID              string
+	}{
+		{2, 1, 0, root},
+		{3, 2, 1, root + "-child"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("OnProgress called %d times, want %d: %v", len(events), len(want), events)
+	}
+	for i := range want {
+		got := events[i]
+		if got.Nodes != want[i].nodes || got.Edges != want[i].edges || got.Depth != want[i].depth {
+			t.Errorf("OnProgress call %d = %d nodes, %d edges, depth %d, want %v", i, got.Nodes, got.Edges, got.Depth, want[i])
+		}
+		if got.Node == nil || got.Node.ID != want[i].nodeID {
+			t.Errorf("OnProgress call %d node = %v, want %s", i, got.Node, want[i].nodeID)
+		}
+		if i > 0 && got.Nodes <= events[i-1].Nodes {
+			t.Errorf("OnProgress node count did not increase: %d then %d", events[i-1].Nodes, got.Nodes)
+		}
+	}
+}
+
+func TestDiscoverFrom(t *testing.T) {
+	d := New(&awsx.Clients{}, &Options{MaxDepth: 1, MaxNodes: 100})
+	var expanded []string
+	d.discoverNodeFunc = func(_ context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+		expanded = append(expanded, node.ID)
+		if node.Type != ResourceTypeLambda {
+			return nil, nil
+		}
+		// Both functions use the same role, which is expanded once
+		role := &graph.Node{ID: "role", Type: ResourceTypeIAMRole}
+		if !g.HasNode(role.ID) {
+			g.AddNode(role)
+		}
+		g.AddEdge(&graph.Edge{From: node.ID, To: role.ID, RelationType: "uses-role"})
+		return []string{role.ID}, nil
+	}
+
+	seeds := []*graph.Node{
+		{ID: "fn-a", Type: ResourceTypeLambda},
+		{ID: "fn-b", Type: ResourceTypeLambda},
+		{ID: "fn-a", Type: ResourceTypeLambda},
+	}
+	g := graph.New()
+	result, err := d.DiscoverFrom(context.Background(), seeds, g)
+	if err != nil {
+		t.Fatalf("DiscoverFrom() error = %v", err)
+	}
+
+	if g.Root() != "fn-a" {
+		t.Errorf("Root() = %q, want the first seed", g.Root())
+	}
+	want := []string{"fn-a", "fn-b", "role"}
+	if strings.Join(expanded, ",") != strings.Join(want, ",") {
+		t.Errorf("DiscoverFrom() expanded %v, want %v", expanded, want)
+	}
+	if result.Nodes != 3 || result.Edges != 2 {
+		t.Errorf("DiscoverFrom() = %+v, want 3 nodes and 2 edges", result)
+	}
+
+	if _, err := d.DiscoverFrom(context.Background(), nil, graph.New()); err == nil {
+		t.Error("DiscoverFrom() error = nil, want an error without seeds")
+	}
+}
@@ -0,0 +1,172 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// ecrImage is a container image reference that points to an ECR registry
+type ecrImage struct {
+	registry   string
+	account    string
+	region     string
+	partition  string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseECRImage parses an image reference of the form
+// <account>.dkr.ecr.<region>.amazonaws.com/<repo>[:tag|@digest]. ok is false for images
+// hosted anywhere other than ECR.
+func parseECRImage(image string) (ref ecrImage, ok bool) {
+	host, path, found := strings.Cut(image, "/")
+	if !found || path == "" {
+		return ecrImage{}, false
+	}
+
+	// Host: account.dkr.ecr.region.amazonaws.com (or amazonaws.com.cn in China regions)
+	labels := strings.Split(host, ".")
+	if len(labels) < 6 || labels[1] != "dkr" || (labels[2] != "ecr" && labels[2] != "ecr-fips") {
+		return ecrImage{}, false
+	}
+	switch strings.Join(labels[4:], ".") {
+	case "amazonaws.com":
+		ref.partition = "aws"
+	case "amazonaws.com.cn":
+		ref.partition = "aws-cn"
+	default:
+		return ecrImage{}, false
+	}
+	ref.registry = host
+	ref.account = labels[0]
+	ref.region = labels[3]
+
+	// Digest references take precedence; a tag is only after the last path segment's colon
+	if repo, digest, hasDigest := strings.Cut(path, "@"); hasDigest {
+		path = repo
+		ref.digest = digest
+	}
+	if i := strings.LastIndex(path, ":"); i > strings.LastIndex(path, "/") {
+		ref.tag = path[i+1:]
+		path = path[:i]
+	}
+	ref.repository = path
+
+	return ref, ref.repository != ""
+}
+
+// arn returns the ARN of the repository the image is pulled from
+func (ref ecrImage) arn() string {
+	return fmt.Sprintf("arn:%s:ecr:%s:%s:repository/%s", ref.partition, ref.region, ref.account, ref.repository)
+}
+
+// discoverTaskDefinitionImages links a task definition to the ECR repositories its containers
+// pull images from. Images from other registries, such as Docker Hub, are skipped.
+func discoverTaskDefinitionImages(containers []ecstypes.ContainerDefinition, tdNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	for i := range containers {
+		container := &containers[i]
+		if container.Image == nil {
+			continue
+		}
+		ref, ok := parseECRImage(*container.Image)
+		if !ok {
+			continue
+		}
+
+		repoNode := ecrRepositoryToNode(ref)
+		if !g.HasNode(repoNode.ID) {
+			g.AddNode(repoNode)
+		}
+
+		fields := map[string]any{
+			"Image": *container.Image,
+		}
+		if container.Name != nil {
+			fields["Container"] = *container.Name
+		}
+		if ref.tag != "" {
+			fields["Tag"] = ref.tag
+		}
+		if ref.digest != "" {
+			fields["Digest"] = ref.digest
+		}
+		g.AddEdge(&graph.Edge{
+			From:         tdNode.ID,
+			To:           repoNode.ID,
+			RelationType: "pulls-image-from",
+			Evidence: graph.Evidence{
+				APICall: "DescribeTaskDefinition",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, repoNode.ID)
+	}
+
+	return neighbors
+}
+
+// discoverECR fills in repository settings. Repositories are leaves in the graph, so no
+// neighbors are returned.
+func (d *Discoverer) discoverECR(ctx context.Context, node *graph.Node, _ *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering ECR repository", "arn", node.ARN)
+
+	output, err := d.clients.ECR.DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{
+		RegistryId:      &node.Account,
+		RepositoryNames: []string{node.Name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe repository: %w", err)
+	}
+	if len(output.Repositories) == 0 {
+		return nil, fmt.Errorf("ECR repository not found: %s", node.Name)
+	}
+	setECRRepositoryMetadata(node, &output.Repositories[0])
+
+	return nil, nil
+}
+
+// ecrRepositoryToNode creates an ECR repository node from an image reference
+func ecrRepositoryToNode(ref ecrImage) *graph.Node {
+	arn := ref.arn()
+	return &graph.Node{
+		ID:      arn,
+		Type:    ResourceTypeECRRepository,
+		ARN:     arn,
+		Name:    ref.repository,
+		Region:  ref.region,
+		Account: ref.account,
+		Metadata: map[string]any{
+			"repositoryUri": ref.registry + "/" + ref.repository,
+		},
+	}
+}
+
+// setECRRepositoryMetadata records tag mutability, scanning and encryption settings from DescribeRepositories
+func setECRRepositoryMetadata(node *graph.Node, repo *ecrtypes.Repository) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	if repo.RepositoryUri != nil {
+		node.Metadata["repositoryUri"] = *repo.RepositoryUri
+	}
+	node.Metadata["imageTagMutability"] = repo.ImageTagMutability
+	if repo.ImageScanningConfiguration != nil {
+		node.Metadata["scanOnPush"] = repo.ImageScanningConfiguration.ScanOnPush
+	}
+	if repo.EncryptionConfiguration != nil {
+		node.Metadata["encryptionType"] = repo.EncryptionConfiguration.EncryptionType
+	}
+	if repo.CreatedAt != nil {
+		node.Metadata["createdAt"] = *repo.CreatedAt
+	}
+}
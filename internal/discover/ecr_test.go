@@ -0,0 +1,131 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestParseECRImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		want    ecrImage
+		wantARN string
+		wantOK  bool
+	}{
+		{
+			name:  "Tagged image",
+			image: "123456789012.dkr.ecr.us-east-1.amazonaws.com/api:v1.2.3",
+			want: ecrImage{
+				registry:   "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+				account:    "123456789012",
+				region:     "us-east-1",
+				partition:  "aws",
+				repository: "api",
+				tag:        "v1.2.3",
+			},
+			wantARN: "arn:aws:ecr:us-east-1:123456789012:repository/api",
+			wantOK:  true,
+		},
+		{
+			name:  "Namespaced repository pinned by digest",
+			image: "123456789012.dkr.ecr.eu-west-1.amazonaws.com/team/api@sha256:abc123",
+			want: ecrImage{
+				registry:   "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+				account:    "123456789012",
+				region:     "eu-west-1",
+				partition:  "aws",
+				repository: "team/api",
+				digest:     "sha256:abc123",
+			},
+			wantARN: "arn:aws:ecr:eu-west-1:123456789012:repository/team/api",
+			wantOK:  true,
+		},
+		{
+			name:  "Untagged image in a China region",
+			image: "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn/worker",
+			want: ecrImage{
+				registry:   "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn",
+				account:    "123456789012",
+				region:     "cn-north-1",
+				partition:  "aws-cn",
+				repository: "worker",
+			},
+			wantARN: "arn:aws-cn:ecr:cn-north-1:123456789012:repository/worker",
+			wantOK:  true,
+		},
+		{
+			name:   "Docker Hub image",
+			image:  "nginx:1.25",
+			wantOK: false,
+		},
+		{
+			name:   "ECR Public image",
+			image:  "public.ecr.aws/nginx/nginx:latest",
+			wantOK: false,
+		},
+		{
+			name:   "Other registry",
+			image:  "ghcr.io/org/app:main",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseECRImage(tt.image)
+			if ok != tt.wantOK {
+				t.Fatalf("parseECRImage() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseECRImage() = %+v, want %+v", got, tt.want)
+			}
+			if got.arn() != tt.wantARN {
+				t.Errorf("arn() = %s, want %s", got.arn(), tt.wantARN)
+			}
+		})
+	}
+}
+
+func TestDiscoverTaskDefinitionImages(t *testing.T) {
+	g := graph.New()
+	tdNode := &graph.Node{ID: "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7", Type: "TaskDefinition"}
+	g.AddNode(tdNode)
+
+	containers := []ecstypes.ContainerDefinition{
+		{Name: aws.String("app"), Image: aws.String("123456789012.dkr.ecr.us-east-1.amazonaws.com/api:v7")},
+		{Name: aws.String("sidecar"), Image: aws.String("public.ecr.aws/aws-observability/aws-otel-collector:latest")},
+		{Name: aws.String("migrate"), Image: aws.String("123456789012.dkr.ecr.us-east-1.amazonaws.com/api@sha256:abc123")},
+	}
+
+	neighbors := discoverTaskDefinitionImages(containers, tdNode, g)
+
+	repoARN := "arn:aws:ecr:us-east-1:123456789012:repository/api"
+	if len(neighbors) != 2 || neighbors[0] != repoARN || neighbors[1] != repoARN {
+		t.Fatalf("neighbors = %v, want the api repository once per ECR container", neighbors)
+	}
+	repo, ok := g.GetNode(repoARN)
+	if !ok || repo.Type != ResourceTypeECRRepository || repo.Name != "api" {
+		t.Fatalf("repository node = %+v, want ECRRepository named api", repo)
+	}
+
+	// Both containers pull from the same repository, so one edge is kept with the first evidence
+	edges := g.EdgesFrom(tdNode.ID)
+	if len(edges) != 1 {
+		t.Fatalf("got %d edges, want 1", len(edges))
+	}
+	edge := edges[0]
+	if edge.RelationType != "pulls-image-from" || edge.Evidence.APICall != "DescribeTaskDefinition" {
+		t.Errorf("edge = %+v, want pulls-image-from from DescribeTaskDefinition", edge)
+	}
+	if edge.Evidence.Fields["Container"] != "app" || edge.Evidence.Fields["Tag"] != "v7" {
+		t.Errorf("edge fields = %v, want container app with tag v7", edge.Evidence.Fields)
+	}
+}
@@ -3,20 +3,21 @@ package discover
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
 	appscalingtypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
 // resolveECSService resolves an ECS service by cluster and service name
 func (d *Discoverer) resolveECSService(ctx context.Context, cluster, service string) (*graph.Node, error) {
-	slog.Debug("Resolving ECS service", "cluster", cluster, "service", service)
+	d.log.Debug("Resolving ECS service", "cluster", cluster, "service", service)
 
 	output, err := d.clients.ECS.DescribeServices(ctx, &ecs.DescribeServicesInput{
 		Cluster:  &cluster,
@@ -34,9 +35,111 @@ func (d *Discoverer) resolveECSService(ctx context.Context, cluster, service str
 	return d.ecsServiceToNode(svc, cluster), nil
 }
 
+// ecsDescribeServicesBatchSize is the most services DescribeServices accepts per call
+const ecsDescribeServicesBatchSize = 10
+
+// DiscoverCluster seeds an ECS cluster and every service in it into the graph, returning the
+// IDs of the services added. clusterARN may also be a bare cluster name.
+func (d *Discoverer) DiscoverCluster(ctx context.Context, clusterARN string, g *graph.Graph) ([]string, error) {
+	clusterNode := ecsClusterToNode(clusterARN, "", "")
+	if existing, ok := g.GetNode(clusterNode.ID); ok {
+		clusterNode = existing
+	} else {
+		g.AddNode(clusterNode)
+	}
+	return d.discoverECSCluster(ctx, clusterNode, g)
+}
+
+// discoverECSCluster discovers the services running in an ECS cluster, stopping once the
+// graph reaches MaxNodes
+func (d *Discoverer) discoverECSCluster(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering ECS cluster services", "cluster", node.ID)
+
+	cluster := node.ARN
+	if cluster == "" {
+		cluster = node.LocalID()
+	}
+
+	// Services listed before a failure are still added
+	serviceARNs, err := d.listClusterServices(ctx, cluster)
+	if err != nil {
+		if len(serviceARNs) == 0 {
+			return nil, err
+		}
+		d.warn(node.ID, "Failed to list all cluster services", err)
+	}
+
+	var neighbors []string
+	for start := 0; start < len(serviceARNs); start += ecsDescribeServicesBatchSize {
+		if g.NodeCount() >= d.opts.MaxNodes {
+			d.log.Warn("Reached max nodes limit while adding cluster services",
+				"cluster", node.Name,
+				"services", len(serviceARNs),
+				"added", len(neighbors))
+			g.MarkTruncated(TruncatedMaxNodes)
+			break
+		}
+
+		end := min(start+ecsDescribeServicesBatchSize, len(serviceARNs))
+		output, descErr := d.clients.ECS.DescribeServices(ctx, &ecs.DescribeServicesInput{
+			Cluster:  &cluster,
+			Services: serviceARNs[start:end],
+			Include:  []ecstypes.ServiceField{ecstypes.ServiceFieldTags},
+		})
+		if descErr != nil {
+			d.warn(node.ID, "Failed to describe ECS services", descErr, "cluster", node.Name)
+			continue
+		}
+
+		for i := range output.Services {
+			svc := &output.Services[i]
+			if svc.ServiceArn == nil {
+				continue
+			}
+
+			svcNode := d.ecsServiceToNode(svc, node.Name)
+			if !g.HasNode(svcNode.ID) {
+				g.AddNode(svcNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         svcNode.ID,
+				To:           node.ID,
+				RelationType: "runs-in",
+				Evidence: graph.Evidence{
+					APICall: "ListServices",
+					Fields: map[string]any{
+						"ServiceArn": *svc.ServiceArn,
+					},
+				},
+			})
+			neighbors = append(neighbors, svcNode.ID)
+		}
+	}
+
+	return neighbors, nil
+}
+
+// listClusterServices lists the ARNs of every service in a cluster
+func (d *Discoverer) listClusterServices(ctx context.Context, cluster string) ([]string, error) {
+	var serviceARNs []string
+
+	paginator := ecs.NewListServicesPaginator(d.clients.ECS, &ecs.ListServicesInput{
+		Cluster: &cluster,
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *ecs.ListServicesOutput) error {
+		serviceARNs = append(serviceARNs, output.ServiceArns...)
+		return nil
+	})
+	if pageErr != nil {
+		return serviceARNs, fmt.Errorf("failed to list ECS services: %w", pageErr)
+	}
+
+	return serviceARNs, nil
+}
+
 // discoverECSService discovers dependencies for an ECS service
 func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering ECS service dependencies", "arn", node.ARN)
+	d.log.Debug("Discovering ECS service dependencies", "arn", node.ARN)
 
 	var neighbors []string
 
@@ -68,15 +171,15 @@ func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g
 
 	svc := &output.Services[0]
 
-	// Discover cluster
-	clusterNode := &graph.Node{
-		ID:      cluster,
-		Type:    "ECSCluster",
-		Name:    cluster,
-		Region:  node.Region,
-		Account: node.Account,
+	// Discover cluster, keyed by ARN so it matches cluster nodes from other sources
+	clusterRef := cluster
+	if svc.ClusterArn != nil {
+		clusterRef = *svc.ClusterArn
+	}
+	clusterNode := ecsClusterToNode(clusterRef, node.Region, node.Account)
+	if !g.HasNode(clusterNode.ID) {
+		g.AddNode(clusterNode)
 	}
-	g.AddNode(clusterNode)
 	g.AddEdge(&graph.Edge{
 		From:         node.ID,
 		To:           clusterNode.ID,
@@ -94,7 +197,7 @@ func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g
 	if svc.TaskDefinition != nil {
 		tdNeighbors, tdErr := d.discoverTaskDefinition(ctx, *svc.TaskDefinition, node, g)
 		if tdErr != nil {
-			slog.Warn("Failed to discover task definition", "arn", *svc.TaskDefinition, "error", tdErr)
+			d.warn(node.ID, "Failed to discover task definition", tdErr, "arn", *svc.TaskDefinition)
 		} else {
 			neighbors = append(neighbors, tdNeighbors...)
 		}
@@ -155,7 +258,7 @@ func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g
 
 		for _, sgID := range awsvpc.SecurityGroups {
 			sgNode := &graph.Node{
-				ID:      sgID,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, sgID),
 				Type:    "SecurityGroup",
 				Name:    sgID,
 				Region:  node.Region,
@@ -178,7 +281,7 @@ func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g
 
 		for _, subnetID := range awsvpc.Subnets {
 			subnetNode := &graph.Node{
-				ID:      subnetID,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSubnet, subnetID),
 				Type:    "Subnet",
 				Name:    subnetID,
 				Region:  node.Region,
@@ -200,20 +303,33 @@ func (d *Discoverer) discoverECSService(ctx context.Context, node *graph.Node, g
 		}
 	}
 
+	// Discover Cloud Map services the service registers in or reaches through Service Connect
+	neighbors = append(neighbors, d.discoverECSServiceCloudMap(ctx, svc, node, g)...)
+
 	// Discover Application Auto Scaling policies
 	scalingNeighbors, scalingErr := d.discoverECSScalingPolicies(ctx, cluster, *svc.ServiceName, node, g)
 	if scalingErr != nil {
-		slog.Warn("Failed to discover scaling policies", "error", scalingErr)
+		d.warn(node.ID, "Failed to discover scaling policies", scalingErr)
 	} else {
 		neighbors = append(neighbors, scalingNeighbors...)
 	}
 
+	// Running tasks change with every deployment and scaling event, so they are opt-in
+	if d.opts.IncludeRuntime {
+		taskNeighbors, taskErr := d.discoverECSTasks(ctx, cluster, *svc.ServiceName, node, g)
+		if taskErr != nil {
+			d.warn(node.ID, "Failed to discover running tasks", taskErr)
+		} else {
+			neighbors = append(neighbors, taskNeighbors...)
+		}
+	}
+
 	return neighbors, nil
 }
 
 // discoverTaskDefinition discovers a task definition and its dependencies
 func (d *Discoverer) discoverTaskDefinition(ctx context.Context, taskDefARN string, sourceNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering task definition", "arn", taskDefARN)
+	d.log.Debug("Discovering task definition", "arn", taskDefARN)
 
 	var neighbors []string
 
@@ -305,12 +421,35 @@ func (d *Discoverer) discoverTaskDefinition(ctx context.Context, taskDefARN stri
 		neighbors = append(neighbors, execRoleNode.ID)
 	}
 
+	// Discover ECR repositories the containers pull images from
+	neighbors = append(neighbors, discoverTaskDefinitionImages(td.ContainerDefinitions, tdNode, g)...)
+
+	// Discover EFS file systems mounted as volumes
+	neighbors = append(neighbors, discoverTaskDefinitionVolumes(td.Volumes, tdNode, g)...)
+
+	// Discover secrets and parameters ECS injects into the containers
+	neighbors = append(neighbors, discoverTaskDefinitionSecrets(td.ContainerDefinitions, tdNode, g)...)
+
+	// Discover secrets and parameters referenced by ARN in container environments
+	if d.hasHeuristic("env-arn") {
+		for i := range td.ContainerDefinitions {
+			container := &td.ContainerDefinitions[i]
+			env := make(map[string]string, len(container.Environment))
+			for _, kv := range container.Environment {
+				if kv.Name != nil && kv.Value != nil {
+					env[*kv.Name] = *kv.Value
+				}
+			}
+			neighbors = append(neighbors, d.discoverEnvSecretReferences(env, "DescribeTaskDefinition", tdNode, g)...)
+		}
+	}
+
 	return neighbors, nil
 }
 
 // discoverECSScalingPolicies discovers Application Auto Scaling policies for an ECS service
 func (d *Discoverer) discoverECSScalingPolicies(ctx context.Context, cluster, serviceName string, serviceNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering ECS scaling policies", "cluster", cluster, "service", serviceName)
+	d.log.Debug("Discovering ECS scaling policies", "cluster", cluster, "service", serviceName)
 
 	var neighbors []string
 
@@ -361,6 +500,151 @@ func (d *Discoverer) discoverECSScalingPolicies(ctx context.Context, cluster, se
 	return neighbors, nil
 }
 
+// ecsDescribeTasksBatchSize is the most tasks DescribeTasks accepts per call
+const ecsDescribeTasksBatchSize = 100
+
+// discoverECSTasks discovers the running tasks of an ECS service and the capacity they run on:
+// the container instance for EC2 tasks, or the network interface for Fargate tasks
+func (d *Discoverer) discoverECSTasks(ctx context.Context, cluster, serviceName string, serviceNode *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering ECS tasks", "cluster", cluster, "service", serviceName)
+
+	var taskARNs []string
+	paginator := ecs.NewListTasksPaginator(d.clients.ECS, &ecs.ListTasksInput{
+		Cluster:     &cluster,
+		ServiceName: &serviceName,
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *ecs.ListTasksOutput) error {
+		taskARNs = append(taskARNs, output.TaskArns...)
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to list ECS tasks: %w", pageErr)
+	}
+
+	var neighbors []string
+	for start := 0; start < len(taskARNs); start += ecsDescribeTasksBatchSize {
+		if g.NodeCount() >= d.opts.MaxNodes {
+			d.log.Warn("Reached max nodes limit while adding service tasks",
+				"service", serviceName,
+				"tasks", len(taskARNs),
+				"added", len(neighbors))
+			g.MarkTruncated(TruncatedMaxNodes)
+			break
+		}
+
+		end := min(start+ecsDescribeTasksBatchSize, len(taskARNs))
+		output, descErr := d.clients.ECS.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+			Cluster: &cluster,
+			Tasks:   taskARNs[start:end],
+		})
+		if descErr != nil {
+			d.warn(serviceNode.ID, "Failed to describe ECS tasks", descErr, "service", serviceName)
+			continue
+		}
+
+		for i := range output.Tasks {
+			task := &output.Tasks[i]
+			if task.TaskArn == nil {
+				continue
+			}
+
+			taskNode := ecsTaskToNode(task, serviceNode.Region, serviceNode.Account)
+			if !g.HasNode(taskNode.ID) {
+				g.AddNode(taskNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         serviceNode.ID,
+				To:           taskNode.ID,
+				RelationType: "has-task",
+				Evidence: graph.Evidence{
+					APICall: "ListTasks",
+					Fields: map[string]any{
+						"TaskArn": *task.TaskArn,
+					},
+				},
+			})
+			neighbors = append(neighbors, taskNode.ID)
+			linkECSTaskCapacity(task, taskNode, g)
+		}
+	}
+
+	return neighbors, nil
+}
+
+// linkECSTaskCapacity adds runs-on edges from a task to its container instance, or to its
+// network interfaces when it has no container instance (Fargate)
+func linkECSTaskCapacity(task *ecstypes.Task, taskNode *graph.Node, g *graph.Graph) {
+	if task.ContainerInstanceArn != nil {
+		instanceNode := &graph.Node{
+			ID:       *task.ContainerInstanceArn,
+			Type:     ResourceTypeECSContainerInstance,
+			ARN:      *task.ContainerInstanceArn,
+			Name:     extractNameFromARN(*task.ContainerInstanceArn),
+			Region:   taskNode.Region,
+			Account:  taskNode.Account,
+			Metadata: make(map[string]any),
+		}
+		if !g.HasNode(instanceNode.ID) {
+			g.AddNode(instanceNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         taskNode.ID,
+			To:           instanceNode.ID,
+			RelationType: "runs-on",
+			Evidence: graph.Evidence{
+				APICall: "DescribeTasks",
+				Fields: map[string]any{
+					"ContainerInstanceArn": *task.ContainerInstanceArn,
+				},
+			},
+		})
+		return
+	}
+
+	for _, eniID := range taskNetworkInterfaces(task) {
+		eniNode := &graph.Node{
+			ID:       canonicalID(taskNode.Region, taskNode.Account, ResourceTypeNetworkInterface, eniID),
+			Type:     ResourceTypeNetworkInterface,
+			Name:     eniID,
+			Region:   taskNode.Region,
+			Account:  taskNode.Account,
+			Metadata: make(map[string]any),
+		}
+		if !g.HasNode(eniNode.ID) {
+			g.AddNode(eniNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         taskNode.ID,
+			To:           eniNode.ID,
+			RelationType: "runs-on",
+			Evidence: graph.Evidence{
+				APICall: "DescribeTasks",
+				Fields: map[string]any{
+					"networkInterfaceId": eniID,
+					"LaunchType":         task.LaunchType,
+				},
+			},
+		})
+	}
+}
+
+// taskNetworkInterfaces returns the IDs of the elastic network interfaces attached to a task
+func taskNetworkInterfaces(task *ecstypes.Task) []string {
+	var eniIDs []string
+	for i := range task.Attachments {
+		attachment := &task.Attachments[i]
+		if attachment.Type == nil || *attachment.Type != "ElasticNetworkInterface" {
+			continue
+		}
+		for _, detail := range attachment.Details {
+			if detail.Name != nil && *detail.Name == "networkInterfaceId" && detail.Value != nil {
+				eniIDs = append(eniIDs, *detail.Value)
+			}
+		}
+	}
+	return eniIDs
+}
+
 // Helper functions to convert AWS types to graph nodes
 
 func (d *Discoverer) ecsServiceToNode(svc *ecstypes.Service, cluster string) *graph.Node {
@@ -409,6 +693,60 @@ func (d *Discoverer) ecsServiceToNode(svc *ecstypes.Service, cluster string) *gr
 	}
 }
 
+// ecsTaskToNode converts a running ECS task to a graph node named by its task ID
+func ecsTaskToNode(task *ecstypes.Task, region, account string) *graph.Node {
+	metadata := map[string]any{
+		"launchType":   task.LaunchType,
+		"healthStatus": task.HealthStatus,
+	}
+	if task.LastStatus != nil {
+		metadata["lastStatus"] = *task.LastStatus
+	}
+	if task.AvailabilityZone != nil {
+		metadata["availabilityZone"] = *task.AvailabilityZone
+	}
+	if task.TaskDefinitionArn != nil {
+		metadata["taskDefinitionArn"] = *task.TaskDefinitionArn
+	}
+	if task.StartedAt != nil {
+		metadata["startedAt"] = task.StartedAt.UTC().Format(time.RFC3339)
+	}
+
+	return &graph.Node{
+		ID:       *task.TaskArn,
+		Type:     ResourceTypeECSTask,
+		ARN:      *task.TaskArn,
+		Name:     extractNameFromARN(*task.TaskArn),
+		Region:   region,
+		Account:  account,
+		Metadata: metadata,
+	}
+}
+
+// ecsClusterToNode creates an ECS cluster node from a cluster ARN or name. Region and account
+// come from the ARN when there is one; a bare name is keyed by canonicalID.
+func ecsClusterToNode(cluster, region, account string) *graph.Node {
+	node := &graph.Node{
+		ID:       canonicalID(region, account, ResourceTypeECSCluster, cluster),
+		Type:     ResourceTypeECSCluster,
+		Name:     cluster,
+		Region:   region,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:aws:ecs:region:account:cluster/cluster-name
+	if parts := strings.SplitN(cluster, ":", 6); len(parts) == 6 && parts[0] == "arn" {
+		node.ID = cluster
+		node.ARN = cluster
+		node.Region = parts[3]
+		node.Account = parts[4]
+		node.Name = strings.TrimPrefix(parts[5], "cluster/")
+	}
+
+	return node
+}
+
 func (d *Discoverer) taskDefinitionToNode(td *ecstypes.TaskDefinition, region, account string) *graph.Node {
 	var name string
 	if td.Family != nil {
@@ -1,7 +1,14 @@
 package discover
 
 import (
+	"context"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
 func TestExtractNameFromARN(t *testing.T) {
@@ -69,3 +76,140 @@ func TestExtractRoleNameFromARN(t *testing.T) {
 		})
 	}
 }
+
+func TestECSClusterToNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     string
+		wantID      string
+		wantName    string
+		wantARN     string
+		wantRegion  string
+		wantAccount string
+	}{
+		{
+			name:        "Cluster ARN",
+			cluster:     "arn:aws:ecs:us-west-2:123456789012:cluster/prod",
+			wantID:      "arn:aws:ecs:us-west-2:123456789012:cluster/prod",
+			wantName:    "prod",
+			wantARN:     "arn:aws:ecs:us-west-2:123456789012:cluster/prod",
+			wantRegion:  "us-west-2",
+			wantAccount: "123456789012",
+		},
+		{
+			name:        "Cluster name",
+			cluster:     "prod",
+			wantID:      "ECSCluster:210987654321:us-east-1:prod",
+			wantName:    "prod",
+			wantRegion:  "us-east-1",
+			wantAccount: "210987654321",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := ecsClusterToNode(tt.cluster, "us-east-1", "210987654321")
+
+			if node.Type != ResourceTypeECSCluster {
+				t.Errorf("ecsClusterToNode() Type = %v, want %v", node.Type, ResourceTypeECSCluster)
+			}
+			if node.ID != tt.wantID {
+				t.Errorf("ecsClusterToNode() ID = %v, want %v", node.ID, tt.wantID)
+			}
+			if got := node.LocalID(); node.ARN == "" && got != tt.cluster {
+				t.Errorf("LocalID() = %v, want %v", got, tt.cluster)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("ecsClusterToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+			if node.ARN != tt.wantARN {
+				t.Errorf("ecsClusterToNode() ARN = %v, want %v", node.ARN, tt.wantARN)
+			}
+			if node.Region != tt.wantRegion || node.Account != tt.wantAccount {
+				t.Errorf("ecsClusterToNode() Region/Account = %v/%v, want %v/%v", node.Region, node.Account, tt.wantRegion, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestDiscoverECSTasks(t *testing.T) {
+	const (
+		ec2TaskARN     = "arn:aws:ecs:us-east-1:123456789012:task/prod/0a1b2c3d"
+		fargateTaskARN = "arn:aws:ecs:us-east-1:123456789012:task/prod/4e5f6a7b"
+		instanceARN    = "arn:aws:ecs:us-east-1:123456789012:container-instance/prod/9c8d7e6f"
+	)
+	stub := newStubAPI(map[string]any{
+		"ListTasks": &ecs.ListTasksOutput{
+			TaskArns: []string{ec2TaskARN, fargateTaskARN},
+		},
+		"DescribeTasks": &ecs.DescribeTasksOutput{
+			Tasks: []ecstypes.Task{
+				{
+					TaskArn:              aws.String(ec2TaskARN),
+					LastStatus:           aws.String("RUNNING"),
+					LaunchType:           ecstypes.LaunchTypeEc2,
+					ContainerInstanceArn: aws.String(instanceARN),
+				},
+				{
+					TaskArn:    aws.String(fargateTaskARN),
+					LastStatus: aws.String("RUNNING"),
+					LaunchType: ecstypes.LaunchTypeFargate,
+					Attachments: []ecstypes.Attachment{
+						{
+							Type: aws.String("ElasticNetworkInterface"),
+							Details: []ecstypes.KeyValuePair{
+								{Name: aws.String("subnetId"), Value: aws.String("subnet-123")},
+								{Name: aws.String("networkInterfaceId"), Value: aws.String("eni-0abc")},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{MaxNodes: 100, IncludeRuntime: true})
+	g := graph.New()
+	service := &graph.Node{ID: "svc", Type: ResourceTypeECSService, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(service)
+
+	neighbors, err := d.discoverECSTasks(context.Background(), "prod", "api", service, g)
+	if err != nil {
+		t.Fatalf("discoverECSTasks() error = %v", err)
+	}
+	if len(neighbors) != 2 || neighbors[0] != ec2TaskARN || neighbors[1] != fargateTaskARN {
+		t.Fatalf("discoverECSTasks() neighbors = %v, want both tasks", neighbors)
+	}
+
+	tests := []struct {
+		task      string
+		wantType  string
+		wantRunOn string
+	}{
+		{task: ec2TaskARN, wantType: ResourceTypeECSContainerInstance, wantRunOn: instanceARN},
+		{task: fargateTaskARN, wantType: ResourceTypeNetworkInterface, wantRunOn: "NetworkInterface:123456789012:us-east-1:eni-0abc"},
+	}
+	for _, tt := range tests {
+		t.Run(extractNameFromARN(tt.task), func(t *testing.T) {
+			taskNode, ok := g.GetNode(tt.task)
+			if !ok || taskNode.Type != ResourceTypeECSTask || taskNode.Metadata["lastStatus"] != "RUNNING" {
+				t.Errorf("task node = %+v, want a running ECSTask", taskNode)
+			}
+
+			edges := g.EdgesFrom(tt.task)
+			if len(edges) != 1 || edges[0].RelationType != "runs-on" || edges[0].To != tt.wantRunOn {
+				t.Fatalf("task edges = %v, want one runs-on edge to %s", edges, tt.wantRunOn)
+			}
+			capacity, _ := g.GetNode(tt.wantRunOn)
+			if capacity == nil || capacity.Type != tt.wantType {
+				t.Errorf("capacity node = %+v, want %s", capacity, tt.wantType)
+			}
+		})
+	}
+
+	for _, edge := range g.EdgesFrom(service.ID) {
+		if edge.RelationType != "has-task" {
+			t.Errorf("service edge to %s = %q, want has-task", edge.To, edge.RelationType)
+		}
+	}
+}
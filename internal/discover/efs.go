@@ -0,0 +1,354 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	efstypes "github.com/aws/aws-sdk-go-v2/service/efs/types"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// efsFileSystemIDPattern matches a bare EFS file system ID
+var efsFileSystemIDPattern = regexp.MustCompile(`^fs-[0-9a-f]{8,40}$`)
+
+// resolveEFSFileSystem resolves an EFS file system by ID
+func (d *Discoverer) resolveEFSFileSystem(ctx context.Context, fileSystemID string) (*graph.Node, error) {
+	d.log.Debug("Resolving EFS file system", "fileSystemId", fileSystemID)
+
+	fileSystem, err := d.describeFileSystem(ctx, fileSystemID)
+	if err != nil {
+		return nil, err
+	}
+
+	// ARN format: arn:aws:elasticfilesystem:region:account:file-system/fs-id
+	parts := strings.SplitN(aws.ToString(fileSystem.FileSystemArn), ":", 6)
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("EFS file system has no ARN: %s", fileSystemID)
+	}
+
+	node := efsFileSystemToNode(fileSystemID, parts[3], parts[4])
+	setEFSFileSystemMetadata(node, fileSystem)
+	return node, nil
+}
+
+// describeFileSystem describes one EFS file system by ID
+func (d *Discoverer) describeFileSystem(ctx context.Context, fileSystemID string) (*efstypes.FileSystemDescription, error) {
+	output, err := d.clients.EFS.DescribeFileSystems(ctx, &efs.DescribeFileSystemsInput{
+		FileSystemId: &fileSystemID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe file system: %w", err)
+	}
+	if len(output.FileSystems) == 0 {
+		return nil, fmt.Errorf("EFS file system not found: %s", fileSystemID)
+	}
+	return &output.FileSystems[0], nil
+}
+
+// setEFSFileSystemMetadata records a file system's name, throughput and performance modes,
+// encryption and lifecycle state on its node
+func setEFSFileSystemMetadata(node *graph.Node, fileSystem *efstypes.FileSystemDescription) {
+	if fileSystem.Name != nil && *fileSystem.Name != "" {
+		node.Metadata["name"] = *fileSystem.Name
+	}
+	node.Metadata["throughputMode"] = string(fileSystem.ThroughputMode)
+	if fileSystem.ProvisionedThroughputInMibps != nil {
+		node.Metadata["provisionedThroughputMibps"] = *fileSystem.ProvisionedThroughputInMibps
+	}
+	node.Metadata["performanceMode"] = string(fileSystem.PerformanceMode)
+	node.Metadata["encrypted"] = aws.ToBool(fileSystem.Encrypted)
+	node.Metadata["lifeCycleState"] = string(fileSystem.LifeCycleState)
+}
+
+// discoverTaskDefinitionVolumes links a task definition to the EFS file systems and access
+// points its volumes mount
+func discoverTaskDefinitionVolumes(volumes []ecstypes.Volume, tdNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	for i := range volumes {
+		volume := &volumes[i]
+		config := volume.EfsVolumeConfiguration
+		if config == nil || config.FileSystemId == nil {
+			continue
+		}
+
+		fields := map[string]any{
+			"FileSystemId": *config.FileSystemId,
+		}
+		if volume.Name != nil {
+			fields["Volume"] = *volume.Name
+		}
+		if config.RootDirectory != nil {
+			fields["RootDirectory"] = *config.RootDirectory
+		}
+
+		// Mounting through an access point depends on the access point, which in turn
+		// belongs to the file system
+		target := efsFileSystemToNode(*config.FileSystemId, tdNode.Region, tdNode.Account)
+		if auth := config.AuthorizationConfig; auth != nil && auth.AccessPointId != nil {
+			fields["AccessPointId"] = *auth.AccessPointId
+			fsNode := target
+			target = efsAccessPointToNode(efsARN(tdNode.Region, tdNode.Account, "access-point/"+*auth.AccessPointId))
+			target.Metadata["fileSystemId"] = *config.FileSystemId
+			addEFSAccessPointEdge(g, target, fsNode, "DescribeTaskDefinition")
+		}
+
+		if !g.HasNode(target.ID) {
+			g.AddNode(target)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         tdNode.ID,
+			To:           target.ID,
+			RelationType: "mounts",
+			Evidence: graph.Evidence{
+				APICall: "DescribeTaskDefinition",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, target.ID)
+	}
+
+	return neighbors
+}
+
+// discoverLambdaFileSystems links a function to the EFS access points it mounts
+func discoverLambdaFileSystems(configs []lambdatypes.FileSystemConfig, lambdaNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	for i := range configs {
+		config := &configs[i]
+		if config.Arn == nil {
+			continue
+		}
+
+		apNode := efsAccessPointToNode(*config.Arn)
+		if !g.HasNode(apNode.ID) {
+			g.AddNode(apNode)
+		}
+
+		fields := map[string]any{
+			"Arn": *config.Arn,
+		}
+		if config.LocalMountPath != nil {
+			fields["LocalMountPath"] = *config.LocalMountPath
+		}
+		g.AddEdge(&graph.Edge{
+			From:         lambdaNode.ID,
+			To:           apNode.ID,
+			RelationType: "mounts",
+			Evidence: graph.Evidence{
+				APICall: "GetFunction",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, apNode.ID)
+	}
+
+	return neighbors
+}
+
+// discoverEFS expands an EFS access point to its file system, or a file system to the
+// subnets and security groups of its mount targets
+func (d *Discoverer) discoverEFS(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	if node.Type == ResourceTypeEFSAccessPoint {
+		return d.discoverEFSAccessPoint(ctx, node, g)
+	}
+
+	fileSystemID := extractNameFromARN(node.ARN)
+
+	// The file system's settings and key are recorded before its mount targets are expanded
+	var neighbors []string
+	fileSystem, describeErr := d.describeFileSystem(ctx, fileSystemID)
+	if describeErr != nil {
+		d.warn(node.ID, "Failed to describe EFS file system", describeErr)
+	} else {
+		setEFSFileSystemMetadata(node, fileSystem)
+		if fileSystem.KmsKeyId != nil && *fileSystem.KmsKeyId != "" {
+			neighbors = append(neighbors, addKMSKeyEdge(g, node, *fileSystem.KmsKeyId, "DescribeFileSystems", "KmsKeyId"))
+		}
+	}
+
+	d.log.Debug("Discovering EFS mount targets", "arn", node.ARN)
+
+	var mountTargets int
+	input := &efs.DescribeMountTargetsInput{
+		FileSystemId: &fileSystemID,
+	}
+	for {
+		output, err := d.clients.EFS.DescribeMountTargets(ctx, input)
+		if err != nil {
+			return neighbors, fmt.Errorf("failed to describe mount targets: %w", err)
+		}
+
+		for i := range output.MountTargets {
+			target := &output.MountTargets[i]
+			if target.MountTargetId == nil {
+				continue
+			}
+			mountTargets++
+
+			if target.SubnetId != nil {
+				subnetNode := &graph.Node{
+					ID:      canonicalID(node.Region, node.Account, ResourceTypeSubnet, *target.SubnetId),
+					Type:    "Subnet",
+					Name:    *target.SubnetId,
+					Region:  node.Region,
+					Account: node.Account,
+				}
+				if !g.HasNode(subnetNode.ID) {
+					g.AddNode(subnetNode)
+				}
+				g.AddEdge(&graph.Edge{
+					From:         node.ID,
+					To:           subnetNode.ID,
+					RelationType: "runs-in-subnet",
+					Evidence: graph.Evidence{
+						APICall: "DescribeMountTargets",
+						Fields: map[string]any{
+							"MountTargetId": *target.MountTargetId,
+							"SubnetId":      *target.SubnetId,
+						},
+					},
+				})
+				neighbors = append(neighbors, subnetNode.ID)
+			}
+
+			sgOutput, sgErr := d.clients.EFS.DescribeMountTargetSecurityGroups(ctx, &efs.DescribeMountTargetSecurityGroupsInput{
+				MountTargetId: target.MountTargetId,
+			})
+			if sgErr != nil {
+				d.warn(node.ID, "Failed to describe mount target security groups", sgErr, "mountTarget", *target.MountTargetId)
+				continue
+			}
+			for _, sgID := range sgOutput.SecurityGroups {
+				sgNode := &graph.Node{
+					ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, sgID),
+					Type:    "SecurityGroup",
+					Name:    sgID,
+					Region:  node.Region,
+					Account: node.Account,
+				}
+				if !g.HasNode(sgNode.ID) {
+					g.AddNode(sgNode)
+				}
+				g.AddEdge(&graph.Edge{
+					From:         node.ID,
+					To:           sgNode.ID,
+					RelationType: "uses-security-group",
+					Evidence: graph.Evidence{
+						APICall: "DescribeMountTargetSecurityGroups",
+						Fields: map[string]any{
+							"MountTargetId": *target.MountTargetId,
+						},
+					},
+				})
+				neighbors = append(neighbors, sgNode.ID)
+			}
+		}
+
+		if output.NextMarker == nil || *output.NextMarker == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+	node.Metadata["mountTargets"] = mountTargets
+
+	return neighbors, nil
+}
+
+// discoverEFSAccessPoint records an access point's root directory and links it to its file system
+func (d *Discoverer) discoverEFSAccessPoint(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering EFS access point", "arn", node.ARN)
+
+	accessPointID := extractNameFromARN(node.ARN)
+	output, err := d.clients.EFS.DescribeAccessPoints(ctx, &efs.DescribeAccessPointsInput{
+		AccessPointId: &accessPointID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe access point: %w", err)
+	}
+	if len(output.AccessPoints) == 0 {
+		return nil, fmt.Errorf("EFS access point not found: %s", accessPointID)
+	}
+
+	accessPoint := &output.AccessPoints[0]
+	if accessPoint.Name != nil && *accessPoint.Name != "" {
+		node.Name = *accessPoint.Name
+	}
+	if accessPoint.RootDirectory != nil && accessPoint.RootDirectory.Path != nil {
+		node.Metadata["rootDirectory"] = *accessPoint.RootDirectory.Path
+	}
+	if accessPoint.FileSystemId == nil {
+		return nil, nil
+	}
+	node.Metadata["fileSystemId"] = *accessPoint.FileSystemId
+
+	fsNode := efsFileSystemToNode(*accessPoint.FileSystemId, node.Region, node.Account)
+	return []string{addEFSAccessPointEdge(g, node, fsNode, "DescribeAccessPoints")}, nil
+}
+
+// addEFSAccessPointEdge links an access point to the file system it belongs to, sharing one
+// node per file system, and returns the file system's node ID
+func addEFSAccessPointEdge(g *graph.Graph, apNode, fsNode *graph.Node, apiCall string) string {
+	if !g.HasNode(apNode.ID) {
+		g.AddNode(apNode)
+	}
+	if !g.HasNode(fsNode.ID) {
+		g.AddNode(fsNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         apNode.ID,
+		To:           fsNode.ID,
+		RelationType: "belongs-to",
+		Evidence: graph.Evidence{
+			APICall: apiCall,
+			Fields: map[string]any{
+				"FileSystemId": fsNode.Name,
+			},
+		},
+	})
+	return fsNode.ID
+}
+
+// efsARN builds an EFS resource ARN
+func efsARN(region, account, resource string) string {
+	return fmt.Sprintf("arn:aws:elasticfilesystem:%s:%s:%s", region, account, resource)
+}
+
+// efsFileSystemToNode creates an EFS file system node from its ID
+func efsFileSystemToNode(fileSystemID, region, account string) *graph.Node {
+	arn := efsARN(region, account, "file-system/"+fileSystemID)
+	return &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeEFSFileSystem,
+		ARN:      arn,
+		Name:     fileSystemID,
+		Region:   region,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+}
+
+// efsAccessPointToNode creates an EFS access point node from its ARN
+func efsAccessPointToNode(arn string) *graph.Node {
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeEFSAccessPoint,
+		ARN:      arn,
+		Name:     extractNameFromARN(arn),
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:aws:elasticfilesystem:region:account:access-point/fsap-id
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		node.Region = parts[3]
+		node.Account = parts[4]
+	}
+
+	return node
+}
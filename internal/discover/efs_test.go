@@ -0,0 +1,219 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	efstypes "github.com/aws/aws-sdk-go-v2/service/efs/types"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const (
+	testFileSystemARN  = "arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/fs-0123456789abcdef0"
+	testAccessPointARN = "arn:aws:elasticfilesystem:us-east-1:123456789012:access-point/fsap-0123456789abcdef0"
+)
+
+func TestDiscoverTaskDefinitionVolumes(t *testing.T) {
+	g := graph.New()
+	tdNode := &graph.Node{
+		ID:      "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7",
+		Type:    "TaskDefinition",
+		Region:  "us-east-1",
+		Account: "123456789012",
+	}
+	g.AddNode(tdNode)
+
+	volumes := []ecstypes.Volume{
+		{
+			Name: aws.String("shared"),
+			EfsVolumeConfiguration: &ecstypes.EFSVolumeConfiguration{
+				FileSystemId: aws.String("fs-0123456789abcdef0"),
+			},
+		},
+		{
+			Name: aws.String("uploads"),
+			EfsVolumeConfiguration: &ecstypes.EFSVolumeConfiguration{
+				FileSystemId: aws.String("fs-0123456789abcdef0"),
+				AuthorizationConfig: &ecstypes.EFSAuthorizationConfig{
+					AccessPointId: aws.String("fsap-0123456789abcdef0"),
+				},
+			},
+		},
+		{
+			// Bind mounts are not EFS
+			Name: aws.String("scratch"),
+		},
+	}
+
+	neighbors := discoverTaskDefinitionVolumes(volumes, tdNode, g)
+
+	if len(neighbors) != 2 || neighbors[0] != testFileSystemARN || neighbors[1] != testAccessPointARN {
+		t.Fatalf("neighbors = %v, want the file system then the access point", neighbors)
+	}
+
+	fs, ok := g.GetNode(testFileSystemARN)
+	if !ok || fs.Type != ResourceTypeEFSFileSystem || fs.Name != "fs-0123456789abcdef0" {
+		t.Errorf("file system node = %+v, want EFSFileSystem fs-0123456789abcdef0", fs)
+	}
+	ap, ok := g.GetNode(testAccessPointARN)
+	if !ok || ap.Type != ResourceTypeEFSAccessPoint {
+		t.Fatalf("access point node = %+v, want EFSAccessPoint", ap)
+	}
+
+	mounts := g.EdgesFrom(tdNode.ID)
+	if len(mounts) != 2 {
+		t.Fatalf("got %d edges from the task definition, want 2", len(mounts))
+	}
+	for _, edge := range mounts {
+		if edge.RelationType != "mounts" {
+			t.Errorf("edge relation = %s, want mounts", edge.RelationType)
+		}
+	}
+	if mounts[1].Evidence.Fields["Volume"] != "uploads" || mounts[1].Evidence.Fields["AccessPointId"] != "fsap-0123456789abcdef0" {
+		t.Errorf("access point mount fields = %v", mounts[1].Evidence.Fields)
+	}
+
+	// The access point is linked to the file system it belongs to
+	apEdges := g.EdgesFrom(testAccessPointARN)
+	if len(apEdges) != 1 || apEdges[0].To != testFileSystemARN || apEdges[0].RelationType != "belongs-to" {
+		t.Errorf("access point edges = %v, want belongs-to the file system", apEdges)
+	}
+}
+
+func TestDiscoverLambdaFileSystems(t *testing.T) {
+	g := graph.New()
+	fn := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:api", Type: ResourceTypeLambda}
+	g.AddNode(fn)
+
+	neighbors := discoverLambdaFileSystems([]lambdatypes.FileSystemConfig{{
+		Arn:            aws.String(testAccessPointARN),
+		LocalMountPath: aws.String("/mnt/data"),
+	}}, fn, g)
+
+	if len(neighbors) != 1 || neighbors[0] != testAccessPointARN {
+		t.Fatalf("neighbors = %v, want [%s]", neighbors, testAccessPointARN)
+	}
+	ap, ok := g.GetNode(testAccessPointARN)
+	if !ok || ap.Region != "us-east-1" || ap.Account != "123456789012" || ap.Name != "fsap-0123456789abcdef0" {
+		t.Errorf("access point node = %+v", ap)
+	}
+	edges := g.EdgesFrom(fn.ID)
+	if len(edges) != 1 || edges[0].RelationType != "mounts" || edges[0].Evidence.Fields["LocalMountPath"] != "/mnt/data" {
+		t.Errorf("edges = %v, want one mounts edge with the mount path", edges)
+	}
+}
+
+func TestDiscoverEFSMountTargets(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"DescribeFileSystems": &efs.DescribeFileSystemsOutput{
+			FileSystems: []efstypes.FileSystemDescription{{FileSystemId: aws.String("fs-0123456789abcdef0")}},
+		},
+		"DescribeMountTargets": &efs.DescribeMountTargetsOutput{
+			MountTargets: []efstypes.MountTargetDescription{
+				{MountTargetId: aws.String("fsmt-a"), SubnetId: aws.String("subnet-a")},
+				{MountTargetId: aws.String("fsmt-b"), SubnetId: aws.String("subnet-b")},
+			},
+		},
+		"DescribeMountTargetSecurityGroups": &efs.DescribeMountTargetSecurityGroupsOutput{
+			SecurityGroups: []string{"sg-efs"},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := efsFileSystemToNode("fs-0123456789abcdef0", "us-east-1", "123456789012")
+	g.AddNode(node)
+
+	neighbors, err := d.discoverEFS(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverEFS() error = %v", err)
+	}
+
+	const sgID = "SecurityGroup:123456789012:us-east-1:sg-efs"
+	want := []string{"Subnet:123456789012:us-east-1:subnet-a", sgID, "Subnet:123456789012:us-east-1:subnet-b", sgID}
+	if len(neighbors) != len(want) {
+		t.Fatalf("discoverEFS() neighbors = %v, want %v", neighbors, want)
+	}
+	for i := range want {
+		if neighbors[i] != want[i] {
+			t.Errorf("discoverEFS() neighbors[%d] = %s, want %s", i, neighbors[i], want[i])
+		}
+	}
+
+	// Both mount targets share the security group, so it is linked once
+	if edges := g.EdgesFrom(node.ID); len(edges) != 3 {
+		t.Errorf("discoverEFS() added %d edges, want 3", len(edges))
+	}
+	if node.Metadata["mountTargets"] != 2 {
+		t.Errorf("mountTargets = %v, want 2", node.Metadata["mountTargets"])
+	}
+}
+
+func TestDiscoverEFSFileSystemDetails(t *testing.T) {
+	const keyARN = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	stub := newStubAPI(map[string]any{
+		"DescribeFileSystems": &efs.DescribeFileSystemsOutput{
+			FileSystems: []efstypes.FileSystemDescription{{
+				FileSystemId:                 aws.String("fs-0123456789abcdef0"),
+				FileSystemArn:                aws.String(testFileSystemARN),
+				Name:                         aws.String("shared-data"),
+				ThroughputMode:               efstypes.ThroughputModeProvisioned,
+				ProvisionedThroughputInMibps: aws.Float64(128),
+				PerformanceMode:              efstypes.PerformanceModeGeneralPurpose,
+				LifeCycleState:               efstypes.LifeCycleStateAvailable,
+				Encrypted:                    aws.Bool(true),
+				KmsKeyId:                     aws.String(keyARN),
+			}},
+		},
+		"DescribeMountTargets": &efs.DescribeMountTargetsOutput{},
+	})
+
+	d := New(stubClients(stub), &Options{})
+
+	// A bare file system ID resolves to the same node the ARN would
+	node, err := d.Identify(context.Background(), "fs-0123456789abcdef0")
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if node.ID != testFileSystemARN || node.Type != ResourceTypeEFSFileSystem || node.Account != "123456789012" {
+		t.Errorf("Identify() = %s %s in %s, want the file system node", node.Type, node.ID, node.Account)
+	}
+
+	g := graph.New()
+	g.AddNode(node)
+	neighbors, err := d.discoverEFS(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverEFS() error = %v", err)
+	}
+	if len(neighbors) != 1 || neighbors[0] != keyARN {
+		t.Errorf("discoverEFS() neighbors = %v, want the KMS key", neighbors)
+	}
+	if errs := d.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v, want none", errs)
+	}
+
+	want := map[string]any{
+		"name":                       "shared-data",
+		"throughputMode":             "provisioned",
+		"provisionedThroughputMibps": float64(128),
+		"performanceMode":            "generalPurpose",
+		"encrypted":                  true,
+		"lifeCycleState":             "available",
+		"mountTargets":               0,
+	}
+	for key, value := range want {
+		if got := node.Metadata[key]; got != value {
+			t.Errorf("Metadata[%q] = %v, want %v", key, got, value)
+		}
+	}
+
+	edges := g.EdgesFrom(node.ID)
+	if len(edges) != 1 || edges[0].RelationType != "encrypted-with" {
+		t.Errorf("discoverEFS() edges = %v, want one encrypted-with edge", edges)
+	}
+}
@@ -0,0 +1,57 @@
+package discover
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/smithy-go"
+)
+
+// DiscoveryError records a failure that left part of the graph undiscovered
+type DiscoveryError struct {
+	// NodeID is the node being discovered when the failure occurred
+	NodeID string
+	// APICall is the AWS operation that failed, if the failure came from an API call
+	APICall string
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface
+func (e *DiscoveryError) Error() string {
+	if e.APICall != "" {
+		return fmt.Sprintf("%s: %s: %v", e.NodeID, e.APICall, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.NodeID, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *DiscoveryError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns the failures recorded by the last Discover call. Each is a *DiscoveryError;
+// a non-empty result means the graph is incomplete.
+func (d *Discoverer) Errors() []error {
+	errs := make([]error, len(d.errs))
+	for i, err := range d.errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// warn logs a failed sub-discovery and records it against nodeID. args are extra log attributes.
+func (d *Discoverer) warn(nodeID, msg string, err error, args ...any) {
+	d.log.Warn(msg, append(args, "error", err)...)
+	d.recordError(nodeID, err)
+}
+
+// recordError records a failure against nodeID, naming the API call when err came from the SDK
+func (d *Discoverer) recordError(nodeID string, err error) {
+	discoveryErr := &DiscoveryError{NodeID: nodeID, Err: err}
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		discoveryErr.APICall = opErr.Operation()
+	}
+	d.errs = append(d.errs, discoveryErr)
+}
@@ -0,0 +1,323 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// eventBridgeRuleTarget is a rule paired with one of its targets
+type eventBridgeRuleTarget struct {
+	rule   *graph.Node
+	target ebtypes.Target
+}
+
+// discoverEventBridgeRule discovers the targets an EventBridge rule routes events to
+func (d *Discoverer) discoverEventBridgeRule(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering EventBridge rule dependencies", "arn", node.ARN)
+
+	eventBusName, _ := node.Metadata["eventBusName"].(string)
+
+	// Fill in the event pattern and schedule when the rule came from an ARN
+	if _, ok := node.Metadata["state"]; !ok {
+		output, err := d.clients.EventBridge.DescribeRule(ctx, &eventbridge.DescribeRuleInput{
+			Name:         &node.Name,
+			EventBusName: optionalString(eventBusName),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe EventBridge rule: %w", err)
+		}
+		setEventBridgeRuleMetadata(node, output.EventBusName, output.EventPattern, output.ScheduleExpression, output.State)
+	}
+
+	targets, err := d.listEventBridgeTargets(ctx, node.Name, eventBusName)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for i := range targets {
+		target := &targets[i]
+		if target.Arn == nil {
+			continue
+		}
+
+		targetNode := d.eventBridgeTargetToNode(*target.Arn, node.Region, node.Account)
+		if !g.HasNode(targetNode.ID) {
+			g.AddNode(targetNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           targetNode.ID,
+			RelationType: "routes-to",
+			Evidence: graph.Evidence{
+				APICall: "ListTargetsByRule",
+				Fields:  eventBridgeTargetFields(target),
+			},
+		})
+		neighbors = append(neighbors, targetNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// discoverEventBridgeUpstream discovers EventBridge rules that route events to a Lambda function
+func (d *Discoverer) discoverEventBridgeUpstream(ctx context.Context, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering EventBridge rules for function", "arn", lambdaNode.ARN)
+
+	index, err := d.eventBridgeIndex(ctx, lambdaNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for _, entry := range index[normalizeLambdaARN(lambdaNode.ARN)] {
+		if !g.HasNode(entry.rule.ID) {
+			g.AddNode(entry.rule)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         entry.rule.ID,
+			To:           lambdaNode.ID,
+			RelationType: "routes-to",
+			Evidence: graph.Evidence{
+				APICall: "ListRules/ListTargetsByRule",
+				Fields:  eventBridgeTargetFields(&entry.target),
+			},
+		})
+		neighbors = append(neighbors, entry.rule.ID)
+	}
+
+	return neighbors, nil
+}
+
+// eventBridgeIndex lazily builds an index of every rule target across all event buses in the
+// source node's account and region, keyed by target ARN
+func (d *Discoverer) eventBridgeIndex(ctx context.Context, sourceNode *graph.Node) (map[string][]eventBridgeRuleTarget, error) {
+	if index, ok := d.eventRuleTargets[scopeKey(sourceNode)]; ok {
+		return index, nil
+	}
+
+	busNames, err := d.listEventBusNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string][]eventBridgeRuleTarget)
+	for _, busName := range busNames {
+		var nextToken *string
+		for {
+			output, listErr := d.clients.EventBridge.ListRules(ctx, &eventbridge.ListRulesInput{
+				EventBusName: &busName,
+				NextToken:    nextToken,
+			})
+			if listErr != nil {
+				d.warn(sourceNode.ID, "Failed to list EventBridge rules", listErr, "eventBus", busName)
+				break
+			}
+
+			for i := range output.Rules {
+				rule := &output.Rules[i]
+				if rule.Arn == nil || rule.Name == nil {
+					continue
+				}
+
+				ruleNode := d.eventBridgeRuleToNode(rule, sourceNode.Region, sourceNode.Account)
+				targets, targetsErr := d.listEventBridgeTargets(ctx, *rule.Name, busName)
+				if targetsErr != nil {
+					d.warn(sourceNode.ID, "Failed to list EventBridge rule targets", targetsErr, "rule", *rule.Name)
+					continue
+				}
+
+				for _, target := range targets {
+					if target.Arn == nil {
+						continue
+					}
+					key := normalizeLambdaARN(*target.Arn)
+					index[key] = append(index[key], eventBridgeRuleTarget{rule: ruleNode, target: target})
+				}
+			}
+
+			if output.NextToken == nil {
+				break
+			}
+			nextToken = output.NextToken
+		}
+	}
+
+	if d.eventRuleTargets == nil {
+		d.eventRuleTargets = make(map[string]map[string][]eventBridgeRuleTarget)
+	}
+	d.eventRuleTargets[scopeKey(sourceNode)] = index
+	return index, nil
+}
+
+// listEventBusNames lists the default and custom event buses in the account
+func (d *Discoverer) listEventBusNames(ctx context.Context) ([]string, error) {
+	var names []string
+
+	var nextToken *string
+	for {
+		output, err := d.clients.EventBridge.ListEventBuses(ctx, &eventbridge.ListEventBusesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list event buses: %w", err)
+		}
+
+		for i := range output.EventBuses {
+			if output.EventBuses[i].Name != nil {
+				names = append(names, *output.EventBuses[i].Name)
+			}
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	return names, nil
+}
+
+// listEventBridgeTargets lists every target of a rule
+func (d *Discoverer) listEventBridgeTargets(ctx context.Context, ruleName, eventBusName string) ([]ebtypes.Target, error) {
+	var targets []ebtypes.Target
+
+	var nextToken *string
+	for {
+		output, err := d.clients.EventBridge.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+			Rule:         &ruleName,
+			EventBusName: optionalString(eventBusName),
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list targets by rule: %w", err)
+		}
+
+		targets = append(targets, output.Targets...)
+
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	return targets, nil
+}
+
+// eventBridgeTargetToNode creates a node for a rule target based on the service in its ARN
+func (d *Discoverer) eventBridgeTargetToNode(arn, region, account string) *graph.Node {
+	if strings.Contains(arn, ":lambda:") {
+		if node, err := d.parseARN(normalizeLambdaARN(arn)); err == nil {
+			return node
+		}
+	}
+
+	node := &graph.Node{
+		ID:      arn,
+		Type:    eventBridgeTargetType(arn),
+		ARN:     arn,
+		Name:    extractNameFromARN(arn),
+		Region:  region,
+		Account: account,
+	}
+
+	// Step Functions and SNS/SQS ARNs use ':' rather than '/' before the name
+	if parts := strings.Split(arn, ":"); len(parts) >= 6 && !strings.Contains(parts[len(parts)-1], "/") {
+		node.Name = parts[len(parts)-1]
+	}
+
+	return node
+}
+
+// eventBridgeTargetType maps a target ARN to a resource type
+func eventBridgeTargetType(arn string) string {
+	switch {
+	case strings.Contains(arn, ":sqs:"):
+		return ResourceTypeSQSQueue
+	case strings.Contains(arn, ":sns:"):
+		return ResourceTypeSNSTopic
+	case strings.Contains(arn, ":states:"):
+		return ResourceTypeStateMachine
+	case strings.Contains(arn, ":ecs:") && strings.Contains(arn, ":cluster/"):
+		return ResourceTypeECSCluster
+	case strings.Contains(arn, ":kinesis:"):
+		return ResourceTypeKinesisStream
+	case strings.Contains(arn, ":firehose:"):
+		return ResourceTypeFirehoseDeliveryStream
+	default:
+		return ResourceTypeEventTarget
+	}
+}
+
+// eventBridgeTargetFields returns the evidence fields recorded for a rule target
+func eventBridgeTargetFields(target *ebtypes.Target) map[string]any {
+	fields := map[string]any{
+		"Id":  target.Id,
+		"Arn": target.Arn,
+	}
+	if target.EcsParameters != nil && target.EcsParameters.TaskDefinitionArn != nil {
+		fields["TaskDefinitionArn"] = *target.EcsParameters.TaskDefinitionArn
+	}
+	if target.DeadLetterConfig != nil && target.DeadLetterConfig.Arn != nil {
+		fields["DeadLetterArn"] = *target.DeadLetterConfig.Arn
+	}
+	return fields
+}
+
+// Helper function to convert an EventBridge rule to a graph node
+func (d *Discoverer) eventBridgeRuleToNode(rule *ebtypes.Rule, region, account string) *graph.Node {
+	var name string
+	if rule.Name != nil {
+		name = *rule.Name
+	}
+
+	// Rule ARNs carry their own region and account
+	if parts := strings.Split(*rule.Arn, ":"); len(parts) >= 6 {
+		region = parts[3]
+		account = parts[4]
+	}
+
+	node := &graph.Node{
+		ID:       *rule.Arn,
+		Type:     ResourceTypeEventBridgeRule,
+		ARN:      *rule.Arn,
+		Name:     name,
+		Region:   region,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+	setEventBridgeRuleMetadata(node, rule.EventBusName, rule.EventPattern, rule.ScheduleExpression, rule.State)
+
+	return node
+}
+
+// setEventBridgeRuleMetadata records the bus, event pattern, schedule and state of a rule
+func setEventBridgeRuleMetadata(node *graph.Node, eventBusName, eventPattern, scheduleExpression *string, state ebtypes.RuleState) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	if eventBusName != nil {
+		node.Metadata["eventBusName"] = *eventBusName
+	}
+	if eventPattern != nil {
+		node.Metadata["eventPattern"] = *eventPattern
+	}
+	if scheduleExpression != nil {
+		node.Metadata["scheduleExpression"] = *scheduleExpression
+	}
+	node.Metadata["state"] = state
+}
+
+// optionalString returns nil for an empty string so the API default is used
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
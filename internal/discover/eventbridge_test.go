@@ -0,0 +1,82 @@
+package discover
+
+import (
+	"testing"
+)
+
+func TestEventBridgeTargetToNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		arn      string
+		wantType string
+		wantName string
+		wantID   string
+	}{
+		{
+			name:     "Lambda function",
+			arn:      "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+			wantType: ResourceTypeLambda,
+			wantName: "my-function",
+			wantID:   "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		},
+		{
+			name:     "Qualified Lambda function",
+			arn:      "arn:aws:lambda:us-east-1:123456789012:function:my-function:live",
+			wantType: ResourceTypeLambda,
+			wantName: "my-function",
+			wantID:   "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+		},
+		{
+			name:     "SQS queue",
+			arn:      "arn:aws:sqs:us-east-1:123456789012:orders-queue",
+			wantType: ResourceTypeSQSQueue,
+			wantName: "orders-queue",
+			wantID:   "arn:aws:sqs:us-east-1:123456789012:orders-queue",
+		},
+		{
+			name:     "SNS topic",
+			arn:      "arn:aws:sns:us-east-1:123456789012:alerts",
+			wantType: ResourceTypeSNSTopic,
+			wantName: "alerts",
+			wantID:   "arn:aws:sns:us-east-1:123456789012:alerts",
+		},
+		{
+			name:     "Step Functions state machine",
+			arn:      "arn:aws:states:us-east-1:123456789012:stateMachine:order-workflow",
+			wantType: ResourceTypeStateMachine,
+			wantName: "order-workflow",
+			wantID:   "arn:aws:states:us-east-1:123456789012:stateMachine:order-workflow",
+		},
+		{
+			name:     "ECS cluster",
+			arn:      "arn:aws:ecs:us-east-1:123456789012:cluster/batch",
+			wantType: ResourceTypeECSCluster,
+			wantName: "batch",
+			wantID:   "arn:aws:ecs:us-east-1:123456789012:cluster/batch",
+		},
+		{
+			name:     "Other target",
+			arn:      "arn:aws:events:us-east-1:123456789012:event-bus/other",
+			wantType: ResourceTypeEventTarget,
+			wantName: "other",
+			wantID:   "arn:aws:events:us-east-1:123456789012:event-bus/other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Discoverer{}
+			node := d.eventBridgeTargetToNode(tt.arn, "us-east-1", "123456789012")
+
+			if node.Type != tt.wantType {
+				t.Errorf("eventBridgeTargetToNode() Type = %v, want %v", node.Type, tt.wantType)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("eventBridgeTargetToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+			if node.ID != tt.wantID {
+				t.Errorf("eventBridgeTargetToNode() ID = %v, want %v", node.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package discover
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// IsInternetEntryPoint reports whether a node accepts traffic directly from the internet.
+// It combines the individual entry-point predicates below; callers wanting a narrower view
+// can compose their own with graph.AnyOf.
+func IsInternetEntryPoint(node *graph.Node) bool {
+	return graph.AnyOf(
+		IsInternetFacingLoadBalancer,
+		IsPublicRDSInstance,
+		IsPublicAPIGateway,
+		IsPublicFunctionURL,
+	)(node)
+}
+
+// IsInternetFacingLoadBalancer reports whether a node is a load balancer with the internet-facing scheme
+func IsInternetFacingLoadBalancer(node *graph.Node) bool {
+	return node.Type == ResourceTypeLoadBalancer && metadataString(node, "scheme") == "internet-facing"
+}
+
+// IsPublicRDSInstance reports whether a node is a DB instance with a publicly accessible endpoint
+func IsPublicRDSInstance(node *graph.Node) bool {
+	return node.Type == ResourceTypeRDSInstance && metadataString(node, "publiclyAccessible") == "true"
+}
+
+// IsPublicAPIGateway reports whether a node is an API Gateway API with a public endpoint.
+// HTTP APIs are always public; REST APIs are public unless every endpoint type is PRIVATE.
+func IsPublicAPIGateway(node *graph.Node) bool {
+	switch node.Type {
+	case ResourceTypeAPIGatewayHTTPAPI:
+		return true
+	case ResourceTypeAPIGatewayRestAPI:
+		types := metadataStrings(node, "endpointTypes")
+		return len(types) == 0 || slices.ContainsFunc(types, func(t string) bool { return t != "PRIVATE" })
+	default:
+		return false
+	}
+}
+
+// IsPublicFunctionURL reports whether a node is a Lambda function URL that accepts
+// unauthenticated requests (auth type NONE)
+func IsPublicFunctionURL(node *graph.Node) bool {
+	return node.Type == ResourceTypeLambdaFunctionURL && metadataString(node, "authType") == "NONE"
+}
+
+// metadataString formats a metadata value as a string. Values may be SDK enum types when
+// freshly discovered or plain strings and bools after a snapshot round trip.
+func metadataString(node *graph.Node, key string) string {
+	switch v := node.Metadata[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *bool:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// metadataStrings formats each element of a list-valued metadata entry as a string, covering
+// slices of SDK enum types as well as the []any produced by a snapshot round trip
+func metadataStrings(node *graph.Node, key string) []string {
+	v := reflect.ValueOf(node.Metadata[key])
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	values := make([]string, v.Len())
+	for i := range values {
+		values[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return values
+}
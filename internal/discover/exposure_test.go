@@ -0,0 +1,120 @@
+package discover
+
+import (
+	"testing"
+
+	apigwtypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestIsInternetEntryPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		node *graph.Node
+		want bool
+	}{
+		{
+			name: "internet-facing load balancer",
+			node: &graph.Node{Type: ResourceTypeLoadBalancer, Metadata: map[string]any{"scheme": elbv2types.LoadBalancerSchemeEnumInternetFacing}},
+			want: true,
+		},
+		{
+			name: "internet-facing load balancer from snapshot",
+			node: &graph.Node{Type: ResourceTypeLoadBalancer, Metadata: map[string]any{"scheme": "internet-facing"}},
+			want: true,
+		},
+		{
+			name: "internal load balancer",
+			node: &graph.Node{Type: ResourceTypeLoadBalancer, Metadata: map[string]any{"scheme": elbv2types.LoadBalancerSchemeEnumInternal}},
+			want: false,
+		},
+		{
+			name: "public RDS instance",
+			node: &graph.Node{Type: ResourceTypeRDSInstance, Metadata: map[string]any{"publiclyAccessible": true}},
+			want: true,
+		},
+		{
+			name: "private RDS instance",
+			node: &graph.Node{Type: ResourceTypeRDSInstance, Metadata: map[string]any{"publiclyAccessible": false}},
+			want: false,
+		},
+		{
+			name: "regional REST API",
+			node: &graph.Node{Type: ResourceTypeAPIGatewayRestAPI, Metadata: map[string]any{"endpointTypes": []apigwtypes.EndpointType{apigwtypes.EndpointTypeRegional}}},
+			want: true,
+		},
+		{
+			name: "private REST API",
+			node: &graph.Node{Type: ResourceTypeAPIGatewayRestAPI, Metadata: map[string]any{"endpointTypes": []any{"PRIVATE"}}},
+			want: false,
+		},
+		{
+			name: "HTTP API",
+			node: &graph.Node{Type: ResourceTypeAPIGatewayHTTPAPI, Metadata: map[string]any{}},
+			want: true,
+		},
+		{
+			name: "public function URL",
+			node: &graph.Node{Type: ResourceTypeLambdaFunctionURL, Metadata: map[string]any{"authType": lambdatypes.FunctionUrlAuthTypeNone}},
+			want: true,
+		},
+		{
+			name: "IAM-authenticated function URL",
+			node: &graph.Node{Type: ResourceTypeLambdaFunctionURL, Metadata: map[string]any{"authType": "AWS_IAM"}},
+			want: false,
+		},
+		{
+			name: "Lambda function",
+			node: &graph.Node{Type: ResourceTypeLambda, Metadata: map[string]any{}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsInternetEntryPoint(tt.node); got != tt.want {
+				t.Errorf("IsInternetEntryPoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInternetReachableNodes(t *testing.T) {
+	g := graph.New()
+
+	// public-alb -> listener -> tg -> private service
+	// internal-alb -> internal-tg -> internal service
+	nodes := []*graph.Node{
+		{ID: "public-alb", Type: ResourceTypeLoadBalancer, Metadata: map[string]any{"scheme": elbv2types.LoadBalancerSchemeEnumInternetFacing}},
+		{ID: "listener", Type: ResourceTypeListener, Metadata: map[string]any{}},
+		{ID: "tg", Type: ResourceTypeTargetGroup, Metadata: map[string]any{}},
+		{ID: "service", Type: ResourceTypeECSService, Metadata: map[string]any{}},
+		{ID: "internal-alb", Type: ResourceTypeLoadBalancer, Metadata: map[string]any{"scheme": elbv2types.LoadBalancerSchemeEnumInternal}},
+		{ID: "internal-tg", Type: ResourceTypeTargetGroup, Metadata: map[string]any{}},
+		{ID: "internal-service", Type: ResourceTypeECSService, Metadata: map[string]any{}},
+	}
+	for _, node := range nodes {
+		g.AddNode(node)
+	}
+	g.AddEdge(&graph.Edge{From: "public-alb", To: "listener", RelationType: "has-listener"})
+	g.AddEdge(&graph.Edge{From: "listener", To: "tg", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "tg", To: "service", RelationType: "routes-to-target"})
+	g.AddEdge(&graph.Edge{From: "internal-alb", To: "internal-tg", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "internal-tg", To: "internal-service", RelationType: "routes-to-target"})
+
+	reachable := g.ReachableFrom(IsInternetEntryPoint)
+
+	for _, id := range []string{"public-alb", "listener", "tg", "service"} {
+		if !reachable[id] {
+			t.Errorf("expected %s behind the public ALB to be internet-reachable", id)
+		}
+	}
+	for _, id := range []string{"internal-alb", "internal-tg", "internal-service"} {
+		if reachable[id] {
+			t.Errorf("expected private %s not to be internet-reachable", id)
+		}
+	}
+}
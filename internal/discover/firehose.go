@@ -0,0 +1,173 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	firehosetypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// firehoseDestination is a resource a delivery stream writes records to
+type firehoseDestination struct {
+	arn   string
+	field string
+}
+
+// discoverFirehose links a delivery stream to the Kinesis stream it reads from, the S3 buckets
+// and OpenSearch domains it delivers to, and its KMS key
+func (d *Discoverer) discoverFirehose(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Firehose delivery stream", "arn", node.ARN)
+
+	streamName := extractNameFromARN(node.ARN)
+	output, err := d.clients.Firehose.DescribeDeliveryStream(ctx, &firehose.DescribeDeliveryStreamInput{
+		DeliveryStreamName: &streamName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe delivery stream: %w", err)
+	}
+	description := output.DeliveryStreamDescription
+	if description == nil {
+		return nil, fmt.Errorf("Firehose delivery stream not found: %s", streamName)
+	}
+
+	node.Metadata["status"] = description.DeliveryStreamStatus
+	node.Metadata["deliveryStreamType"] = description.DeliveryStreamType
+
+	var neighbors []string
+
+	// Delivery streams fed by a Kinesis stream break when the source stream does
+	if source := description.Source; source != nil && source.KinesisStreamSourceDescription != nil &&
+		source.KinesisStreamSourceDescription.KinesisStreamARN != nil {
+		sourceARN := *source.KinesisStreamSourceDescription.KinesisStreamARN
+		sourceNode := d.policyResourceToNode(sourceARN)
+		if !g.HasNode(sourceNode.ID) {
+			g.AddNode(sourceNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           sourceNode.ID,
+			RelationType: "reads-from",
+			Evidence: graph.Evidence{
+				APICall: "DescribeDeliveryStream",
+				Fields: map[string]any{
+					"KinesisStreamARN": sourceARN,
+				},
+			},
+		})
+		neighbors = append(neighbors, sourceNode.ID)
+	}
+
+	for i := range description.Destinations {
+		destination := &description.Destinations[i]
+		for _, target := range firehoseDestinations(destination) {
+			targetNode := firehoseDestinationToNode(target.arn)
+			if !g.HasNode(targetNode.ID) {
+				g.AddNode(targetNode)
+			}
+
+			fields := map[string]any{
+				target.field: target.arn,
+			}
+			if destination.DestinationId != nil {
+				fields["DestinationId"] = *destination.DestinationId
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           targetNode.ID,
+				RelationType: "delivers-to",
+				Evidence: graph.Evidence{
+					APICall: "DescribeDeliveryStream",
+					Fields:  fields,
+				},
+			})
+			neighbors = append(neighbors, targetNode.ID)
+		}
+	}
+
+	// AWS owned keys are not visible in the account, so only customer managed keys are linked
+	if encryption := description.DeliveryStreamEncryptionConfiguration; encryption != nil {
+		node.Metadata["encryptionStatus"] = encryption.Status
+		if encryption.KeyType == firehosetypes.KeyTypeCustomerManagedCmk && encryption.KeyARN != nil {
+			neighbors = append(neighbors, addKMSKeyEdge(g, node, *encryption.KeyARN, "DescribeDeliveryStream", "KeyARN"))
+		}
+	}
+
+	return neighbors, nil
+}
+
+// firehoseDestinations returns the S3 buckets and OpenSearch domains a destination writes to.
+// Redshift and Splunk destinations are followed only as far as their S3 staging or backup bucket.
+func firehoseDestinations(destination *firehosetypes.DestinationDescription) []firehoseDestination {
+	var targets []firehoseDestination
+	add := func(arn *string, field string) {
+		if arn == nil {
+			return
+		}
+		for _, target := range targets {
+			if target.arn == *arn {
+				return
+			}
+		}
+		targets = append(targets, firehoseDestination{arn: *arn, field: field})
+	}
+	addBucket := func(s3 *firehosetypes.S3DestinationDescription) {
+		if s3 != nil {
+			add(s3.BucketARN, "BucketARN")
+		}
+	}
+
+	// Extended S3 destinations are also reported as plain S3 destinations for the same bucket
+	if extended := destination.ExtendedS3DestinationDescription; extended != nil {
+		add(extended.BucketARN, "BucketARN")
+	}
+	addBucket(destination.S3DestinationDescription)
+	if redshift := destination.RedshiftDestinationDescription; redshift != nil {
+		addBucket(redshift.S3DestinationDescription)
+	}
+	if splunk := destination.SplunkDestinationDescription; splunk != nil {
+		addBucket(splunk.S3DestinationDescription)
+	}
+	if opensearch := destination.AmazonopensearchserviceDestinationDescription; opensearch != nil {
+		add(opensearch.DomainARN, "DomainARN")
+	}
+	if elasticsearch := destination.ElasticsearchDestinationDescription; elasticsearch != nil {
+		add(elasticsearch.DomainARN, "DomainARN")
+	}
+
+	return targets
+}
+
+// firehoseDestinationToNode creates a node for a delivery destination, typed as an S3 bucket or
+// OpenSearch domain
+func firehoseDestinationToNode(arn string) *graph.Node {
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeAWSResource,
+		ARN:      arn,
+		Name:     extractNameFromARN(arn),
+		Metadata: make(map[string]any),
+	}
+
+	// ARN formats: arn:aws:s3:::bucket and arn:aws:es:region:account:domain/name
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 {
+		return node
+	}
+	node.Region = parts[3]
+	node.Account = parts[4]
+	switch parts[2] {
+	case "s3":
+		node.Type = ResourceTypeS3Bucket
+		node.Name = parts[5]
+	case "es":
+		node.Type = ResourceTypeOpenSearchDomain
+	default:
+		node.Metadata["service"] = parts[2]
+	}
+
+	return node
+}
@@ -0,0 +1,144 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	firehosetypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestFirehoseDestinationToNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		arn         string
+		wantType    string
+		wantName    string
+		wantAccount string
+	}{
+		{
+			name:     "S3 bucket",
+			arn:      "arn:aws:s3:::orders-archive",
+			wantType: ResourceTypeS3Bucket,
+			wantName: "orders-archive",
+		},
+		{
+			name:        "OpenSearch domain",
+			arn:         "arn:aws:es:us-east-1:123456789012:domain/orders",
+			wantType:    ResourceTypeOpenSearchDomain,
+			wantName:    "orders",
+			wantAccount: "123456789012",
+		},
+		{
+			name:        "other service",
+			arn:         "arn:aws:aoss:us-east-1:123456789012:collection/orders-search",
+			wantType:    ResourceTypeAWSResource,
+			wantName:    "orders-search",
+			wantAccount: "123456789012",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := firehoseDestinationToNode(tt.arn)
+			if node.Type != tt.wantType || node.Name != tt.wantName || node.Account != tt.wantAccount {
+				t.Errorf("firehoseDestinationToNode(%q) = %s %q (account %q), want %s %q (account %q)",
+					tt.arn, node.Type, node.Name, node.Account, tt.wantType, tt.wantName, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestDiscoverFirehose(t *testing.T) {
+	const (
+		streamARN = "arn:aws:firehose:us-east-1:123456789012:deliverystream/orders-to-s3"
+		bucketARN = "arn:aws:s3:::orders-archive"
+		domainARN = "arn:aws:es:us-east-1:123456789012:domain/orders"
+		keyARN    = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	)
+	stub := newStubAPI(map[string]any{
+		"DescribeDeliveryStream": &firehose.DescribeDeliveryStreamOutput{
+			DeliveryStreamDescription: &firehosetypes.DeliveryStreamDescription{
+				DeliveryStreamARN:    aws.String(streamARN),
+				DeliveryStreamName:   aws.String("orders-to-s3"),
+				DeliveryStreamStatus: firehosetypes.DeliveryStreamStatusActive,
+				DeliveryStreamType:   firehosetypes.DeliveryStreamTypeKinesisStreamAsSource,
+				Source: &firehosetypes.SourceDescription{
+					KinesisStreamSourceDescription: &firehosetypes.KinesisStreamSourceDescription{
+						KinesisStreamARN: aws.String(testKinesisStreamARN),
+					},
+				},
+				Destinations: []firehosetypes.DestinationDescription{
+					{
+						DestinationId: aws.String("destinationId-000000000001"),
+						// Extended S3 destinations repeat the bucket as a plain S3 destination
+						ExtendedS3DestinationDescription: &firehosetypes.ExtendedS3DestinationDescription{
+							BucketARN: aws.String(bucketARN),
+						},
+						S3DestinationDescription: &firehosetypes.S3DestinationDescription{
+							BucketARN: aws.String(bucketARN),
+						},
+					},
+					{
+						DestinationId: aws.String("destinationId-000000000002"),
+						AmazonopensearchserviceDestinationDescription: &firehosetypes.AmazonopensearchserviceDestinationDescription{
+							DomainARN: aws.String(domainARN),
+						},
+					},
+				},
+				DeliveryStreamEncryptionConfiguration: &firehosetypes.DeliveryStreamEncryptionConfiguration{
+					KeyARN:  aws.String(keyARN),
+					KeyType: firehosetypes.KeyTypeCustomerManagedCmk,
+					Status:  firehosetypes.DeliveryStreamEncryptionStatusEnabled,
+				},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node, err := d.parseARN(streamARN)
+	if err != nil {
+		t.Fatalf("parseARN() error = %v", err)
+	}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverFirehose(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverFirehose() error = %v", err)
+	}
+
+	want := []string{testKinesisStreamARN, bucketARN, domainARN, keyARN}
+	if len(neighbors) != len(want) {
+		t.Fatalf("discoverFirehose() neighbors = %v, want %v", neighbors, want)
+	}
+	for i := range want {
+		if neighbors[i] != want[i] {
+			t.Errorf("discoverFirehose() neighbors[%d] = %s, want %s", i, neighbors[i], want[i])
+		}
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesFrom(node.ID) {
+		relations[edge.To] = edge.RelationType
+	}
+	wantRelations := map[string]string{
+		testKinesisStreamARN: "reads-from",
+		bucketARN:            "delivers-to",
+		domainARN:            "delivers-to",
+		keyARN:               "encrypted-with",
+	}
+	for to, relation := range wantRelations {
+		if relations[to] != relation {
+			t.Errorf("edge to %s = %q, want %q", to, relations[to], relation)
+		}
+	}
+
+	source, ok := g.GetNode(testKinesisStreamARN)
+	if !ok || source.Type != ResourceTypeKinesisStream {
+		t.Errorf("source node = %+v, want KinesisStream", source)
+	}
+}
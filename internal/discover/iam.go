@@ -0,0 +1,545 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// awsAccountIDPattern matches a bare 12-digit account ID, as used for account principals
+var awsAccountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// policyDocument is the subset of an IAM policy document needed to find granted resources
+type policyDocument struct {
+	Statement policyStatements `json:"Statement"`
+}
+
+// policyStatement is a single statement of an IAM policy document
+type policyStatement struct {
+	Effect    string          `json:"Effect"`
+	Principal policyPrincipal `json:"Principal"`
+	Action    stringOrSlice   `json:"Action"`
+	Resource  stringOrSlice   `json:"Resource"`
+	Sid       string          `json:"Sid"`
+	Condition policyCondition `json:"Condition"`
+}
+
+// policyCondition maps condition operators (ArnLike, StringEquals, ...) to their keys and values
+type policyCondition map[string]map[string]stringOrSlice
+
+// policyStatements accepts either a single statement object or an array of statements
+type policyStatements []policyStatement
+
+// UnmarshalJSON decodes a statement object or array
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	var single policyStatement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = policyStatements{single}
+		return nil
+	}
+	var many []policyStatement
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
+// policyPrincipal maps principal types (AWS, Service, Federated) to principals. The wildcard
+// principal "*" is stored under the "*" type.
+type policyPrincipal map[string]stringOrSlice
+
+// UnmarshalJSON decodes "*" or a principal object
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		*p = policyPrincipal{"*": {wildcard}}
+		return nil
+	}
+	var principals map[string]stringOrSlice
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	*p = principals
+	return nil
+}
+
+// stringOrSlice accepts either a single string or an array of strings
+type stringOrSlice []string
+
+// UnmarshalJSON decodes a string or string array
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = stringOrSlice{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
+// allowedPrincipal is a principal a trust or resource policy allows, with the actions it is
+// allowed (e.g. sts:AssumeRole or secretsmanager:GetSecretValue)
+type allowedPrincipal struct {
+	kind    string
+	id      string
+	actions []string
+}
+
+// policyGrant is a concrete resource a policy allows access to, with the actions allowed on it
+type policyGrant struct {
+	resource string
+	actions  []string
+}
+
+// discoverIAMRole links a role to its attached and inline policies and to the principals its
+// trust policy allows to assume it. With the iam-policy heuristic, the resources the policies
+// grant access to are linked too; only statements naming concrete ARNs can be resolved.
+func (d *Discoverer) discoverIAMRole(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering IAM role", "arn", node.ARN)
+
+	roleName := node.Name
+	if roleName == "" {
+		roleName = extractRoleNameFromARN(node.ARN)
+	}
+
+	neighbors, err := d.discoverRoleTrust(ctx, roleName, node, g)
+	if err != nil {
+		d.warn(node.ID, "Failed to get role trust policy", err, "role", roleName)
+	}
+	grants := d.hasHeuristic("iam-policy")
+
+	// Managed policies attached to the role
+	attached := iam.NewListAttachedRolePoliciesPaginator(d.clients.IAM, &iam.ListAttachedRolePoliciesInput{
+		RoleName: &roleName,
+	})
+	for attached.HasMorePages() {
+		output, pageErr := attached.NextPage(ctx)
+		if pageErr != nil {
+			return neighbors, fmt.Errorf("failed to list attached role policies: %w", pageErr)
+		}
+
+		for i := range output.AttachedPolicies {
+			policy := &output.AttachedPolicies[i]
+			if policy.PolicyArn == nil {
+				continue
+			}
+
+			policyNode := iamManagedPolicyToNode(*policy.PolicyArn)
+			policyID, ok := d.addRolePolicyEdge(g, node, policyNode, "ListAttachedRolePolicies")
+			if !ok {
+				return neighbors, nil
+			}
+			neighbors = append(neighbors, policyID)
+
+			if !grants {
+				continue
+			}
+			document, docErr := d.managedPolicyDocument(ctx, *policy.PolicyArn)
+			if docErr != nil {
+				d.warn(node.ID, "Failed to get managed policy document", docErr, "policy", *policy.PolicyArn)
+				continue
+			}
+			neighbors = append(neighbors, d.addPolicyGrantEdges(g, node, document, "GetPolicyVersion", map[string]any{
+				"PolicyArn": *policy.PolicyArn,
+			})...)
+		}
+	}
+
+	// Inline policies embedded in the role
+	inline := iam.NewListRolePoliciesPaginator(d.clients.IAM, &iam.ListRolePoliciesInput{
+		RoleName: &roleName,
+	})
+	for inline.HasMorePages() {
+		output, pageErr := inline.NextPage(ctx)
+		if pageErr != nil {
+			return neighbors, fmt.Errorf("failed to list role policies: %w", pageErr)
+		}
+
+		for _, policyName := range output.PolicyNames {
+			policyID, ok := d.addRolePolicyEdge(g, node, iamInlinePolicyToNode(node, policyName), "ListRolePolicies")
+			if !ok {
+				return neighbors, nil
+			}
+			neighbors = append(neighbors, policyID)
+
+			if !grants {
+				continue
+			}
+			policy, policyErr := d.clients.IAM.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+				RoleName:   &roleName,
+				PolicyName: &policyName,
+			})
+			if policyErr != nil || policy.PolicyDocument == nil {
+				d.warn(node.ID, "Failed to get inline role policy", policyErr, "role", roleName, "policy", policyName)
+				continue
+			}
+			neighbors = append(neighbors, d.addPolicyGrantEdges(g, node, *policy.PolicyDocument, "GetRolePolicy", map[string]any{
+				"PolicyName": policyName,
+			})...)
+		}
+	}
+
+	return neighbors, nil
+}
+
+// discoverRoleTrust records role details from GetRole and adds a trusted-by edge to each
+// principal the trust policy allows to assume the role
+func (d *Discoverer) discoverRoleTrust(ctx context.Context, roleName string, node *graph.Node, g *graph.Graph) ([]string, error) {
+	output, err := d.clients.IAM.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: &roleName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	role := output.Role
+	if role == nil {
+		return nil, fmt.Errorf("IAM role not found: %s", roleName)
+	}
+
+	if role.MaxSessionDuration != nil {
+		node.Metadata["maxSessionDuration"] = *role.MaxSessionDuration
+	}
+	if role.RoleLastUsed != nil && role.RoleLastUsed.LastUsedDate != nil {
+		node.Metadata["lastUsed"] = *role.RoleLastUsed.LastUsedDate
+	}
+	if role.AssumeRolePolicyDocument == nil {
+		return nil, nil
+	}
+
+	principals, err := parsePolicyPrincipals(*role.AssumeRolePolicyDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for _, principal := range principals {
+		principalNode := d.principalToNode(principal)
+		if !g.HasNode(principalNode.ID) {
+			if g.NodeCount() >= d.opts.MaxNodes {
+				d.log.Warn("Reached max nodes limit while adding trusted principals", "role", roleName)
+				g.MarkTruncated(TruncatedMaxNodes)
+				break
+			}
+			g.AddNode(principalNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           principalNode.ID,
+			RelationType: "trusted-by",
+			Evidence: graph.Evidence{
+				APICall: "GetRole",
+				Fields: map[string]any{
+					"Principal": principal.kind + ":" + principal.id,
+					"Actions":   principal.actions,
+				},
+			},
+		})
+		neighbors = append(neighbors, principalNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// addRolePolicyEdge links a role to one of its policies, sharing one node per managed policy
+// across roles. ok is false once MaxNodes leaves no room for a new policy node.
+func (d *Discoverer) addRolePolicyEdge(g *graph.Graph, roleNode, policyNode *graph.Node, apiCall string) (id string, ok bool) {
+	if !g.HasNode(policyNode.ID) {
+		if g.NodeCount() >= d.opts.MaxNodes {
+			d.log.Warn("Reached max nodes limit while adding role policies", "role", roleNode.Name)
+			g.MarkTruncated(TruncatedMaxNodes)
+			return "", false
+		}
+		g.AddNode(policyNode)
+	}
+
+	fields := map[string]any{
+		"PolicyName": policyNode.Name,
+	}
+	if policyNode.ARN != "" {
+		fields["PolicyArn"] = policyNode.ARN
+	}
+	g.AddEdge(&graph.Edge{
+		From:         roleNode.ID,
+		To:           policyNode.ID,
+		RelationType: "has-policy",
+		Evidence: graph.Evidence{
+			APICall: apiCall,
+			Fields:  fields,
+		},
+	})
+	return policyNode.ID, true
+}
+
+// managedPolicyDocument fetches the default version of a managed policy
+func (d *Discoverer) managedPolicyDocument(ctx context.Context, policyARN string) (string, error) {
+	policy, err := d.clients.IAM.GetPolicy(ctx, &iam.GetPolicyInput{
+		PolicyArn: &policyARN,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get policy: %w", err)
+	}
+	if policy.Policy == nil || policy.Policy.DefaultVersionId == nil {
+		return "", fmt.Errorf("policy has no default version: %s", policyARN)
+	}
+
+	version, err := d.clients.IAM.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: &policyARN,
+		VersionId: policy.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get policy version: %w", err)
+	}
+	if version.PolicyVersion == nil || version.PolicyVersion.Document == nil {
+		return "", fmt.Errorf("policy version has no document: %s", policyARN)
+	}
+
+	return *version.PolicyVersion.Document, nil
+}
+
+// addPolicyGrantEdges adds a can-access edge from a role to each concrete resource a policy document allows
+func (d *Discoverer) addPolicyGrantEdges(g *graph.Graph, roleNode *graph.Node, document, apiCall string, fields map[string]any) []string {
+	grants, err := parsePolicyGrants(document)
+	if err != nil {
+		d.warn(roleNode.ID, "Failed to parse policy document", err, "role", roleNode.Name)
+		return nil
+	}
+
+	var neighbors []string
+	for _, grant := range grants {
+		resourceNode := d.policyResourceToNode(grant.resource)
+		if !g.HasNode(resourceNode.ID) {
+			g.AddNode(resourceNode)
+		}
+
+		edgeFields := map[string]any{
+			"Resource": grant.resource,
+			"Actions":  grant.actions,
+		}
+		for k, v := range fields {
+			edgeFields[k] = v
+		}
+
+		g.AddEdge(&graph.Edge{
+			From:         roleNode.ID,
+			To:           resourceNode.ID,
+			RelationType: "can-access",
+			Evidence: graph.Evidence{
+				APICall:   apiCall,
+				Fields:    edgeFields,
+				Heuristic: true,
+			},
+		})
+		neighbors = append(neighbors, resourceNode.ID)
+	}
+
+	return neighbors
+}
+
+// parsePolicyGrants returns the concrete resource ARNs allowed by a policy document, in order of
+// first appearance. Wildcard resources, NotResource and Deny statements are skipped.
+func parsePolicyGrants(document string) ([]policyGrant, error) {
+	doc, err := decodePolicyDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var grants []policyGrant
+	index := make(map[string]int)
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		for _, resource := range statement.Resource {
+			if !strings.HasPrefix(resource, "arn:") || strings.ContainsAny(resource, "*?") {
+				continue
+			}
+			if i, ok := index[resource]; ok {
+				grants[i].actions = append(grants[i].actions, statement.Action...)
+				continue
+			}
+			index[resource] = len(grants)
+			grants = append(grants, policyGrant{
+				resource: resource,
+				actions:  append([]string(nil), statement.Action...),
+			})
+		}
+	}
+
+	return grants, nil
+}
+
+// parsePolicyPrincipals returns the principals a trust or resource policy allows, in order of first
+// appearance. Principal types within a statement are visited alphabetically so the order is
+// stable. Deny statements are skipped.
+func parsePolicyPrincipals(document string) ([]allowedPrincipal, error) {
+	doc, err := decodePolicyDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var principals []allowedPrincipal
+	index := make(map[string]int)
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+
+		kinds := make([]string, 0, len(statement.Principal))
+		for kind := range statement.Principal {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			for _, id := range statement.Principal[kind] {
+				// {"AWS": "*"} is the same as "*"
+				principalKind := kind
+				if id == "*" {
+					principalKind = "*"
+				}
+				key := principalKind + ":" + id
+				if i, ok := index[key]; ok {
+					principals[i].actions = append(principals[i].actions, statement.Action...)
+					continue
+				}
+				index[key] = len(principals)
+				principals = append(principals, allowedPrincipal{
+					kind:    principalKind,
+					id:      id,
+					actions: append([]string(nil), statement.Action...),
+				})
+			}
+		}
+	}
+
+	return principals, nil
+}
+
+// decodePolicyDocument parses a policy document, URL-decoding it first if needed
+func decodePolicyDocument(document string) (policyDocument, error) {
+	// Documents returned by IAM are URL-encoded
+	if !strings.HasPrefix(strings.TrimSpace(document), "{") {
+		decoded, err := url.PathUnescape(document)
+		if err != nil {
+			return policyDocument{}, fmt.Errorf("failed to decode policy document: %w", err)
+		}
+		document = decoded
+	}
+
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return policyDocument{}, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	return doc, nil
+}
+
+// principalToNode creates a node for a policy principal. Roles are typed as IAMRole so
+// role chains can be followed; accounts, services and federated providers become IAMPrincipal nodes.
+func (d *Discoverer) principalToNode(principal allowedPrincipal) *graph.Node {
+	if principal.kind == "AWS" {
+		if node, err := d.parseARN(principal.id); err == nil {
+			return node
+		}
+	}
+
+	node := &graph.Node{
+		ID:   principal.id,
+		Type: ResourceTypeIAMPrincipal,
+		Name: principal.id,
+		Metadata: map[string]any{
+			"principalType": principal.kind,
+		},
+	}
+
+	// Principal ARN formats: arn:aws:iam::account:root, arn:aws:iam::account:user/name,
+	// arn:aws:iam::account:oidc-provider/host
+	if parts := strings.SplitN(principal.id, ":", 6); len(parts) == 6 && parts[0] == "arn" {
+		node.ARN = principal.id
+		node.Account = parts[4]
+		node.Name = extractNameFromARN(parts[5])
+		if parts[5] == "root" {
+			node.Name = parts[4]
+		}
+	} else if principal.kind == "AWS" && awsAccountIDPattern.MatchString(principal.id) {
+		node.Account = principal.id
+	}
+
+	return node
+}
+
+// iamManagedPolicyToNode creates a managed policy node from its ARN. AWS managed policies
+// (arn:aws:iam::aws:policy/...) have no owning account.
+func iamManagedPolicyToNode(arn string) *graph.Node {
+	node := &graph.Node{
+		ID:   arn,
+		Type: ResourceTypeIAMPolicy,
+		ARN:  arn,
+		Name: extractNameFromARN(arn),
+		Metadata: map[string]any{
+			"policyType": "managed",
+		},
+	}
+
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		if parts[4] == "aws" {
+			node.Metadata["awsManaged"] = true
+		} else {
+			node.Account = parts[4]
+		}
+	}
+
+	return node
+}
+
+// iamInlinePolicyToNode creates a node for a policy embedded in a role. Inline policies have no
+// ARN, so the ID is derived from the role's.
+func iamInlinePolicyToNode(roleNode *graph.Node, policyName string) *graph.Node {
+	return &graph.Node{
+		ID:      roleNode.ID + "/inline-policy/" + policyName,
+		Type:    ResourceTypeIAMPolicy,
+		Name:    policyName,
+		Account: roleNode.Account,
+		Metadata: map[string]any{
+			"policyType": "inline",
+		},
+	}
+}
+
+// policyResourceToNode creates a node for a resource named in a policy, typed via parseARN
+// when the service is supported and as a generic AWSResource otherwise
+func (d *Discoverer) policyResourceToNode(arn string) *graph.Node {
+	if node, err := d.parseARN(arn); err == nil && node.Type != "" {
+		return node
+	}
+
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeAWSResource,
+		ARN:      arn,
+		Name:     arn,
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:partition:service:region:account:resource
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		node.Region = parts[3]
+		node.Account = parts[4]
+		node.Metadata["service"] = parts[2]
+		node.Name = extractNameFromARN(parts[5])
+	}
+
+	return node
+}
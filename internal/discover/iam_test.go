@@ -0,0 +1,320 @@
+package discover
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestParsePolicyGrants(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []policyGrant
+		wantErr  bool
+	}{
+		{
+			name: "Statement array with concrete and wildcard resources",
+			document: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Effect": "Allow", "Action": ["dynamodb:PutItem"], "Resource": ["arn:aws:dynamodb:us-east-1:123456789012:table/orders", "arn:aws:dynamodb:us-east-1:123456789012:table/orders/index/*"]},
+					{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::assets"},
+					{"Effect": "Allow", "Action": "logs:*", "Resource": "*"}
+				]
+			}`,
+			want: []policyGrant{
+				{resource: "arn:aws:dynamodb:us-east-1:123456789012:table/orders", actions: []string{"dynamodb:PutItem"}},
+				{resource: "arn:aws:s3:::assets", actions: []string{"s3:GetObject"}},
+			},
+		},
+		{
+			name:     "Single statement object",
+			document: `{"Statement": {"Effect": "Allow", "Action": "sqs:SendMessage", "Resource": "arn:aws:sqs:us-east-1:123456789012:jobs"}}`,
+			want: []policyGrant{
+				{resource: "arn:aws:sqs:us-east-1:123456789012:jobs", actions: []string{"sqs:SendMessage"}},
+			},
+		},
+		{
+			name: "Deny statements skipped and repeated resources merged",
+			document: `{"Statement": [
+				{"Effect": "Allow", "Action": "sqs:SendMessage", "Resource": "arn:aws:sqs:us-east-1:123456789012:jobs"},
+				{"Effect": "Deny", "Action": "sqs:DeleteQueue", "Resource": "arn:aws:sqs:us-east-1:123456789012:other"},
+				{"Effect": "Allow", "Action": "sqs:ReceiveMessage", "Resource": "arn:aws:sqs:us-east-1:123456789012:jobs"}
+			]}`,
+			want: []policyGrant{
+				{resource: "arn:aws:sqs:us-east-1:123456789012:jobs", actions: []string{"sqs:SendMessage", "sqs:ReceiveMessage"}},
+			},
+		},
+		{
+			name:     "URL-encoded document",
+			document: url.PathEscape(`{"Statement": {"Effect": "Allow", "Action": "kms:Decrypt", "Resource": "arn:aws:kms:us-east-1:123456789012:key/abc"}}`),
+			want: []policyGrant{
+				{resource: "arn:aws:kms:us-east-1:123456789012:key/abc", actions: []string{"kms:Decrypt"}},
+			},
+		},
+		{
+			name:     "Invalid document",
+			document: `{"Statement": 42}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePolicyGrants(tt.document)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePolicyGrants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePolicyGrants() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicyPrincipals(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []allowedPrincipal
+		wantErr  bool
+	}{
+		{
+			name:     "Single service principal",
+			document: `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": {"Service": "lambda.amazonaws.com"}, "Action": "sts:AssumeRole"}]}`,
+			want: []allowedPrincipal{
+				{kind: "Service", id: "lambda.amazonaws.com", actions: []string{"sts:AssumeRole"}},
+			},
+		},
+		{
+			name:     "Several service principals in one statement",
+			document: `{"Statement": {"Effect": "Allow", "Principal": {"Service": ["ecs-tasks.amazonaws.com", "events.amazonaws.com"]}, "Action": "sts:AssumeRole"}}`,
+			want: []allowedPrincipal{
+				{kind: "Service", id: "ecs-tasks.amazonaws.com", actions: []string{"sts:AssumeRole"}},
+				{kind: "Service", id: "events.amazonaws.com", actions: []string{"sts:AssumeRole"}},
+			},
+		},
+		{
+			name: "Account, role and mixed principal types",
+			document: `{"Statement": [{"Effect": "Allow", "Principal": {
+				"Service": "lambda.amazonaws.com",
+				"AWS": ["arn:aws:iam::210987654321:root", "arn:aws:iam::123456789012:role/deployer", "111122223333"]
+			}, "Action": ["sts:AssumeRole", "sts:TagSession"]}]}`,
+			want: []allowedPrincipal{
+				{kind: "AWS", id: "arn:aws:iam::210987654321:root", actions: []string{"sts:AssumeRole", "sts:TagSession"}},
+				{kind: "AWS", id: "arn:aws:iam::123456789012:role/deployer", actions: []string{"sts:AssumeRole", "sts:TagSession"}},
+				{kind: "AWS", id: "111122223333", actions: []string{"sts:AssumeRole", "sts:TagSession"}},
+				{kind: "Service", id: "lambda.amazonaws.com", actions: []string{"sts:AssumeRole", "sts:TagSession"}},
+			},
+		},
+		{
+			name: "Federated web identity",
+			document: `{"Statement": [{"Effect": "Allow",
+				"Principal": {"Federated": "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com"},
+				"Action": "sts:AssumeRoleWithWebIdentity",
+				"Condition": {"StringLike": {"token.actions.githubusercontent.com:sub": "repo:org/app:*"}}}]}`,
+			want: []allowedPrincipal{
+				{kind: "Federated", id: "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com", actions: []string{"sts:AssumeRoleWithWebIdentity"}},
+			},
+		},
+		{
+			name: "Wildcard principals, Deny skipped and repeats merged",
+			document: `{"Statement": [
+				{"Effect": "Allow", "Principal": "*", "Action": "sts:AssumeRole"},
+				{"Effect": "Deny", "Principal": {"AWS": "arn:aws:iam::999999999999:root"}, "Action": "sts:AssumeRole"},
+				{"Effect": "Allow", "Principal": {"AWS": "*"}, "Action": "sts:TagSession"}
+			]}`,
+			want: []allowedPrincipal{
+				{kind: "*", id: "*", actions: []string{"sts:AssumeRole", "sts:TagSession"}},
+			},
+		},
+		{
+			name:     "URL-encoded document",
+			document: url.PathEscape(`{"Statement": [{"Effect": "Allow", "Principal": {"Service": "states.amazonaws.com"}, "Action": "sts:AssumeRole"}]}`),
+			want: []allowedPrincipal{
+				{kind: "Service", id: "states.amazonaws.com", actions: []string{"sts:AssumeRole"}},
+			},
+		},
+		{
+			name:     "Invalid principal",
+			document: `{"Statement": [{"Effect": "Allow", "Principal": 42, "Action": "sts:AssumeRole"}]}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePolicyPrincipals(tt.document)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePolicyPrincipals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePolicyPrincipals() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverIAMRole(t *testing.T) {
+	const (
+		roleARN      = "arn:aws:iam::123456789012:role/api"
+		sharedPolicy = "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+	)
+	stub := newStubAPI(map[string]any{
+		"GetRole": &iam.GetRoleOutput{
+			Role: &iamtypes.Role{
+				RoleName:                 aws.String("api"),
+				Arn:                      aws.String(roleARN),
+				AssumeRolePolicyDocument: aws.String(url.PathEscape(`{"Statement": [{"Effect": "Allow", "Principal": {"Service": "lambda.amazonaws.com"}, "Action": "sts:AssumeRole"}]}`)),
+				MaxSessionDuration:       aws.Int32(3600),
+			},
+		},
+		"ListAttachedRolePolicies": &iam.ListAttachedRolePoliciesOutput{
+			AttachedPolicies: []iamtypes.AttachedPolicy{
+				{PolicyArn: aws.String(sharedPolicy), PolicyName: aws.String("AWSLambdaBasicExecutionRole")},
+			},
+		},
+		"ListRolePolicies": &iam.ListRolePoliciesOutput{
+			PolicyNames: []string{"orders-access"},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{MaxNodes: 100})
+	g := graph.New()
+
+	// A second role already shares the AWS managed policy
+	other := &graph.Node{ID: "arn:aws:iam::123456789012:role/worker", Type: ResourceTypeIAMRole, Name: "worker"}
+	g.AddNode(other)
+	g.AddNode(iamManagedPolicyToNode(sharedPolicy))
+	g.AddEdge(&graph.Edge{From: other.ID, To: sharedPolicy, RelationType: "has-policy"})
+
+	node, err := d.parseARN(roleARN)
+	if err != nil {
+		t.Fatalf("parseARN() error = %v", err)
+	}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverIAMRole(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverIAMRole() error = %v", err)
+	}
+
+	inlineID := roleARN + "/inline-policy/orders-access"
+	want := []string{"lambda.amazonaws.com", sharedPolicy, inlineID}
+	if !reflect.DeepEqual(neighbors, want) {
+		t.Fatalf("discoverIAMRole() neighbors = %v, want %v", neighbors, want)
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesFrom(node.ID) {
+		relations[edge.To] = edge.RelationType
+	}
+	wantRelations := map[string]string{
+		"lambda.amazonaws.com": "trusted-by",
+		sharedPolicy:           "has-policy",
+		inlineID:               "has-policy",
+	}
+	if !reflect.DeepEqual(relations, wantRelations) {
+		t.Errorf("edges from role = %v, want %v", relations, wantRelations)
+	}
+
+	// The managed policy is one node shared by both roles
+	if edges := g.EdgesTo(sharedPolicy); len(edges) != 2 {
+		t.Errorf("edges to shared policy = %d, want 2", len(edges))
+	}
+	if principal, ok := g.GetNode("lambda.amazonaws.com"); !ok || principal.Type != ResourceTypeIAMPrincipal {
+		t.Errorf("principal node = %+v, want IAMPrincipal", principal)
+	}
+	if node.Metadata["maxSessionDuration"] != int32(3600) {
+		t.Errorf("maxSessionDuration = %v, want 3600", node.Metadata["maxSessionDuration"])
+	}
+
+	// Policy documents are only read with the iam-policy heuristic
+	if stub.called("GetPolicyVersion") || stub.called("GetRolePolicy") {
+		t.Error("discoverIAMRole() read policy documents without the iam-policy heuristic")
+	}
+}
+
+func TestDiscoverIAMRoleMaxNodes(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"GetRole": &iam.GetRoleOutput{Role: &iamtypes.Role{RoleName: aws.String("api")}},
+		"ListAttachedRolePolicies": &iam.ListAttachedRolePoliciesOutput{
+			AttachedPolicies: []iamtypes.AttachedPolicy{
+				{PolicyArn: aws.String("arn:aws:iam::123456789012:policy/one")},
+				{PolicyArn: aws.String("arn:aws:iam::123456789012:policy/two")},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{MaxNodes: 2})
+	g := graph.New()
+	node := &graph.Node{ID: "arn:aws:iam::123456789012:role/api", Type: ResourceTypeIAMRole, Name: "api", Metadata: map[string]any{}}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverIAMRole(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverIAMRole() error = %v", err)
+	}
+	if len(neighbors) != 1 || g.NodeCount() != 2 {
+		t.Errorf("discoverIAMRole() neighbors = %v with %d nodes, want one policy within MaxNodes", neighbors, g.NodeCount())
+	}
+	if g.TruncationReason() != TruncatedMaxNodes {
+		t.Errorf("truncation = %q, want %q", g.TruncationReason(), TruncatedMaxNodes)
+	}
+	if stub.called("ListRolePolicies") {
+		t.Error("discoverIAMRole() kept listing policies after reaching MaxNodes")
+	}
+}
+
+func TestPolicyResourceToNode(t *testing.T) {
+	d := New(nil, &Options{})
+
+	tests := []struct {
+		name     string
+		arn      string
+		wantType string
+		wantName string
+	}{
+		{
+			name:     "Supported service typed via parseARN",
+			arn:      "arn:aws:lambda:us-east-1:123456789012:function:worker",
+			wantType: ResourceTypeLambda,
+			wantName: "worker",
+		},
+		{
+			name:     "DynamoDB table",
+			arn:      "arn:aws:dynamodb:us-east-1:123456789012:table/orders",
+			wantType: ResourceTypeAWSResource,
+			wantName: "orders",
+		},
+		{
+			name:     "S3 bucket",
+			arn:      "arn:aws:s3:::assets",
+			wantType: ResourceTypeAWSResource,
+			wantName: "assets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := d.policyResourceToNode(tt.arn)
+			if node.ID != tt.arn {
+				t.Errorf("policyResourceToNode() ID = %v, want %v", node.ID, tt.arn)
+			}
+			if node.Type != tt.wantType {
+				t.Errorf("policyResourceToNode() Type = %v, want %v", node.Type, tt.wantType)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("policyResourceToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+		})
+	}
+}
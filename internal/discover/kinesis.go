@@ -0,0 +1,126 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// discoverKinesis records a stream's capacity and encryption settings and links it to its
+// KMS key and registered enhanced fan-out consumers
+func (d *Discoverer) discoverKinesis(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Kinesis stream", "arn", node.ARN)
+
+	// Event source mappings may name a consumer rather than the stream itself
+	streamARN := kinesisStreamARN(node.ARN)
+
+	output, err := d.clients.Kinesis.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{
+		StreamARN: &streamARN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stream summary: %w", err)
+	}
+	summary := output.StreamDescriptionSummary
+	if summary == nil {
+		return nil, fmt.Errorf("Kinesis stream not found: %s", streamARN)
+	}
+	setKinesisStreamMetadata(node, summary)
+
+	var neighbors []string
+	if summary.EncryptionType == kinesistypes.EncryptionTypeKms && summary.KeyId != nil {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *summary.KeyId, "DescribeStreamSummary", "KeyId"))
+	}
+
+	paginator := kinesis.NewListStreamConsumersPaginator(d.clients.Kinesis, &kinesis.ListStreamConsumersInput{
+		StreamARN: &streamARN,
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(page *kinesis.ListStreamConsumersOutput) error {
+		for i := range page.Consumers {
+			consumer := &page.Consumers[i]
+			if consumer.ConsumerARN == nil {
+				continue
+			}
+
+			consumerNode := &graph.Node{
+				ID:      *consumer.ConsumerARN,
+				Type:    ResourceTypeKinesisConsumer,
+				ARN:     *consumer.ConsumerARN,
+				Name:    kinesisConsumerName(consumer),
+				Region:  node.Region,
+				Account: node.Account,
+				Metadata: map[string]any{
+					"status": consumer.ConsumerStatus,
+				},
+			}
+			if !g.HasNode(consumerNode.ID) {
+				g.AddNode(consumerNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           consumerNode.ID,
+				RelationType: "consumed-by",
+				Evidence: graph.Evidence{
+					APICall: "ListStreamConsumers",
+					Fields: map[string]any{
+						"ConsumerARN": *consumer.ConsumerARN,
+					},
+				},
+			})
+			neighbors = append(neighbors, consumerNode.ID)
+		}
+		return nil
+	})
+	if pageErr != nil {
+		d.warn(node.ID, "Failed to list stream consumers", pageErr, "stream", streamARN)
+	}
+
+	return neighbors, nil
+}
+
+// kinesisStreamARN returns the stream ARN for a stream or consumer ARN. Consumer ARNs have the
+// form arn:aws:kinesis:region:account:stream/name/consumer/consumer-name:timestamp.
+func kinesisStreamARN(arn string) string {
+	if i := strings.Index(arn, "/consumer/"); i >= 0 {
+		return arn[:i]
+	}
+	return arn
+}
+
+// kinesisConsumerName returns a consumer's name, falling back to its ARN
+func kinesisConsumerName(consumer *kinesistypes.Consumer) string {
+	if consumer.ConsumerName != nil {
+		return *consumer.ConsumerName
+	}
+	return *consumer.ConsumerARN
+}
+
+// setKinesisStreamMetadata records status, capacity mode, retention and encryption from DescribeStreamSummary
+func setKinesisStreamMetadata(node *graph.Node, summary *kinesistypes.StreamDescriptionSummary) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	if summary.StreamName != nil {
+		node.Name = *summary.StreamName
+	}
+	node.Metadata["streamStatus"] = summary.StreamStatus
+	if summary.StreamModeDetails != nil {
+		node.Metadata["streamMode"] = summary.StreamModeDetails.StreamMode
+	}
+	if summary.OpenShardCount != nil {
+		node.Metadata["openShardCount"] = *summary.OpenShardCount
+	}
+	if summary.RetentionPeriodHours != nil {
+		node.Metadata["retentionPeriodHours"] = *summary.RetentionPeriodHours
+	}
+	if summary.ConsumerCount != nil {
+		node.Metadata["consumerCount"] = *summary.ConsumerCount
+	}
+	node.Metadata["encryptionType"] = summary.EncryptionType
+}
@@ -0,0 +1,101 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const testKinesisStreamARN = "arn:aws:kinesis:us-east-1:123456789012:stream/orders"
+
+func TestKinesisStreamARN(t *testing.T) {
+	tests := []struct {
+		name string
+		arn  string
+		want string
+	}{
+		{
+			name: "stream ARN is unchanged",
+			arn:  testKinesisStreamARN,
+			want: testKinesisStreamARN,
+		},
+		{
+			name: "consumer ARN is trimmed to its stream",
+			arn:  testKinesisStreamARN + "/consumer/analytics:1700000000",
+			want: testKinesisStreamARN,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kinesisStreamARN(tt.arn); got != tt.want {
+				t.Errorf("kinesisStreamARN(%q) = %q, want %q", tt.arn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverKinesis(t *testing.T) {
+	consumerARN := testKinesisStreamARN + "/consumer/analytics:1700000000"
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	stub := newStubAPI(map[string]any{
+		"DescribeStreamSummary": &kinesis.DescribeStreamSummaryOutput{
+			StreamDescriptionSummary: &kinesistypes.StreamDescriptionSummary{
+				StreamARN:            aws.String(testKinesisStreamARN),
+				StreamName:           aws.String("orders"),
+				StreamStatus:         kinesistypes.StreamStatusActive,
+				StreamModeDetails:    &kinesistypes.StreamModeDetails{StreamMode: kinesistypes.StreamModeOnDemand},
+				OpenShardCount:       aws.Int32(4),
+				RetentionPeriodHours: aws.Int32(24),
+				EncryptionType:       kinesistypes.EncryptionTypeKms,
+				KeyId:                aws.String(keyARN),
+			},
+		},
+		"ListStreamConsumers": &kinesis.ListStreamConsumersOutput{
+			Consumers: []kinesistypes.Consumer{
+				{
+					ConsumerARN:    aws.String(consumerARN),
+					ConsumerName:   aws.String("analytics"),
+					ConsumerStatus: kinesistypes.ConsumerStatusActive,
+				},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node, err := d.parseARN(testKinesisStreamARN)
+	if err != nil {
+		t.Fatalf("parseARN() error = %v", err)
+	}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverKinesis(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverKinesis() error = %v", err)
+	}
+	if len(neighbors) != 2 || neighbors[0] != keyARN || neighbors[1] != consumerARN {
+		t.Fatalf("discoverKinesis() neighbors = %v, want the key then the consumer", neighbors)
+	}
+
+	if node.Metadata["openShardCount"] != int32(4) || node.Metadata["streamMode"] != kinesistypes.StreamModeOnDemand {
+		t.Errorf("stream metadata = %v", node.Metadata)
+	}
+	consumer, ok := g.GetNode(consumerARN)
+	if !ok || consumer.Type != ResourceTypeKinesisConsumer || consumer.Name != "analytics" {
+		t.Errorf("consumer node = %+v, want KinesisConsumer analytics", consumer)
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesFrom(node.ID) {
+		relations[edge.To] = edge.RelationType
+	}
+	if relations[keyARN] != "encrypted-with" || relations[consumerARN] != "consumed-by" {
+		t.Errorf("edge relations = %v", relations)
+	}
+}
@@ -0,0 +1,180 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// kmsKeyIDPattern matches a bare KMS key ID (a UUID, or an mrk- prefixed multi-Region key ID)
+var kmsKeyIDPattern = regexp.MustCompile(`^(mrk-[0-9a-f]{32}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// resolveKMSKey resolves a KMS key by key ID or alias name
+func (d *Discoverer) resolveKMSKey(ctx context.Context, keyID string) (*graph.Node, error) {
+	d.log.Debug("Resolving KMS key", "keyId", keyID)
+
+	output, err := d.clients.KMS.DescribeKey(ctx, &kms.DescribeKeyInput{
+		KeyId: &keyID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe KMS key: %w", err)
+	}
+	if output.KeyMetadata == nil || output.KeyMetadata.Arn == nil {
+		return nil, fmt.Errorf("KMS key not found: %s", keyID)
+	}
+
+	node := kmsKeyToNode(*output.KeyMetadata.Arn)
+	setKMSKeyMetadata(node, output.KeyMetadata)
+	return node, nil
+}
+
+// discoverKMSKey fills in key state, rotation status and aliases for a KMS key.
+// Keys are leaves in the graph, so no neighbors are returned.
+func (d *Discoverer) discoverKMSKey(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering KMS key details", "id", node.ID)
+
+	// Keys referenced by bare ID have no ARN until described
+	keyRef := node.ARN
+	if keyRef == "" {
+		keyRef = node.ID
+	}
+
+	output, err := d.clients.KMS.DescribeKey(ctx, &kms.DescribeKeyInput{
+		KeyId: &keyRef,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe KMS key: %w", err)
+	}
+	if output.KeyMetadata == nil {
+		return nil, nil
+	}
+	setKMSKeyMetadata(node, output.KeyMetadata)
+
+	// Rotation status is only available for customer managed keys
+	if output.KeyMetadata.KeyManager == kmstypes.KeyManagerTypeCustomer {
+		rotation, rotationErr := d.clients.KMS.GetKeyRotationStatus(ctx, &kms.GetKeyRotationStatusInput{
+			KeyId: &keyRef,
+		})
+		if rotationErr != nil {
+			d.log.Debug("Failed to get KMS key rotation status", "key", keyRef, "error", rotationErr)
+		} else {
+			node.Metadata["rotationEnabled"] = rotation.KeyRotationEnabled
+		}
+	}
+
+	if output.KeyMetadata.KeyId != nil {
+		aliases, aliasErr := d.listKMSAliases(ctx, *output.KeyMetadata.KeyId)
+		if aliasErr != nil {
+			d.log.Debug("Failed to list KMS aliases", "key", keyRef, "error", aliasErr)
+		} else if len(aliases) > 0 {
+			node.Metadata["alias"] = aliases[0]
+			node.Metadata["aliases"] = aliases
+		}
+	}
+
+	return nil, nil
+}
+
+// listKMSAliases lists the alias names pointing at a key
+func (d *Discoverer) listKMSAliases(ctx context.Context, keyID string) ([]string, error) {
+	var aliases []string
+
+	paginator := kms.NewListAliasesPaginator(d.clients.KMS, &kms.ListAliasesInput{
+		KeyId: &keyID,
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *kms.ListAliasesOutput) error {
+		for i := range output.Aliases {
+			if output.Aliases[i].AliasName != nil {
+				aliases = append(aliases, *output.Aliases[i].AliasName)
+			}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", pageErr)
+	}
+
+	return aliases, nil
+}
+
+// addKMSKeyEdge links a resource to the KMS key that encrypts it, sharing one node per key
+func addKMSKeyEdge(g *graph.Graph, from *graph.Node, keyARN, apiCall, field string) string {
+	keyNode := kmsKeyToNode(keyARN)
+	if keyNode.Region == "" {
+		keyNode.Region = from.Region
+	}
+	if keyNode.Account == "" {
+		keyNode.Account = from.Account
+	}
+
+	if !g.HasNode(keyNode.ID) {
+		g.AddNode(keyNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         from.ID,
+		To:           keyNode.ID,
+		RelationType: "encrypted-with",
+		Evidence: graph.Evidence{
+			APICall: apiCall,
+			Fields: map[string]any{
+				field: keyARN,
+			},
+		},
+	})
+
+	return keyNode.ID
+}
+
+// kmsKeyToNode creates a KMS key node from a key ARN, alias ARN or bare key ID
+func kmsKeyToNode(keyARN string) *graph.Node {
+	node := &graph.Node{
+		ID:       keyARN,
+		Type:     ResourceTypeKMSKey,
+		Name:     keyARN,
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:aws:kms:region:account:key/key-id or alias/alias-name
+	parts := strings.SplitN(keyARN, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		node.Metadata["keyId"] = keyARN
+		return node
+	}
+
+	node.ARN = keyARN
+	node.Region = parts[3]
+	node.Account = parts[4]
+
+	resource := parts[5]
+	switch {
+	case strings.HasPrefix(resource, "key/"):
+		node.Name = strings.TrimPrefix(resource, "key/")
+		node.Metadata["keyId"] = node.Name
+	case strings.HasPrefix(resource, "alias/"):
+		node.Name = resource
+		node.Metadata["alias"] = resource
+	}
+
+	return node
+}
+
+// setKMSKeyMetadata records key state and ownership from DescribeKey
+func setKMSKeyMetadata(node *graph.Node, metadata *kmstypes.KeyMetadata) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	node.Metadata["keyState"] = metadata.KeyState
+	node.Metadata["keyManager"] = metadata.KeyManager
+	node.Metadata["keySpec"] = metadata.KeySpec
+	node.Metadata["enabled"] = metadata.Enabled
+	if metadata.Description != nil && *metadata.Description != "" {
+		node.Metadata["description"] = *metadata.Description
+	}
+}
@@ -0,0 +1,100 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestKMSKeyToNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyARN      string
+		wantName    string
+		wantARN     string
+		wantRegion  string
+		wantAccount string
+		wantKeyID   string
+	}{
+		{
+			name:        "Key ARN",
+			keyARN:      "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantName:    "1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantARN:     "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantRegion:  "us-east-1",
+			wantAccount: "123456789012",
+			wantKeyID:   "1234abcd-12ab-34cd-56ef-1234567890ab",
+		},
+		{
+			name:        "Alias ARN",
+			keyARN:      "arn:aws:kms:eu-west-1:123456789012:alias/rds-prod",
+			wantName:    "alias/rds-prod",
+			wantARN:     "arn:aws:kms:eu-west-1:123456789012:alias/rds-prod",
+			wantRegion:  "eu-west-1",
+			wantAccount: "123456789012",
+		},
+		{
+			name:      "Bare key ID",
+			keyARN:    "1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantName:  "1234abcd-12ab-34cd-56ef-1234567890ab",
+			wantKeyID: "1234abcd-12ab-34cd-56ef-1234567890ab",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := kmsKeyToNode(tt.keyARN)
+
+			if node.Type != ResourceTypeKMSKey {
+				t.Errorf("kmsKeyToNode() Type = %v, want %v", node.Type, ResourceTypeKMSKey)
+			}
+			if node.ID != tt.keyARN {
+				t.Errorf("kmsKeyToNode() ID = %v, want %v", node.ID, tt.keyARN)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("kmsKeyToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+			if node.ARN != tt.wantARN {
+				t.Errorf("kmsKeyToNode() ARN = %v, want %v", node.ARN, tt.wantARN)
+			}
+			if node.Region != tt.wantRegion {
+				t.Errorf("kmsKeyToNode() Region = %v, want %v", node.Region, tt.wantRegion)
+			}
+			if node.Account != tt.wantAccount {
+				t.Errorf("kmsKeyToNode() Account = %v, want %v", node.Account, tt.wantAccount)
+			}
+			if tt.wantKeyID != "" && node.Metadata["keyId"] != tt.wantKeyID {
+				t.Errorf("kmsKeyToNode() keyId = %v, want %v", node.Metadata["keyId"], tt.wantKeyID)
+			}
+		})
+	}
+}
+
+func TestAddKMSKeyEdgeSharesNode(t *testing.T) {
+	g := graph.New()
+	keyARN := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+	db := &graph.Node{ID: "db", Type: ResourceTypeRDSInstance}
+	fn := &graph.Node{ID: "fn", Type: ResourceTypeLambda}
+	g.AddNode(db)
+	g.AddNode(fn)
+
+	addKMSKeyEdge(g, db, keyARN, "DescribeDBInstances", "KmsKeyId")
+	keyID := addKMSKeyEdge(g, fn, keyARN, "GetFunction", "KMSKeyArn")
+
+	if keyID != keyARN {
+		t.Errorf("addKMSKeyEdge() returned %q, want %q", keyID, keyARN)
+	}
+	if g.NodeCount() != 3 {
+		t.Errorf("addKMSKeyEdge() expected a single shared key node, got %d nodes", g.NodeCount())
+	}
+	edges := g.EdgesTo(keyARN)
+	if len(edges) != 2 {
+		t.Fatalf("addKMSKeyEdge() expected 2 edges to key, got %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.RelationType != "encrypted-with" {
+			t.Errorf("addKMSKeyEdge() relation = %q, want encrypted-with", edge.RelationType)
+		}
+	}
+}
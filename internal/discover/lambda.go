@@ -4,18 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
 
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
 // resolveLambdaFunction resolves a Lambda function by name
 func (d *Discoverer) resolveLambdaFunction(ctx context.Context, name string) (*graph.Node, error) {
-	slog.Debug("Resolving Lambda function", "name", name)
+	d.log.Debug("Resolving Lambda function", "name", name)
 
 	output, err := d.clients.Lambda.GetFunction(ctx, &lambda.GetFunctionInput{
 		FunctionName: &name,
@@ -29,7 +30,7 @@ func (d *Discoverer) resolveLambdaFunction(ctx context.Context, name string) (*g
 
 // discoverLambda discovers dependencies for a Lambda function
 func (d *Discoverer) discoverLambda(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering Lambda function dependencies", "arn", node.ARN)
+	d.log.Debug("Discovering Lambda function dependencies", "arn", node.ARN)
 
 	var neighbors []string
 
@@ -79,7 +80,7 @@ func (d *Discoverer) discoverLambda(ctx context.Context, node *graph.Node, g *gr
 		// Add security groups
 		for _, sgID := range config.VpcConfig.SecurityGroupIds {
 			sgNode := &graph.Node{
-				ID:      sgID,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, sgID),
 				Type:    "SecurityGroup",
 				Name:    sgID,
 				Region:  node.Region,
@@ -103,7 +104,7 @@ func (d *Discoverer) discoverLambda(ctx context.Context, node *graph.Node, g *gr
 		// Add subnets
 		for _, subnetID := range config.VpcConfig.SubnetIds {
 			subnetNode := &graph.Node{
-				ID:      subnetID,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSubnet, subnetID),
 				Type:    "Subnet",
 				Name:    subnetID,
 				Region:  node.Region,
@@ -125,43 +126,73 @@ func (d *Discoverer) discoverLambda(ctx context.Context, node *graph.Node, g *gr
 		}
 	}
 
+	// Discover EFS access points mounted by the function
+	neighbors = append(neighbors, discoverLambdaFileSystems(config.FileSystemConfigs, node, g)...)
+
+	// Discover the layers and container image the function's code comes from
+	neighbors = append(neighbors, discoverLambdaLayers(config.Layers, node, g)...)
+	if config.PackageType == lambdatypes.PackageTypeImage && output.Code != nil {
+		neighbors = append(neighbors, discoverLambdaImage(output.Code, node, g)...)
+	}
+
 	// Discover Dead Letter Queue
 	if config.DeadLetterConfig != nil && config.DeadLetterConfig.TargetArn != nil {
-		dlqNode := &graph.Node{
-			ID:      *config.DeadLetterConfig.TargetArn,
-			Type:    "DLQ",
-			ARN:     *config.DeadLetterConfig.TargetArn,
-			Name:    extractNameFromARN(*config.DeadLetterConfig.TargetArn),
-			Region:  node.Region,
-			Account: node.Account,
-		}
-		g.AddNode(dlqNode)
-		g.AddEdge(&graph.Edge{
-			From:         node.ID,
-			To:           dlqNode.ID,
-			RelationType: "sends-failures-to",
-			Evidence: graph.Evidence{
-				APICall: "GetFunction",
-				Fields: map[string]any{
-					"TargetArn": *config.DeadLetterConfig.TargetArn,
-				},
-			},
-		})
-		neighbors = append(neighbors, dlqNode.ID)
+		neighbors = append(neighbors, addLambdaDLQ(g, node, *config.DeadLetterConfig.TargetArn))
+	}
+
+	// Discover secrets and parameters referenced by ARN in environment variables
+	if d.hasHeuristic("env-arn") && config.Environment != nil {
+		neighbors = append(neighbors, d.discoverEnvSecretReferences(config.Environment.Variables, "GetFunction", node, g)...)
+	}
+
+	// Discover the key encrypting environment variables
+	if config.KMSKeyArn != nil {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *config.KMSKeyArn, "GetFunction", "KMSKeyArn"))
 	}
 
 	// Discover event source mappings
 	eventSourceNeighbors, eventSourceErr := d.discoverEventSourceMappings(ctx, node.ARN, node, g)
 	if eventSourceErr != nil {
-		slog.Warn("Failed to discover event source mappings", "error", eventSourceErr)
+		d.warn(node.ID, "Failed to discover event source mappings", eventSourceErr)
+	}
+	neighbors = append(neighbors, eventSourceNeighbors...)
+
+	// Discover API Gateway REST/HTTP APIs that invoke this function
+	apiNeighbors, apiErr := d.discoverAPIGatewayUpstream(ctx, node, g)
+	if apiErr != nil {
+		d.warn(node.ID, "Failed to discover API Gateway integrations", apiErr)
 	} else {
-		neighbors = append(neighbors, eventSourceNeighbors...)
+		neighbors = append(neighbors, apiNeighbors...)
+	}
+
+	// Discover EventBridge rules that route events to this function
+	ruleNeighbors, ruleErr := d.discoverEventBridgeUpstream(ctx, node, g)
+	if ruleErr != nil {
+		d.warn(node.ID, "Failed to discover EventBridge rules", ruleErr)
+	} else {
+		neighbors = append(neighbors, ruleNeighbors...)
+	}
+
+	// Discover the function URL, if any
+	urlNeighbors, urlErr := d.discoverFunctionURL(ctx, functionName, node, g)
+	if urlErr != nil {
+		d.warn(node.ID, "Failed to discover function URL", urlErr)
+	} else {
+		neighbors = append(neighbors, urlNeighbors...)
+	}
+
+	// Discover services the resource policy allows to invoke this function
+	invokerNeighbors, invokerErr := d.discoverPolicyInvokers(ctx, functionName, node, g)
+	if invokerErr != nil {
+		d.warn(node.ID, "Failed to discover function policy invokers", invokerErr)
+	} else {
+		neighbors = append(neighbors, invokerNeighbors...)
 	}
 
 	// Discover function event invoke config (destinations)
 	destinationNeighbors, destErr := d.discoverFunctionDestinations(ctx, functionName, node, g)
 	if destErr != nil {
-		slog.Warn("Failed to discover function destinations", "error", destErr)
+		d.warn(node.ID, "Failed to discover function destinations", destErr)
 	} else {
 		neighbors = append(neighbors, destinationNeighbors...)
 	}
@@ -171,7 +202,7 @@ func (d *Discoverer) discoverLambda(ctx context.Context, node *graph.Node, g *gr
 
 // discoverEventSourceMappings discovers event source mappings for a Lambda function
 func (d *Discoverer) discoverEventSourceMappings(ctx context.Context, functionARN string, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering event source mappings", "functionArn", functionARN)
+	d.log.Debug("Discovering event source mappings", "functionArn", functionARN)
 
 	var neighbors []string
 
@@ -179,12 +210,7 @@ func (d *Discoverer) discoverEventSourceMappings(ctx context.Context, functionAR
 		FunctionName: &functionARN,
 	})
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list event source mappings: %w", err)
-		}
-
+	pageErr := awsx.EachPage(ctx, paginator, func(output *lambda.ListEventSourceMappingsOutput) error {
 		for i := range output.EventSourceMappings {
 			mapping := &output.EventSourceMappings[i]
 			if mapping.EventSourceArn == nil {
@@ -239,14 +265,7 @@ func (d *Discoverer) discoverEventSourceMappings(ctx context.Context, functionAR
 
 			// Discover destination on failure for event source mapping
 			if mapping.DestinationConfig != nil && mapping.DestinationConfig.OnFailure != nil && mapping.DestinationConfig.OnFailure.Destination != nil {
-				destNode := &graph.Node{
-					ID:      *mapping.DestinationConfig.OnFailure.Destination,
-					Type:    "EventDestination",
-					ARN:     *mapping.DestinationConfig.OnFailure.Destination,
-					Name:    extractNameFromARN(*mapping.DestinationConfig.OnFailure.Destination),
-					Region:  lambdaNode.Region,
-					Account: lambdaNode.Account,
-				}
+				destNode := messagingTargetNode(*mapping.DestinationConfig.OnFailure.Destination, ResourceTypeEventDestination, lambdaNode)
 				g.AddNode(destNode)
 				g.AddEdge(&graph.Edge{
 					From:         sourceNode.ID,
@@ -262,6 +281,10 @@ func (d *Discoverer) discoverEventSourceMappings(ctx context.Context, functionAR
 				neighbors = append(neighbors, destNode.ID)
 			}
 		}
+		return nil
+	})
+	if pageErr != nil {
+		return neighbors, fmt.Errorf("failed to list event source mappings: %w", pageErr)
 	}
 
 	return neighbors, nil
@@ -269,7 +292,7 @@ func (d *Discoverer) discoverEventSourceMappings(ctx context.Context, functionAR
 
 // discoverFunctionDestinations discovers Lambda function event invoke config destinations
 func (d *Discoverer) discoverFunctionDestinations(ctx context.Context, functionName string, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering function destinations", "function", functionName)
+	d.log.Debug("Discovering function destinations", "function", functionName)
 
 	var neighbors []string
 
@@ -281,7 +304,7 @@ func (d *Discoverer) discoverFunctionDestinations(ctx context.Context, functionN
 		// Not all functions have event invoke config - ResourceNotFoundException is expected
 		var notFoundErr *lambdatypes.ResourceNotFoundException
 		if errors.As(err, &notFoundErr) {
-			slog.Debug("No event invoke config found", "function", functionName)
+			d.log.Debug("No event invoke config found", "function", functionName)
 			return neighbors, nil
 		}
 		// Other errors should be returned
@@ -292,63 +315,233 @@ func (d *Discoverer) discoverFunctionDestinations(ctx context.Context, functionN
 		return neighbors, nil
 	}
 
-	// Discover OnSuccess destination
+	// Discover OnSuccess and OnFailure destinations
 	if output.DestinationConfig.OnSuccess != nil && output.DestinationConfig.OnSuccess.Destination != nil {
-		destNode := &graph.Node{
-			ID:      *output.DestinationConfig.OnSuccess.Destination,
-			Type:    "EventDestination",
-			ARN:     *output.DestinationConfig.OnSuccess.Destination,
-			Name:    extractNameFromARN(*output.DestinationConfig.OnSuccess.Destination),
-			Region:  lambdaNode.Region,
-			Account: lambdaNode.Account,
-			Metadata: map[string]any{
-				"destinationType": "OnSuccess",
-			},
+		neighbors = append(neighbors, addLambdaDestination(g, lambdaNode, *output.DestinationConfig.OnSuccess.Destination, "OnSuccess", "sends-success-to"))
+	}
+	if output.DestinationConfig.OnFailure != nil && output.DestinationConfig.OnFailure.Destination != nil {
+		neighbors = append(neighbors, addLambdaDestination(g, lambdaNode, *output.DestinationConfig.OnFailure.Destination, "OnFailure", "sends-failures-to"))
+	}
+
+	return neighbors, nil
+}
+
+// discoverLambdaLayers links a function to the layer versions it uses. Layers shared by several
+// functions become a single node, so a bad layer version shows every function it breaks.
+func discoverLambdaLayers(layers []lambdatypes.Layer, lambdaNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	for i := range layers {
+		layer := &layers[i]
+		if layer.Arn == nil {
+			continue
+		}
+
+		layerNode := lambdaLayerToNode(*layer.Arn)
+		if layer.CodeSize != 0 {
+			layerNode.Metadata["codeSize"] = layer.CodeSize
+		}
+		if !g.HasNode(layerNode.ID) {
+			g.AddNode(layerNode)
 		}
-		g.AddNode(destNode)
 		g.AddEdge(&graph.Edge{
 			From:         lambdaNode.ID,
-			To:           destNode.ID,
-			RelationType: "sends-success-to",
+			To:           layerNode.ID,
+			RelationType: "uses-layer",
 			Evidence: graph.Evidence{
-				APICall: "GetFunctionEventInvokeConfig",
+				APICall: "GetFunction",
 				Fields: map[string]any{
-					"Destination": *output.DestinationConfig.OnSuccess.Destination,
+					"LayerArn": *layer.Arn,
 				},
 			},
 		})
-		neighbors = append(neighbors, destNode.ID)
+		neighbors = append(neighbors, layerNode.ID)
 	}
 
-	// Discover OnFailure destination
-	if output.DestinationConfig.OnFailure != nil && output.DestinationConfig.OnFailure.Destination != nil {
-		destNode := &graph.Node{
-			ID:      *output.DestinationConfig.OnFailure.Destination,
-			Type:    "EventDestination",
-			ARN:     *output.DestinationConfig.OnFailure.Destination,
-			Name:    extractNameFromARN(*output.DestinationConfig.OnFailure.Destination),
-			Region:  lambdaNode.Region,
-			Account: lambdaNode.Account,
-			Metadata: map[string]any{
-				"destinationType": "OnFailure",
+	return neighbors
+}
+
+// discoverLambdaLayer links a layer back to every function in its account and region that
+// uses it, so deleting a layer version shows each function it breaks. A layer ARN without a
+// version matches functions using any of its versions.
+func (d *Discoverer) discoverLambdaLayer(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Lambda layer users", "arn", node.ID)
+
+	index, err := d.lambdaLayerIndex(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	versioned := metadataString(node, "version") != ""
+	var layerARNs []string
+	for layerARN := range index {
+		if layerARN == node.ID || (!versioned && strings.HasPrefix(layerARN, node.ID+":")) {
+			layerARNs = append(layerARNs, layerARN)
+		}
+	}
+	sort.Strings(layerARNs)
+
+	var neighbors []string
+	for _, layerARN := range layerARNs {
+		for _, functionNode := range index[layerARN] {
+			if !g.HasNode(functionNode.ID) {
+				g.AddNode(functionNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         functionNode.ID,
+				To:           node.ID,
+				RelationType: "uses-layer",
+				Evidence: graph.Evidence{
+					APICall: "ListFunctions",
+					Fields: map[string]any{
+						"LayerArn": layerARN,
+					},
+				},
+			})
+			neighbors = append(neighbors, functionNode.ID)
+		}
+	}
+
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	node.Metadata["functionCount"] = len(neighbors)
+	return neighbors, nil
+}
+
+// lambdaLayerIndex lazily builds an index of the functions in the layer node's account and
+// region, keyed by the layer version ARNs they use
+func (d *Discoverer) lambdaLayerIndex(ctx context.Context, layerNode *graph.Node) (map[string][]*graph.Node, error) {
+	if index, ok := d.layerFunctions[scopeKey(layerNode)]; ok {
+		return index, nil
+	}
+
+	index := make(map[string][]*graph.Node)
+	paginator := lambda.NewListFunctionsPaginator(d.clients.Lambda, &lambda.ListFunctionsInput{})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *lambda.ListFunctionsOutput) error {
+		for i := range output.Functions {
+			config := &output.Functions[i]
+			if config.FunctionArn == nil {
+				continue
+			}
+			functionNode := d.lambdaFunctionToNode(config)
+			for _, layer := range config.Layers {
+				if layer.Arn != nil {
+					index[*layer.Arn] = append(index[*layer.Arn], functionNode)
+				}
+			}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to list Lambda functions: %w", pageErr)
+	}
+
+	if d.layerFunctions == nil {
+		d.layerFunctions = make(map[string]map[string][]*graph.Node)
+	}
+	d.layerFunctions[scopeKey(layerNode)] = index
+	return index, nil
+}
+
+// lambdaLayerToNode creates a node for a layer version ARN of the form
+// arn:aws:lambda:region:account:layer:name:version. An ARN without the version names the
+// layer itself.
+func lambdaLayerToNode(arn string) *graph.Node {
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeLambdaLayer,
+		ARN:      arn,
+		Name:     arn,
+		Metadata: make(map[string]any),
+	}
+
+	parts := strings.Split(arn, ":")
+	switch {
+	case len(parts) == 8 && parts[5] == "layer":
+		node.Region = parts[3]
+		node.Account = parts[4]
+		node.Name = parts[6] + ":" + parts[7]
+		node.Metadata["layerName"] = parts[6]
+		node.Metadata["version"] = parts[7]
+	case len(parts) == 7 && parts[5] == "layer":
+		node.Region = parts[3]
+		node.Account = parts[4]
+		node.Name = parts[6]
+		node.Metadata["layerName"] = parts[6]
+	}
+	return node
+}
+
+// discoverLambdaImage links a container image function to the image it runs and, for ECR
+// images, the image to its repository
+func discoverLambdaImage(code *lambdatypes.FunctionCodeLocation, lambdaNode *graph.Node, g *graph.Graph) []string {
+	if code.ImageUri == nil {
+		return nil
+	}
+	imageURI := *code.ImageUri
+
+	imageNode := &graph.Node{
+		ID:       imageURI,
+		Type:     ResourceTypeECRImage,
+		Name:     imageURI,
+		Region:   lambdaNode.Region,
+		Account:  lambdaNode.Account,
+		Metadata: make(map[string]any),
+	}
+	if code.ResolvedImageUri != nil {
+		imageNode.Metadata["resolvedImageUri"] = *code.ResolvedImageUri
+	}
+	ref, isECR := parseECRImage(imageURI)
+	if isECR {
+		imageNode.Name = ref.repository
+		imageNode.Region = ref.region
+		imageNode.Account = ref.account
+		if ref.tag != "" {
+			imageNode.Name += ":" + ref.tag
+			imageNode.Metadata["tag"] = ref.tag
+		}
+		if ref.digest != "" {
+			imageNode.Metadata["digest"] = ref.digest
+		}
+	}
+	if !g.HasNode(imageNode.ID) {
+		g.AddNode(imageNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         lambdaNode.ID,
+		To:           imageNode.ID,
+		RelationType: "uses-image",
+		Evidence: graph.Evidence{
+			APICall: "GetFunction",
+			Fields: map[string]any{
+				"ImageUri":         imageURI,
+				"ResolvedImageUri": code.ResolvedImageUri,
 			},
+		},
+	})
+	neighbors := []string{imageNode.ID}
+
+	// Repositories are described when expanded, like those found from task definitions
+	if isECR {
+		repoNode := ecrRepositoryToNode(ref)
+		if !g.HasNode(repoNode.ID) {
+			g.AddNode(repoNode)
 		}
-		g.AddNode(destNode)
 		g.AddEdge(&graph.Edge{
-			From:         lambdaNode.ID,
-			To:           destNode.ID,
-			RelationType: "sends-failures-to",
+			From:         imageNode.ID,
+			To:           repoNode.ID,
+			RelationType: "stored-in",
 			Evidence: graph.Evidence{
-				APICall: "GetFunctionEventInvokeConfig",
+				APICall: "GetFunction",
 				Fields: map[string]any{
-					"Destination": *output.DestinationConfig.OnFailure.Destination,
+					"ImageUri": imageURI,
 				},
 			},
 		})
-		neighbors = append(neighbors, destNode.ID)
+		neighbors = append(neighbors, repoNode.ID)
 	}
 
-	return neighbors, nil
+	return neighbors
 }
 
 // Helper function to convert Lambda function to graph node
@@ -413,3 +606,82 @@ func (d *Discoverer) lambdaFunctionToNode(config *lambdatypes.FunctionConfigurat
 		Metadata: metadata,
 	}
 }
+
+// addLambdaDLQ links a function to its dead-letter queue or topic and returns the target's ID.
+// The target is typed by service so it merges with the same queue or topic reached another way,
+// keeping that node's metadata.
+func addLambdaDLQ(g *graph.Graph, lambdaNode *graph.Node, arn string) string {
+	if !g.HasNode(arn) {
+		g.AddNode(dlqNode(arn, lambdaNode))
+	}
+	g.AddEdge(&graph.Edge{
+		From:         lambdaNode.ID,
+		To:           arn,
+		RelationType: "sends-failures-to",
+		Evidence: graph.Evidence{
+			APICall: "GetFunction",
+			Fields: map[string]any{
+				"TargetArn": arn,
+			},
+		},
+	})
+	return arn
+}
+
+// addLambdaDestination links a function to an asynchronous invocation destination. A queue or
+// topic already in the graph keeps its node; which destination it is goes on the edge.
+func addLambdaDestination(g *graph.Graph, lambdaNode *graph.Node, arn, destinationType, relation string) string {
+	destNode := messagingTargetNode(arn, ResourceTypeEventDestination, lambdaNode)
+	if !g.HasNode(destNode.ID) {
+		g.AddNode(destNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         lambdaNode.ID,
+		To:           destNode.ID,
+		RelationType: relation,
+		Evidence: graph.Evidence{
+			APICall: "GetFunctionEventInvokeConfig",
+			Fields: map[string]any{
+				"Destination":     arn,
+				"destinationType": destinationType,
+			},
+		},
+	})
+	return destNode.ID
+}
+
+// dlqNode builds the node for a dead-letter target, typed SQSQueue or SNSTopic from its ARN.
+// Targets of another service keep the generic DLQ type.
+func dlqNode(arn string, lambdaNode *graph.Node) *graph.Node {
+	return messagingTargetNode(arn, ResourceTypeDLQ, lambdaNode)
+}
+
+// messagingTargetNode builds the node for a queue or topic a resource sends to, typed SQSQueue
+// or SNSTopic from its ARN so that every sender of a shared topic links to the same node.
+// Targets of another service get fallbackType and the source's region and account.
+func messagingTargetNode(arn, fallbackType string, source *graph.Node) *graph.Node {
+	node := &graph.Node{
+		ID:      arn,
+		Type:    fallbackType,
+		ARN:     arn,
+		Name:    extractNameFromARN(arn),
+		Region:  source.Region,
+		Account: source.Account,
+	}
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 {
+		return node
+	}
+	switch parts[2] {
+	case "sqs":
+		node.Type = ResourceTypeSQSQueue
+	case "sns":
+		node.Type = ResourceTypeSNSTopic
+	default:
+		return node
+	}
+	// SQS and SNS ARNs end in the name, and carry their own region and account
+	node.Name = parts[5]
+	node.Region, node.Account = parts[3], parts[4]
+	return node
+}
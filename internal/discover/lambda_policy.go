@@ -0,0 +1,244 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// lambdaInvoker is a service principal a function's resource policy allows to invoke it,
+// optionally scoped to a source resource by an AWS:SourceArn condition
+type lambdaInvoker struct {
+	sid           string
+	service       string
+	sourceARN     string
+	sourceAccount string
+}
+
+// discoverFunctionURL links a function to its function URL. The URL node points at the
+// function so that exposure flows from the URL, like an API Gateway integration.
+// Functions without a URL are skipped.
+func (d *Discoverer) discoverFunctionURL(ctx context.Context, functionName string, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering function URL", "function", functionName)
+
+	output, err := d.clients.Lambda.GetFunctionUrlConfig(ctx, &lambda.GetFunctionUrlConfigInput{
+		FunctionName: &functionName,
+	})
+	if err != nil {
+		var notFoundErr *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.log.Debug("No function URL found", "function", functionName)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get function URL config: %w", err)
+	}
+	if output.FunctionUrl == nil {
+		return nil, nil
+	}
+
+	urlNode := &graph.Node{
+		ID:      *output.FunctionUrl,
+		Type:    ResourceTypeLambdaFunctionURL,
+		Name:    *output.FunctionUrl,
+		Region:  lambdaNode.Region,
+		Account: lambdaNode.Account,
+		Metadata: map[string]any{
+			"authType":   output.AuthType,
+			"invokeMode": output.InvokeMode,
+		},
+	}
+	g.AddNode(urlNode)
+	g.AddEdge(&graph.Edge{
+		From:         urlNode.ID,
+		To:           lambdaNode.ID,
+		RelationType: "exposed-via-url",
+		Evidence: graph.Evidence{
+			APICall: "GetFunctionUrlConfig",
+			Fields: map[string]any{
+				"FunctionUrl": *output.FunctionUrl,
+				"AuthType":    output.AuthType,
+			},
+		},
+	})
+
+	return []string{urlNode.ID}, nil
+}
+
+// discoverPolicyInvokers adds a can-invoke edge from each service its resource policy allows
+// to invoke the function. When the statement names a source ARN the edge starts at that
+// resource, otherwise at the service principal. Functions without a policy are skipped.
+func (d *Discoverer) discoverPolicyInvokers(ctx context.Context, functionName string, lambdaNode *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering function policy invokers", "function", functionName)
+
+	output, err := d.clients.Lambda.GetPolicy(ctx, &lambda.GetPolicyInput{
+		FunctionName: &functionName,
+	})
+	if err != nil {
+		var notFoundErr *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			d.log.Debug("No function policy found", "function", functionName)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get function policy: %w", err)
+	}
+	if output.Policy == nil {
+		return nil, nil
+	}
+
+	invokers, err := parseLambdaInvokers(*output.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for _, invoker := range invokers {
+		invokerNode := d.invokerToNode(g, invoker)
+		if !g.HasNode(invokerNode.ID) {
+			g.AddNode(invokerNode)
+		}
+
+		fields := map[string]any{
+			"Principal": invoker.service,
+		}
+		if invoker.sid != "" {
+			fields["Sid"] = invoker.sid
+		}
+		if invoker.sourceARN != "" {
+			fields["SourceArn"] = invoker.sourceARN
+		}
+		if invoker.sourceAccount != "" {
+			fields["SourceAccount"] = invoker.sourceAccount
+		}
+		g.AddEdge(&graph.Edge{
+			From:         invokerNode.ID,
+			To:           lambdaNode.ID,
+			RelationType: "can-invoke",
+			Evidence: graph.Evidence{
+				APICall: "GetPolicy",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, invokerNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// parseLambdaInvokers returns the service principals a function policy allows, one per
+// statement and source ARN, in document order. Deny statements and non-service principals
+// are skipped.
+func parseLambdaInvokers(document string) ([]lambdaInvoker, error) {
+	doc, err := decodePolicyDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var invokers []lambdaInvoker
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+
+		sourceARNs := statement.Condition.values("AWS:SourceArn", "ArnLike", "ArnEquals", "StringLike", "StringEquals")
+		sourceAccounts := statement.Condition.values("AWS:SourceAccount", "StringEquals", "StringLike")
+		var sourceAccount string
+		if len(sourceAccounts) > 0 {
+			sourceAccount = sourceAccounts[0]
+		}
+		if len(sourceARNs) == 0 {
+			sourceARNs = []string{""}
+		}
+
+		for _, service := range statement.Principal["Service"] {
+			for _, sourceARN := range sourceARNs {
+				invokers = append(invokers, lambdaInvoker{
+					sid:           statement.Sid,
+					service:       service,
+					sourceARN:     sourceARN,
+					sourceAccount: sourceAccount,
+				})
+			}
+		}
+	}
+
+	return invokers, nil
+}
+
+// values returns the values of a condition key under any of the given operators. Condition
+// keys are case-insensitive, so AWS:SourceArn also matches aws:sourcearn.
+func (c policyCondition) values(key string, operators ...string) []string {
+	var values []string
+	for _, operator := range operators {
+		keys := make([]string, 0, len(c[operator]))
+		for k := range c[operator] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if strings.EqualFold(k, key) {
+				values = append(values, c[operator][k]...)
+			}
+		}
+	}
+	return values
+}
+
+// invokerToNode creates the upstream node for a policy invoker. API Gateway source ARNs
+// (execute-api) reuse an API node already in the graph when there is one.
+func (d *Discoverer) invokerToNode(g *graph.Graph, invoker lambdaInvoker) *graph.Node {
+	if invoker.sourceARN == "" {
+		return d.principalToNode(allowedPrincipal{kind: "Service", id: invoker.service})
+	}
+
+	// ARN format: arn:partition:service:region:account:resource
+	parts := strings.SplitN(invoker.sourceARN, ":", 6)
+	if len(parts) != 6 {
+		return d.policyResourceToNode(invoker.sourceARN)
+	}
+
+	switch parts[2] {
+	case "execute-api":
+		// arn:aws:execute-api:region:account:api-id/stage/method/path
+		apiID, _, _ := strings.Cut(parts[5], "/")
+		for _, prefix := range []string{"/restapis/", "/apis/"} {
+			id := fmt.Sprintf("arn:aws:apigateway:%s::%s%s", parts[3], prefix, apiID)
+			if node, ok := g.GetNode(id); ok {
+				return node
+			}
+		}
+		node := d.policyResourceToNode(fmt.Sprintf("arn:%s:execute-api:%s:%s:%s", parts[1], parts[3], parts[4], apiID))
+		node.Name = apiID
+		node.Metadata["apiId"] = apiID
+		return node
+	case "s3":
+		// arn:aws:s3:::bucket
+		return &graph.Node{
+			ID:       invoker.sourceARN,
+			Type:     ResourceTypeS3Bucket,
+			ARN:      invoker.sourceARN,
+			Name:     parts[5],
+			Account:  invoker.sourceAccount,
+			Metadata: make(map[string]any),
+		}
+	case "sns":
+		// arn:aws:sns:region:account:topic
+		return &graph.Node{
+			ID:       invoker.sourceARN,
+			Type:     ResourceTypeSNSTopic,
+			ARN:      invoker.sourceARN,
+			Name:     parts[5],
+			Region:   parts[3],
+			Account:  parts[4],
+			Metadata: make(map[string]any),
+		}
+	default:
+		return d.policyResourceToNode(invoker.sourceARN)
+	}
+}
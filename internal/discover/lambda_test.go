@@ -1,7 +1,15 @@
 package discover
 
 import (
+	"context"
+	"reflect"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
 func TestLambdaARNPatterns(t *testing.T) {
@@ -51,3 +59,421 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestDiscoverLambdaLayers(t *testing.T) {
+	const (
+		sharedLayer = "arn:aws:lambda:us-east-1:123456789012:layer:common-deps:7"
+		otherLayer  = "arn:aws:lambda:us-east-1:999999999999:layer:telemetry:3"
+	)
+	g := graph.New()
+	api := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:api", Type: ResourceTypeLambda}
+	worker := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:worker", Type: ResourceTypeLambda}
+	g.AddNode(api)
+	g.AddNode(worker)
+
+	discoverLambdaLayers([]lambdatypes.Layer{
+		{Arn: aws.String(sharedLayer), CodeSize: 1024},
+		{Arn: aws.String(otherLayer)},
+	}, api, g)
+	neighbors := discoverLambdaLayers([]lambdatypes.Layer{{Arn: aws.String(sharedLayer)}}, worker, g)
+
+	if len(neighbors) != 1 || neighbors[0] != sharedLayer {
+		t.Fatalf("discoverLambdaLayers() neighbors = %v, want [%s]", neighbors, sharedLayer)
+	}
+	if got := g.CountByType()[ResourceTypeLambdaLayer]; got != 2 {
+		t.Errorf("LambdaLayer nodes = %d, want 2 (shared layer deduplicated)", got)
+	}
+	if users := g.EdgesTo(sharedLayer); len(users) != 2 {
+		t.Errorf("edges to shared layer = %d, want one per function", len(users))
+	}
+
+	layer, _ := g.GetNode(otherLayer)
+	if layer == nil || layer.Name != "telemetry:3" || layer.Account != "999999999999" || layer.Metadata["version"] != "3" {
+		t.Errorf("layer node = %+v, want telemetry:3 in account 999999999999", layer)
+	}
+	for _, edge := range g.EdgesFrom(api.ID) {
+		if edge.RelationType != "uses-layer" {
+			t.Errorf("edge %s -> %s = %q, want uses-layer", edge.From, edge.To, edge.RelationType)
+		}
+	}
+}
+
+func TestDiscoverLambdaLayerUsers(t *testing.T) {
+	const (
+		layerV7 = "arn:aws:lambda:us-east-1:123456789012:layer:common-deps:7"
+		layerV8 = "arn:aws:lambda:us-east-1:123456789012:layer:common-deps:8"
+		api     = "arn:aws:lambda:us-east-1:123456789012:function:api"
+		worker  = "arn:aws:lambda:us-east-1:123456789012:function:worker"
+		report  = "arn:aws:lambda:us-east-1:123456789012:function:report"
+	)
+	tests := []struct {
+		name          string
+		layerARN      string
+		wantNeighbors []string
+	}{
+		{
+			name:          "layer version",
+			layerARN:      layerV7,
+			wantNeighbors: []string{api, worker},
+		},
+		{
+			name:          "layer without a version matches every version",
+			layerARN:      "arn:aws:lambda:us-east-1:123456789012:layer:common-deps",
+			wantNeighbors: []string{api, worker, report},
+		},
+		{
+			name:     "unused layer version",
+			layerARN: "arn:aws:lambda:us-east-1:123456789012:layer:common-deps:6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubAPI(map[string]any{
+				"ListFunctions": &lambda.ListFunctionsOutput{Functions: []lambdatypes.FunctionConfiguration{
+					{FunctionName: aws.String("api"), FunctionArn: aws.String(api), Layers: []lambdatypes.Layer{{Arn: aws.String(layerV7)}}},
+					{FunctionName: aws.String("worker"), FunctionArn: aws.String(worker), Layers: []lambdatypes.Layer{{Arn: aws.String(layerV7)}}},
+					{FunctionName: aws.String("report"), FunctionArn: aws.String(report), Layers: []lambdatypes.Layer{{Arn: aws.String(layerV8)}}},
+					{FunctionName: aws.String("plain"), FunctionArn: aws.String("arn:aws:lambda:us-east-1:123456789012:function:plain")},
+				}},
+			})
+			d := New(stubClients(stub), &Options{})
+			layer, err := d.parseARN(tt.layerARN)
+			if err != nil {
+				t.Fatalf("parseARN() error = %v", err)
+			}
+			g := graph.New()
+			g.AddNode(layer)
+
+			neighbors, err := d.discoverLambdaLayer(context.Background(), layer, g)
+			if err != nil {
+				t.Fatalf("discoverLambdaLayer() error = %v", err)
+			}
+			if !reflect.DeepEqual(neighbors, tt.wantNeighbors) {
+				t.Errorf("discoverLambdaLayer() neighbors = %v, want %v", neighbors, tt.wantNeighbors)
+			}
+			if layer.Metadata["functionCount"] != len(tt.wantNeighbors) {
+				t.Errorf("functionCount = %v, want %d", layer.Metadata["functionCount"], len(tt.wantNeighbors))
+			}
+			for _, id := range tt.wantNeighbors {
+				edges := g.EdgesBetween(id, layer.ID)
+				if len(edges) != 1 || edges[0].RelationType != "uses-layer" || edges[0].Evidence.APICall != "ListFunctions" {
+					t.Errorf("edges %s -> %s = %+v, want one uses-layer from ListFunctions", id, layer.ID, edges)
+				}
+			}
+
+			// The index is built once per account and region
+			if _, err = d.discoverLambdaLayer(context.Background(), layer, g); err != nil {
+				t.Fatalf("discoverLambdaLayer() second call error = %v", err)
+			}
+			if count := stub.callCount("ListFunctions"); count != 1 {
+				t.Errorf("ListFunctions calls = %d, want 1", count)
+			}
+		})
+	}
+}
+
+func TestDiscoverLambdaImage(t *testing.T) {
+	const repoARN = "arn:aws:ecr:us-east-1:123456789012:repository/orders/api"
+	tests := []struct {
+		name         string
+		imageURI     string
+		wantName     string
+		wantNeighbor []string
+	}{
+		{
+			name:         "ECR image",
+			imageURI:     "123456789012.dkr.ecr.us-east-1.amazonaws.com/orders/api:v42",
+			wantName:     "orders/api:v42",
+			wantNeighbor: []string{"123456789012.dkr.ecr.us-east-1.amazonaws.com/orders/api:v42", repoARN},
+		},
+		{
+			name:         "Other registry",
+			imageURI:     "public.example.com/orders/api:v42",
+			wantName:     "public.example.com/orders/api:v42",
+			wantNeighbor: []string{"public.example.com/orders/api:v42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.New()
+			fn := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:api", Type: ResourceTypeLambda}
+			g.AddNode(fn)
+
+			neighbors := discoverLambdaImage(&lambdatypes.FunctionCodeLocation{
+				ImageUri:         aws.String(tt.imageURI),
+				ResolvedImageUri: aws.String("123456789012.dkr.ecr.us-east-1.amazonaws.com/orders/api@sha256:abc"),
+			}, fn, g)
+
+			if !reflect.DeepEqual(neighbors, tt.wantNeighbor) {
+				t.Fatalf("discoverLambdaImage() neighbors = %v, want %v", neighbors, tt.wantNeighbor)
+			}
+			image, _ := g.GetNode(tt.imageURI)
+			if image == nil || image.Type != ResourceTypeECRImage || image.Name != tt.wantName {
+				t.Errorf("image node = %+v, want ECRImage %s", image, tt.wantName)
+			}
+			edges := g.EdgesFrom(fn.ID)
+			if len(edges) != 1 || edges[0].RelationType != "uses-image" {
+				t.Errorf("function edges = %v, want one uses-image edge", edges)
+			}
+			if len(tt.wantNeighbor) > 1 {
+				stored := g.EdgesFrom(tt.imageURI)
+				if len(stored) != 1 || stored[0].To != repoARN || stored[0].RelationType != "stored-in" {
+					t.Errorf("image edges = %v, want stored-in %s", stored, repoARN)
+				}
+			}
+		})
+	}
+}
+
+func TestDLQNode(t *testing.T) {
+	lambdaNode := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:api", Region: "us-east-1", Account: "123456789012"}
+	tests := []struct {
+		name        string
+		arn         string
+		wantType    string
+		wantName    string
+		wantAccount string
+	}{
+		{"SQS queue", "arn:aws:sqs:us-east-1:123456789012:api-failures", ResourceTypeSQSQueue, "api-failures", "123456789012"},
+		{"SNS topic in another account", "arn:aws:sns:us-east-1:999999999999:alerts", ResourceTypeSNSTopic, "alerts", "999999999999"},
+		{"unknown service", "arn:aws:events:us-east-1:123456789012:event-bus/failures", ResourceTypeDLQ, "failures", "123456789012"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := dlqNode(tt.arn, lambdaNode)
+			if node.ID != tt.arn || node.Type != tt.wantType || node.Name != tt.wantName || node.Account != tt.wantAccount {
+				t.Errorf("dlqNode() = %+v, want %s %s in %s", node, tt.wantType, tt.wantName, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestAddLambdaDLQMergesWithQueue(t *testing.T) {
+	const (
+		functionARN = "arn:aws:lambda:us-east-1:123456789012:function:api"
+		queueARN    = "arn:aws:sqs:us-east-1:123456789012:api-failures"
+	)
+	g := graph.New()
+	// The queue was already reached as an event source, with metadata of its own
+	g.AddNode(&graph.Node{ID: queueARN, Type: ResourceTypeSQSQueue, ARN: queueARN, Metadata: map[string]any{"batchSize": 10}})
+	node := &graph.Node{ID: functionARN, Type: ResourceTypeLambda, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(node)
+
+	if got := addLambdaDLQ(g, node, queueARN); got != queueARN {
+		t.Fatalf("addLambdaDLQ() = %q, want %q", got, queueARN)
+	}
+
+	queue, _ := g.GetNode(queueARN)
+	if queue.Type != ResourceTypeSQSQueue || queue.Metadata["batchSize"] != 10 {
+		t.Errorf("queue node = %+v, want the existing SQSQueue kept", queue)
+	}
+	if g.NodeCount() != 2 {
+		t.Errorf("graph has %d nodes, want the function and the queue", g.NodeCount())
+	}
+	edges := g.EdgesBetween(functionARN, queueARN)
+	if len(edges) != 1 || edges[0].RelationType != "sends-failures-to" {
+		t.Errorf("edges to queue = %+v, want one sends-failures-to", edges)
+	}
+}
+
+func TestAddLambdaDestinationKeepsExistingTopic(t *testing.T) {
+	const (
+		functionARN = "arn:aws:lambda:us-east-1:123456789012:function:api"
+		topicARN    = "arn:aws:sns:us-east-1:123456789012:alerts"
+	)
+	g := graph.New()
+	// The topic was already reached as an SES event destination
+	g.AddNode(&graph.Node{ID: topicARN, Type: ResourceTypeSNSTopic, ARN: topicARN, Metadata: map[string]any{"eventTypes": "BOUNCE"}})
+	node := &graph.Node{ID: functionARN, Type: ResourceTypeLambda, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(node)
+
+	if got := addLambdaDestination(g, node, topicARN, "OnFailure", "sends-failures-to"); got != topicARN {
+		t.Fatalf("addLambdaDestination() = %q, want %q", got, topicARN)
+	}
+
+	topic, _ := g.GetNode(topicARN)
+	if topic.Metadata["eventTypes"] != "BOUNCE" || topic.Metadata["destinationType"] != nil {
+		t.Errorf("topic metadata = %v, want the existing metadata kept", topic.Metadata)
+	}
+	edges := g.EdgesBetween(functionARN, topicARN)
+	if len(edges) != 1 || edges[0].Evidence.Fields["destinationType"] != "OnFailure" {
+		t.Errorf("edges to topic = %+v, want one with destinationType OnFailure", edges)
+	}
+}
+
+func TestParseLambdaInvokers(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []lambdaInvoker
+	}{
+		{
+			name: "API Gateway with source ARN",
+			document: `{"Version": "2012-10-17", "Statement": [{
+				"Sid": "apigw",
+				"Effect": "Allow",
+				"Principal": {"Service": "apigateway.amazonaws.com"},
+				"Action": "lambda:InvokeFunction",
+				"Resource": "arn:aws:lambda:us-east-1:123456789012:function:api",
+				"Condition": {"ArnLike": {"AWS:SourceArn": "arn:aws:execute-api:us-east-1:123456789012:abc123/*/GET/orders"}}
+			}]}`,
+			want: []lambdaInvoker{
+				{sid: "apigw", service: "apigateway.amazonaws.com", sourceARN: "arn:aws:execute-api:us-east-1:123456789012:abc123/*/GET/orders"},
+			},
+		},
+		{
+			name: "S3 with source account and lowercase key",
+			document: `{"Statement": {
+				"Effect": "Allow",
+				"Principal": {"Service": "s3.amazonaws.com"},
+				"Action": "lambda:InvokeFunction",
+				"Condition": {
+					"ArnLike": {"aws:sourcearn": "arn:aws:s3:::uploads"},
+					"StringEquals": {"AWS:SourceAccount": "123456789012"}
+				}
+			}}`,
+			want: []lambdaInvoker{
+				{service: "s3.amazonaws.com", sourceARN: "arn:aws:s3:::uploads", sourceAccount: "123456789012"},
+			},
+		},
+		{
+			name: "Multiple source ARNs and no condition",
+			document: `{"Statement": [
+				{
+					"Effect": "Allow",
+					"Principal": {"Service": "sns.amazonaws.com"},
+					"Action": "lambda:InvokeFunction",
+					"Condition": {"ArnEquals": {"AWS:SourceArn": ["arn:aws:sns:us-east-1:123456789012:a", "arn:aws:sns:us-east-1:123456789012:b"]}}
+				},
+				{
+					"Effect": "Allow",
+					"Principal": {"Service": "events.amazonaws.com"},
+					"Action": "lambda:InvokeFunction"
+				}
+			]}`,
+			want: []lambdaInvoker{
+				{service: "sns.amazonaws.com", sourceARN: "arn:aws:sns:us-east-1:123456789012:a"},
+				{service: "sns.amazonaws.com", sourceARN: "arn:aws:sns:us-east-1:123456789012:b"},
+				{service: "events.amazonaws.com"},
+			},
+		},
+		{
+			name: "Deny and account principals skipped",
+			document: `{"Statement": [
+				{"Effect": "Deny", "Principal": {"Service": "s3.amazonaws.com"}, "Action": "lambda:InvokeFunction"},
+				{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::210987654321:root"}, "Action": "lambda:InvokeFunction"}
+			]}`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLambdaInvokers(tt.document)
+			if err != nil {
+				t.Fatalf("parseLambdaInvokers() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLambdaInvokers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverFunctionURLAndPolicyInvokers(t *testing.T) {
+	const (
+		functionARN = "arn:aws:lambda:us-east-1:123456789012:function:api"
+		functionURL = "https://abc123.lambda-url.us-east-1.on.aws/"
+		restAPI     = "arn:aws:apigateway:us-east-1::/restapis/abc123"
+		topic       = "arn:aws:sns:us-east-1:123456789012:orders"
+	)
+	stub := newStubAPI(map[string]any{
+		"GetFunctionUrlConfig": &lambda.GetFunctionUrlConfigOutput{
+			FunctionUrl: aws.String(functionURL),
+			AuthType:    lambdatypes.FunctionUrlAuthTypeNone,
+		},
+		"GetPolicy": &lambda.GetPolicyOutput{
+			Policy: aws.String(`{"Statement": [
+				{
+					"Effect": "Allow",
+					"Principal": {"Service": "apigateway.amazonaws.com"},
+					"Action": "lambda:InvokeFunction",
+					"Condition": {"ArnLike": {"AWS:SourceArn": "arn:aws:execute-api:us-east-1:123456789012:abc123/*/*"}}
+				},
+				{
+					"Effect": "Allow",
+					"Principal": {"Service": "sns.amazonaws.com"},
+					"Action": "lambda:InvokeFunction",
+					"Condition": {"ArnLike": {"AWS:SourceArn": "` + topic + `"}}
+				}
+			]}`),
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := &graph.Node{ID: functionARN, Type: ResourceTypeLambda, ARN: functionARN, Name: "api"}
+	g.AddNode(node)
+	// Already found through its integration, so the policy statement should reuse it
+	g.AddNode(&graph.Node{ID: restAPI, Type: ResourceTypeAPIGatewayRestAPI, Metadata: map[string]any{"apiId": "abc123"}})
+
+	urlNeighbors, err := d.discoverFunctionURL(context.Background(), "api", node, g)
+	if err != nil {
+		t.Fatalf("discoverFunctionURL() error = %v", err)
+	}
+	if len(urlNeighbors) != 1 || urlNeighbors[0] != functionURL {
+		t.Fatalf("discoverFunctionURL() = %v, want [%s]", urlNeighbors, functionURL)
+	}
+	urlNode, _ := g.GetNode(functionURL)
+	if urlNode == nil || !IsPublicFunctionURL(urlNode) {
+		t.Errorf("function URL node = %+v, want public LambdaFunctionURL", urlNode)
+	}
+
+	invokers, err := d.discoverPolicyInvokers(context.Background(), "api", node, g)
+	if err != nil {
+		t.Fatalf("discoverPolicyInvokers() error = %v", err)
+	}
+	if want := []string{restAPI, topic}; !reflect.DeepEqual(invokers, want) {
+		t.Fatalf("discoverPolicyInvokers() = %v, want %v", invokers, want)
+	}
+	if got := g.CountByType()[ResourceTypeAPIGatewayRestAPI]; got != 1 {
+		t.Errorf("APIGatewayRestAPI nodes = %d, want 1", got)
+	}
+	if topicNode, _ := g.GetNode(topic); topicNode == nil || topicNode.Type != ResourceTypeSNSTopic || topicNode.Name != "orders" {
+		t.Errorf("topic node = %+v, want SNSTopic orders", topicNode)
+	}
+	for _, edge := range g.EdgesTo(functionARN) {
+		want := "can-invoke"
+		if edge.From == functionURL {
+			want = "exposed-via-url"
+		}
+		if edge.RelationType != want {
+			t.Errorf("edge %s -> %s = %q, want %q", edge.From, edge.To, edge.RelationType, want)
+		}
+	}
+}
+
+func TestDiscoverFunctionWithoutURLOrPolicy(t *testing.T) {
+	notFound := &lambdatypes.ResourceNotFoundException{Message: aws.String("not found")}
+	stub := newStubAPI(map[string]any{
+		"GetFunctionUrlConfig": notFound,
+		"GetPolicy":            notFound,
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := &graph.Node{ID: "arn:aws:lambda:us-east-1:123456789012:function:api", Type: ResourceTypeLambda}
+	g.AddNode(node)
+
+	if neighbors, err := d.discoverFunctionURL(context.Background(), "api", node, g); err != nil || len(neighbors) != 0 {
+		t.Errorf("discoverFunctionURL() = %v, %v, want no neighbors and no error", neighbors, err)
+	}
+	if neighbors, err := d.discoverPolicyInvokers(context.Background(), "api", node, g); err != nil || len(neighbors) != 0 {
+		t.Errorf("discoverPolicyInvokers() = %v, %v, want no neighbors and no error", neighbors, err)
+	}
+	if g.NodeCount() != 1 {
+		t.Errorf("graph has %d nodes, want only the function", g.NodeCount())
+	}
+}
@@ -0,0 +1,206 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	kafkatypes "github.com/aws/aws-sdk-go-v2/service/kafka/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// discoverMSK records an MSK cluster's version, size and encryption settings and links it to
+// the subnets and security groups its brokers run in, its configuration, its KMS key and the
+// Lambda functions consuming it. Provisioned and serverless clusters are both handled;
+// serverless clusters have no brokers, configuration or customer-managed key of their own.
+func (d *Discoverer) discoverMSK(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering MSK cluster", "arn", node.ARN)
+
+	output, err := d.clients.Kafka.DescribeClusterV2(ctx, &kafka.DescribeClusterV2Input{
+		ClusterArn: &node.ARN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe MSK cluster: %w", err)
+	}
+	cluster := output.ClusterInfo
+	if cluster == nil {
+		return nil, fmt.Errorf("MSK cluster not found: %s", node.ARN)
+	}
+	setMSKClusterMetadata(node, cluster)
+
+	var neighbors []string
+	subnets, securityGroups := mskClusterNetwork(cluster)
+	for _, subnetID := range subnets {
+		neighbors = append(neighbors, addMSKNetworkEdge(g, node, ResourceTypeSubnet, subnetID, "runs-in-subnet", "SubnetId"))
+	}
+	for _, sgID := range securityGroups {
+		neighbors = append(neighbors, addMSKNetworkEdge(g, node, ResourceTypeSecurityGroup, sgID, "uses-security-group", "SecurityGroupId"))
+	}
+
+	provisioned := cluster.Provisioned
+	if provisioned == nil {
+		return append(neighbors, d.discoverMSKConsumers(ctx, node, g)...), nil
+	}
+
+	if software := provisioned.CurrentBrokerSoftwareInfo; software != nil && software.ConfigurationArn != nil {
+		configNode := &graph.Node{
+			ID:      *software.ConfigurationArn,
+			Type:    ResourceTypeMSKConfiguration,
+			ARN:     *software.ConfigurationArn,
+			Name:    mskResourceName(*software.ConfigurationArn),
+			Region:  node.Region,
+			Account: node.Account,
+		}
+		if !g.HasNode(configNode.ID) {
+			g.AddNode(configNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           configNode.ID,
+			RelationType: "uses-configuration",
+			Evidence: graph.Evidence{
+				APICall: "DescribeClusterV2",
+				Fields: map[string]any{
+					"ConfigurationArn":      *software.ConfigurationArn,
+					"ConfigurationRevision": software.ConfigurationRevision,
+				},
+			},
+		})
+		neighbors = append(neighbors, configNode.ID)
+	}
+
+	if encryption := provisioned.EncryptionInfo; encryption != nil && encryption.EncryptionAtRest != nil && encryption.EncryptionAtRest.DataVolumeKMSKeyId != nil {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *encryption.EncryptionAtRest.DataVolumeKMSKeyId, "DescribeClusterV2", "DataVolumeKMSKeyId"))
+	}
+
+	return append(neighbors, d.discoverMSKConsumers(ctx, node, g)...), nil
+}
+
+// discoverMSKConsumers links a cluster to the Lambda functions whose event source mappings read
+// from it, the same triggers edges discovering the functions adds
+func (d *Discoverer) discoverMSKConsumers(ctx context.Context, node *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	paginator := lambda.NewListEventSourceMappingsPaginator(d.clients.Lambda, &lambda.ListEventSourceMappingsInput{
+		EventSourceArn: &node.ARN,
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(page *lambda.ListEventSourceMappingsOutput) error {
+		for i := range page.EventSourceMappings {
+			mapping := &page.EventSourceMappings[i]
+			if mapping.FunctionArn == nil {
+				continue
+			}
+			lambdaNode, parseErr := d.parseARN(normalizeLambdaARN(*mapping.FunctionArn))
+			if parseErr != nil {
+				d.log.Debug("Skipping unsupported consumer function ARN", "arn", *mapping.FunctionArn, "error", parseErr)
+				continue
+			}
+			if !g.HasNode(lambdaNode.ID) {
+				g.AddNode(lambdaNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           lambdaNode.ID,
+				RelationType: "triggers",
+				Evidence: graph.Evidence{
+					APICall: "ListEventSourceMappings",
+					Fields: map[string]any{
+						"EventSourceArn": node.ARN,
+						"UUID":           mapping.UUID,
+						"State":          mapping.State,
+					},
+				},
+			})
+			neighbors = append(neighbors, lambdaNode.ID)
+		}
+		return nil
+	})
+	if pageErr != nil {
+		d.warn(node.ID, "Failed to list MSK consumer functions", pageErr)
+	}
+	return neighbors
+}
+
+// setMSKClusterMetadata records type, state, broker count, Kafka version and encryption from DescribeClusterV2
+func setMSKClusterMetadata(node *graph.Node, cluster *kafkatypes.Cluster) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	if cluster.ClusterName != nil {
+		node.Name = *cluster.ClusterName
+	}
+	node.Metadata["clusterType"] = string(cluster.ClusterType)
+	node.Metadata["state"] = string(cluster.State)
+
+	provisioned := cluster.Provisioned
+	if provisioned == nil {
+		return
+	}
+	node.Metadata["brokerCount"] = provisioned.NumberOfBrokerNodes
+	if provisioned.BrokerNodeGroupInfo != nil && provisioned.BrokerNodeGroupInfo.InstanceType != nil {
+		node.Metadata["instanceType"] = *provisioned.BrokerNodeGroupInfo.InstanceType
+	}
+	if provisioned.CurrentBrokerSoftwareInfo != nil && provisioned.CurrentBrokerSoftwareInfo.KafkaVersion != nil {
+		node.Metadata["kafkaVersion"] = *provisioned.CurrentBrokerSoftwareInfo.KafkaVersion
+	}
+	if encryption := provisioned.EncryptionInfo; encryption != nil && encryption.EncryptionInTransit != nil {
+		node.Metadata["encryptionInTransit"] = string(encryption.EncryptionInTransit.ClientBroker)
+		node.Metadata["encryptionInCluster"] = encryption.EncryptionInTransit.InCluster
+	}
+}
+
+// mskClusterNetwork returns the subnets and security groups of a cluster's brokers, or of the
+// VPC connections of a serverless cluster
+func mskClusterNetwork(cluster *kafkatypes.Cluster) (subnets, securityGroups []string) {
+	if cluster.Provisioned != nil && cluster.Provisioned.BrokerNodeGroupInfo != nil {
+		info := cluster.Provisioned.BrokerNodeGroupInfo
+		return info.ClientSubnets, info.SecurityGroups
+	}
+	if cluster.Serverless != nil {
+		for _, vpcConfig := range cluster.Serverless.VpcConfigs {
+			subnets = append(subnets, vpcConfig.SubnetIds...)
+			securityGroups = append(securityGroups, vpcConfig.SecurityGroupIds...)
+		}
+	}
+	return subnets, securityGroups
+}
+
+// addMSKNetworkEdge links a cluster to one of its subnets or security groups and returns its ID
+func addMSKNetworkEdge(g *graph.Graph, node *graph.Node, nodeType, id, relation, field string) string {
+	nodeID := canonicalID(node.Region, node.Account, nodeType, id)
+	if !g.HasNode(nodeID) {
+		g.AddNode(&graph.Node{
+			ID:      nodeID,
+			Type:    nodeType,
+			Name:    id,
+			Region:  node.Region,
+			Account: node.Account,
+		})
+	}
+	g.AddEdge(&graph.Edge{
+		From:         node.ID,
+		To:           nodeID,
+		RelationType: relation,
+		Evidence: graph.Evidence{
+			APICall: "DescribeClusterV2",
+			Fields: map[string]any{
+				field: id,
+			},
+		},
+	})
+	return nodeID
+}
+
+// mskResourceName returns the name in an MSK cluster or configuration ARN, which has the form
+// arn:aws:kafka:region:account:cluster/name/uuid
+func mskResourceName(arn string) string {
+	parts := strings.Split(arn, "/")
+	if len(parts) >= 3 {
+		return parts[1]
+	}
+	return extractNameFromARN(arn)
+}
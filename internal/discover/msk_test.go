@@ -0,0 +1,186 @@
+package discover
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	kafkatypes "github.com/aws/aws-sdk-go-v2/service/kafka/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+const (
+	testMSKClusterARN = "arn:aws:kafka:us-east-1:123456789012:cluster/orders-events/0b8d4c2a-1f3e-4a5b-9c6d-7e8f9a0b1c2d-2"
+	testMSKConfigARN  = "arn:aws:kafka:us-east-1:123456789012:configuration/tuned/5f1c7e2a-3b4d-4c6e-8f9a-0b1c2d3e4f5a-1"
+	testMSKKeyARN     = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	testMSKLambdaARN  = "arn:aws:lambda:us-east-1:123456789012:function:orders-consumer"
+)
+
+func TestDiscoverMSK(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"DescribeClusterV2": &kafka.DescribeClusterV2Output{ClusterInfo: &kafkatypes.Cluster{
+			ClusterArn:  aws.String(testMSKClusterARN),
+			ClusterName: aws.String("orders-events"),
+			ClusterType: kafkatypes.ClusterTypeProvisioned,
+			State:       kafkatypes.ClusterStateActive,
+			Provisioned: &kafkatypes.Provisioned{
+				NumberOfBrokerNodes: aws.Int32(3),
+				BrokerNodeGroupInfo: &kafkatypes.BrokerNodeGroupInfo{
+					InstanceType:   aws.String("kafka.m5.large"),
+					ClientSubnets:  []string{"subnet-a", "subnet-b", "subnet-c"},
+					SecurityGroups: []string{"sg-brokers"},
+				},
+				CurrentBrokerSoftwareInfo: &kafkatypes.BrokerSoftwareInfo{
+					KafkaVersion:          aws.String("3.6.0"),
+					ConfigurationArn:      aws.String(testMSKConfigARN),
+					ConfigurationRevision: aws.Int64(1),
+				},
+				EncryptionInfo: &kafkatypes.EncryptionInfo{
+					EncryptionAtRest:    &kafkatypes.EncryptionAtRest{DataVolumeKMSKeyId: aws.String(testMSKKeyARN)},
+					EncryptionInTransit: &kafkatypes.EncryptionInTransit{ClientBroker: kafkatypes.ClientBrokerTls, InCluster: aws.Bool(true)},
+				},
+			},
+		}},
+		"ListEventSourceMappings": &lambda.ListEventSourceMappingsOutput{
+			EventSourceMappings: []lambdatypes.EventSourceMappingConfiguration{
+				// Mappings can name a function by version or alias
+				{FunctionArn: aws.String(testMSKLambdaARN + ":live"), EventSourceArn: aws.String(testMSKClusterARN), UUID: aws.String("mapping-1"), State: aws.String("Enabled")},
+				{UUID: aws.String("mapping-without-function")},
+			},
+		},
+	})
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	// Lambda event source mappings name the cluster by the last segment of its ARN
+	node := &graph.Node{ID: testMSKClusterARN, Type: ResourceTypeKafkaCluster, ARN: testMSKClusterARN, Name: "0b8d4c2a-1f3e-4a5b-9c6d-7e8f9a0b1c2d-2", Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverNode(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverNode() error = %v", err)
+	}
+
+	// Subnets and security groups are keyed by their ID in the cluster's account and region
+	scoped := func(resourceType, id string) string {
+		return canonicalID("us-east-1", "123456789012", resourceType, id)
+	}
+	want := []string{
+		scoped(ResourceTypeSubnet, "subnet-a"),
+		scoped(ResourceTypeSubnet, "subnet-b"),
+		scoped(ResourceTypeSubnet, "subnet-c"),
+		scoped(ResourceTypeSecurityGroup, "sg-brokers"),
+		testMSKConfigARN, testMSKKeyARN, testMSKLambdaARN,
+	}
+	if !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("discoverMSK() neighbors = %v, want %v", neighbors, want)
+	}
+	if node.Name != "orders-events" {
+		t.Errorf("Name = %q, want orders-events", node.Name)
+	}
+	if count, _ := node.MetaInt("brokerCount"); count != 3 {
+		t.Errorf("brokerCount = %d, want 3", count)
+	}
+	if version, _ := node.MetaString("kafkaVersion"); version != "3.6.0" {
+		t.Errorf("kafkaVersion = %q, want 3.6.0", version)
+	}
+	if inCluster, _ := node.MetaBool("encryptionInCluster"); node.Metadata["encryptionInTransit"] != "TLS" || !inCluster {
+		t.Errorf("encryption metadata = %v, want TLS in transit and in cluster", node.Metadata)
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesFrom(node.ID) {
+		relations[edge.To] = edge.RelationType
+	}
+	wantRelations := map[string]string{
+		want[0]:          "runs-in-subnet",
+		want[1]:          "runs-in-subnet",
+		want[2]:          "runs-in-subnet",
+		want[3]:          "uses-security-group",
+		testMSKConfigARN: "uses-configuration",
+		testMSKKeyARN:    "encrypted-with",
+		testMSKLambdaARN: "triggers",
+	}
+	if !reflect.DeepEqual(relations, wantRelations) {
+		t.Errorf("edges = %v, want %v", relations, wantRelations)
+	}
+	if config, _ := g.GetNode(testMSKConfigARN); config == nil || config.Type != ResourceTypeMSKConfiguration || config.Name != "tuned" {
+		t.Errorf("configuration node = %+v, want MSKConfiguration tuned", config)
+	}
+	if consumer, _ := g.GetNode(testMSKLambdaARN); consumer == nil || consumer.Type != ResourceTypeLambda || consumer.Name != "orders-consumer" {
+		t.Errorf("consumer node = %+v, want Lambda orders-consumer", consumer)
+	}
+}
+
+func TestMSKClusterNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *kafkatypes.Cluster
+		wantSubnets []string
+		wantGroups  []string
+	}{
+		{
+			name: "provisioned",
+			cluster: &kafkatypes.Cluster{Provisioned: &kafkatypes.Provisioned{BrokerNodeGroupInfo: &kafkatypes.BrokerNodeGroupInfo{
+				ClientSubnets:  []string{"subnet-a", "subnet-b"},
+				SecurityGroups: []string{"sg-1"},
+			}}},
+			wantSubnets: []string{"subnet-a", "subnet-b"},
+			wantGroups:  []string{"sg-1"},
+		},
+		{
+			name: "serverless with two VPC connections",
+			cluster: &kafkatypes.Cluster{Serverless: &kafkatypes.Serverless{VpcConfigs: []kafkatypes.VpcConfig{
+				{SubnetIds: []string{"subnet-a"}, SecurityGroupIds: []string{"sg-1"}},
+				{SubnetIds: []string{"subnet-x"}},
+			}}},
+			wantSubnets: []string{"subnet-a", "subnet-x"},
+			wantGroups:  []string{"sg-1"},
+		},
+		{
+			name:    "neither",
+			cluster: &kafkatypes.Cluster{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subnets, groups := mskClusterNetwork(tt.cluster)
+			if !reflect.DeepEqual(subnets, tt.wantSubnets) || !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Errorf("mskClusterNetwork() = %v, %v, want %v, %v", subnets, groups, tt.wantSubnets, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestDiscoverServerlessMSK(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"DescribeClusterV2": &kafka.DescribeClusterV2Output{ClusterInfo: &kafkatypes.Cluster{
+			ClusterName: aws.String("events"),
+			ClusterType: kafkatypes.ClusterTypeServerless,
+			State:       kafkatypes.ClusterStateActive,
+			Serverless: &kafkatypes.Serverless{VpcConfigs: []kafkatypes.VpcConfig{
+				{SubnetIds: []string{"subnet-a"}, SecurityGroupIds: []string{"sg-1"}},
+			}},
+		}},
+		"ListEventSourceMappings": &lambda.ListEventSourceMappingsOutput{},
+	})
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := &graph.Node{ID: testMSKClusterARN, Type: ResourceTypeKafkaCluster, ARN: testMSKClusterARN}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverMSK(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverMSK() error = %v", err)
+	}
+	if want := []string{"subnet-a", "sg-1"}; !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("discoverMSK() neighbors = %v, want %v", neighbors, want)
+	}
+	if node.Metadata["clusterType"] != "SERVERLESS" || node.Metadata["brokerCount"] != nil {
+		t.Errorf("metadata = %v, want a serverless cluster without brokers", node.Metadata)
+	}
+}
@@ -0,0 +1,184 @@
+package discover
+
+import (
+	"sort"
+)
+
+// identifyActions are the IAM actions Identify may call to resolve a friendly name. ARNs are
+// parsed without any calls.
+var identifyActions = []string{
+	"ecs:DescribeServices",
+	"elasticfilesystem:DescribeFileSystems",
+	"elasticloadbalancing:DescribeLoadBalancers",
+	"kms:DescribeKey",
+	"lambda:GetFunction",
+	"rds:DescribeDBClusters",
+	"rds:DescribeDBInstances",
+	"tag:GetResources",
+}
+
+// apiGatewayReadActions covers every API Gateway call; REST and HTTP API reads are all apigateway:GET
+var apiGatewayReadActions = []string{"apigateway:GET"}
+
+// discovererActions lists the IAM actions each resource type's discoverer may call, including
+// lookups of upstream resources (e.g. API Gateway integrations of a Lambda function). Calls made
+// when the resources it finds are expanded in turn are listed under their own types.
+var discovererActions = map[string][]string{
+	ResourceTypeLoadBalancer: {
+		"acm:DescribeCertificate",
+		"autoscaling:DescribeAutoScalingGroups",
+		"autoscaling:DescribeAutoScalingInstances",
+		"elasticloadbalancing:DescribeListeners",
+		"elasticloadbalancing:DescribeLoadBalancers",
+		"elasticloadbalancing:DescribeRules",
+		"elasticloadbalancing:DescribeTargetGroups",
+		"elasticloadbalancing:DescribeTargetHealth",
+		"route53:ListHostedZones",
+		"route53:ListResourceRecordSets",
+		"wafv2:GetWebACLForResource",
+	},
+	ResourceTypeECSService: {
+		"application-autoscaling:DescribeScalableTargets",
+		"application-autoscaling:DescribeScalingPolicies",
+		"ecs:DescribeServices",
+		"ecs:DescribeTaskDefinition",
+		"ecs:DescribeTasks",
+		"ecs:ListTasks",
+		"servicediscovery:ListServices",
+	},
+	ResourceTypeECSCluster: {
+		"ecs:DescribeServices",
+		"ecs:ListServices",
+	},
+	ResourceTypeLambda: append([]string{
+		"events:ListEventBuses",
+		"events:ListRules",
+		"events:ListTargetsByRule",
+		"lambda:GetFunction",
+		"lambda:GetFunctionEventInvokeConfig",
+		"lambda:GetFunctionUrlConfig",
+		"lambda:GetPolicy",
+		"lambda:ListEventSourceMappings",
+	}, apiGatewayReadActions...),
+	ResourceTypeLambdaLayer: {
+		"lambda:ListFunctions",
+	},
+	ResourceTypeRDSInstance: {
+		"rds:DescribeDBInstances",
+	},
+	ResourceTypeRDSCluster: {
+		"rds:DescribeDBClusters",
+		"rds:DescribeGlobalClusters",
+	},
+	ResourceTypeRDSGlobalCluster: {
+		"rds:DescribeGlobalClusters",
+	},
+	ResourceTypeAPIGatewayRestAPI: apiGatewayReadActions,
+	ResourceTypeAPIGatewayHTTPAPI: apiGatewayReadActions,
+	ResourceTypeEventBridgeRule: {
+		"events:DescribeRule",
+		"events:ListTargetsByRule",
+	},
+	ResourceTypeKMSKey: {
+		"kms:DescribeKey",
+		"kms:GetKeyRotationStatus",
+		"kms:ListAliases",
+	},
+	ResourceTypeSecretsManagerSecret: {
+		"secretsmanager:DescribeSecret",
+		"secretsmanager:GetResourcePolicy",
+	},
+	ResourceTypeSSMParameter: {
+		"ssm:DescribeParameters",
+	},
+	ResourceTypeACMCertificate: {
+		"acm:DescribeCertificate",
+	},
+	ResourceTypeIAMRole: {
+		"iam:GetPolicy",
+		"iam:GetPolicyVersion",
+		"iam:GetRole",
+		"iam:GetRolePolicy",
+		"iam:ListAttachedRolePolicies",
+		"iam:ListRolePolicies",
+	},
+	ResourceTypeWAFWebACL: {
+		"wafv2:GetWebACL",
+		"wafv2:ListResourcesForWebACL",
+	},
+	ResourceTypeECRRepository: {
+		"ecr:DescribeRepositories",
+	},
+	ResourceTypeEFSFileSystem: {
+		"elasticfilesystem:DescribeFileSystems",
+		"elasticfilesystem:DescribeMountTargetSecurityGroups",
+		"elasticfilesystem:DescribeMountTargets",
+	},
+	ResourceTypeEFSAccessPoint: {
+		"elasticfilesystem:DescribeAccessPoints",
+	},
+	ResourceTypeCloudMapService: {
+		"servicediscovery:GetService",
+		"servicediscovery:ListInstances",
+	},
+	ResourceTypeKinesisStream: {
+		"kinesis:DescribeStreamSummary",
+		"kinesis:ListStreamConsumers",
+	},
+	ResourceTypeFirehoseDeliveryStream: {
+		"firehose:DescribeDeliveryStream",
+	},
+	ResourceTypeKafkaCluster: {
+		"kafka:DescribeClusterV2",
+		"lambda:ListEventSourceMappings",
+	},
+	ResourceTypeSESConfigurationSet: {
+		"ses:GetConfigurationSetEventDestinations",
+	},
+	ResourceTypeSubnet: {
+		"ec2:DescribeRouteTables",
+		"ec2:DescribeSubnets",
+	},
+	ResourceTypeNATGateway: {
+		"ec2:DescribeNatGateways",
+	},
+}
+
+// RequiredActions returns the IAM actions, sorted, that discovering a resource of the given type
+// calls directly. It returns nil for types that are never expanded.
+func RequiredActions(resourceType string) []string {
+	actions, ok := discovererActions[resourceType]
+	if !ok {
+		return nil
+	}
+	return sortedActions(actions)
+}
+
+// IdentifyActions returns the IAM actions, sorted, that resolving a friendly name may call
+func IdentifyActions() []string {
+	return sortedActions(identifyActions)
+}
+
+// AllActions returns every IAM action discovery may call, sorted. A read-only policy granting
+// these covers a run from any starting resource.
+func AllActions() []string {
+	all := append([]string(nil), identifyActions...)
+	for _, actions := range discovererActions {
+		all = append(all, actions...)
+	}
+	return sortedActions(all)
+}
+
+// sortedActions returns a sorted copy of actions without duplicates
+func sortedActions(actions []string) []string {
+	seen := make(map[string]bool, len(actions))
+	sorted := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if !seen[action] {
+			seen[action] = true
+			sorted = append(sorted, action)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,49 @@
+package discover
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestRequiredActions(t *testing.T) {
+	actionPattern := regexp.MustCompile(`^[a-z0-9-]+:[A-Z][A-Za-z0-9]+$|^apigateway:GET$`)
+
+	for resourceType := range discovererActions {
+		actions := RequiredActions(resourceType)
+		if len(actions) == 0 {
+			t.Errorf("RequiredActions(%s) is empty", resourceType)
+		}
+		if !slices.IsSorted(actions) {
+			t.Errorf("RequiredActions(%s) = %v, want sorted", resourceType, actions)
+		}
+		for _, action := range actions {
+			if !actionPattern.MatchString(action) {
+				t.Errorf("RequiredActions(%s) has malformed action %q", resourceType, action)
+			}
+		}
+	}
+
+	if got := RequiredActions(ResourceTypeSecurityGroup); got != nil {
+		t.Errorf("RequiredActions(SecurityGroup) = %v, want nil for a type that is never expanded", got)
+	}
+
+	lambdaActions := RequiredActions(ResourceTypeLambda)
+	for _, want := range []string{"lambda:GetFunction", "lambda:GetPolicy", "apigateway:GET", "events:ListRules"} {
+		if !slices.Contains(lambdaActions, want) {
+			t.Errorf("RequiredActions(Lambda) missing %s", want)
+		}
+	}
+}
+
+func TestAllActions(t *testing.T) {
+	all := AllActions()
+	if !slices.IsSorted(all) || len(slices.Compact(slices.Clone(all))) != len(all) {
+		t.Fatalf("AllActions() = %v, want sorted without duplicates", all)
+	}
+	for _, action := range append(IdentifyActions(), RequiredActions(ResourceTypeIAMRole)...) {
+		if !slices.Contains(all, action) {
+			t.Errorf("AllActions() missing %s", action)
+		}
+	}
+}
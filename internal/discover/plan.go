@@ -0,0 +1,264 @@
+package discover
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// arnResourceTypes are the expanded types parseARN recognizes, any of which a policy may name
+var arnResourceTypes = []string{
+	ResourceTypeLoadBalancer,
+	ResourceTypeECSService,
+	ResourceTypeECSCluster,
+	ResourceTypeLambda,
+	ResourceTypeLambdaLayer,
+	ResourceTypeRDSInstance,
+	ResourceTypeRDSCluster,
+	ResourceTypeRDSGlobalCluster,
+	ResourceTypeAPIGatewayRestAPI,
+	ResourceTypeAPIGatewayHTTPAPI,
+	ResourceTypeEventBridgeRule,
+	ResourceTypeIAMRole,
+	ResourceTypeKMSKey,
+	ResourceTypeSecretsManagerSecret,
+	ResourceTypeSSMParameter,
+	ResourceTypeACMCertificate,
+	ResourceTypeWAFWebACL,
+	ResourceTypeECRRepository,
+	ResourceTypeEFSFileSystem,
+	ResourceTypeEFSAccessPoint,
+	ResourceTypeCloudMapService,
+	ResourceTypeKinesisStream,
+	ResourceTypeFirehoseDeliveryStream,
+	ResourceTypeKafkaCluster,
+	ResourceTypeSESConfigurationSet,
+}
+
+// discoveredTypes lists the expanded resource types each type's discoverer may add to the
+// graph. Types that are never expanded, such as security groups, are left out since they add
+// no calls.
+// With discovererActions it lets Plan describe a run without calling AWS.
+var discoveredTypes = map[string][]string{
+	ResourceTypeLoadBalancer: {
+		ResourceTypeACMCertificate,
+		ResourceTypeLambda,
+		ResourceTypeSubnet,
+		ResourceTypeWAFWebACL,
+	},
+	ResourceTypeECSService: {
+		ResourceTypeCloudMapService,
+		ResourceTypeECRRepository,
+		ResourceTypeECSCluster,
+		ResourceTypeEFSAccessPoint,
+		ResourceTypeEFSFileSystem,
+		ResourceTypeIAMRole,
+		ResourceTypeSSMParameter,
+		ResourceTypeSecretsManagerSecret,
+		ResourceTypeSubnet,
+	},
+	ResourceTypeECSCluster: {
+		ResourceTypeECSService,
+	},
+	ResourceTypeLambda: {
+		ResourceTypeAPIGatewayHTTPAPI,
+		ResourceTypeAPIGatewayRestAPI,
+		ResourceTypeECRRepository,
+		ResourceTypeEFSAccessPoint,
+		ResourceTypeEventBridgeRule,
+		ResourceTypeIAMRole,
+		ResourceTypeKMSKey,
+		ResourceTypeKafkaCluster,
+		ResourceTypeKinesisStream,
+		ResourceTypeLambdaLayer,
+		ResourceTypeSubnet,
+	},
+	ResourceTypeLambdaLayer: {
+		ResourceTypeLambda,
+	},
+	ResourceTypeRDSInstance: {
+		ResourceTypeKMSKey,
+		ResourceTypeRDSCluster,
+		ResourceTypeSubnet,
+	},
+	ResourceTypeRDSCluster: {
+		ResourceTypeKMSKey,
+		ResourceTypeRDSGlobalCluster,
+		ResourceTypeRDSInstance,
+	},
+	ResourceTypeRDSGlobalCluster: {
+		ResourceTypeRDSCluster,
+	},
+	ResourceTypeAPIGatewayRestAPI: {
+		ResourceTypeLambda,
+		ResourceTypeWAFWebACL,
+	},
+	ResourceTypeAPIGatewayHTTPAPI: {
+		ResourceTypeLambda,
+	},
+	ResourceTypeEventBridgeRule: {
+		ResourceTypeECSCluster,
+		ResourceTypeFirehoseDeliveryStream,
+		ResourceTypeKinesisStream,
+		ResourceTypeLambda,
+	},
+	ResourceTypeSecretsManagerSecret: {
+		ResourceTypeIAMRole,
+		ResourceTypeKMSKey,
+		ResourceTypeLambda,
+	},
+	ResourceTypeSSMParameter: {
+		ResourceTypeKMSKey,
+	},
+	// Policy statements may name any resource
+	ResourceTypeIAMRole: arnResourceTypes,
+	ResourceTypeWAFWebACL: {
+		ResourceTypeAPIGatewayRestAPI,
+		ResourceTypeLoadBalancer,
+	},
+	ResourceTypeEFSFileSystem: {
+		ResourceTypeKMSKey,
+		ResourceTypeSubnet,
+	},
+	ResourceTypeEFSAccessPoint: {
+		ResourceTypeEFSFileSystem,
+	},
+	ResourceTypeCloudMapService: {
+		ResourceTypeECSService,
+	},
+	ResourceTypeKinesisStream: {
+		ResourceTypeKMSKey,
+	},
+	ResourceTypeFirehoseDeliveryStream: {
+		ResourceTypeKMSKey,
+		ResourceTypeKinesisStream,
+	},
+	ResourceTypeKafkaCluster: {
+		ResourceTypeKMSKey,
+		ResourceTypeLambda,
+		ResourceTypeSubnet,
+	},
+	ResourceTypeSESConfigurationSet: {
+		ResourceTypeFirehoseDeliveryStream,
+		ResourceTypeIAMRole,
+	},
+	ResourceTypeSubnet: {
+		ResourceTypeNATGateway,
+	},
+	ResourceTypeNATGateway: {
+		ResourceTypeSubnet,
+	},
+}
+
+// PlanLevel is what a run may do at one depth of the traversal
+type PlanLevel struct {
+	Depth   int      // Distance from the starting resources
+	Types   []string // Resource types that may be expanded at this depth, sorted
+	Actions []string // IAM actions, sorted, that expanding them may call
+}
+
+// Plan describes the discoverers and API calls a run would use, worked out without calling AWS
+type Plan struct {
+	// Start is the starting resource parsed from its ARN, or nil if only a lookup can type it
+	Start *graph.Node
+	// ResolveActions are the IAM actions, sorted, a lookup of the identifier may call; set when
+	// Start is nil
+	ResolveActions []string
+	// Levels are the depths at which resources may be expanded, from the start at depth 0
+	Levels []PlanLevel
+}
+
+// Plan works out which discoverers and API calls a run from resourceID would use at each depth
+// without calling AWS. Only ARNs can be typed offline; for names and IDs the plan has no levels
+// and lists the calls resolving the identifier may make instead.
+func (d *Discoverer) Plan(resourceID string) (Plan, error) {
+	if !strings.HasPrefix(resourceID, "arn:") {
+		return Plan{ResolveActions: IdentifyActions()}, nil
+	}
+
+	start, err := d.parseARN(resourceID)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to identify resource: %w", err)
+	}
+	plan := d.PlanFrom([]*graph.Node{start})
+	plan.Start = start
+	return plan, nil
+}
+
+// PlanFrom works out the levels of a run from several starting nodes, e.g. those built with
+// SeedNode. The plan is an upper bound: a level lists every type a discoverer may add, whether
+// or not the account has any, honoring MaxDepth, DepthOverrides, the type filters, SkipIAM,
+// SkipRoute53 and IncludeRuntime.
+func (d *Discoverer) PlanFrom(seeds []*graph.Node) Plan {
+	filter := d.typeFilter()
+
+	// As in traverse, the starting resources are never dropped by type filters
+	types := make(map[string]bool)
+	for _, seed := range seeds {
+		types[seed.Type] = true
+	}
+
+	var plan Plan
+	for depth := 0; depth <= d.opts.MaxDepth && len(types) > 0; depth++ {
+		level := PlanLevel{Depth: depth}
+		var actions []string
+		next := make(map[string]bool)
+		for _, resourceType := range sortedTypes(types) {
+			if !d.plansExpansion(resourceType, depth) {
+				continue
+			}
+			level.Types = append(level.Types, resourceType)
+			actions = append(actions, d.plannedActions(resourceType)...)
+			for _, found := range discoveredTypes[resourceType] {
+				if filter == nil || filter(&graph.Node{Type: found}) {
+					next[found] = true
+				}
+			}
+		}
+		if len(level.Types) == 0 {
+			break
+		}
+		level.Actions = sortedActions(actions)
+		plan.Levels = append(plan.Levels, level)
+		types = next
+	}
+	return plan
+}
+
+// plansExpansion reports whether resources of a type found at depth would be expanded
+func (d *Discoverer) plansExpansion(resourceType string, depth int) bool {
+	if _, ok := discovererActions[resourceType]; !ok {
+		return false
+	}
+	if resourceType == ResourceTypeIAMRole && d.opts.SkipIAM {
+		return false
+	}
+	limit, ok := d.opts.DepthOverrides[resourceType]
+	return depth == 0 || !ok || depth <= limit
+}
+
+// plannedActions returns the actions expanding a type may call, less those options turn off
+func (d *Discoverer) plannedActions(resourceType string) []string {
+	var actions []string
+	for _, action := range RequiredActions(resourceType) {
+		switch {
+		case d.opts.SkipRoute53 && strings.HasPrefix(action, "route53:"):
+		case !d.opts.IncludeRuntime && (action == "ecs:ListTasks" || action == "ecs:DescribeTasks"):
+		default:
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// sortedTypes returns the keys of a set of resource types, sorted
+func sortedTypes(types map[string]bool) []string {
+	sorted := make([]string, 0, len(types))
+	for resourceType := range types {
+		sorted = append(sorted, resourceType)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
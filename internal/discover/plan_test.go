@@ -0,0 +1,115 @@
+package discover
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlanLoadBalancerARN(t *testing.T) {
+	const albARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+
+	tests := []struct {
+		name string
+		opts Options
+		want []PlanLevel
+	}{
+		{
+			name: "depth 1",
+			opts: Options{MaxDepth: 1},
+			want: []PlanLevel{
+				{Depth: 0, Types: []string{ResourceTypeLoadBalancer}, Actions: RequiredActions(ResourceTypeLoadBalancer)},
+				{
+					Depth: 1,
+					Types: []string{ResourceTypeACMCertificate, ResourceTypeLambda, ResourceTypeSubnet, ResourceTypeWAFWebACL},
+					Actions: sortedActions(slices.Concat(
+						RequiredActions(ResourceTypeACMCertificate),
+						RequiredActions(ResourceTypeLambda),
+						RequiredActions(ResourceTypeSubnet),
+						RequiredActions(ResourceTypeWAFWebACL),
+					)),
+				},
+			},
+		},
+		{
+			name: "skip route53 and exclude lambda",
+			opts: Options{MaxDepth: 1, SkipRoute53: true, ExcludeTypes: []string{ResourceTypeLambda}},
+			want: []PlanLevel{
+				{
+					Depth: 0,
+					Types: []string{ResourceTypeLoadBalancer},
+					Actions: slices.DeleteFunc(RequiredActions(ResourceTypeLoadBalancer), func(action string) bool {
+						return strings.HasPrefix(action, "route53:")
+					}),
+				},
+				{
+					Depth: 1,
+					Types: []string{ResourceTypeACMCertificate, ResourceTypeSubnet, ResourceTypeWAFWebACL},
+					Actions: sortedActions(slices.Concat(
+						RequiredActions(ResourceTypeACMCertificate),
+						RequiredActions(ResourceTypeSubnet),
+						RequiredActions(ResourceTypeWAFWebACL),
+					)),
+				},
+			},
+		},
+		{
+			name: "depth override",
+			opts: Options{MaxDepth: 3, DepthOverrides: map[string]int{ResourceTypeACMCertificate: 0, ResourceTypeLambda: 0, ResourceTypeSubnet: 0, ResourceTypeWAFWebACL: 0}},
+			want: []PlanLevel{
+				{Depth: 0, Types: []string{ResourceTypeLoadBalancer}, Actions: RequiredActions(ResourceTypeLoadBalancer)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New(nil, &tt.opts)
+			plan, err := d.Plan(albARN)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if plan.Start == nil || plan.Start.Type != ResourceTypeLoadBalancer || plan.Start.Name != "my-alb" {
+				t.Errorf("Plan() start = %+v, want LoadBalancer my-alb", plan.Start)
+			}
+			if plan.ResolveActions != nil {
+				t.Errorf("Plan() resolve actions = %v, want none for an ARN", plan.ResolveActions)
+			}
+			if !reflect.DeepEqual(plan.Levels, tt.want) {
+				t.Errorf("Plan() levels = %+v, want %+v", plan.Levels, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanName(t *testing.T) {
+	d := New(nil, &Options{MaxDepth: 2})
+	plan, err := d.Plan("my-alb")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.Start != nil || plan.Levels != nil {
+		t.Errorf("Plan() = %+v, want no start or levels for a name", plan)
+	}
+	if !reflect.DeepEqual(plan.ResolveActions, IdentifyActions()) {
+		t.Errorf("Plan() resolve actions = %v, want %v", plan.ResolveActions, IdentifyActions())
+	}
+
+	if _, err := d.Plan("arn:aws:s3:::my-bucket"); err == nil {
+		t.Error("Plan() error = nil for an unsupported ARN")
+	}
+}
+
+func TestDiscoveredTypesAreExpanded(t *testing.T) {
+	for resourceType, found := range discoveredTypes {
+		if _, ok := discovererActions[resourceType]; !ok {
+			t.Errorf("discoveredTypes has %s, which has no discoverer", resourceType)
+		}
+		for _, foundType := range found {
+			if _, ok := discovererActions[foundType]; !ok {
+				t.Errorf("discoveredTypes[%s] has %s, which is never expanded", resourceType, foundType)
+			}
+		}
+	}
+}
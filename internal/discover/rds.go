@@ -3,7 +3,6 @@ package discover
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/rds"
@@ -14,7 +13,7 @@ import (
 
 // resolveRDSInstance resolves an RDS instance by identifier
 func (d *Discoverer) resolveRDSInstance(ctx context.Context, identifier string) (*graph.Node, error) {
-	slog.Debug("Resolving RDS instance", "identifier", identifier)
+	d.log.Debug("Resolving RDS instance", "identifier", identifier)
 
 	output, err := d.clients.RDS.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
 		DBInstanceIdentifier: &identifier,
@@ -32,7 +31,7 @@ func (d *Discoverer) resolveRDSInstance(ctx context.Context, identifier string)
 
 // resolveRDSCluster resolves an RDS cluster by identifier
 func (d *Discoverer) resolveRDSCluster(ctx context.Context, identifier string) (*graph.Node, error) {
-	slog.Debug("Resolving RDS cluster", "identifier", identifier)
+	d.log.Debug("Resolving RDS cluster", "identifier", identifier)
 
 	output, err := d.clients.RDS.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
 		DBClusterIdentifier: &identifier,
@@ -50,13 +49,15 @@ func (d *Discoverer) resolveRDSCluster(ctx context.Context, identifier string) (
 
 // discoverRDS discovers dependencies for an RDS instance or cluster
 func (d *Discoverer) discoverRDS(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering RDS dependencies", "type", node.Type, "arn", node.ARN)
+	d.log.Debug("Discovering RDS dependencies", "type", node.Type, "arn", node.ARN)
 
 	switch node.Type {
 	case ResourceTypeRDSInstance:
 		return d.discoverRDSInstance(ctx, node, g)
 	case ResourceTypeRDSCluster:
 		return d.discoverRDSCluster(ctx, node, g)
+	case ResourceTypeRDSGlobalCluster:
+		return d.discoverRDSGlobalCluster(ctx, node, g)
 	default:
 		return nil, fmt.Errorf("unknown RDS type: %s", node.Type)
 	}
@@ -64,7 +65,7 @@ func (d *Discoverer) discoverRDS(ctx context.Context, node *graph.Node, g *graph
 
 // discoverRDSInstance discovers dependencies for an RDS instance
 func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering RDS instance dependencies", "name", node.Name)
+	d.log.Debug("Discovering RDS instance dependencies", "name", node.Name)
 
 	var neighbors []string
 
@@ -85,7 +86,7 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 	// Discover subnet group
 	if instance.DBSubnetGroup != nil && instance.DBSubnetGroup.DBSubnetGroupName != nil {
 		subnetGroupNode := &graph.Node{
-			ID:      *instance.DBSubnetGroup.DBSubnetGroupName,
+			ID:      canonicalID(node.Region, node.Account, ResourceTypeDBSubnetGroup, *instance.DBSubnetGroup.DBSubnetGroupName),
 			Type:    ResourceTypeDBSubnetGroup,
 			Name:    *instance.DBSubnetGroup.DBSubnetGroupName,
 			Region:  node.Region,
@@ -118,7 +119,7 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 				continue
 			}
 			subnetNode := &graph.Node{
-				ID:      *subnet.SubnetIdentifier,
+				ID:      canonicalID(node.Region, node.Account, ResourceTypeSubnet, *subnet.SubnetIdentifier),
 				Type:    ResourceTypeSubnet,
 				Name:    *subnet.SubnetIdentifier,
 				Region:  node.Region,
@@ -152,7 +153,7 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 			continue
 		}
 		sgNode := &graph.Node{
-			ID:      *sg.VpcSecurityGroupId,
+			ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, *sg.VpcSecurityGroupId),
 			Type:    ResourceTypeSecurityGroup,
 			Name:    *sg.VpcSecurityGroupId,
 			Region:  node.Region,
@@ -209,6 +210,11 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 		}
 	}
 
+	// Discover storage encryption key
+	if instance.KmsKeyId != nil {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *instance.KmsKeyId, "DescribeDBInstances", "KmsKeyId"))
+	}
+
 	// Discover cluster membership (if instance is part of a cluster)
 	if instance.DBClusterIdentifier != nil {
 		clusterNode := &graph.Node{
@@ -237,7 +243,7 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 	if d.hasHeuristic("rds-endpoint") && instance.Endpoint != nil && instance.Endpoint.Address != nil {
 		upstreamNeighbors, heuristicErr := d.discoverRDSUpstream(ctx, *instance.Endpoint.Address, node, g)
 		if heuristicErr != nil {
-			slog.Warn("Failed to discover RDS upstream connections", "error", heuristicErr)
+			d.warn(node.ID, "Failed to discover RDS upstream connections", heuristicErr)
 		} else {
 			neighbors = append(neighbors, upstreamNeighbors...)
 		}
@@ -248,7 +254,7 @@ func (d *Discoverer) discoverRDSInstance(ctx context.Context, node *graph.Node,
 
 // discoverRDSCluster discovers dependencies for an RDS cluster
 func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering RDS cluster dependencies", "name", node.Name)
+	d.log.Debug("Discovering RDS cluster dependencies", "name", node.Name)
 
 	var neighbors []string
 
@@ -265,6 +271,20 @@ func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g
 	}
 
 	cluster := &output.DBClusters[0]
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	setRDSClusterCapacityMetadata(node.Metadata, cluster)
+
+	// Discover global database membership; other members may live in other regions
+	if cluster.GlobalClusterIdentifier != nil && *cluster.GlobalClusterIdentifier != "" {
+		globalNeighbors, globalErr := d.discoverGlobalClusterMembership(ctx, *cluster.GlobalClusterIdentifier, node, g)
+		if globalErr != nil {
+			d.warn(node.ID, "Failed to discover global cluster", globalErr, "globalCluster", *cluster.GlobalClusterIdentifier)
+		} else {
+			neighbors = append(neighbors, globalNeighbors...)
+		}
+	}
 
 	// Discover cluster members (instances)
 	for i := range cluster.DBClusterMembers {
@@ -301,7 +321,7 @@ func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g
 	// Discover subnet group
 	if cluster.DBSubnetGroup != nil {
 		subnetGroupNode := &graph.Node{
-			ID:      *cluster.DBSubnetGroup,
+			ID:      canonicalID(node.Region, node.Account, ResourceTypeDBSubnetGroup, *cluster.DBSubnetGroup),
 			Type:    ResourceTypeDBSubnetGroup,
 			Name:    *cluster.DBSubnetGroup,
 			Region:  node.Region,
@@ -329,7 +349,7 @@ func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g
 			continue
 		}
 		sgNode := &graph.Node{
-			ID:      *sg.VpcSecurityGroupId,
+			ID:      canonicalID(node.Region, node.Account, ResourceTypeSecurityGroup, *sg.VpcSecurityGroupId),
 			Type:    ResourceTypeSecurityGroup,
 			Name:    *sg.VpcSecurityGroupId,
 			Region:  node.Region,
@@ -377,11 +397,16 @@ func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g
 		neighbors = append(neighbors, pgNode.ID)
 	}
 
+	// Discover storage encryption key
+	if cluster.KmsKeyId != nil {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *cluster.KmsKeyId, "DescribeDBClusters", "KmsKeyId"))
+	}
+
 	// Discover upstream connections using heuristics if enabled
 	if d.hasHeuristic("rds-endpoint") && cluster.Endpoint != nil {
 		upstreamNeighbors, heuristicErr := d.discoverRDSUpstream(ctx, *cluster.Endpoint, node, g)
 		if heuristicErr != nil {
-			slog.Warn("Failed to discover RDS upstream connections", "error", heuristicErr)
+			d.warn(node.ID, "Failed to discover RDS upstream connections", heuristicErr)
 		} else {
 			neighbors = append(neighbors, upstreamNeighbors...)
 		}
@@ -390,11 +415,94 @@ func (d *Discoverer) discoverRDSCluster(ctx context.Context, node *graph.Node, g
 	return neighbors, nil
 }
 
+// discoverGlobalClusterMembership links a regional cluster's global cluster and its members.
+// Member edges are added once, when the global cluster node is first created.
+func (d *Discoverer) discoverGlobalClusterMembership(ctx context.Context, globalID string, clusterNode *graph.Node, g *graph.Graph) ([]string, error) {
+	global, err := d.describeGlobalCluster(ctx, globalID)
+	if err != nil {
+		return nil, err
+	}
+
+	globalNode := rdsGlobalClusterToNode(global, clusterNode.Account)
+	if g.HasNode(globalNode.ID) {
+		return nil, nil
+	}
+	g.AddNode(globalNode)
+
+	neighbors := []string{globalNode.ID}
+	neighbors = append(neighbors, d.addGlobalClusterMembers(g, global, globalNode)...)
+	return neighbors, nil
+}
+
+// discoverRDSGlobalCluster discovers the regional clusters in a global database
+func (d *Discoverer) discoverRDSGlobalCluster(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering RDS global cluster members", "name", node.Name)
+
+	global, err := d.describeGlobalCluster(ctx, node.Name)
+	if err != nil {
+		return nil, err
+	}
+	setRDSGlobalClusterMetadata(node, global)
+
+	return d.addGlobalClusterMembers(g, global, node), nil
+}
+
+// describeGlobalCluster fetches a global database cluster by identifier
+func (d *Discoverer) describeGlobalCluster(ctx context.Context, globalID string) (*rdstypes.GlobalCluster, error) {
+	output, err := d.clients.RDS.DescribeGlobalClusters(ctx, &rds.DescribeGlobalClustersInput{
+		GlobalClusterIdentifier: &globalID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe global cluster: %w", err)
+	}
+	if len(output.GlobalClusters) == 0 {
+		return nil, fmt.Errorf("global cluster not found: %s", globalID)
+	}
+	return &output.GlobalClusters[0], nil
+}
+
+// addGlobalClusterMembers adds a member-of-global edge from each regional cluster to the global cluster
+func (d *Discoverer) addGlobalClusterMembers(g *graph.Graph, global *rdstypes.GlobalCluster, globalNode *graph.Node) []string {
+	var neighbors []string
+
+	for i := range global.GlobalClusterMembers {
+		member := &global.GlobalClusterMembers[i]
+		if member.DBClusterArn == nil {
+			continue
+		}
+
+		memberNode, err := d.parseARN(*member.DBClusterArn)
+		if err != nil || memberNode.Type != ResourceTypeRDSCluster {
+			d.log.Debug("Skipping unsupported global cluster member", "arn", *member.DBClusterArn, "error", err)
+			continue
+		}
+		if !g.HasNode(memberNode.ID) {
+			g.AddNode(memberNode)
+		}
+
+		g.AddEdge(&graph.Edge{
+			From:         memberNode.ID,
+			To:           globalNode.ID,
+			RelationType: "member-of-global",
+			Evidence: graph.Evidence{
+				APICall: "DescribeGlobalClusters",
+				Fields: map[string]any{
+					"DBClusterArn": *member.DBClusterArn,
+					"IsWriter":     member.IsWriter,
+				},
+			},
+		})
+		neighbors = append(neighbors, memberNode.ID)
+	}
+
+	return neighbors
+}
+
 // discoverRDSUpstream discovers upstream resources that connect to an RDS endpoint
 // This uses heuristic-based discovery by searching for Lambda functions and ECS services
 // that have environment variables containing the RDS endpoint
 func (d *Discoverer) discoverRDSUpstream(ctx context.Context, endpoint string, rdsNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering RDS upstream connections (heuristic)", "endpoint", endpoint)
+	d.log.Debug("Discovering RDS upstream connections (heuristic)", "endpoint", endpoint)
 
 	var neighbors []string
 
@@ -406,7 +514,7 @@ func (d *Discoverer) discoverRDSUpstream(ctx context.Context, endpoint string, r
 	// For MVP, we'll log that this is a placeholder for heuristic discovery
 	// and return empty list. Full implementation would be more complex.
 
-	slog.Debug("RDS upstream heuristic discovery not yet fully implemented", "endpoint", endpoint)
+	d.log.Debug("RDS upstream heuristic discovery not yet fully implemented", "endpoint", endpoint)
 
 	return neighbors, nil
 }
@@ -522,6 +630,7 @@ func (d *Discoverer) rdsClusterToNode(cluster *rdstypes.DBCluster) *graph.Node {
 	if cluster.ReaderEndpoint != nil {
 		metadata["readerEndpoint"] = *cluster.ReaderEndpoint
 	}
+	setRDSClusterCapacityMetadata(metadata, cluster)
 
 	// DBClusterArn is required for node creation
 	arn := ""
@@ -539,3 +648,69 @@ func (d *Discoverer) rdsClusterToNode(cluster *rdstypes.DBCluster) *graph.Node {
 		Metadata: metadata,
 	}
 }
+
+// setRDSClusterCapacityMetadata records the engine mode, Serverless v2 capacity range and global
+// database a cluster belongs to
+func setRDSClusterCapacityMetadata(metadata map[string]any, cluster *rdstypes.DBCluster) {
+	if cluster.EngineMode != nil {
+		metadata["engineMode"] = *cluster.EngineMode
+	}
+	if scaling := cluster.ServerlessV2ScalingConfiguration; scaling != nil {
+		if scaling.MinCapacity != nil {
+			metadata["serverlessV2MinACU"] = *scaling.MinCapacity
+		}
+		if scaling.MaxCapacity != nil {
+			metadata["serverlessV2MaxACU"] = *scaling.MaxCapacity
+		}
+	}
+	if cluster.GlobalClusterIdentifier != nil && *cluster.GlobalClusterIdentifier != "" {
+		metadata["globalClusterIdentifier"] = *cluster.GlobalClusterIdentifier
+	}
+}
+
+// rdsGlobalClusterToNode creates a global database cluster node. Global clusters have no
+// region, and their ARNs carry the account.
+func rdsGlobalClusterToNode(global *rdstypes.GlobalCluster, account string) *graph.Node {
+	var name string
+	if global.GlobalClusterIdentifier != nil {
+		name = *global.GlobalClusterIdentifier
+	}
+
+	arn := name
+	if global.GlobalClusterArn != nil {
+		arn = *global.GlobalClusterArn
+		// ARN format: arn:aws:rds::account:global-cluster:name
+		if parts := strings.Split(arn, ":"); len(parts) >= 5 && parts[4] != "" {
+			account = parts[4]
+		}
+	}
+
+	node := &graph.Node{
+		ID:       arn,
+		Type:     ResourceTypeRDSGlobalCluster,
+		ARN:      arn,
+		Name:     name,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+	setRDSGlobalClusterMetadata(node, global)
+
+	return node
+}
+
+// setRDSGlobalClusterMetadata records engine and status details from DescribeGlobalClusters
+func setRDSGlobalClusterMetadata(node *graph.Node, global *rdstypes.GlobalCluster) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	if global.Engine != nil {
+		node.Metadata["engine"] = *global.Engine
+	}
+	if global.EngineVersion != nil {
+		node.Metadata["engineVersion"] = *global.EngineVersion
+	}
+	if global.Status != nil {
+		node.Metadata["status"] = *global.Status
+	}
+	node.Metadata["members"] = len(global.GlobalClusterMembers)
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
 func TestRDSInstanceToNode(t *testing.T) {
@@ -178,3 +180,114 @@ func TestHasHeuristic(t *testing.T) {
 		})
 	}
 }
+
+func TestRDSClusterToNodeServerlessV2(t *testing.T) {
+	d := &Discoverer{}
+
+	arn := "arn:aws:rds:us-east-1:123456789012:cluster:my-cluster"
+	identifier := "my-cluster"
+	engineMode := "provisioned"
+	globalID := "my-global"
+	minCapacity := 0.5
+	maxCapacity := 16.0
+
+	cluster := &rdstypes.DBCluster{
+		DBClusterArn:            &arn,
+		DBClusterIdentifier:     &identifier,
+		EngineMode:              &engineMode,
+		GlobalClusterIdentifier: &globalID,
+		ServerlessV2ScalingConfiguration: &rdstypes.ServerlessV2ScalingConfigurationInfo{
+			MinCapacity: &minCapacity,
+			MaxCapacity: &maxCapacity,
+		},
+	}
+
+	node := d.rdsClusterToNode(cluster)
+
+	if node.Metadata["engineMode"] != engineMode {
+		t.Errorf("Expected engineMode %s, got %v", engineMode, node.Metadata["engineMode"])
+	}
+	if node.Metadata["serverlessV2MinACU"] != minCapacity {
+		t.Errorf("Expected serverlessV2MinACU %v, got %v", minCapacity, node.Metadata["serverlessV2MinACU"])
+	}
+	if node.Metadata["serverlessV2MaxACU"] != maxCapacity {
+		t.Errorf("Expected serverlessV2MaxACU %v, got %v", maxCapacity, node.Metadata["serverlessV2MaxACU"])
+	}
+	if node.Metadata["globalClusterIdentifier"] != globalID {
+		t.Errorf("Expected globalClusterIdentifier %s, got %v", globalID, node.Metadata["globalClusterIdentifier"])
+	}
+}
+
+func TestGlobalClusterMembers(t *testing.T) {
+	d := &Discoverer{}
+	g := graph.New()
+
+	globalARN := "arn:aws:rds::123456789012:global-cluster:my-global"
+	globalID := "my-global"
+	engine := "aurora-postgresql"
+	status := "available"
+	primaryARN := "arn:aws:rds:us-east-1:123456789012:cluster:primary"
+	secondaryARN := "arn:aws:rds:eu-west-1:123456789012:cluster:secondary"
+	writer := true
+
+	global := &rdstypes.GlobalCluster{
+		GlobalClusterArn:        &globalARN,
+		GlobalClusterIdentifier: &globalID,
+		Engine:                  &engine,
+		Status:                  &status,
+		GlobalClusterMembers: []rdstypes.GlobalClusterMember{
+			{DBClusterArn: &primaryARN, IsWriter: &writer},
+			{DBClusterArn: &secondaryARN},
+		},
+	}
+
+	globalNode := rdsGlobalClusterToNode(global, "999999999999")
+	if globalNode.ID != globalARN || globalNode.Type != ResourceTypeRDSGlobalCluster {
+		t.Fatalf("Unexpected global cluster node %s (%s)", globalNode.ID, globalNode.Type)
+	}
+	if globalNode.Name != globalID {
+		t.Errorf("Expected Name %s, got %s", globalID, globalNode.Name)
+	}
+	if globalNode.Account != "123456789012" {
+		t.Errorf("Expected Account from ARN, got %s", globalNode.Account)
+	}
+	if globalNode.Region != "" {
+		t.Errorf("Expected no Region for global cluster, got %s", globalNode.Region)
+	}
+	if globalNode.Metadata["members"] != 2 {
+		t.Errorf("Expected 2 members in metadata, got %v", globalNode.Metadata["members"])
+	}
+	g.AddNode(globalNode)
+
+	neighbors := d.addGlobalClusterMembers(g, global, globalNode)
+	if len(neighbors) != 2 || neighbors[0] != primaryARN || neighbors[1] != secondaryARN {
+		t.Fatalf("Expected both regional clusters as neighbors, got %v", neighbors)
+	}
+
+	wantRegions := map[string]string{primaryARN: "us-east-1", secondaryARN: "eu-west-1"}
+	for arn, region := range wantRegions {
+		node, ok := g.GetNode(arn)
+		if !ok {
+			t.Fatalf("Expected member node %s", arn)
+		}
+		if node.Type != ResourceTypeRDSCluster || node.Region != region {
+			t.Errorf("Expected RDSCluster in %s for %s, got %s in %s", region, arn, node.Type, node.Region)
+		}
+	}
+
+	edges := g.Edges()
+	if len(edges) != 2 {
+		t.Fatalf("Expected 2 edges, got %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.To != globalARN || edge.RelationType != "member-of-global" {
+			t.Errorf("Unexpected edge %s -[%s]-> %s", edge.From, edge.RelationType, edge.To)
+		}
+		if edge.Evidence.APICall != "DescribeGlobalClusters" {
+			t.Errorf("Expected DescribeGlobalClusters evidence, got %s", edge.Evidence.APICall)
+		}
+	}
+	if edges[0].Evidence.Fields["IsWriter"] != &writer {
+		t.Errorf("Expected IsWriter evidence on primary edge")
+	}
+}
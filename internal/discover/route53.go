@@ -3,69 +3,112 @@ package discover
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
 
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
-// discoverRoute53Aliases discovers Route53 records that alias to a given DNS name
+// route53Target is an alias or CNAME record together with the hosted zone it belongs to
+type route53Target struct {
+	record route53types.ResourceRecordSet
+	zone   *route53types.HostedZone
+}
+
+// discoverRoute53Aliases discovers Route53 alias and CNAME records that point to a given DNS name
 func (d *Discoverer) discoverRoute53Aliases(ctx context.Context, dnsName string, targetNode *graph.Node, g *graph.Graph) ([]string, error) {
-	slog.Debug("Discovering Route53 aliases", "dnsName", dnsName)
+	d.log.Debug("Discovering Route53 aliases", "dnsName", dnsName)
+
+	index, err := d.route53AliasIndex(ctx, targetNode)
+	if err != nil {
+		return nil, err
+	}
 
 	var neighbors []string
+	targets := index[normalizeDNSName(dnsName)]
+	for i := range targets {
+		record := &targets[i].record
+		zone := targets[i].zone
+		recordNode := d.route53RecordToNode(record, zone, targetNode.Region, targetNode.Account)
+		g.AddNode(recordNode)
 
-	// Normalize DNS name (remove trailing dot if present)
-	dnsName = strings.TrimSuffix(dnsName, ".")
+		relation := "aliases-to"
+		fields := map[string]any{
+			"Name":           record.Name,
+			"Type":           record.Type,
+			"HostedZoneId":   *zone.Id,
+			"HostedZoneName": zone.Name,
+		}
+		if record.AliasTarget != nil {
+			fields["AliasTarget"] = record.AliasTarget
+		} else {
+			relation = "cname-to"
+			fields["ResourceRecords"] = resourceRecordValues(record)
+		}
+		if record.SetIdentifier != nil {
+			fields["SetIdentifier"] = *record.SetIdentifier
+		}
+
+		g.AddEdge(&graph.Edge{
+			From:         recordNode.ID,
+			To:           targetNode.ID,
+			RelationType: relation,
+			Evidence: graph.Evidence{
+				APICall: "ListResourceRecordSets",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, recordNode.ID)
+	}
+
+	return neighbors, nil
+}
 
-	// List all hosted zones
+// route53AliasIndex lazily builds an index of every alias and CNAME record across all hosted
+// zones in the target node's account, keyed by the normalized DNS name they point to. Route53 is
+// global, so the index is shared by every region of the account.
+func (d *Discoverer) route53AliasIndex(ctx context.Context, targetNode *graph.Node) (map[string][]route53Target, error) {
+	if index, ok := d.route53Aliases[targetNode.Account]; ok {
+		return index, nil
+	}
+
+	// Zones listed before a failure are still searched
 	hostedZones, err := d.listHostedZones(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list hosted zones: %w", err)
+		if len(hostedZones) == 0 {
+			return nil, err
+		}
+		d.warn(targetNode.ID, "Failed to list all hosted zones", err)
 	}
 
-	// Search each hosted zone for alias records pointing to this DNS name
+	index := make(map[string][]route53Target)
 	for i := range hostedZones {
 		zone := &hostedZones[i]
 		if zone.Id == nil {
 			continue
 		}
 
-		records, err := d.findAliasRecordsInZone(ctx, *zone.Id, dnsName)
-		if err != nil {
-			slog.Warn("Failed to search hosted zone for aliases",
-				"zoneId", *zone.Id,
-				"error", err)
-			continue
+		// Records from pages read before a failure are still indexed
+		records, listErr := d.listTargetRecordsInZone(ctx, *zone.Id)
+		if listErr != nil {
+			d.warn(targetNode.ID, "Failed to search hosted zone for aliases", listErr, "zoneId", *zone.Id)
 		}
-
 		for j := range records {
-			record := &records[j]
-			recordNode := d.route53RecordToNode(record, zone, targetNode.Region, targetNode.Account)
-			g.AddNode(recordNode)
-			g.AddEdge(&graph.Edge{
-				From:         recordNode.ID,
-				To:           targetNode.ID,
-				RelationType: "aliases-to",
-				Evidence: graph.Evidence{
-					APICall: "ListResourceRecordSets",
-					Fields: map[string]any{
-						"Name":           record.Name,
-						"Type":           record.Type,
-						"AliasTarget":    record.AliasTarget,
-						"HostedZoneId":   *zone.Id,
-						"HostedZoneName": zone.Name,
-					},
-				},
-			})
-			neighbors = append(neighbors, recordNode.ID)
+			for _, name := range recordTargetNames(&records[j]) {
+				key := normalizeDNSName(name)
+				index[key] = append(index[key], route53Target{record: records[j], zone: zone})
+			}
 		}
 	}
 
-	return neighbors, nil
+	if d.route53Aliases == nil {
+		d.route53Aliases = make(map[string]map[string][]route53Target)
+	}
+	d.route53Aliases[targetNode.Account] = index
+	return index, nil
 }
 
 // listHostedZones lists all Route53 hosted zones
@@ -74,53 +117,71 @@ func (d *Discoverer) listHostedZones(ctx context.Context) ([]route53types.Hosted
 
 	paginator := route53.NewListHostedZonesPaginator(d.clients.Route53, &route53.ListHostedZonesInput{})
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list hosted zones: %w", err)
-		}
+	pageErr := awsx.EachPage(ctx, paginator, func(output *route53.ListHostedZonesOutput) error {
 		zones = append(zones, output.HostedZones...)
+		return nil
+	})
+	if pageErr != nil {
+		return zones, fmt.Errorf("failed to list hosted zones: %w", pageErr)
 	}
 
 	return zones, nil
 }
 
-// findAliasRecordsInZone finds alias records in a hosted zone that point to the given DNS name
-func (d *Discoverer) findAliasRecordsInZone(ctx context.Context, hostedZoneID, targetDNS string) ([]route53types.ResourceRecordSet, error) {
-	var matchingRecords []route53types.ResourceRecordSet
-
-	// Normalize target DNS name
-	targetDNS = strings.TrimSuffix(targetDNS, ".")
-	targetDNSWithDot := targetDNS + "."
+// listTargetRecordsInZone lists the alias and CNAME records in a hosted zone
+func (d *Discoverer) listTargetRecordsInZone(ctx context.Context, hostedZoneID string) ([]route53types.ResourceRecordSet, error) {
+	var targetRecords []route53types.ResourceRecordSet
 
 	paginator := route53.NewListResourceRecordSetsPaginator(d.clients.Route53, &route53.ListResourceRecordSetsInput{
 		HostedZoneId: &hostedZoneID,
 	})
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list resource record sets: %w", err)
-		}
-
+	pageErr := awsx.EachPage(ctx, paginator, func(output *route53.ListResourceRecordSetsOutput) error {
 		for i := range output.ResourceRecordSets {
 			record := &output.ResourceRecordSets[i]
-			// Check if this is an alias record
-			if record.AliasTarget == nil || record.AliasTarget.DNSName == nil {
-				continue
+			if len(recordTargetNames(record)) > 0 {
+				targetRecords = append(targetRecords, *record)
 			}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return targetRecords, fmt.Errorf("failed to list resource record sets: %w", pageErr)
+	}
 
-			// Normalize alias target DNS name
-			aliasDNS := strings.TrimSuffix(*record.AliasTarget.DNSName, ".")
+	return targetRecords, nil
+}
 
-			// Check if it matches our target
-			if aliasDNS == targetDNS || *record.AliasTarget.DNSName == targetDNSWithDot {
-				matchingRecords = append(matchingRecords, *record)
-			}
+// recordTargetNames returns the DNS names a record points to: the alias target of an alias
+// record, or the value of a CNAME record. Other records point to nothing discoverable.
+func recordTargetNames(record *route53types.ResourceRecordSet) []string {
+	if record.AliasTarget != nil {
+		if record.AliasTarget.DNSName == nil {
+			return nil
 		}
+		return []string{*record.AliasTarget.DNSName}
 	}
+	if record.Type == route53types.RRTypeCname {
+		return resourceRecordValues(record)
+	}
+	return nil
+}
+
+// resourceRecordValues returns the values of a record's non-alias resource records
+func resourceRecordValues(record *route53types.ResourceRecordSet) []string {
+	var values []string
+	for _, rr := range record.ResourceRecords {
+		if rr.Value != nil {
+			values = append(values, *rr.Value)
+		}
+	}
+	return values
+}
 
-	return matchingRecords, nil
+// normalizeDNSName lowercases a DNS name and removes its trailing dot, so alias targets and
+// load balancer DNS names compare equal however they are written
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
 }
 
 // route53RecordToNode converts a Route53 record to a graph node
@@ -151,9 +212,30 @@ func (d *Discoverer) route53RecordToNode(record *route53types.ResourceRecordSet,
 	if record.SetIdentifier != nil {
 		metadata["setIdentifier"] = *record.SetIdentifier
 	}
+	if record.Type == route53types.RRTypeCname {
+		metadata["cnameTarget"] = strings.Join(resourceRecordValues(record), ",")
+	}
+
+	// Routing policy attributes, which decide how traffic is split between records sharing a name
+	if record.Weight != nil {
+		metadata["weight"] = *record.Weight
+	}
+	if record.Region != "" {
+		metadata["latencyRegion"] = record.Region
+	}
+	if record.Failover != "" {
+		metadata["failover"] = record.Failover
+	}
+	if geo := record.GeoLocation; geo != nil {
+		metadata["geoLocation"] = geoLocationString(geo)
+	}
 
-	// Generate a unique ID for the record
+	// Generate a unique ID for the record; routing policy variants of the same name are told
+	// apart by their set identifier
 	id := fmt.Sprintf("route53:%s:%s:%s", *zone.Id, name, record.Type)
+	if record.SetIdentifier != nil {
+		id += ":" + *record.SetIdentifier
+	}
 
 	return &graph.Node{
 		ID:       id,
@@ -164,3 +246,15 @@ func (d *Discoverer) route53RecordToNode(record *route53types.ResourceRecordSet,
 		Metadata: metadata,
 	}
 }
+
+// geoLocationString formats a geolocation routing rule as continent, country and subdivision
+// codes joined by slashes, such as "NA/US/WA"; "*" is the default location
+func geoLocationString(geo *route53types.GeoLocation) string {
+	var parts []string
+	for _, code := range []*string{geo.ContinentCode, geo.CountryCode, geo.SubdivisionCode} {
+		if code != nil {
+			parts = append(parts, *code)
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,256 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestNormalizeDNSName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Trailing dot", in: "my-alb-123.us-east-1.elb.amazonaws.com.", want: "my-alb-123.us-east-1.elb.amazonaws.com"},
+		{name: "Mixed case", in: "My-ALB-123.us-east-1.elb.amazonaws.com", want: "my-alb-123.us-east-1.elb.amazonaws.com"},
+		{name: "Already normalized", in: "api.example.com", want: "api.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDNSName(tt.in); got != tt.want {
+				t.Errorf("normalizeDNSName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverRoute53AliasesFetchesInventoryOnce(t *testing.T) {
+	alias := func(name, target string) route53types.ResourceRecordSet {
+		return route53types.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: route53types.RRTypeA,
+			AliasTarget: &route53types.AliasTarget{
+				DNSName:      aws.String(target),
+				HostedZoneId: aws.String("Z35SXDOTRQ7X7K"),
+			},
+		}
+	}
+	stub := newStubAPI(map[string]any{
+		"ListHostedZones": &route53.ListHostedZonesOutput{
+			HostedZones: []route53types.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+			},
+		},
+		"ListResourceRecordSets": &route53.ListResourceRecordSetsOutput{
+			ResourceRecordSets: []route53types.ResourceRecordSet{
+				alias("api.example.com.", "api-alb-1.us-east-1.elb.amazonaws.com."),
+				alias("www.example.com.", "Web-ALB-2.us-east-1.elb.amazonaws.com."),
+				{Name: aws.String("mail.example.com."), Type: route53types.RRTypeMx},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+
+	tests := []struct {
+		dnsName string
+		want    int
+	}{
+		{dnsName: "api-alb-1.us-east-1.elb.amazonaws.com", want: 1},
+		{dnsName: "web-alb-2.us-east-1.elb.amazonaws.com", want: 1},
+		{dnsName: "unaliased-alb-3.us-east-1.elb.amazonaws.com", want: 0},
+	}
+	for _, tt := range tests {
+		target := &graph.Node{ID: tt.dnsName, Type: ResourceTypeLoadBalancer, Account: "123456789012", Region: "us-east-1"}
+		g.AddNode(target)
+
+		neighbors, err := d.discoverRoute53Aliases(context.Background(), tt.dnsName, target, g)
+		if err != nil {
+			t.Fatalf("discoverRoute53Aliases(%q) error = %v", tt.dnsName, err)
+		}
+		if len(neighbors) != tt.want {
+			t.Errorf("discoverRoute53Aliases(%q) neighbors = %v, want %d", tt.dnsName, neighbors, tt.want)
+		}
+	}
+
+	if got := stub.callCount("ListHostedZones"); got != 1 {
+		t.Errorf("ListHostedZones called %d times, want 1", got)
+	}
+	if got := stub.callCount("ListResourceRecordSets"); got != 1 {
+		t.Errorf("ListResourceRecordSets called %d times, want 1", got)
+	}
+}
+
+func TestDiscoverRoute53AliasesKeepsPagesBeforeFailure(t *testing.T) {
+	const lbDNS = "api-alb-1.us-east-1.elb.amazonaws.com"
+
+	denied := errors.New("access denied")
+	stub := newStubAPI(map[string]any{
+		"ListHostedZones": &route53.ListHostedZonesOutput{
+			HostedZones: []route53types.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+			},
+		},
+		// The second page of the zone fails after the first was read
+		"ListResourceRecordSets": []any{
+			&route53.ListResourceRecordSetsOutput{
+				ResourceRecordSets: []route53types.ResourceRecordSet{{
+					Name: aws.String("api.example.com."),
+					Type: route53types.RRTypeA,
+					AliasTarget: &route53types.AliasTarget{
+						DNSName:      aws.String(lbDNS + "."),
+						HostedZoneId: aws.String("Z35SXDOTRQ7X7K"),
+					},
+				}},
+				IsTruncated:    true,
+				NextRecordName: aws.String("www.example.com."),
+			},
+			denied,
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	target := &graph.Node{ID: lbDNS, Type: ResourceTypeLoadBalancer, Account: "123456789012", Region: "us-east-1"}
+	g.AddNode(target)
+
+	neighbors, err := d.discoverRoute53Aliases(context.Background(), lbDNS, target, g)
+	if err != nil {
+		t.Fatalf("discoverRoute53Aliases() error = %v", err)
+	}
+	if len(neighbors) != 1 {
+		t.Errorf("discoverRoute53Aliases() neighbors = %v, want the alias from the first page", neighbors)
+	}
+	if got := stub.callCount("ListResourceRecordSets"); got != 2 {
+		t.Errorf("ListResourceRecordSets called %d times, want 2", got)
+	}
+
+	errs := d.Errors()
+	if len(errs) != 1 || !errors.Is(errs[0], denied) {
+		t.Errorf("Errors() = %v, want the failed page", errs)
+	}
+}
+
+func TestDiscoverRoute53CNAMEAndWeightedRecords(t *testing.T) {
+	const lbDNS = "api-alb-1.us-east-1.elb.amazonaws.com"
+	weighted := func(id string, weight int64) route53types.ResourceRecordSet {
+		return route53types.ResourceRecordSet{
+			Name:          aws.String("api.example.com."),
+			Type:          route53types.RRTypeA,
+			SetIdentifier: aws.String(id),
+			Weight:        aws.Int64(weight),
+			AliasTarget: &route53types.AliasTarget{
+				DNSName:      aws.String(lbDNS + "."),
+				HostedZoneId: aws.String("Z35SXDOTRQ7X7K"),
+			},
+		}
+	}
+	stub := newStubAPI(map[string]any{
+		"ListHostedZones": &route53.ListHostedZonesOutput{
+			HostedZones: []route53types.HostedZone{
+				{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com.")},
+			},
+		},
+		"ListResourceRecordSets": &route53.ListResourceRecordSetsOutput{
+			ResourceRecordSets: []route53types.ResourceRecordSet{
+				weighted("blue", 70),
+				weighted("green", 30),
+				{
+					Name:            aws.String("legacy.example.com."),
+					Type:            route53types.RRTypeCname,
+					ResourceRecords: []route53types.ResourceRecord{{Value: aws.String(lbDNS)}},
+				},
+				{
+					Name:            aws.String("_acme.example.com."),
+					Type:            route53types.RRTypeTxt,
+					ResourceRecords: []route53types.ResourceRecord{{Value: aws.String(lbDNS)}},
+				},
+			},
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	target := &graph.Node{ID: "lb", Type: ResourceTypeLoadBalancer, Account: "123456789012", Region: "us-east-1"}
+	g.AddNode(target)
+
+	neighbors, err := d.discoverRoute53Aliases(context.Background(), lbDNS, target, g)
+	if err != nil {
+		t.Fatalf("discoverRoute53Aliases() error = %v", err)
+	}
+	if len(neighbors) != 3 {
+		t.Fatalf("discoverRoute53Aliases() neighbors = %v, want 2 weighted records and 1 CNAME", neighbors)
+	}
+
+	relations := make(map[string]string)
+	for _, edge := range g.EdgesTo(target.ID) {
+		relations[edge.From] = edge.RelationType
+	}
+
+	tests := []struct {
+		id           string
+		wantRelation string
+		wantMetadata map[string]any
+	}{
+		{
+			id:           "route53:/hostedzone/Z1:api.example.com:A:blue",
+			wantRelation: "aliases-to",
+			wantMetadata: map[string]any{"weight": int64(70), "setIdentifier": "blue"},
+		},
+		{
+			id:           "route53:/hostedzone/Z1:api.example.com:A:green",
+			wantRelation: "aliases-to",
+			wantMetadata: map[string]any{"weight": int64(30), "setIdentifier": "green"},
+		},
+		{
+			id:           "route53:/hostedzone/Z1:legacy.example.com:CNAME",
+			wantRelation: "cname-to",
+			wantMetadata: map[string]any{"cnameTarget": lbDNS},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			node, ok := g.GetNode(tt.id)
+			if !ok {
+				t.Fatalf("record node %s not found", tt.id)
+			}
+			if relations[tt.id] != tt.wantRelation {
+				t.Errorf("relation = %q, want %q", relations[tt.id], tt.wantRelation)
+			}
+			for key, want := range tt.wantMetadata {
+				if node.Metadata[key] != want {
+					t.Errorf("metadata[%s] = %v, want %v", key, node.Metadata[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoLocationString(t *testing.T) {
+	tests := []struct {
+		name string
+		geo  route53types.GeoLocation
+		want string
+	}{
+		{name: "Subdivision", geo: route53types.GeoLocation{CountryCode: aws.String("US"), SubdivisionCode: aws.String("WA")}, want: "US/WA"},
+		{name: "Continent", geo: route53types.GeoLocation{ContinentCode: aws.String("EU")}, want: "EU"},
+		{name: "Default", geo: route53types.GeoLocation{CountryCode: aws.String("*")}, want: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geoLocationString(&tt.geo); got != tt.want {
+				t.Errorf("geoLocationString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
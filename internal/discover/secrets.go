@@ -0,0 +1,352 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+var (
+	// secretARNPattern matches a Secrets Manager secret ARN embedded in a configuration value
+	secretARNPattern = regexp.MustCompile(`arn:aws[a-zA-Z-]*:secretsmanager:[a-z0-9-]+:\d{12}:secret:[A-Za-z0-9/_+=.@-]+`)
+	// ssmParameterARNPattern matches an SSM parameter ARN embedded in a configuration value
+	ssmParameterARNPattern = regexp.MustCompile(`arn:aws[a-zA-Z-]*:ssm:[a-z0-9-]+:\d{12}:parameter/[A-Za-z0-9/_.-]+`)
+)
+
+// discoverEnvSecretReferences links a resource to the secrets and parameters referenced by
+// ARN in its environment variables. Only variable names are recorded, never values.
+func (d *Discoverer) discoverEnvSecretReferences(env map[string]string, apiCall string, sourceNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+
+	// Iterate in a stable order so edges are added deterministically
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, arn := range findSecretReferences(env[key]) {
+			secretNode := secretReferenceToNode(arn, sourceNode.Region, sourceNode.Account)
+			if !g.HasNode(secretNode.ID) {
+				g.AddNode(secretNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         sourceNode.ID,
+				To:           secretNode.ID,
+				RelationType: "reads-secret",
+				Evidence: graph.Evidence{
+					APICall: apiCall,
+					Fields: map[string]any{
+						"EnvironmentVariable": key,
+					},
+					Heuristic: true,
+				},
+			})
+			neighbors = append(neighbors, secretNode.ID)
+		}
+	}
+
+	return neighbors
+}
+
+// discoverTaskDefinitionSecrets links a task definition to the secrets and parameters ECS
+// injects into its containers: secrets blocks, log driver secret options and private
+// registry credentials. Only secret names are recorded, never values.
+func discoverTaskDefinitionSecrets(containers []ecstypes.ContainerDefinition, tdNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	addSecret := func(valueFrom string, fields map[string]any) {
+		arn := secretValueFromARN(valueFrom, tdNode)
+		secretNode := secretReferenceToNode(arn, tdNode.Region, tdNode.Account)
+		if !g.HasNode(secretNode.ID) {
+			g.AddNode(secretNode)
+		}
+		fields["ValueFrom"] = valueFrom
+		g.AddEdge(&graph.Edge{
+			From:         tdNode.ID,
+			To:           secretNode.ID,
+			RelationType: "reads-secret",
+			Evidence: graph.Evidence{
+				APICall: "DescribeTaskDefinition",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, secretNode.ID)
+	}
+
+	for i := range containers {
+		container := &containers[i]
+		containerName := aws.ToString(container.Name)
+
+		secrets := append([]ecstypes.Secret(nil), container.Secrets...)
+		if container.LogConfiguration != nil {
+			secrets = append(secrets, container.LogConfiguration.SecretOptions...)
+		}
+		for _, secret := range secrets {
+			if secret.ValueFrom == nil {
+				continue
+			}
+			addSecret(*secret.ValueFrom, map[string]any{
+				"Container": containerName,
+				"Secret":    aws.ToString(secret.Name),
+			})
+		}
+
+		if creds := container.RepositoryCredentials; creds != nil && creds.CredentialsParameter != nil {
+			addSecret(*creds.CredentialsParameter, map[string]any{
+				"Container":            containerName,
+				"CredentialsParameter": *creds.CredentialsParameter,
+			})
+		}
+	}
+
+	return neighbors
+}
+
+// secretValueFromARN normalizes an ECS valueFrom reference to the ARN of the secret or
+// parameter. Secrets Manager references may carry a JSON key, version stage and version ID
+// after the secret ARN; SSM parameters in the task's own region may be given by name alone.
+func secretValueFromARN(valueFrom string, tdNode *graph.Node) string {
+	if strings.HasPrefix(valueFrom, "arn:") {
+		// arn:aws:secretsmanager:region:account:secret:name-AbCdEf[:json-key:version-stage:version-id]
+		if parts := strings.Split(valueFrom, ":"); len(parts) > 7 && parts[2] == "secretsmanager" {
+			return strings.Join(parts[:7], ":")
+		}
+		return valueFrom
+	}
+
+	partition := "aws"
+	if parts := strings.SplitN(tdNode.ARN, ":", 3); len(parts) == 3 && parts[0] == "arn" {
+		partition = parts[1]
+	}
+	return fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s", partition, tdNode.Region, tdNode.Account, strings.TrimPrefix(valueFrom, "/"))
+}
+
+// findSecretReferences returns the Secrets Manager and SSM parameter ARNs found in value
+func findSecretReferences(value string) []string {
+	var arns []string
+	arns = append(arns, secretARNPattern.FindAllString(value, -1)...)
+	arns = append(arns, ssmParameterARNPattern.FindAllString(value, -1)...)
+	return arns
+}
+
+// discoverSecret fetches secret metadata and links the rotation Lambda and encryption key.
+// The secret value is never read.
+func (d *Discoverer) discoverSecret(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Secrets Manager secret", "arn", node.ARN)
+
+	var neighbors []string
+
+	output, err := d.clients.SecretsManager.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: &node.ARN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
+	}
+
+	if output.Name != nil {
+		node.Name = *output.Name
+	}
+	if output.ARN != nil {
+		node.ARN = *output.ARN
+	}
+	node.Metadata["rotationEnabled"] = aws.ToBool(output.RotationEnabled)
+	if output.LastChangedDate != nil {
+		node.Metadata["lastChangedDate"] = *output.LastChangedDate
+	}
+	if output.LastRotatedDate != nil {
+		node.Metadata["lastRotatedDate"] = *output.LastRotatedDate
+	}
+	if output.Description != nil && *output.Description != "" {
+		node.Metadata["description"] = *output.Description
+	}
+	if len(output.Tags) > 0 {
+		node.Tags = make(map[string]string, len(output.Tags))
+		for _, tag := range output.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				node.Tags[*tag.Key] = *tag.Value
+			}
+		}
+	}
+
+	// Discover rotation Lambda
+	if output.RotationLambdaARN != nil && *output.RotationLambdaARN != "" {
+		node.Metadata["rotationLambdaArn"] = *output.RotationLambdaARN
+		lambdaNode, parseErr := d.parseARN(normalizeLambdaARN(*output.RotationLambdaARN))
+		if parseErr != nil {
+			d.log.Debug("Skipping unsupported rotation Lambda ARN", "arn", *output.RotationLambdaARN, "error", parseErr)
+		} else {
+			if !g.HasNode(lambdaNode.ID) {
+				g.AddNode(lambdaNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           lambdaNode.ID,
+				RelationType: "rotated-by",
+				Evidence: graph.Evidence{
+					APICall: "DescribeSecret",
+					Fields: map[string]any{
+						"RotationLambdaARN": *output.RotationLambdaARN,
+					},
+				},
+			})
+			neighbors = append(neighbors, lambdaNode.ID)
+		}
+	}
+
+	// Discover encryption key
+	if output.KmsKeyId != nil && *output.KmsKeyId != "" {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *output.KmsKeyId, "DescribeSecret", "KmsKeyId"))
+	}
+
+	// Discover principals the resource policy shares the secret with
+	policyNeighbors, err := d.discoverSecretPolicy(ctx, node, g)
+	if err != nil {
+		d.warn(node.ID, "Failed to get secret resource policy", err, "secret", node.Name)
+	}
+	neighbors = append(neighbors, policyNeighbors...)
+
+	return neighbors, nil
+}
+
+// discoverSecretPolicy adds a grants-access-to edge to each principal the secret's resource
+// policy allows. Secrets without a resource policy are only reachable through IAM policies.
+func (d *Discoverer) discoverSecretPolicy(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	output, err := d.clients.SecretsManager.GetResourcePolicy(ctx, &secretsmanager.GetResourcePolicyInput{
+		SecretId: &node.ARN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource policy: %w", err)
+	}
+	node.Metadata["hasResourcePolicy"] = output.ResourcePolicy != nil
+	if output.ResourcePolicy == nil {
+		return nil, nil
+	}
+
+	principals, err := parsePolicyPrincipals(*output.ResourcePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []string
+	for _, principal := range principals {
+		principalNode := d.principalToNode(principal)
+		if !g.HasNode(principalNode.ID) {
+			g.AddNode(principalNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           principalNode.ID,
+			RelationType: "grants-access-to",
+			Evidence: graph.Evidence{
+				APICall: "GetResourcePolicy",
+				Fields: map[string]any{
+					"Principal": principal.kind + ":" + principal.id,
+					"Actions":   principal.actions,
+				},
+			},
+		})
+		neighbors = append(neighbors, principalNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// discoverSSMParameter fetches parameter metadata and links its encryption key.
+// DescribeParameters is used so the parameter value is never read.
+func (d *Discoverer) discoverSSMParameter(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering SSM parameter", "arn", node.ARN)
+
+	var neighbors []string
+
+	output, err := d.clients.SSM.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []ssmtypes.ParameterStringFilter{
+			{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: []string{node.Name},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe parameters: %w", err)
+	}
+
+	if len(output.Parameters) == 0 {
+		return nil, fmt.Errorf("SSM parameter not found: %s", node.Name)
+	}
+
+	param := &output.Parameters[0]
+	node.Metadata["parameterType"] = param.Type
+	node.Metadata["tier"] = param.Tier
+	node.Metadata["version"] = param.Version
+	if param.LastModifiedDate != nil {
+		node.Metadata["lastModifiedDate"] = *param.LastModifiedDate
+	}
+	if param.Description != nil && *param.Description != "" {
+		node.Metadata["description"] = *param.Description
+	}
+
+	// Only SecureString parameters are encrypted with a KMS key
+	if param.Type == ssmtypes.ParameterTypeSecureString && param.KeyId != nil && *param.KeyId != "" {
+		neighbors = append(neighbors, addKMSKeyEdge(g, node, *param.KeyId, "DescribeParameters", "KeyId"))
+	}
+
+	return neighbors, nil
+}
+
+// secretReferenceToNode creates a Secrets Manager secret or SSM parameter node from its ARN
+func secretReferenceToNode(arn, region, account string) *graph.Node {
+	node := &graph.Node{
+		ID:       arn,
+		ARN:      arn,
+		Name:     arn,
+		Region:   region,
+		Account:  account,
+		Metadata: make(map[string]any),
+	}
+
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) >= 6 {
+		node.Region = parts[3]
+		node.Account = parts[4]
+	}
+
+	switch {
+	case strings.Contains(arn, ":secretsmanager:"):
+		node.Type = ResourceTypeSecretsManagerSecret
+		// arn:aws:secretsmanager:region:account:secret:name-AbCdEf
+		if len(parts) == 7 {
+			node.Name = parts[6]
+		}
+	case strings.Contains(arn, ":ssm:"):
+		node.Type = ResourceTypeSSMParameter
+		node.Name = ssmParameterNameFromARN(arn)
+	}
+
+	return node
+}
+
+// ssmParameterNameFromARN returns the parameter name for an SSM parameter ARN. Hierarchical
+// names keep their leading slash; the ARN of a flat name has none of its own.
+func ssmParameterNameFromARN(arn string) string {
+	idx := strings.Index(arn, ":parameter/")
+	if idx < 0 {
+		return arn
+	}
+	name := arn[idx+len(":parameter/"):]
+	if strings.Contains(name, "/") {
+		return "/" + name
+	}
+	return name
+}
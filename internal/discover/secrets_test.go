@@ -0,0 +1,273 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestFindSecretReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "Secrets Manager ARN",
+			value: "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+			want:  []string{"arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf"},
+		},
+		{
+			name:  "SSM parameter ARN",
+			value: "arn:aws:ssm:us-east-1:123456789012:parameter/app/prod/api-key",
+			want:  []string{"arn:aws:ssm:us-east-1:123456789012:parameter/app/prod/api-key"},
+		},
+		{
+			name:  "Multiple references in one value",
+			value: "arn:aws:secretsmanager:us-east-1:123456789012:secret:a-AbCdEf,arn:aws:ssm:us-east-1:123456789012:parameter/b",
+			want: []string{
+				"arn:aws:secretsmanager:us-east-1:123456789012:secret:a-AbCdEf",
+				"arn:aws:ssm:us-east-1:123456789012:parameter/b",
+			},
+		},
+		{
+			name:  "Plain value",
+			value: "postgres://db.internal:5432/app",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSecretReferences(tt.value)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findSecretReferences() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("findSecretReferences()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSecretReferenceToNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		arn      string
+		wantType string
+		wantName string
+	}{
+		{
+			name:     "Secrets Manager secret",
+			arn:      "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+			wantType: ResourceTypeSecretsManagerSecret,
+			wantName: "prod/db-AbCdEf",
+		},
+		{
+			name:     "Hierarchical SSM parameter",
+			arn:      "arn:aws:ssm:us-east-1:123456789012:parameter/app/prod/api-key",
+			wantType: ResourceTypeSSMParameter,
+			wantName: "/app/prod/api-key",
+		},
+		{
+			name:     "Flat SSM parameter",
+			arn:      "arn:aws:ssm:us-east-1:123456789012:parameter/api-key",
+			wantType: ResourceTypeSSMParameter,
+			wantName: "api-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := secretReferenceToNode(tt.arn, "", "")
+			if node.Type != tt.wantType {
+				t.Errorf("secretReferenceToNode() Type = %v, want %v", node.Type, tt.wantType)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("secretReferenceToNode() Name = %v, want %v", node.Name, tt.wantName)
+			}
+			if node.Region != "us-east-1" || node.Account != "123456789012" {
+				t.Errorf("secretReferenceToNode() Region/Account = %v/%v", node.Region, node.Account)
+			}
+		})
+	}
+}
+
+func TestDiscoverEnvSecretReferences(t *testing.T) {
+	d := &Discoverer{opts: &Options{}}
+	g := graph.New()
+	fn := &graph.Node{ID: "fn", Type: ResourceTypeLambda, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(fn)
+
+	secretARN := "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf"
+	neighbors := d.discoverEnvSecretReferences(map[string]string{
+		"DB_SECRET_ARN": secretARN,
+		"LOG_LEVEL":     "debug",
+	}, "GetFunction", fn, g)
+
+	if len(neighbors) != 1 || neighbors[0] != secretARN {
+		t.Fatalf("discoverEnvSecretReferences() = %v, want [%s]", neighbors, secretARN)
+	}
+
+	edges := g.EdgesFrom("fn")
+	if len(edges) != 1 {
+		t.Fatalf("discoverEnvSecretReferences() expected 1 edge, got %d", len(edges))
+	}
+	edge := edges[0]
+	if edge.RelationType != "reads-secret" || !edge.Evidence.Heuristic {
+		t.Errorf("discoverEnvSecretReferences() edge = %s (heuristic=%v), want reads-secret heuristic", edge.RelationType, edge.Evidence.Heuristic)
+	}
+	if edge.Evidence.Fields["EnvironmentVariable"] != "DB_SECRET_ARN" {
+		t.Errorf("discoverEnvSecretReferences() evidence = %v, want variable name only", edge.Evidence.Fields)
+	}
+	if _, ok := edge.Evidence.Fields["Value"]; ok {
+		t.Error("discoverEnvSecretReferences() must not record environment values")
+	}
+}
+
+func TestSecretValueFromARN(t *testing.T) {
+	td := &graph.Node{
+		ID:      "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7",
+		ARN:     "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7",
+		Region:  "us-east-1",
+		Account: "123456789012",
+	}
+
+	tests := []struct {
+		name      string
+		valueFrom string
+		want      string
+	}{
+		{
+			name:      "Secret ARN",
+			valueFrom: "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+			want:      "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+		},
+		{
+			name:      "Secret ARN with JSON key and version stage",
+			valueFrom: "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf:password:AWSCURRENT:",
+			want:      "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf",
+		},
+		{
+			name:      "Parameter ARN",
+			valueFrom: "arn:aws:ssm:us-west-2:123456789012:parameter/prod/api-key",
+			want:      "arn:aws:ssm:us-west-2:123456789012:parameter/prod/api-key",
+		},
+		{
+			name:      "Hierarchical parameter name",
+			valueFrom: "/prod/api-key",
+			want:      "arn:aws:ssm:us-east-1:123456789012:parameter/prod/api-key",
+		},
+		{
+			name:      "Flat parameter name",
+			valueFrom: "api-key",
+			want:      "arn:aws:ssm:us-east-1:123456789012:parameter/api-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretValueFromARN(tt.valueFrom, td); got != tt.want {
+				t.Errorf("secretValueFromARN(%q) = %q, want %q", tt.valueFrom, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverTaskDefinitionSecrets(t *testing.T) {
+	const (
+		secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf"
+		paramARN  = "arn:aws:ssm:us-east-1:123456789012:parameter/prod/api-key"
+		registry  = "arn:aws:secretsmanager:us-east-1:123456789012:secret:dockerhub-XyZ123"
+	)
+	g := graph.New()
+	td := &graph.Node{
+		ID:      "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7",
+		ARN:     "arn:aws:ecs:us-east-1:123456789012:task-definition/api:7",
+		Type:    ResourceTypeECSTaskDefinition,
+		Region:  "us-east-1",
+		Account: "123456789012",
+	}
+	g.AddNode(td)
+
+	neighbors := discoverTaskDefinitionSecrets([]ecstypes.ContainerDefinition{
+		{
+			Name: aws.String("app"),
+			Secrets: []ecstypes.Secret{
+				{Name: aws.String("DB_PASSWORD"), ValueFrom: aws.String(secretARN + ":password::")},
+				{Name: aws.String("API_KEY"), ValueFrom: aws.String("/prod/api-key")},
+			},
+			RepositoryCredentials: &ecstypes.RepositoryCredentials{CredentialsParameter: aws.String(registry)},
+		},
+	}, td, g)
+
+	want := []string{secretARN, paramARN, registry}
+	if len(neighbors) != len(want) {
+		t.Fatalf("discoverTaskDefinitionSecrets() = %v, want %v", neighbors, want)
+	}
+	for i := range want {
+		if neighbors[i] != want[i] {
+			t.Errorf("discoverTaskDefinitionSecrets()[%d] = %s, want %s", i, neighbors[i], want[i])
+		}
+	}
+
+	param, ok := g.GetNode(paramARN)
+	if !ok || param.Type != ResourceTypeSSMParameter || param.Name != "/prod/api-key" {
+		t.Errorf("parameter node = %+v, want SSMParameter /prod/api-key", param)
+	}
+	for _, edge := range g.EdgesFrom(td.ID) {
+		if edge.RelationType != "reads-secret" || edge.Evidence.Heuristic {
+			t.Errorf("edge to %s = %s (heuristic=%v), want non-heuristic reads-secret", edge.To, edge.RelationType, edge.Evidence.Heuristic)
+		}
+		if edge.To == secretARN && edge.Evidence.Fields["Secret"] != "DB_PASSWORD" {
+			t.Errorf("secret edge fields = %v, want the DB_PASSWORD secret name", edge.Evidence.Fields)
+		}
+	}
+}
+
+func TestDiscoverSecretPolicy(t *testing.T) {
+	const secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf"
+	stub := newStubAPI(map[string]any{
+		"GetResourcePolicy": &secretsmanager.GetResourcePolicyOutput{
+			ResourcePolicy: aws.String(`{"Version": "2012-10-17", "Statement": [{
+				"Effect": "Allow",
+				"Principal": {"AWS": "arn:aws:iam::210987654321:root"},
+				"Action": "secretsmanager:GetSecretValue",
+				"Resource": "*"
+			}]}`),
+		},
+	})
+
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	node := secretReferenceToNode(secretARN, "us-east-1", "123456789012")
+	g.AddNode(node)
+
+	neighbors, err := d.discoverSecretPolicy(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverSecretPolicy() error = %v", err)
+	}
+
+	const principal = "arn:aws:iam::210987654321:root"
+	if len(neighbors) != 1 || neighbors[0] != principal {
+		t.Fatalf("discoverSecretPolicy() = %v, want [%s]", neighbors, principal)
+	}
+	account, ok := g.GetNode(principal)
+	if !ok || account.Type != ResourceTypeIAMPrincipal || account.Name != "210987654321" {
+		t.Errorf("principal node = %+v, want IAMPrincipal for account 210987654321", account)
+	}
+	edges := g.EdgesFrom(node.ID)
+	if len(edges) != 1 || edges[0].RelationType != "grants-access-to" {
+		t.Errorf("edges = %v, want one grants-access-to edge", edges)
+	}
+	if node.Metadata["hasResourcePolicy"] != true {
+		t.Errorf("hasResourcePolicy = %v, want true", node.Metadata["hasResourcePolicy"])
+	}
+}
@@ -0,0 +1,67 @@
+package discover
+
+import (
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// idKeyedTypes are resource types whose nodes are keyed by their AWS ID (sg-..., subnet-...)
+// scoped by canonicalID rather than by their ARN, matching how discoverers add them
+var idKeyedTypes = map[string]bool{
+	ResourceTypeSecurityGroup:   true,
+	ResourceTypeSubnet:          true,
+	ResourceTypeVPC:             true,
+	ResourceTypeNATGateway:      true,
+	ResourceTypeElasticIP:       true,
+	ResourceTypeInternetGateway: true,
+	ResourceTypeEC2Instance:     true,
+	ResourceTypeLaunchTemplate:  true,
+	ResourceTypeDBSubnetGroup:   true,
+}
+
+// SeedNode builds a node for a resource known from elsewhere, such as a Terraform state, so
+// traversal can start from it without any calls. ARNs of discoverable types are parsed as
+// Identify parses them; other resources get a node of the given type keyed as discoverers
+// key them. It returns nil when neither an ARN nor an ID is given.
+func (d *Discoverer) SeedNode(resourceType, arn, id, name string) *graph.Node {
+	if arn != "" && !idKeyedTypes[resourceType] {
+		if node, err := d.parseARN(arn); err == nil && node.Type == resourceType {
+			if node.Name == "" {
+				node.Name = name
+			}
+			if node.Metadata == nil {
+				node.Metadata = make(map[string]any)
+			}
+			return node
+		}
+	}
+
+	node := &graph.Node{
+		ID:       arn,
+		Type:     resourceType,
+		ARN:      arn,
+		Name:     name,
+		Metadata: make(map[string]any),
+	}
+	if arn == "" || idKeyedTypes[resourceType] {
+		node.ID = id
+	}
+	if node.ID == "" {
+		return nil
+	}
+	if node.Name == "" {
+		node.Name = node.ID
+	}
+
+	// ARN format: arn:partition:service:region:account:resource
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		node.Region = parts[3]
+		node.Account = parts[4]
+	}
+	if idKeyedTypes[resourceType] {
+		node.ID = canonicalID(node.Region, node.Account, resourceType, node.ID)
+	}
+
+	return node
+}
@@ -0,0 +1,90 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+)
+
+func TestSeedNode(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType string
+		arn          string
+		id           string
+		resName      string
+		wantID       string
+		wantName     string
+		wantRegion   string
+		wantMetadata map[string]any
+	}{
+		{
+			name:         "Discoverable ARN is parsed",
+			resourceType: ResourceTypeECSService,
+			arn:          "arn:aws:ecs:us-east-1:123456789012:service/prod/web",
+			wantID:       "arn:aws:ecs:us-east-1:123456789012:service/prod/web",
+			wantName:     "web",
+			wantRegion:   "us-east-1",
+			wantMetadata: map[string]any{"cluster": "prod"},
+		},
+		{
+			name:         "ARN of another type keeps the given type",
+			resourceType: ResourceTypeTargetGroup,
+			arn:          "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/abc",
+			resName:      "backend",
+			wantID:       "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/backend/abc",
+			wantName:     "backend",
+			wantRegion:   "us-east-1",
+		},
+		{
+			name:         "Unsupported service",
+			resourceType: ResourceTypeSQSQueue,
+			arn:          "arn:aws:sqs:eu-west-1:123456789012:jobs",
+			id:           "https://sqs.eu-west-1.amazonaws.com/123456789012/jobs",
+			resName:      "jobs",
+			wantID:       "arn:aws:sqs:eu-west-1:123456789012:jobs",
+			wantName:     "jobs",
+			wantRegion:   "eu-west-1",
+		},
+		{
+			name:         "Security groups are keyed by ID",
+			resourceType: ResourceTypeSecurityGroup,
+			arn:          "arn:aws:ec2:us-east-1:123456789012:security-group/sg-0abc",
+			id:           "sg-0abc",
+			resName:      "web",
+			wantID:       "SecurityGroup:123456789012:us-east-1:sg-0abc",
+			wantName:     "web",
+			wantRegion:   "us-east-1",
+		},
+		{
+			name:         "ID only",
+			resourceType: ResourceTypeSubnet,
+			id:           "subnet-0abc",
+			wantID:       "subnet-0abc",
+			wantName:     "subnet-0abc",
+		},
+	}
+
+	d := New(&awsx.Clients{}, &Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := d.SeedNode(tt.resourceType, tt.arn, tt.id, tt.resName)
+			if node == nil {
+				t.Fatal("SeedNode() = nil")
+			}
+			if node.Type != tt.resourceType || node.ID != tt.wantID || node.Name != tt.wantName || node.Region != tt.wantRegion {
+				t.Errorf("SeedNode() = %s %s %q in %q, want %s %s %q in %q",
+					node.Type, node.ID, node.Name, node.Region, tt.resourceType, tt.wantID, tt.wantName, tt.wantRegion)
+			}
+			for key, value := range tt.wantMetadata {
+				if node.Metadata[key] != value {
+					t.Errorf("Metadata[%q] = %v, want %v", key, node.Metadata[key], value)
+				}
+			}
+		})
+	}
+
+	if node := d.SeedNode(ResourceTypeLambda, "", "", "orphan"); node != nil {
+		t.Errorf("SeedNode() = %+v, want nil without an ARN or ID", node)
+	}
+}
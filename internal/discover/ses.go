@@ -0,0 +1,142 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sesv2types "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// sesMetricsNamespace is the CloudWatch namespace SES publishes event metrics to
+const sesMetricsNamespace = "AWS/SES"
+
+// discoverSESConfigurationSet links a configuration set to the SNS topics, Firehose delivery
+// streams, CloudWatch metrics and other targets its event destinations publish sending events
+// such as bounces and complaints to
+func (d *Discoverer) discoverSESConfigurationSet(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering SES configuration set", "arn", node.ARN)
+
+	output, err := d.clients.SESv2.GetConfigurationSetEventDestinations(ctx, &sesv2.GetConfigurationSetEventDestinationsInput{
+		ConfigurationSetName: &node.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration set event destinations: %w", err)
+	}
+	node.Metadata["eventDestinationCount"] = len(output.EventDestinations)
+
+	var neighbors []string
+	for i := range output.EventDestinations {
+		destination := &output.EventDestinations[i]
+		neighbors = append(neighbors, d.addSESEventDestination(g, node, destination)...)
+	}
+	return neighbors, nil
+}
+
+// addSESEventDestination adds the edges for one event destination and returns the IDs of the
+// targets. Destinations configure exactly one target, but each kind is checked in turn.
+func (d *Discoverer) addSESEventDestination(g *graph.Graph, node *graph.Node, destination *sesv2types.EventDestination) []string {
+	fields := map[string]any{
+		"Enabled": destination.Enabled,
+	}
+	if destination.Name != nil {
+		fields["EventDestinationName"] = *destination.Name
+	}
+	if len(destination.MatchingEventTypes) > 0 {
+		eventTypes := make([]string, len(destination.MatchingEventTypes))
+		for i, eventType := range destination.MatchingEventTypes {
+			eventTypes[i] = string(eventType)
+		}
+		fields["MatchingEventTypes"] = strings.Join(eventTypes, ",")
+	}
+
+	var neighbors []string
+	addTarget := func(target *graph.Node, field, value string) {
+		if !g.HasNode(target.ID) {
+			g.AddNode(target)
+		}
+		edgeFields := make(map[string]any, len(fields)+1)
+		for key, fieldValue := range fields {
+			edgeFields[key] = fieldValue
+		}
+		edgeFields[field] = value
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           target.ID,
+			RelationType: "publishes-events-to",
+			Evidence: graph.Evidence{
+				APICall: "GetConfigurationSetEventDestinations",
+				Fields:  edgeFields,
+			},
+		})
+		neighbors = append(neighbors, target.ID)
+	}
+
+	if sns := destination.SnsDestination; sns != nil && sns.TopicArn != nil {
+		addTarget(messagingTargetNode(*sns.TopicArn, ResourceTypeEventTarget, node), "TopicArn", *sns.TopicArn)
+	}
+
+	if firehose := destination.KinesisFirehoseDestination; firehose != nil && firehose.DeliveryStreamArn != nil {
+		addTarget(d.policyResourceToNode(*firehose.DeliveryStreamArn), "DeliveryStreamArn", *firehose.DeliveryStreamArn)
+
+		// SES assumes the role to write to the stream, so the destination fails without it
+		if firehose.IamRoleArn != nil {
+			roleNode := d.policyResourceToNode(*firehose.IamRoleArn)
+			if !g.HasNode(roleNode.ID) {
+				g.AddNode(roleNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           roleNode.ID,
+				RelationType: "uses-role",
+				Evidence: graph.Evidence{
+					APICall: "GetConfigurationSetEventDestinations",
+					Fields: map[string]any{
+						"IamRoleArn": *firehose.IamRoleArn,
+					},
+				},
+			})
+			neighbors = append(neighbors, roleNode.ID)
+		}
+	}
+
+	if cloudWatch := destination.CloudWatchDestination; cloudWatch != nil {
+		metricsNode := sesMetricsNode(node)
+		dimensions := make([]string, 0, len(cloudWatch.DimensionConfigurations))
+		for _, dimension := range cloudWatch.DimensionConfigurations {
+			if dimension.DimensionName != nil {
+				dimensions = append(dimensions, *dimension.DimensionName)
+			}
+		}
+		addTarget(metricsNode, "DimensionNames", strings.Join(dimensions, ","))
+	}
+
+	if eventBridge := destination.EventBridgeDestination; eventBridge != nil && eventBridge.EventBusArn != nil {
+		addTarget(d.policyResourceToNode(*eventBridge.EventBusArn), "EventBusArn", *eventBridge.EventBusArn)
+	}
+
+	if pinpoint := destination.PinpointDestination; pinpoint != nil && pinpoint.ApplicationArn != nil {
+		addTarget(d.policyResourceToNode(*pinpoint.ApplicationArn), "ApplicationArn", *pinpoint.ApplicationArn)
+	}
+
+	return neighbors
+}
+
+// sesMetricsNode returns the node for the SES metrics in CloudWatch, one per account and region
+// since every configuration set publishing there shares the namespace. Metrics have no ARN, so
+// the ID is built from the namespace.
+func sesMetricsNode(node *graph.Node) *graph.Node {
+	return &graph.Node{
+		ID:      fmt.Sprintf("cloudwatch:%s:%s:%s", node.Region, node.Account, sesMetricsNamespace),
+		Type:    ResourceTypeCloudWatchMetrics,
+		Name:    sesMetricsNamespace,
+		Region:  node.Region,
+		Account: node.Account,
+		Metadata: map[string]any{
+			"namespace": sesMetricsNamespace,
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sesv2types "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestDiscoverSESConfigurationSet(t *testing.T) {
+	const (
+		configSetARN = "arn:aws:ses:us-east-1:123456789012:configuration-set/transactional"
+		topicARN     = "arn:aws:sns:us-east-1:123456789012:email-bounces"
+		streamARN    = "arn:aws:firehose:us-east-1:123456789012:deliverystream/email-events"
+		roleARN      = "arn:aws:iam::123456789012:role/ses-firehose"
+		functionARN  = "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	)
+	stub := newStubAPI(map[string]any{
+		"GetConfigurationSetEventDestinations": &sesv2.GetConfigurationSetEventDestinationsOutput{
+			EventDestinations: []sesv2types.EventDestination{
+				{
+					Name:               aws.String("bounces"),
+					Enabled:            true,
+					MatchingEventTypes: []sesv2types.EventType{sesv2types.EventTypeBounce, sesv2types.EventTypeComplaint},
+					SnsDestination:     &sesv2types.SnsDestination{TopicArn: aws.String(topicARN)},
+				},
+				{
+					Name:               aws.String("archive"),
+					Enabled:            true,
+					MatchingEventTypes: []sesv2types.EventType{sesv2types.EventTypeSend},
+					KinesisFirehoseDestination: &sesv2types.KinesisFirehoseDestination{
+						DeliveryStreamArn: aws.String(streamARN),
+						IamRoleArn:        aws.String(roleARN),
+					},
+				},
+				{
+					Name:               aws.String("metrics"),
+					MatchingEventTypes: []sesv2types.EventType{sesv2types.EventTypeDelivery},
+					CloudWatchDestination: &sesv2types.CloudWatchDestination{
+						DimensionConfigurations: []sesv2types.CloudWatchDimensionConfiguration{
+							{DimensionName: aws.String("campaign")},
+						},
+					},
+				},
+			},
+		},
+	})
+	d := New(stubClients(stub), &Options{})
+
+	g := graph.New()
+	// A Lambda function already sends its failures to the same topic
+	function := &graph.Node{ID: functionARN, Type: ResourceTypeLambda, Region: "us-east-1", Account: "123456789012"}
+	g.AddNode(function)
+	addLambdaDLQ(g, function, topicARN)
+
+	node, err := d.parseARN(configSetARN)
+	if err != nil {
+		t.Fatalf("parseARN() error = %v", err)
+	}
+	g.AddNode(node)
+
+	neighbors, err := d.discoverSESConfigurationSet(context.Background(), node, g)
+	if err != nil {
+		t.Fatalf("discoverSESConfigurationSet() error = %v", err)
+	}
+	if count := stub.callCount("GetConfigurationSetEventDestinations"); count != 1 {
+		t.Errorf("GetConfigurationSetEventDestinations calls = %d, want 1", count)
+	}
+
+	metricsID := "cloudwatch:us-east-1:123456789012:AWS/SES"
+	wantNeighbors := []string{topicARN, streamARN, roleARN, metricsID}
+	if len(neighbors) != len(wantNeighbors) {
+		t.Fatalf("neighbors = %v, want %v", neighbors, wantNeighbors)
+	}
+	for i, want := range wantNeighbors {
+		if neighbors[i] != want {
+			t.Errorf("neighbors[%d] = %q, want %q", i, neighbors[i], want)
+		}
+	}
+
+	// The shared topic is one node with both the function and the configuration set as producers
+	topic, _ := g.GetNode(topicARN)
+	if topic.Type != ResourceTypeSNSTopic || topic.Name != "email-bounces" {
+		t.Errorf("topic node = %+v, want SNSTopic email-bounces", topic)
+	}
+	var producers []string
+	for _, edge := range g.EdgesTo(topicARN) {
+		producers = append(producers, edge.From+" "+edge.RelationType)
+	}
+	if len(producers) != 2 {
+		t.Errorf("producers of the topic = %v, want the function and the configuration set", producers)
+	}
+
+	edges := g.EdgesBetween(configSetARN, topicARN)
+	if len(edges) != 1 || edges[0].RelationType != "publishes-events-to" {
+		t.Fatalf("edges to topic = %+v, want one publishes-events-to", edges)
+	}
+	fields := edges[0].Evidence.Fields
+	if edges[0].Evidence.APICall != "GetConfigurationSetEventDestinations" ||
+		fields["TopicArn"] != topicARN || fields["EventDestinationName"] != "bounces" ||
+		fields["MatchingEventTypes"] != "BOUNCE,COMPLAINT" || fields["Enabled"] != true {
+		t.Errorf("topic edge evidence = %+v", edges[0].Evidence)
+	}
+
+	stream, _ := g.GetNode(streamARN)
+	if stream.Type != ResourceTypeFirehoseDeliveryStream {
+		t.Errorf("stream node type = %s, want %s", stream.Type, ResourceTypeFirehoseDeliveryStream)
+	}
+	if roleEdges := g.EdgesBetween(configSetARN, roleARN); len(roleEdges) != 1 || roleEdges[0].RelationType != "uses-role" {
+		t.Errorf("edges to role = %+v, want one uses-role", roleEdges)
+	}
+
+	metrics, _ := g.GetNode(metricsID)
+	if metrics == nil || metrics.Type != ResourceTypeCloudWatchMetrics || metrics.Name != "AWS/SES" {
+		t.Errorf("metrics node = %+v, want CloudWatchMetrics AWS/SES", metrics)
+	}
+	if metricsEdges := g.EdgesBetween(configSetARN, metricsID); len(metricsEdges) != 1 || metricsEdges[0].Evidence.Fields["DimensionNames"] != "campaign" {
+		t.Errorf("edges to metrics = %+v, want one with the campaign dimension", metricsEdges)
+	}
+
+	if node.Metadata["eventDestinationCount"] != 3 {
+		t.Errorf("eventDestinationCount = %v, want 3", node.Metadata["eventDestinationCount"])
+	}
+}
@@ -0,0 +1,126 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kafka"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+)
+
+// stubAPI short-circuits AWS SDK operations with canned responses, so discoverers can run
+// against real service clients without network access. Responses are keyed by operation
+// name and are either the operation's output or an error, or a []any of them answered in
+// turn, e.g. successive pages, with the last one repeated.
+type stubAPI struct {
+	mu        sync.Mutex
+	responses map[string]any
+	calls     []string
+}
+
+// newStubAPI returns a stub answering each operation in responses
+func newStubAPI(responses map[string]any) *stubAPI {
+	return &stubAPI{responses: responses}
+}
+
+// apiOptions returns the options to set as a service client's APIOptions
+func (s *stubAPI) apiOptions() []func(*middleware.Stack) error {
+	return []func(*middleware.Stack) error{
+		func(stack *middleware.Stack) error {
+			// Added after the SDK's own initialize middleware so the operation name is known
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("stubAPI", s.handle), middleware.After)
+		},
+	}
+}
+
+func (s *stubAPI) handle(ctx context.Context, _ middleware.InitializeInput, _ middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	op := awsmiddleware.GetOperationName(ctx)
+
+	s.mu.Lock()
+	s.calls = append(s.calls, op)
+	response, ok := s.responses[op]
+	if sequence, isSequence := response.([]any); isSequence && len(sequence) > 0 {
+		response = sequence[0]
+		if len(sequence) > 1 {
+			s.responses[op] = sequence[1:]
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return middleware.InitializeOutput{}, middleware.Metadata{}, fmt.Errorf("unexpected call to %s", op)
+	}
+	if err, isErr := response.(error); isErr {
+		return middleware.InitializeOutput{}, middleware.Metadata{}, err
+	}
+	return middleware.InitializeOutput{Result: response}, middleware.Metadata{}, nil
+}
+
+// called reports whether op was invoked
+func (s *stubAPI) called(op string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, call := range s.calls {
+		if call == op {
+			return true
+		}
+	}
+	return false
+}
+
+// callCount returns how many times op was invoked
+func (s *stubAPI) callCount(op string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, call := range s.calls {
+		if call == op {
+			count++
+		}
+	}
+	return count
+}
+
+// stubClients returns service clients whose calls are all answered by stub
+func stubClients(stub *stubAPI) *awsx.Clients {
+	const region = "us-east-1"
+	return &awsx.Clients{
+		ELBv2:            elasticloadbalancingv2.New(elasticloadbalancingv2.Options{Region: region, APIOptions: stub.apiOptions()}),
+		EC2:              ec2.New(ec2.Options{Region: region, APIOptions: stub.apiOptions()}),
+		ECS:              ecs.New(ecs.Options{Region: region, APIOptions: stub.apiOptions()}),
+		AutoScaling:      autoscaling.New(autoscaling.Options{Region: region, APIOptions: stub.apiOptions()}),
+		ACM:              acm.New(acm.Options{Region: region, APIOptions: stub.apiOptions()}),
+		EFS:              efs.New(efs.Options{Region: region, APIOptions: stub.apiOptions()}),
+		WAFv2:            wafv2.New(wafv2.Options{Region: region, APIOptions: stub.apiOptions()}),
+		IAM:              iam.New(iam.Options{Region: region, APIOptions: stub.apiOptions()}),
+		SecretsManager:   secretsmanager.New(secretsmanager.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Kinesis:          kinesis.New(kinesis.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Lambda:           lambda.New(lambda.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Firehose:         firehose.New(firehose.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Kafka:            kafka.New(kafka.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Route53:          route53.New(route53.Options{Region: region, APIOptions: stub.apiOptions()}),
+		ServiceDiscovery: servicediscovery.New(servicediscovery.Options{Region: region, APIOptions: stub.apiOptions()}),
+		SESv2:            sesv2.New(sesv2.Options{Region: region, APIOptions: stub.apiOptions()}),
+		Tagging:          resourcegroupstaggingapi.New(resourcegroupstaggingapi.Options{Region: region, APIOptions: stub.apiOptions()}),
+	}
+}
@@ -0,0 +1,71 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// nameTagKey is the tag the console displays as a resource's name
+const nameTagKey = "Name"
+
+// resolveByNameTag resolves the single resource whose Name tag equals name. Several matches
+// are an error listing the candidate ARNs, so the caller can pass one of them instead.
+func (d *Discoverer) resolveByNameTag(ctx context.Context, name string) (*graph.Node, error) {
+	d.log.Debug("Resolving resource by Name tag", "name", name)
+
+	arns, err := d.findResourcesByNameTag(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(arns) {
+	case 0:
+		return nil, fmt.Errorf("no resource has Name tag %q", name)
+	case 1:
+		node, parseErr := d.parseARN(arns[0])
+		if parseErr != nil {
+			return nil, fmt.Errorf("resource tagged Name=%s is not supported: %w", name, parseErr)
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("ambiguous resource %q: %d resources have this Name tag, pass one of their ARNs instead:\n  %s",
+			name, len(arns), strings.Join(arns, "\n  "))
+	}
+}
+
+// findResourcesByNameTag returns the ARNs of resources whose Name tag equals name, sorted
+func (d *Discoverer) findResourcesByNameTag(ctx context.Context, name string) ([]string, error) {
+	var arns []string
+
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(d.clients.Tagging, &resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: []taggingtypes.TagFilter{
+			{
+				Key:    aws.String(nameTagKey),
+				Values: []string{name},
+			},
+		},
+	})
+	pageErr := awsx.EachPage(ctx, paginator, func(output *resourcegroupstaggingapi.GetResourcesOutput) error {
+		for i := range output.ResourceTagMappingList {
+			if arn := output.ResourceTagMappingList[i].ResourceARN; arn != nil {
+				arns = append(arns, *arn)
+			}
+		}
+		return nil
+	})
+	if pageErr != nil {
+		return nil, fmt.Errorf("failed to get resources by tag: %w", pageErr)
+	}
+
+	sort.Strings(arns)
+	return arns, nil
+}
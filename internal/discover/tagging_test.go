@@ -0,0 +1,84 @@
+package discover
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+func TestResolveByNameTag(t *testing.T) {
+	const (
+		dbARN      = "arn:aws:rds:us-east-1:123456789012:db:payments-primary"
+		replicaARN = "arn:aws:rds:eu-west-1:123456789012:db:payments-replica"
+		bucketARN  = "arn:aws:s3:::prod-payments-db-backups"
+	)
+
+	tests := []struct {
+		name     string
+		arns     []string
+		wantID   string
+		wantType string
+		wantErr  []string
+	}{
+		{
+			name:    "no matches",
+			wantErr: []string{`no resource has Name tag "prod-payments-db"`},
+		},
+		{
+			name:     "one match",
+			arns:     []string{dbARN},
+			wantID:   dbARN,
+			wantType: ResourceTypeRDSInstance,
+		},
+		{
+			name:    "multiple matches",
+			arns:    []string{replicaARN, dbARN},
+			wantErr: []string{"ambiguous", "2 resources", dbARN, replicaARN},
+		},
+		{
+			name:    "unsupported service",
+			arns:    []string{bucketARN},
+			wantErr: []string{"not supported"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappings := make([]taggingtypes.ResourceTagMapping, len(tt.arns))
+			for i, arn := range tt.arns {
+				mappings[i] = taggingtypes.ResourceTagMapping{
+					ResourceARN: aws.String(arn),
+					Tags:        []taggingtypes.Tag{{Key: aws.String("Name"), Value: aws.String("prod-payments-db")}},
+				}
+			}
+			stub := newStubAPI(map[string]any{
+				"GetResources": &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: mappings},
+			})
+			d := New(stubClients(stub), &Options{})
+
+			node, err := d.resolveByNameTag(context.Background(), "prod-payments-db")
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("resolveByNameTag() = %v, want error", node.ID)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("resolveByNameTag() error = %q, want it to contain %q", err, want)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resolveByNameTag() error = %v", err)
+			}
+			if node.ID != tt.wantID || node.Type != tt.wantType {
+				t.Errorf("resolveByNameTag() = %s (%s), want %s (%s)", node.ID, node.Type, tt.wantID, tt.wantType)
+			}
+		})
+	}
+}
@@ -4,28 +4,78 @@ package discover
 const (
 	ResourceTypeLoadBalancer            = "LoadBalancer"
 	ResourceTypeListener                = "Listener"
+	ResourceTypeListenerRule            = "ListenerRule"
 	ResourceTypeTargetGroup             = "TargetGroup"
 	ResourceTypeECSService              = "ECSService"
 	ResourceTypeECSTaskDefinition       = "ECSTaskDefinition"
 	ResourceTypeECSCluster              = "ECSCluster"
+	ResourceTypeECSTask                 = "ECSTask"
+	ResourceTypeECSContainerInstance    = "ECSContainerInstance"
+	ResourceTypeCloudMapService         = "CloudMapService"
+	ResourceTypeNetworkInterface        = "NetworkInterface"
 	ResourceTypeLambda                  = "Lambda"
+	ResourceTypeLambdaLayer             = "LambdaLayer"
+	ResourceTypeLambdaFunctionURL       = "LambdaFunctionURL"
 	ResourceTypeRDSInstance             = "RDSInstance"
 	ResourceTypeRDSCluster              = "RDSCluster"
+	ResourceTypeRDSGlobalCluster        = "RDSGlobalCluster"
 	ResourceTypeIAMRole                 = "IAMRole"
+	ResourceTypeIAMPolicy               = "IAMPolicy"
+	ResourceTypeIAMPrincipal            = "IAMPrincipal"
 	ResourceTypeSecurityGroup           = "SecurityGroup"
 	ResourceTypeSubnet                  = "Subnet"
 	ResourceTypeVPC                     = "VPC"
+	ResourceTypeElasticIP               = "ElasticIP"
+	ResourceTypeNATGateway              = "NATGateway"
+	ResourceTypeInternetGateway         = "InternetGateway"
 	ResourceTypeRoute53Record           = "Route53Record"
 	ResourceTypeDLQ                     = "DLQ"
 	ResourceTypeEventSource             = "EventSource"
 	ResourceTypeSQSQueue                = "SQSQueue"
 	ResourceTypeDynamoDBStream          = "DynamoDBStream"
 	ResourceTypeKinesisStream           = "KinesisStream"
+	ResourceTypeKinesisConsumer         = "KinesisConsumer"
+	ResourceTypeFirehoseDeliveryStream  = "FirehoseDeliveryStream"
+	ResourceTypeS3Bucket                = "S3Bucket"
+	ResourceTypeOpenSearchDomain        = "OpenSearchDomain"
 	ResourceTypeKafkaCluster            = "KafkaCluster"
+	ResourceTypeMSKConfiguration        = "MSKConfiguration"
 	ResourceTypeEventDestination        = "EventDestination"
+	ResourceTypeSESConfigurationSet     = "SESConfigurationSet"
+	ResourceTypeCloudWatchMetrics       = "CloudWatchMetrics"
 	ResourceTypeDBSubnetGroup           = "DBSubnetGroup"
 	ResourceTypeDBParameterGroup        = "DBParameterGroup"
 	ResourceTypeDBClusterParameterGroup = "DBClusterParameterGroup"
 	ResourceTypeScalingPolicy           = "ScalingPolicy"
 	ResourceTypeInstance                = "Instance"
+	ResourceTypeEC2Instance             = "EC2Instance"
+	ResourceTypeAutoScalingGroup        = "AutoScalingGroup"
+	ResourceTypeLaunchTemplate          = "LaunchTemplate"
+	ResourceTypeAPIGatewayRestAPI       = "APIGatewayRestAPI"
+	ResourceTypeAPIGatewayHTTPAPI       = "APIGatewayHTTPAPI"
+	ResourceTypeEventBridgeRule         = "EventBridgeRule"
+	ResourceTypeSNSTopic                = "SNSTopic"
+	ResourceTypeStateMachine            = "StateMachine"
+	ResourceTypeEventTarget             = "EventTarget"
+	ResourceTypeKMSKey                  = "KMSKey"
+	ResourceTypeSecretsManagerSecret    = "SecretsManagerSecret"
+	ResourceTypeSSMParameter            = "SSMParameter"
+	ResourceTypeACMCertificate          = "ACMCertificate"
+	ResourceTypeWAFWebACL               = "WAFWebACL"
+	ResourceTypeWAFRuleGroup            = "WAFRuleGroup"
+	ResourceTypeWAFIPSet                = "WAFIPSet"
+	ResourceTypeECRRepository           = "ECRRepository"
+	ResourceTypeECRImage                = "ECRImage"
+	ResourceTypeEFSFileSystem           = "EFSFileSystem"
+	ResourceTypeEFSAccessPoint          = "EFSAccessPoint"
+	ResourceTypeAWSResource             = "AWSResource" // Resource of an unsupported service, e.g. named in an IAM policy
+)
+
+// Truncation reasons recorded on the graph when traversal is cut short
+const (
+	TruncatedMaxNodes    = "max-nodes reached"
+	TruncatedMaxEdges    = "max-edges reached"
+	TruncatedMaxDepth    = "max-depth reached"
+	TruncatedTimeout     = "timeout"
+	TruncatedInterrupted = "interrupted"
 )
@@ -0,0 +1,296 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// discoverSubnet links a subnet to the NAT gateways and internet gateways its route table sends
+// traffic to, so the workloads in a private subnet trace through a NAT gateway to the internet.
+// Subnets without an explicit route table association use their VPC's main route table.
+func (d *Discoverer) discoverSubnet(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering subnet egress", "subnetId", node.LocalID())
+
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	table, main, err := d.subnetRouteTable(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil || table.RouteTableId == nil {
+		return nil, nil
+	}
+	node.Metadata["routeTableId"] = *table.RouteTableId
+	node.Metadata["mainRouteTable"] = main
+
+	var neighbors []string
+	for _, route := range table.Routes {
+		// A blackhole route's target was deleted, so no traffic leaves through it
+		if route.State == ec2types.RouteStateBlackhole {
+			continue
+		}
+
+		fields := map[string]any{
+			"RouteTableId":   *table.RouteTableId,
+			"MainRouteTable": main,
+		}
+		if destination := routeDestination(route); destination != "" {
+			fields["Destination"] = destination
+		}
+
+		var target *graph.Node
+		switch {
+		case route.NatGatewayId != nil:
+			target = natGatewayNode(node, *route.NatGatewayId)
+			fields["NatGatewayId"] = *route.NatGatewayId
+		case route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "igw-"):
+			target = internetGatewayNode(node, *route.GatewayId)
+			fields["GatewayId"] = *route.GatewayId
+		default:
+			continue
+		}
+
+		if !g.HasNode(target.ID) {
+			g.AddNode(target)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           target.ID,
+			RelationType: "egresses-via",
+			Evidence: graph.Evidence{
+				APICall: "DescribeRouteTables",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, target.ID)
+	}
+	return neighbors, nil
+}
+
+// subnetRouteTable returns the route table associated with a subnet, falling back to the main
+// route table of its VPC, and whether it is the main one. It returns nil if neither is found.
+func (d *Discoverer) subnetRouteTable(ctx context.Context, node *graph.Node) (*ec2types.RouteTable, bool, error) {
+	output, err := d.clients.EC2.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("association.subnet-id"), Values: []string{node.LocalID()}},
+		},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to describe route tables: %w", err)
+	}
+	if len(output.RouteTables) > 0 {
+		return &output.RouteTables[0], false, nil
+	}
+
+	vpcID, err := d.subnetVPC(ctx, node)
+	if err != nil {
+		return nil, false, err
+	}
+	output, err = d.clients.EC2.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			{Name: aws.String("association.main"), Values: []string{"true"}},
+		},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to describe main route table: %w", err)
+	}
+	if len(output.RouteTables) == 0 {
+		return nil, true, nil
+	}
+	return &output.RouteTables[0], true, nil
+}
+
+// subnetVPC returns the ID of a subnet's VPC, describing the subnet if no discoverer recorded it
+func (d *Discoverer) subnetVPC(ctx context.Context, node *graph.Node) (string, error) {
+	if vpcID := metadataString(node, "vpcId"); vpcID != "" {
+		return vpcID, nil
+	}
+
+	output, err := d.clients.EC2.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		SubnetIds: []string{node.LocalID()},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe subnet: %w", err)
+	}
+	if len(output.Subnets) == 0 || output.Subnets[0].VpcId == nil {
+		return "", fmt.Errorf("subnet not found: %s", node.LocalID())
+	}
+	subnet := &output.Subnets[0]
+	node.Metadata["vpcId"] = *subnet.VpcId
+	if subnet.CidrBlock != nil {
+		node.Metadata["cidrBlock"] = *subnet.CidrBlock
+	}
+	return *subnet.VpcId, nil
+}
+
+// discoverNATGateway links a NAT gateway to the public subnet it egresses through and the
+// Elastic IPs its traffic leaves from
+func (d *Discoverer) discoverNATGateway(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	natGatewayID := node.LocalID()
+	d.log.Debug("Discovering NAT gateway", "natGatewayId", natGatewayID)
+
+	output, err := d.clients.EC2.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		NatGatewayIds: []string{natGatewayID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe NAT gateway: %w", err)
+	}
+	if len(output.NatGateways) == 0 {
+		return nil, fmt.Errorf("NAT gateway not found: %s", natGatewayID)
+	}
+	nat := &output.NatGateways[0]
+	setNATGatewayMetadata(node, nat)
+
+	var neighbors []string
+	// Regional NAT gateways span zones and have no subnet of their own
+	if nat.SubnetId != nil {
+		subnetNode := &graph.Node{
+			ID:       canonicalID(node.Region, node.Account, ResourceTypeSubnet, *nat.SubnetId),
+			Type:     ResourceTypeSubnet,
+			Name:     *nat.SubnetId,
+			Region:   node.Region,
+			Account:  node.Account,
+			Metadata: map[string]any{},
+		}
+		// Saves describing the subnet if it has no route table of its own
+		if nat.VpcId != nil {
+			subnetNode.Metadata["vpcId"] = *nat.VpcId
+		}
+		if !g.HasNode(subnetNode.ID) {
+			g.AddNode(subnetNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           subnetNode.ID,
+			RelationType: "egresses-via",
+			Evidence: graph.Evidence{
+				APICall: "DescribeNatGateways",
+				Fields: map[string]any{
+					"SubnetId": *nat.SubnetId,
+				},
+			},
+		})
+		neighbors = append(neighbors, subnetNode.ID)
+	}
+
+	for _, address := range nat.NatGatewayAddresses {
+		if address.AllocationId == nil {
+			continue
+		}
+		eipNode := elasticIPNode(node, *address.AllocationId, address.PublicIp)
+		if address.PrivateIp != nil {
+			eipNode.Metadata["privateIpv4Address"] = *address.PrivateIp
+		}
+		if address.NetworkInterfaceId != nil {
+			eipNode.Metadata["networkInterfaceId"] = *address.NetworkInterfaceId
+		}
+		if !g.HasNode(eipNode.ID) {
+			g.AddNode(eipNode)
+		}
+		fields := map[string]any{
+			"AllocationId": *address.AllocationId,
+		}
+		if address.PublicIp != nil {
+			fields["PublicIp"] = *address.PublicIp
+		}
+		g.AddEdge(&graph.Edge{
+			From:         node.ID,
+			To:           eipNode.ID,
+			RelationType: "has-address",
+			Evidence: graph.Evidence{
+				APICall: "DescribeNatGateways",
+				Fields:  fields,
+			},
+		})
+		neighbors = append(neighbors, eipNode.ID)
+	}
+	return neighbors, nil
+}
+
+// setNATGatewayMetadata records a NAT gateway's state, connectivity type, VPC and Name tag
+func setNATGatewayMetadata(node *graph.Node, nat *ec2types.NatGateway) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	node.Metadata["state"] = string(nat.State)
+	node.Metadata["connectivityType"] = string(nat.ConnectivityType)
+	if nat.VpcId != nil {
+		node.Metadata["vpcId"] = *nat.VpcId
+	}
+	for _, tag := range nat.Tags {
+		if tag.Key != nil && *tag.Key == "Name" && tag.Value != nil && *tag.Value != "" {
+			node.Name = *tag.Value
+		}
+	}
+}
+
+// routeDestination returns the CIDR block or prefix list a route matches
+func routeDestination(route ec2types.Route) string {
+	switch {
+	case route.DestinationCidrBlock != nil:
+		return *route.DestinationCidrBlock
+	case route.DestinationIpv6CidrBlock != nil:
+		return *route.DestinationIpv6CidrBlock
+	case route.DestinationPrefixListId != nil:
+		return *route.DestinationPrefixListId
+	default:
+		return ""
+	}
+}
+
+// natGatewayNode returns the node for a NAT gateway a subnet routes through
+func natGatewayNode(subnetNode *graph.Node, natGatewayID string) *graph.Node {
+	return &graph.Node{
+		ID:       canonicalID(subnetNode.Region, subnetNode.Account, ResourceTypeNATGateway, natGatewayID),
+		Type:     ResourceTypeNATGateway,
+		Name:     natGatewayID,
+		Region:   subnetNode.Region,
+		Account:  subnetNode.Account,
+		Metadata: map[string]any{},
+	}
+}
+
+// internetGatewayNode returns the node for an internet gateway a subnet routes through
+func internetGatewayNode(subnetNode *graph.Node, gatewayID string) *graph.Node {
+	metadata := map[string]any{}
+	if vpcID := metadataString(subnetNode, "vpcId"); vpcID != "" {
+		metadata["vpcId"] = vpcID
+	}
+	return &graph.Node{
+		ID:       canonicalID(subnetNode.Region, subnetNode.Account, ResourceTypeInternetGateway, gatewayID),
+		Type:     ResourceTypeInternetGateway,
+		Name:     gatewayID,
+		Region:   subnetNode.Region,
+		Account:  subnetNode.Account,
+		Metadata: metadata,
+	}
+}
+
+// elasticIPNode returns the node for an Elastic IP allocated to a resource, named after its
+// public address when known
+func elasticIPNode(owner *graph.Node, allocationID string, publicIP *string) *graph.Node {
+	node := &graph.Node{
+		ID:      canonicalID(owner.Region, owner.Account, ResourceTypeElasticIP, allocationID),
+		Type:    ResourceTypeElasticIP,
+		Name:    allocationID,
+		Region:  owner.Region,
+		Account: owner.Account,
+		Metadata: map[string]any{
+			"allocationId": allocationID,
+		},
+	}
+	if publicIP != nil {
+		node.Name = *publicIP
+		node.Metadata["publicIp"] = *publicIP
+	}
+	return node
+}
@@ -0,0 +1,127 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestDiscoverSubnetEgressPath(t *testing.T) {
+	stub := newStubAPI(map[string]any{
+		"DescribeRouteTables": []any{
+			// The private subnet has no association of its own, so the main table is looked up
+			&ec2.DescribeRouteTablesOutput{},
+			&ec2.DescribeRouteTablesOutput{
+				RouteTables: []ec2types.RouteTable{{
+					RouteTableId: aws.String("rtb-main"),
+					Routes: []ec2types.Route{
+						{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local"), State: ec2types.RouteStateActive},
+						{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1"), State: ec2types.RouteStateActive},
+					},
+				}},
+			},
+			// The NAT gateway's public subnet
+			&ec2.DescribeRouteTablesOutput{
+				RouteTables: []ec2types.RouteTable{{
+					RouteTableId: aws.String("rtb-public"),
+					Routes: []ec2types.Route{
+						{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1"), State: ec2types.RouteStateActive},
+						{DestinationCidrBlock: aws.String("192.168.0.0/16"), NatGatewayId: aws.String("nat-deleted"), State: ec2types.RouteStateBlackhole},
+					},
+				}},
+			},
+		},
+		"DescribeSubnets": &ec2.DescribeSubnetsOutput{
+			Subnets: []ec2types.Subnet{{SubnetId: aws.String("subnet-private"), VpcId: aws.String("vpc-1"), CidrBlock: aws.String("10.0.1.0/24")}},
+		},
+		"DescribeNatGateways": &ec2.DescribeNatGatewaysOutput{
+			NatGateways: []ec2types.NatGateway{{
+				NatGatewayId:     aws.String("nat-1"),
+				SubnetId:         aws.String("subnet-public"),
+				VpcId:            aws.String("vpc-1"),
+				State:            ec2types.NatGatewayStateAvailable,
+				ConnectivityType: ec2types.ConnectivityTypePublic,
+				Tags:             []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("egress-a")}},
+				NatGatewayAddresses: []ec2types.NatGatewayAddress{{
+					AllocationId: aws.String("eipalloc-1"),
+					PublicIp:     aws.String("203.0.113.5"),
+					PrivateIp:    aws.String("10.0.0.12"),
+				}},
+			}},
+		},
+	})
+	d := New(stubClients(stub), &Options{MaxDepth: 5, MaxNodes: 100})
+
+	// Network resources are keyed by their ID in the subnet's account and region
+	scoped := func(resourceType, id string) string {
+		return canonicalID("us-east-1", "123456789012", resourceType, id)
+	}
+	privateSubnet := scoped(ResourceTypeSubnet, "subnet-private")
+	publicSubnet := scoped(ResourceTypeSubnet, "subnet-public")
+	natID := scoped(ResourceTypeNATGateway, "nat-1")
+	igwID := scoped(ResourceTypeInternetGateway, "igw-1")
+	eipID := scoped(ResourceTypeElasticIP, "eipalloc-1")
+
+	g := graph.New()
+	seed := &graph.Node{ID: privateSubnet, Type: ResourceTypeSubnet, Name: "subnet-private", Region: "us-east-1", Account: "123456789012"}
+	result, err := d.DiscoverFrom(context.Background(), []*graph.Node{seed}, g)
+	if err != nil {
+		t.Fatalf("DiscoverFrom() error = %v", err)
+	}
+	if !result.Complete() {
+		t.Errorf("DiscoverFrom() = %+v, want a complete run", result)
+	}
+
+	// private subnet -> NAT gateway -> public subnet -> internet gateway
+	path := []struct {
+		from, to, relation, apiCall string
+	}{
+		{privateSubnet, natID, "egresses-via", "DescribeRouteTables"},
+		{natID, publicSubnet, "egresses-via", "DescribeNatGateways"},
+		{publicSubnet, igwID, "egresses-via", "DescribeRouteTables"},
+		{natID, eipID, "has-address", "DescribeNatGateways"},
+	}
+	for _, hop := range path {
+		edges := g.EdgesBetween(hop.from, hop.to)
+		if len(edges) != 1 || edges[0].RelationType != hop.relation || edges[0].Evidence.APICall != hop.apiCall {
+			t.Errorf("edges %s -> %s = %+v, want one %s from %s", hop.from, hop.to, edges, hop.relation, hop.apiCall)
+		}
+	}
+	if g.HasNode(scoped(ResourceTypeNATGateway, "nat-deleted")) {
+		t.Error("blackhole route target was added to the graph")
+	}
+	if g.EdgeCount() != len(path) {
+		t.Errorf("EdgeCount() = %d, want %d", g.EdgeCount(), len(path))
+	}
+
+	privateEdge := g.EdgesBetween(privateSubnet, natID)[0]
+	if privateEdge.Evidence.Fields["MainRouteTable"] != true || privateEdge.Evidence.Fields["Destination"] != "0.0.0.0/0" {
+		t.Errorf("private subnet evidence = %+v, want the main table's default route", privateEdge.Evidence.Fields)
+	}
+	if seed.Metadata["vpcId"] != "vpc-1" || seed.Metadata["routeTableId"] != "rtb-main" {
+		t.Errorf("private subnet metadata = %v, want vpc-1 and rtb-main", seed.Metadata)
+	}
+
+	nat, _ := g.GetNode(natID)
+	if nat.Type != ResourceTypeNATGateway || nat.Name != "egress-a" || nat.Metadata["state"] != "available" {
+		t.Errorf("NAT gateway node = %+v, want available NATGateway egress-a", nat)
+	}
+	eip, _ := g.GetNode(eipID)
+	if eip.Type != ResourceTypeElasticIP || eip.Name != "203.0.113.5" {
+		t.Errorf("Elastic IP node = %+v, want ElasticIP 203.0.113.5", eip)
+	}
+	igw, _ := g.GetNode(igwID)
+	if igw.Type != ResourceTypeInternetGateway {
+		t.Errorf("internet gateway type = %s, want %s", igw.Type, ResourceTypeInternetGateway)
+	}
+
+	// The public subnet's VPC came from the NAT gateway, so it was never described
+	if count := stub.callCount("DescribeSubnets"); count != 1 {
+		t.Errorf("DescribeSubnets calls = %d, want 1", count)
+	}
+}
@@ -0,0 +1,347 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	waftypes "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// discoverWebACLForResource links a resource to the WAFv2 Web ACL protecting it, if any
+func (d *Discoverer) discoverWebACLForResource(ctx context.Context, node *graph.Node, g *graph.Graph) (string, error) {
+	d.log.Debug("Discovering Web ACL association", "arn", node.ARN)
+
+	output, err := d.clients.WAFv2.GetWebACLForResource(ctx, &wafv2.GetWebACLForResourceInput{
+		ResourceArn: &node.ARN,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get web ACL for resource: %w", err)
+	}
+	if output.WebACL == nil || output.WebACL.ARN == nil {
+		return "", nil
+	}
+
+	aclNode := webACLToNode(*output.WebACL.ARN)
+	if !g.HasNode(aclNode.ID) {
+		setWebACLMetadata(aclNode, output.WebACL)
+		g.AddNode(aclNode)
+	}
+	g.AddEdge(&graph.Edge{
+		From:         node.ID,
+		To:           aclNode.ID,
+		RelationType: "protected-by",
+		Evidence: graph.Evidence{
+			APICall: "GetWebACLForResource",
+			Fields: map[string]any{
+				"WebACLArn": *output.WebACL.ARN,
+			},
+		},
+	})
+
+	return aclNode.ID, nil
+}
+
+// discoverRestAPIWebACLs links a REST API to the Web ACLs protecting its stages. Each stage
+// carries its Web ACL ARN, so no WAF calls are needed.
+func (d *Discoverer) discoverRestAPIWebACLs(ctx context.Context, apiID string, apiNode *graph.Node, g *graph.Graph) ([]string, error) {
+	output, err := d.clients.APIGateway.GetStages(ctx, &apigateway.GetStagesInput{
+		RestApiId: &apiID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stages: %w", err)
+	}
+
+	var neighbors []string
+	for i := range output.Item {
+		stage := &output.Item[i]
+		if stage.WebAclArn == nil || *stage.WebAclArn == "" {
+			continue
+		}
+
+		aclNode := webACLToNode(*stage.WebAclArn)
+		if !g.HasNode(aclNode.ID) {
+			g.AddNode(aclNode)
+		}
+		g.AddEdge(&graph.Edge{
+			From:         apiNode.ID,
+			To:           aclNode.ID,
+			RelationType: "protected-by",
+			Evidence: graph.Evidence{
+				APICall: "GetStages",
+				Fields: map[string]any{
+					"StageName": stage.StageName,
+					"WebACLArn": *stage.WebAclArn,
+				},
+			},
+		})
+		neighbors = append(neighbors, aclNode.ID)
+	}
+
+	return neighbors, nil
+}
+
+// discoverWAF expands a Web ACL into the rule groups and IP sets its rules reference and the
+// load balancers and REST APIs it protects
+func (d *Discoverer) discoverWAF(ctx context.Context, node *graph.Node, g *graph.Graph) ([]string, error) {
+	d.log.Debug("Discovering Web ACL rules", "arn", node.ARN)
+
+	name, id, ok := wafResourceNameAndID(node.ARN)
+	if !ok {
+		return nil, fmt.Errorf("invalid web ACL ARN: %s", node.ARN)
+	}
+
+	output, err := d.clients.WAFv2.GetWebACL(ctx, &wafv2.GetWebACLInput{
+		Name:  &name,
+		Id:    &id,
+		Scope: wafScopeFromARN(node.ARN),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get web ACL: %w", err)
+	}
+	if output.WebACL == nil {
+		return nil, fmt.Errorf("web ACL not found: %s", node.ARN)
+	}
+	setWebACLMetadata(node, output.WebACL)
+
+	var neighbors []string
+	for i := range output.WebACL.Rules {
+		rule := &output.WebACL.Rules[i]
+		var ruleName string
+		if rule.Name != nil {
+			ruleName = *rule.Name
+		}
+
+		for _, ref := range collectWAFReferences(rule.Statement) {
+			refNode := wafReferenceToNode(ref.arn, ref.resourceType)
+			if !g.HasNode(refNode.ID) {
+				g.AddNode(refNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         node.ID,
+				To:           refNode.ID,
+				RelationType: ref.relation,
+				Evidence: graph.Evidence{
+					APICall: "GetWebACL",
+					Fields: map[string]any{
+						"Rule": ruleName,
+						"ARN":  ref.arn,
+					},
+				},
+			})
+			neighbors = append(neighbors, refNode.ID)
+		}
+	}
+
+	// ListResourcesForWebACL only covers regional ACLs; CloudFront distributions name their
+	// ACL themselves and are not discovered
+	if wafScopeFromARN(node.ARN) == waftypes.ScopeRegional {
+		neighbors = append(neighbors, d.discoverWebACLResources(ctx, node, g)...)
+	} else {
+		d.log.Debug("Skipping associations of CloudFront web ACL", "arn", node.ARN)
+	}
+
+	return neighbors, nil
+}
+
+// webACLResourceTypes are the associated resource types listed for a regional Web ACL
+var webACLResourceTypes = []waftypes.ResourceType{
+	waftypes.ResourceTypeApplicationLoadBalancer,
+	waftypes.ResourceTypeApiGateway,
+}
+
+// discoverWebACLResources links the load balancers and REST APIs associated with a regional
+// Web ACL to it with protected-by edges
+func (d *Discoverer) discoverWebACLResources(ctx context.Context, aclNode *graph.Node, g *graph.Graph) []string {
+	var neighbors []string
+	for _, resourceType := range webACLResourceTypes {
+		output, err := d.clients.WAFv2.ListResourcesForWebACL(ctx, &wafv2.ListResourcesForWebACLInput{
+			WebACLArn:    &aclNode.ARN,
+			ResourceType: resourceType,
+		})
+		if err != nil {
+			d.warn(aclNode.ID, "Failed to list resources for web ACL", err, "resourceType", resourceType)
+			continue
+		}
+
+		for _, resourceARN := range output.ResourceArns {
+			resourceNode, parseErr := d.parseARN(protectedResourceARN(resourceARN))
+			if parseErr != nil || resourceNode.Type == "" {
+				d.log.Debug("Skipping unsupported web ACL resource", "arn", resourceARN, "error", parseErr)
+				continue
+			}
+
+			if !g.HasNode(resourceNode.ID) {
+				g.AddNode(resourceNode)
+			}
+			g.AddEdge(&graph.Edge{
+				From:         resourceNode.ID,
+				To:           aclNode.ID,
+				RelationType: "protected-by",
+				Evidence: graph.Evidence{
+					APICall: "ListResourcesForWebACL",
+					Fields: map[string]any{
+						"ResourceArn": resourceARN,
+					},
+				},
+			})
+			neighbors = append(neighbors, resourceNode.ID)
+		}
+	}
+
+	return neighbors
+}
+
+// protectedResourceARN maps an ARN returned by ListResourcesForWebACL to the node it belongs
+// to: REST API stages become their API, everything else is returned unchanged
+func protectedResourceARN(arn string) string {
+	if i := strings.Index(arn, "/stages/"); i >= 0 && strings.Contains(arn, ":apigateway:") {
+		return arn[:i]
+	}
+	return arn
+}
+
+// wafReference is a rule group or IP set referenced by a Web ACL rule
+type wafReference struct {
+	arn          string
+	resourceType string
+	relation     string
+}
+
+// collectWAFReferences walks a rule statement, including nested and scope-down statements,
+// and returns the rule groups and IP sets it references
+func collectWAFReferences(statement *waftypes.Statement) []wafReference {
+	if statement == nil {
+		return nil
+	}
+
+	var refs []wafReference
+	if s := statement.RuleGroupReferenceStatement; s != nil && s.ARN != nil {
+		refs = append(refs, wafReference{arn: *s.ARN, resourceType: ResourceTypeWAFRuleGroup, relation: "uses-rule-group"})
+	}
+	if s := statement.IPSetReferenceStatement; s != nil && s.ARN != nil {
+		refs = append(refs, wafReference{arn: *s.ARN, resourceType: ResourceTypeWAFIPSet, relation: "uses-ip-set"})
+	}
+	if s := statement.AndStatement; s != nil {
+		for i := range s.Statements {
+			refs = append(refs, collectWAFReferences(&s.Statements[i])...)
+		}
+	}
+	if s := statement.OrStatement; s != nil {
+		for i := range s.Statements {
+			refs = append(refs, collectWAFReferences(&s.Statements[i])...)
+		}
+	}
+	if s := statement.NotStatement; s != nil {
+		refs = append(refs, collectWAFReferences(s.Statement)...)
+	}
+	if s := statement.RateBasedStatement; s != nil {
+		refs = append(refs, collectWAFReferences(s.ScopeDownStatement)...)
+	}
+	if s := statement.ManagedRuleGroupStatement; s != nil {
+		refs = append(refs, collectWAFReferences(s.ScopeDownStatement)...)
+	}
+
+	return refs
+}
+
+// webACLToNode creates a Web ACL node from its ARN
+func webACLToNode(arn string) *graph.Node {
+	return wafReferenceToNode(arn, ResourceTypeWAFWebACL)
+}
+
+// wafReferenceToNode creates a Web ACL, rule group or IP set node from its ARN
+func wafReferenceToNode(arn, resourceType string) *graph.Node {
+	node := &graph.Node{
+		ID:       arn,
+		Type:     resourceType,
+		ARN:      arn,
+		Name:     arn,
+		Metadata: make(map[string]any),
+	}
+
+	// ARN format: arn:aws:wafv2:region:account:regional|global/webacl|rulegroup|ipset/name/id
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		node.Region = parts[3]
+		node.Account = parts[4]
+	}
+	if name, _, ok := wafResourceNameAndID(arn); ok {
+		node.Name = name
+	}
+	node.Metadata["scope"] = wafScopeFromARN(arn)
+
+	return node
+}
+
+// wafResourceNameAndID extracts the name and ID from a WAFv2 resource ARN
+func wafResourceNameAndID(arn string) (name, id string, ok bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 {
+		return "", "", false
+	}
+	// Resource: regional|global/type/name/id
+	segments := strings.Split(parts[5], "/")
+	if len(segments) != 4 {
+		return "", "", false
+	}
+	return segments[2], segments[3], true
+}
+
+// wafScopeFromARN returns the WAFv2 scope for a resource ARN. CloudFront resources are
+// global and live in us-east-1; everything else is regional.
+func wafScopeFromARN(arn string) waftypes.Scope {
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 && strings.HasPrefix(parts[5], "global/") {
+		return waftypes.ScopeCloudfront
+	}
+	return waftypes.ScopeRegional
+}
+
+// setWebACLMetadata records the default action, capacity and managed rule groups of a Web ACL
+func setWebACLMetadata(node *graph.Node, acl *waftypes.WebACL) {
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+
+	if acl.Name != nil {
+		node.Name = *acl.Name
+	}
+	if acl.DefaultAction != nil {
+		switch {
+		case acl.DefaultAction.Block != nil:
+			node.Metadata["defaultAction"] = "block"
+		case acl.DefaultAction.Allow != nil:
+			node.Metadata["defaultAction"] = "allow"
+		}
+	}
+	node.Metadata["capacity"] = acl.Capacity
+	node.Metadata["ruleCount"] = len(acl.Rules)
+	if acl.ManagedByFirewallManager {
+		node.Metadata["managedByFirewallManager"] = true
+	}
+
+	var managed, ruleGroups []string
+	for i := range acl.Rules {
+		s := acl.Rules[i].Statement
+		if s != nil && s.ManagedRuleGroupStatement != nil {
+			group := s.ManagedRuleGroupStatement
+			if group.VendorName != nil && group.Name != nil {
+				managed = append(managed, *group.VendorName+"/"+*group.Name)
+			}
+		}
+		for _, ref := range collectWAFReferences(s) {
+			if ref.resourceType == ResourceTypeWAFRuleGroup {
+				ruleGroups = append(ruleGroups, ref.arn)
+			}
+		}
+	}
+	if len(managed) > 0 {
+		node.Metadata["managedRuleGroups"] = managed
+	}
+	if len(ruleGroups) > 0 {
+		node.Metadata["ruleGroups"] = ruleGroups
+	}
+}
@@ -0,0 +1,222 @@
+package discover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/wafv2"
+	waftypes "github.com/aws/aws-sdk-go-v2/service/wafv2/types"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestWAFReferenceToNode(t *testing.T) {
+	tests := []struct {
+		name         string
+		arn          string
+		resourceType string
+		wantName     string
+		wantRegion   string
+		wantScope    waftypes.Scope
+	}{
+		{
+			name:         "regional web ACL",
+			arn:          "arn:aws:wafv2:eu-west-1:123456789012:regional/webacl/app-acl/1111",
+			resourceType: ResourceTypeWAFWebACL,
+			wantName:     "app-acl",
+			wantRegion:   "eu-west-1",
+			wantScope:    waftypes.ScopeRegional,
+		},
+		{
+			name:         "CloudFront web ACL",
+			arn:          "arn:aws:wafv2:us-east-1:123456789012:global/webacl/edge-acl/2222",
+			resourceType: ResourceTypeWAFWebACL,
+			wantName:     "edge-acl",
+			wantRegion:   "us-east-1",
+			wantScope:    waftypes.ScopeCloudfront,
+		},
+		{
+			name:         "IP set",
+			arn:          "arn:aws:wafv2:eu-west-1:123456789012:regional/ipset/blocked/3333",
+			resourceType: ResourceTypeWAFIPSet,
+			wantName:     "blocked",
+			wantRegion:   "eu-west-1",
+			wantScope:    waftypes.ScopeRegional,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := wafReferenceToNode(tt.arn, tt.resourceType)
+			if node.ID != tt.arn || node.Type != tt.resourceType {
+				t.Errorf("got %s (%s), want %s (%s)", node.ID, node.Type, tt.arn, tt.resourceType)
+			}
+			if node.Name != tt.wantName {
+				t.Errorf("Name = %s, want %s", node.Name, tt.wantName)
+			}
+			if node.Region != tt.wantRegion || node.Account != "123456789012" {
+				t.Errorf("Region/Account = %s/%s", node.Region, node.Account)
+			}
+			if node.Metadata["scope"] != tt.wantScope {
+				t.Errorf("scope = %v, want %v", node.Metadata["scope"], tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestCollectWAFReferences(t *testing.T) {
+	ruleGroupARN := "arn:aws:wafv2:us-east-1:123456789012:regional/rulegroup/custom/1111"
+	allowListARN := "arn:aws:wafv2:us-east-1:123456789012:regional/ipset/allow/2222"
+	blockListARN := "arn:aws:wafv2:us-east-1:123456789012:regional/ipset/block/3333"
+
+	statement := &waftypes.Statement{
+		AndStatement: &waftypes.AndStatement{
+			Statements: []waftypes.Statement{
+				{RuleGroupReferenceStatement: &waftypes.RuleGroupReferenceStatement{ARN: aws.String(ruleGroupARN)}},
+				{NotStatement: &waftypes.NotStatement{
+					Statement: &waftypes.Statement{
+						IPSetReferenceStatement: &waftypes.IPSetReferenceStatement{ARN: aws.String(allowListARN)},
+					},
+				}},
+				{RateBasedStatement: &waftypes.RateBasedStatement{
+					ScopeDownStatement: &waftypes.Statement{
+						IPSetReferenceStatement: &waftypes.IPSetReferenceStatement{ARN: aws.String(blockListARN)},
+					},
+				}},
+			},
+		},
+	}
+
+	refs := collectWAFReferences(statement)
+	if len(refs) != 3 {
+		t.Fatalf("expected 3 references, got %d: %v", len(refs), refs)
+	}
+
+	want := []wafReference{
+		{arn: ruleGroupARN, resourceType: ResourceTypeWAFRuleGroup, relation: "uses-rule-group"},
+		{arn: allowListARN, resourceType: ResourceTypeWAFIPSet, relation: "uses-ip-set"},
+		{arn: blockListARN, resourceType: ResourceTypeWAFIPSet, relation: "uses-ip-set"},
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("refs[%d] = %+v, want %+v", i, refs[i], want[i])
+		}
+	}
+
+	if refs := collectWAFReferences(nil); refs != nil {
+		t.Errorf("expected no references for nil statement, got %v", refs)
+	}
+}
+
+func TestSetWebACLMetadata(t *testing.T) {
+	node := webACLToNode("arn:aws:wafv2:us-east-1:123456789012:regional/webacl/app-acl/1111")
+	acl := &waftypes.WebACL{
+		Name:          aws.String("app-acl"),
+		Capacity:      700,
+		DefaultAction: &waftypes.DefaultAction{Block: &waftypes.BlockAction{}},
+		Rules: []waftypes.Rule{
+			{
+				Name: aws.String("common"),
+				Statement: &waftypes.Statement{
+					ManagedRuleGroupStatement: &waftypes.ManagedRuleGroupStatement{
+						VendorName: aws.String("AWS"),
+						Name:       aws.String("AWSManagedRulesCommonRuleSet"),
+					},
+				},
+			},
+			{
+				Name: aws.String("custom"),
+				Statement: &waftypes.Statement{
+					RuleGroupReferenceStatement: &waftypes.RuleGroupReferenceStatement{
+						ARN: aws.String("arn:aws:wafv2:us-east-1:123456789012:regional/rulegroup/custom/2222"),
+					},
+				},
+			},
+			{Name: aws.String("rate-limit")},
+		},
+	}
+
+	setWebACLMetadata(node, acl)
+
+	if node.Metadata["defaultAction"] != "block" {
+		t.Errorf("defaultAction = %v, want block", node.Metadata["defaultAction"])
+	}
+	if node.Metadata["ruleCount"] != 3 {
+		t.Errorf("ruleCount = %v, want 3", node.Metadata["ruleCount"])
+	}
+	if node.Metadata["capacity"] != int64(700) {
+		t.Errorf("capacity = %v, want 700", node.Metadata["capacity"])
+	}
+	managed, ok := node.Metadata["managedRuleGroups"].([]string)
+	if !ok || len(managed) != 1 || managed[0] != "AWS/AWSManagedRulesCommonRuleSet" {
+		t.Errorf("managedRuleGroups = %v", node.Metadata["managedRuleGroups"])
+	}
+	ruleGroups, ok := node.Metadata["ruleGroups"].([]string)
+	if !ok || len(ruleGroups) != 1 || ruleGroups[0] != "arn:aws:wafv2:us-east-1:123456789012:regional/rulegroup/custom/2222" {
+		t.Errorf("ruleGroups = %v", node.Metadata["ruleGroups"])
+	}
+	if _, ok := node.Metadata["managedByFirewallManager"]; ok {
+		t.Error("expected managedByFirewallManager to be omitted")
+	}
+}
+
+func TestProtectedResourceARN(t *testing.T) {
+	tests := []struct {
+		arn  string
+		want string
+	}{
+		{
+			arn:  "arn:aws:apigateway:us-east-1::/restapis/a1b2c3d4e5/stages/prod",
+			want: "arn:aws:apigateway:us-east-1::/restapis/a1b2c3d4e5",
+		},
+		{
+			arn:  "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188",
+			want: "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := protectedResourceARN(tt.arn); got != tt.want {
+			t.Errorf("protectedResourceARN(%s) = %s, want %s", tt.arn, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverWebACLResources(t *testing.T) {
+	const (
+		aclARN = "arn:aws:wafv2:us-east-1:123456789012:regional/webacl/app-acl/1111"
+		albARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+		apiARN = "arn:aws:apigateway:us-east-1::/restapis/a1b2c3d4e5"
+	)
+
+	// The stub answers both resource types with the same list
+	stub := newStubAPI(map[string]any{
+		"ListResourcesForWebACL": &wafv2.ListResourcesForWebACLOutput{
+			ResourceArns: []string{albARN, apiARN + "/stages/prod"},
+		},
+	})
+	d := New(stubClients(stub), &Options{})
+	g := graph.New()
+	aclNode := webACLToNode(aclARN)
+	g.AddNode(aclNode)
+
+	neighbors := d.discoverWebACLResources(context.Background(), aclNode, g)
+
+	if len(neighbors) != 4 || neighbors[0] != albARN || neighbors[1] != apiARN {
+		t.Fatalf("discoverWebACLResources() = %v, want the ALB and REST API for each resource type", neighbors)
+	}
+	if node, ok := g.GetNode(apiARN); !ok || node.Type != ResourceTypeAPIGatewayRestAPI {
+		t.Errorf("REST API node = %+v, want an APIGatewayRestAPI node for the stage's API", node)
+	}
+
+	edges := g.EdgesTo(aclARN)
+	if len(edges) != 2 {
+		t.Fatalf("got %d edges to the web ACL, want 2", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.RelationType != "protected-by" || edge.Evidence.APICall != "ListResourcesForWebACL" {
+			t.Errorf("edge = %+v, want protected-by from ListResourcesForWebACL", edge)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package graph
 
+import "sort"
+
 // BFSLevel represents nodes at a specific depth level
 type BFSLevel struct {
 	Depth int
 	Nodes []*Node
 }
 
-// BFS performs breadth-first traversal from a starting node
+// BFS performs breadth-first traversal from a starting node. Nodes within each level, and
+// the neighbors enqueued from each node, are ordered by type, name and ID, so the levels do
+// not depend on the order edges were added in.
 func (g *Graph) BFS(startID string) []BFSLevel {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -15,38 +19,156 @@ func (g *Graph) BFS(startID string) []BFSLevel {
 		return nil
 	}
 
+	// Outgoing neighbors of each node, sorted once up front
+	adjacency := make(map[string][]*Node)
+	for _, edge := range g.edges {
+		if to, ok := g.nodes[edge.To]; ok {
+			adjacency[edge.From] = append(adjacency[edge.From], to)
+		}
+	}
+	for _, neighbors := range adjacency {
+		sortNodes(neighbors)
+	}
+
 	visited := make(map[string]bool)
 	levels := make([]BFSLevel, 0)
-	queue := []string{startID}
+	queue := []*Node{g.nodes[startID]}
 	visited[startID] = true
 	currentDepth := 0
 
 	for len(queue) > 0 {
-		levelSize := len(queue)
 		level := BFSLevel{
 			Depth: currentDepth,
-			Nodes: make([]*Node, 0),
+			Nodes: queue,
 		}
+		sortNodes(level.Nodes)
 
-		for i := 0; i < levelSize; i++ {
-			nodeID := queue[0]
-			queue = queue[1:]
-
-			node := g.nodes[nodeID]
-			level.Nodes = append(level.Nodes, node)
-
-			// Find all neighbors (nodes connected by outgoing edges)
-			for _, edge := range g.edges {
-				if edge.From == nodeID && !visited[edge.To] {
-					visited[edge.To] = true
-					queue = append(queue, edge.To)
+		var next []*Node
+		for _, node := range level.Nodes {
+			for _, neighbor := range adjacency[node.ID] {
+				if !visited[neighbor.ID] {
+					visited[neighbor.ID] = true
+					next = append(next, neighbor)
 				}
 			}
 		}
 
 		levels = append(levels, level)
+		queue = next
 		currentDepth++
 	}
 
 	return levels
 }
+
+// sortNodes orders nodes by type, then name, then ID
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Type != nodes[j].Type {
+			return nodes[i].Type < nodes[j].Type
+		}
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// ReachableFrom returns the IDs of every node matching predicate plus every node reachable
+// from one by following outgoing edges
+func (g *Graph) ReachableFrom(predicate func(*Node) bool) map[string]bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	adjacency := make(map[string][]string)
+	for _, edge := range g.edges {
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+	}
+
+	reached := make(map[string]bool)
+	var queue []string
+	for id, node := range g.nodes {
+		if predicate(node) {
+			reached[id] = true
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[nodeID] {
+			if _, ok := g.nodes[next]; ok && !reached[next] {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return reached
+}
+
+// ConnectedComponents groups nodes into sets connected by edges in either direction. Components
+// are ordered largest first, ties broken by their first node ID, and nodes within a component
+// are ordered by ID.
+func (g *Graph) ConnectedComponents() [][]*Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	adjacency := make(map[string][]string)
+	for _, edge := range g.edges {
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		adjacency[edge.To] = append(adjacency[edge.To], edge.From)
+	}
+
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	seen := make(map[string]bool, len(ids))
+	var components [][]*Node
+	for _, start := range ids {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+
+		var component []*Node
+		queue := []string{start}
+		for len(queue) > 0 {
+			nodeID := queue[0]
+			queue = queue[1:]
+			component = append(component, g.nodes[nodeID])
+			for _, next := range adjacency[nodeID] {
+				if _, ok := g.nodes[next]; ok && !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		sort.Slice(component, func(i, j int) bool {
+			return component[i].ID < component[j].ID
+		})
+		components = append(components, component)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+	return components
+}
+
+// AnyOf returns a predicate matching nodes that match any of predicates
+func AnyOf(predicates ...func(*Node) bool) func(*Node) bool {
+	return func(node *Node) bool {
+		for _, predicate := range predicates {
+			if predicate(node) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -45,6 +46,64 @@ func TestBFS(t *testing.T) {
 	}
 }
 
+func TestBFSOrderIsStable(t *testing.T) {
+	nodes := []*Node{
+		{ID: "lb", Type: "LoadBalancer", Name: "web"},
+		{ID: "tg-b", Type: "TargetGroup", Name: "blue"},
+		{ID: "tg-a", Type: "TargetGroup", Name: "api"},
+		{ID: "sg-1", Type: "SecurityGroup", Name: "web-sg"},
+		{ID: "i-2", Type: "EC2Instance", Name: "i-2"},
+		{ID: "i-1", Type: "EC2Instance", Name: "i-1"},
+		{ID: "fn", Type: "Lambda", Name: "api"},
+	}
+	edges := []*Edge{
+		{From: "lb", To: "tg-b", RelationType: "forwards-to"},
+		{From: "lb", To: "sg-1", RelationType: "uses-sg"},
+		{From: "lb", To: "tg-a", RelationType: "forwards-to"},
+		{From: "tg-b", To: "i-2", RelationType: "routes-to-target"},
+		{From: "tg-b", To: "i-1", RelationType: "routes-to-target"},
+		{From: "tg-a", To: "fn", RelationType: "routes-to-target"},
+	}
+
+	build := func(reverse bool) *Graph {
+		g := New()
+		for i := range nodes {
+			if reverse {
+				i = len(nodes) - 1 - i
+			}
+			g.AddNode(nodes[i])
+		}
+		for i := range edges {
+			if reverse {
+				i = len(edges) - 1 - i
+			}
+			g.AddEdge(edges[i])
+		}
+		return g
+	}
+
+	levelIDs := func(levels []BFSLevel) [][]string {
+		ids := make([][]string, len(levels))
+		for i, level := range levels {
+			for _, node := range level.Nodes {
+				ids[i] = append(ids[i], node.ID)
+			}
+		}
+		return ids
+	}
+
+	forward := levelIDs(build(false).BFS("lb"))
+	backward := levelIDs(build(true).BFS("lb"))
+
+	// Each level is ordered by type, then name, then ID
+	want := [][]string{{"lb"}, {"sg-1", "tg-a", "tg-b"}, {"i-1", "i-2", "fn"}}
+	for _, got := range [][][]string{forward, backward} {
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("BFS() levels = %v, want %v", got, want)
+		}
+	}
+}
+
 func TestBFSNonexistentStart(t *testing.T) {
 	g := New()
 	g.AddNode(&Node{ID: "A"})
@@ -82,3 +141,93 @@ func TestBFSCycle(t *testing.T) {
 		t.Errorf("expected to visit 3 nodes exactly once, got %d", totalNodes)
 	}
 }
+
+func TestReachableFrom(t *testing.T) {
+	g := New()
+
+	// public -> listener -> tg -> private
+	//                        \-> tg (cycle back is ignored)
+	// internal -> hidden
+	for _, id := range []string{"public", "listener", "tg", "private", "internal", "hidden"} {
+		g.AddNode(&Node{ID: id, Metadata: map[string]any{}})
+	}
+	public, _ := g.GetNode("public")
+	public.Metadata["exposed"] = true
+
+	g.AddEdge(&Edge{From: "public", To: "listener"})
+	g.AddEdge(&Edge{From: "listener", To: "tg"})
+	g.AddEdge(&Edge{From: "tg", To: "private"})
+	g.AddEdge(&Edge{From: "private", To: "tg"})
+	g.AddEdge(&Edge{From: "internal", To: "hidden"})
+	g.AddEdge(&Edge{From: "hidden", To: "missing"})
+
+	reached := g.ReachableFrom(func(n *Node) bool { return n.Metadata["exposed"] == true })
+
+	for _, id := range []string{"public", "listener", "tg", "private"} {
+		if !reached[id] {
+			t.Errorf("expected %s to be reachable", id)
+		}
+	}
+	for _, id := range []string{"internal", "hidden", "missing"} {
+		if reached[id] {
+			t.Errorf("expected %s not to be reachable", id)
+		}
+	}
+
+	if got := g.ReachableFrom(func(*Node) bool { return false }); len(got) != 0 {
+		t.Errorf("expected nothing reachable without entry points, got %v", got)
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	isA := func(n *Node) bool { return n.ID == "A" }
+	isB := func(n *Node) bool { return n.ID == "B" }
+	pred := AnyOf(isA, isB)
+
+	for id, want := range map[string]bool{"A": true, "B": true, "C": false} {
+		if got := pred(&Node{ID: id}); got != want {
+			t.Errorf("AnyOf(isA, isB)(%s) = %v, want %v", id, got, want)
+		}
+	}
+	if AnyOf()(&Node{ID: "A"}) {
+		t.Error("AnyOf() with no predicates should match nothing")
+	}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	g := New()
+	for _, id := range []string{"svc-a", "svc-b", "sg-shared", "db", "queue", "orphan"} {
+		g.AddNode(&Node{ID: id})
+	}
+	// Two services that share a security group form one component regardless of edge direction
+	g.AddEdge(&Edge{From: "svc-a", To: "sg-shared", RelationType: "uses-security-group"})
+	g.AddEdge(&Edge{From: "svc-b", To: "sg-shared", RelationType: "uses-security-group"})
+	g.AddEdge(&Edge{From: "db", To: "queue", RelationType: "sends-to"})
+	// Edges to nodes outside the graph are ignored
+	g.AddEdge(&Edge{From: "orphan", To: "missing", RelationType: "calls"})
+
+	components := g.ConnectedComponents()
+
+	want := [][]string{
+		{"sg-shared", "svc-a", "svc-b"},
+		{"db", "queue"},
+		{"orphan"},
+	}
+	if len(components) != len(want) {
+		t.Fatalf("ConnectedComponents() returned %d components, want %d", len(components), len(want))
+	}
+	for i := range want {
+		if len(components[i]) != len(want[i]) {
+			t.Fatalf("component %d has %d nodes, want %v", i, len(components[i]), want[i])
+		}
+		for j, id := range want[i] {
+			if components[i][j].ID != id {
+				t.Errorf("component %d node %d = %s, want %s", i, j, components[i][j].ID, id)
+			}
+		}
+	}
+
+	if got := New().ConnectedComponents(); len(got) != 0 {
+		t.Errorf("ConnectedComponents() on an empty graph = %v, want none", got)
+	}
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// GraphDiff describes how a graph changed between two discoveries
+type GraphDiff struct {
+	AddedNodes    []*Node
+	RemovedNodes  []*Node
+	ModifiedNodes []*Node // Nodes present in both graphs whose attributes changed (new version)
+	AddedEdges    []*Edge
+	RemovedEdges  []*Edge
+	ChangedEdges  []*Edge // Edges with the same (From, To, RelationType) whose evidence changed (new version)
+}
+
+// Empty reports whether the two graphs were identical
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 &&
+		len(d.RemovedNodes) == 0 &&
+		len(d.ModifiedNodes) == 0 &&
+		len(d.AddedEdges) == 0 &&
+		len(d.RemovedEdges) == 0 &&
+		len(d.ChangedEdges) == 0
+}
+
+// Diff compares two graphs. Nodes are matched by ID and edges by (From, To, RelationType);
+// a relation change between the same pair of nodes is reported as one removed and one added edge.
+func Diff(oldGraph, newGraph *Graph) GraphDiff {
+	var diff GraphDiff
+
+	oldNodes := make(map[string]*Node)
+	for _, node := range oldGraph.SortedNodes() {
+		oldNodes[node.ID] = node
+	}
+
+	for _, node := range newGraph.SortedNodes() {
+		previous, ok := oldNodes[node.ID]
+		if !ok {
+			diff.AddedNodes = append(diff.AddedNodes, node)
+			continue
+		}
+		if !sameJSON(previous, node) {
+			diff.ModifiedNodes = append(diff.ModifiedNodes, node)
+		}
+		delete(oldNodes, node.ID)
+	}
+	for _, node := range oldNodes {
+		diff.RemovedNodes = append(diff.RemovedNodes, node)
+	}
+	sort.Slice(diff.RemovedNodes, func(i, j int) bool {
+		return diff.RemovedNodes[i].ID < diff.RemovedNodes[j].ID
+	})
+
+	oldEdges := make(map[edgeKey]*Edge)
+	for _, edge := range oldGraph.SortedEdges() {
+		oldEdges[edge.key()] = edge
+	}
+
+	seen := make(map[edgeKey]bool)
+	for _, edge := range newGraph.SortedEdges() {
+		key := edge.key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		previous, ok := oldEdges[key]
+		switch {
+		case !ok:
+			diff.AddedEdges = append(diff.AddedEdges, edge)
+		case !sameJSON(previous.Evidence, edge.Evidence):
+			diff.ChangedEdges = append(diff.ChangedEdges, edge)
+		}
+	}
+	for _, edge := range oldGraph.SortedEdges() {
+		key := edge.key()
+		if !seen[key] {
+			seen[key] = true
+			diff.RemovedEdges = append(diff.RemovedEdges, edge)
+		}
+	}
+
+	return diff
+}
+
+// sameJSON compares two values by their canonical JSON encoding, so a graph restored
+// from a snapshot compares equal to a freshly discovered one despite differing Go types
+func sameJSON(a, b any) bool {
+	aJSON, aErr := canonicalJSON(a)
+	bJSON, bErr := canonicalJSON(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// canonicalJSON encodes v with struct fields flattened into sorted map keys
+func canonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestDiffNodes(t *testing.T) {
+	oldGraph := New()
+	oldGraph.AddNode(&Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	oldGraph.AddNode(&Node{ID: "tg-old", Type: "TargetGroup", Name: "old-tg"})
+	oldGraph.AddNode(&Node{ID: "svc", Type: "ECSService", Metadata: map[string]any{"desiredCount": 2}})
+
+	newGraph := New()
+	newGraph.AddNode(&Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	newGraph.AddNode(&Node{ID: "tg-new", Type: "TargetGroup", Name: "new-tg"})
+	newGraph.AddNode(&Node{ID: "svc", Type: "ECSService", Metadata: map[string]any{"desiredCount": 4}})
+
+	diff := Diff(oldGraph, newGraph)
+
+	if len(diff.AddedNodes) != 1 || diff.AddedNodes[0].ID != "tg-new" {
+		t.Errorf("Diff() AddedNodes = %v, want [tg-new]", nodeIDs(diff.AddedNodes))
+	}
+	if len(diff.RemovedNodes) != 1 || diff.RemovedNodes[0].ID != "tg-old" {
+		t.Errorf("Diff() RemovedNodes = %v, want [tg-old]", nodeIDs(diff.RemovedNodes))
+	}
+	if len(diff.ModifiedNodes) != 1 || diff.ModifiedNodes[0].ID != "svc" {
+		t.Errorf("Diff() ModifiedNodes = %v, want [svc]", nodeIDs(diff.ModifiedNodes))
+	}
+}
+
+func TestDiffEdges(t *testing.T) {
+	oldGraph := New()
+	oldGraph.AddEdge(&Edge{From: "a", To: "b", RelationType: "forwards-to"})
+	oldGraph.AddEdge(&Edge{From: "a", To: "c", RelationType: "uses-security-group"})
+	oldGraph.AddEdge(&Edge{From: "b", To: "c", RelationType: "routes-to", Evidence: Evidence{APICall: "DescribeRules"}})
+
+	newGraph := New()
+	newGraph.AddEdge(&Edge{From: "a", To: "b", RelationType: "forwards-to"})
+	newGraph.AddEdge(&Edge{From: "a", To: "c", RelationType: "runs-in-subnet"})
+	newGraph.AddEdge(&Edge{From: "b", To: "c", RelationType: "routes-to", Evidence: Evidence{APICall: "DescribeRules", Heuristic: true}})
+
+	diff := Diff(oldGraph, newGraph)
+
+	// A relation change is reported as remove + add
+	if len(diff.AddedEdges) != 1 || diff.AddedEdges[0].RelationType != "runs-in-subnet" {
+		t.Errorf("Diff() AddedEdges = %v, want [a->c runs-in-subnet]", diff.AddedEdges)
+	}
+	if len(diff.RemovedEdges) != 1 || diff.RemovedEdges[0].RelationType != "uses-security-group" {
+		t.Errorf("Diff() RemovedEdges = %v, want [a->c uses-security-group]", diff.RemovedEdges)
+	}
+	if len(diff.ChangedEdges) != 1 || diff.ChangedEdges[0].From != "b" {
+		t.Errorf("Diff() ChangedEdges = %v, want [b->c routes-to]", diff.ChangedEdges)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a", Metadata: map[string]any{"port": 443}})
+	g.AddNode(&Node{ID: "b"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "forwards-to"})
+
+	// Round-tripping through JSON must not report spurious changes
+	data := []byte(`{"nodes":[{"ID":"a","Metadata":{"port":443}},{"ID":"b"}],"edges":[{"From":"a","To":"b","RelationType":"forwards-to"}]}`)
+	restored, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+
+	diff := Diff(restored, g)
+	if !diff.Empty() {
+		t.Errorf("Diff() expected no changes, got %+v", diff)
+	}
+}
+
+func nodeIDs(nodes []*Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// MetaString returns a string metadata value, dereferencing *string. It reports false when the
+// key is missing, nil or holds another type.
+func (n *Node) MetaString(key string) (string, bool) {
+	switch v := n.Metadata[key].(type) {
+	case string:
+		return v, true
+	case *string:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return "", false
+}
+
+// MetaInt returns an integer metadata value. Besides the sized int types and pointers to them,
+// it accepts whole float64s and json.Numbers, which is how snapshots decode numbers.
+func (n *Node) MetaInt(key string) (int64, bool) {
+	switch v := n.Metadata[key].(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case *int:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int32:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int64:
+		if v != nil {
+			return *v, true
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), true
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// MetaFloat returns a numeric metadata value as a float64, accepting any value MetaInt accepts
+// as well as float32, *float64 and fractional float64s
+func (n *Node) MetaFloat(key string) (float64, bool) {
+	switch v := n.Metadata[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case *float64:
+		if v != nil {
+			return *v, true
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, true
+		}
+	}
+	if i, ok := n.MetaInt(key); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// MetaBool returns a boolean metadata value, dereferencing *bool
+func (n *Node) MetaBool(key string) (bool, bool) {
+	switch v := n.Metadata[key].(type) {
+	case bool:
+		return v, true
+	case *bool:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return false, false
+}
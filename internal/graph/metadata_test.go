@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetaString(t *testing.T) {
+	s := "vpc-123"
+	var nilString *string
+	node := &Node{Metadata: map[string]any{
+		"value":   "vpc-123",
+		"pointer": &s,
+		"nilPtr":  nilString,
+		"nil":     nil,
+		"number":  42,
+		"empty":   "",
+	}}
+
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"value", "vpc-123", true},
+		{"pointer", "vpc-123", true},
+		{"nilPtr", "", false},
+		{"nil", "", false},
+		{"number", "", false},
+		{"empty", "", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := node.MetaString(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetaString(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := (&Node{}).MetaString("value"); ok {
+		t.Error("MetaString() on nil metadata reported a value")
+	}
+}
+
+func TestMetaInt(t *testing.T) {
+	i, i32, i64 := 3, int32(4), int64(5)
+	var nilInt *int32
+	node := &Node{Metadata: map[string]any{
+		"int":      2,
+		"int32":    int32(7),
+		"int64":    int64(8),
+		"intPtr":   &i,
+		"int32Ptr": &i32,
+		"int64Ptr": &i64,
+		"nilPtr":   nilInt,
+		"float":    float64(6),
+		"fraction": 1.5,
+		"number":   json.Number("9"),
+		"string":   "10",
+		"nil":      nil,
+	}}
+
+	tests := []struct {
+		key    string
+		want   int64
+		wantOK bool
+	}{
+		{"int", 2, true},
+		{"int32", 7, true},
+		{"int64", 8, true},
+		{"intPtr", 3, true},
+		{"int32Ptr", 4, true},
+		{"int64Ptr", 5, true},
+		{"nilPtr", 0, false},
+		{"float", 6, true},
+		{"fraction", 0, false},
+		{"number", 9, true},
+		{"string", 0, false},
+		{"nil", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := node.MetaInt(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetaInt(%q) = %d, %v, want %d, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMetaFloat(t *testing.T) {
+	f, i32 := 2.5, int32(4)
+	var nilFloat *float64
+	node := &Node{Metadata: map[string]any{
+		"float":    1.25,
+		"float32":  float32(0.5),
+		"pointer":  &f,
+		"nilPtr":   nilFloat,
+		"int":      3,
+		"int32Ptr": &i32,
+		"number":   json.Number("5.75"),
+		"string":   "6",
+	}}
+
+	tests := []struct {
+		key    string
+		want   float64
+		wantOK bool
+	}{
+		{"float", 1.25, true},
+		{"float32", 0.5, true},
+		{"pointer", 2.5, true},
+		{"nilPtr", 0, false},
+		{"int", 3, true},
+		{"int32Ptr", 4, true},
+		{"number", 5.75, true},
+		{"string", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := node.MetaFloat(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetaFloat(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMetaBool(t *testing.T) {
+	b := true
+	var nilBool *bool
+	node := &Node{Metadata: map[string]any{
+		"value":   false,
+		"pointer": &b,
+		"nilPtr":  nilBool,
+		"nil":     nil,
+		"string":  "true",
+	}}
+
+	tests := []struct {
+		key    string
+		want   bool
+		wantOK bool
+	}{
+		{"value", false, true},
+		{"pointer", true, true},
+		{"nilPtr", false, false},
+		{"nil", false, false},
+		{"string", false, false},
+		{"missing", false, false},
+	}
+	for _, tt := range tests {
+		got, ok := node.MetaBool(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetaBool(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
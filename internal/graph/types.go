@@ -1,7 +1,12 @@
 package graph
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"unicode"
 )
 
 // Node represents a resource in the dependency graph
@@ -16,6 +21,18 @@ type Node struct {
 	Metadata map[string]any    // Additional metadata
 }
 
+// LocalID returns the AWS ID of a node whose ID is scoped by type, account and region, e.g.
+// i-123 for EC2Instance:123456789012:us-east-1:i-123, as API calls expect it. Any other node's
+// ID is returned as is.
+func (n *Node) LocalID() string {
+	if rest, ok := strings.CutPrefix(n.ID, n.Type+":"); ok {
+		if parts := strings.SplitN(rest, ":", 3); len(parts) == 3 {
+			return parts[2]
+		}
+	}
+	return n.ID
+}
+
 // Edge represents a relationship between two resources
 type Edge struct {
 	From         string   // Source node ID
@@ -24,6 +41,17 @@ type Edge struct {
 	Evidence     Evidence // How this relationship was discovered
 }
 
+// edgeKey identifies a logical edge: at most one edge per key is kept in a graph, and
+// edges are matched across graphs by key
+type edgeKey struct {
+	from, to, relation string
+}
+
+// key returns the edge's identity
+func (e *Edge) key() edgeKey {
+	return edgeKey{from: e.From, to: e.To, relation: e.RelationType}
+}
+
 // Evidence tracks how a relationship was discovered
 type Evidence struct {
 	APICall   string         // AWS API call that revealed this relationship
@@ -34,8 +62,17 @@ type Evidence struct {
 // Graph represents the complete dependency graph
 type Graph struct {
 	mu    sync.RWMutex
-	nodes map[string]*Node // Node ID -> Node
-	edges []*Edge          // All edges
+	root  string            // ID of the node discovery started from
+	nodes map[string]*Node  // Node ID -> Node
+	edges []*Edge           // All edges
+	index map[edgeKey]*Edge // Edge key -> edge, for deduplication
+
+	truncationReason string // Why traversal stopped early, empty if it completed
+
+	filter   func(*Node) bool // Optional predicate deciding which nodes may be added
+	rejected map[string]bool  // IDs of nodes dropped by filter
+
+	edgeLimit int // Edge count at which AddEdge and AddNode stop growing the graph, 0 for none
 }
 
 // New creates a new empty graph
@@ -43,23 +80,259 @@ func New() *Graph {
 	return &Graph{
 		nodes: make(map[string]*Node),
 		edges: make([]*Edge, 0),
+		index: make(map[edgeKey]*Edge),
+	}
+}
+
+// FromJSON rebuilds a graph from a JSON document with "nodes", "edges" and optional
+// "rootId" and "truncationReason", as written by the JSON renderer and snapshots
+func FromJSON(data []byte) (*Graph, error) {
+	var doc struct {
+		RootID           string  `json:"rootId"`
+		TruncationReason string  `json:"truncationReason"`
+		Nodes            []*Node `json:"nodes"`
+		Edges            []*Edge `json:"edges"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse graph JSON: %w", err)
+	}
+
+	g := New()
+	for _, node := range doc.Nodes {
+		if node == nil || node.ID == "" {
+			return nil, fmt.Errorf("graph JSON contains a node without an ID")
+		}
+		g.AddNode(node)
+	}
+	for _, edge := range doc.Edges {
+		if edge == nil {
+			continue
+		}
+		g.AddEdge(edge)
+	}
+	g.SetRoot(doc.RootID)
+	g.MarkTruncated(doc.TruncationReason)
+
+	return g, nil
+}
+
+// SetRoot records the ID of the node discovery started from
+func (g *Graph) SetRoot(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.root = id
+}
+
+// Root returns the ID of the node discovery started from, if set
+func (g *Graph) Root() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.root
+}
+
+// SetNodeFilter installs a predicate that AddNode consults before adding a node.
+// Rejected nodes are not added and edges to or from them are dropped. Pass nil to
+// remove the filter; nodes already in the graph are unaffected.
+func (g *Graph) SetNodeFilter(filter func(*Node) bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.filter = filter
+	g.rejected = nil
+	if filter != nil {
+		g.rejected = make(map[string]bool)
 	}
 }
 
+// SetEdgeLimit caps the number of edges. Once the graph holds limit edges AddEdge drops new
+// edges, and AddNode drops new nodes since they could not be connected. Pass 0 to remove the cap.
+func (g *Graph) SetEdgeLimit(limit int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.edgeLimit = limit
+}
+
+// atEdgeLimit reports whether the edge limit has been reached. Callers must hold g.mu.
+func (g *Graph) atEdgeLimit() bool {
+	return g.edgeLimit > 0 && len(g.edges) >= g.edgeLimit
+}
+
+// MarkTruncated records that traversal stopped before the graph was complete
+func (g *Graph) MarkTruncated(reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.truncationReason = reason
+}
+
+// Truncated reports whether traversal stopped before the graph was complete
+func (g *Graph) Truncated() bool {
+	return g.TruncationReason() != ""
+}
+
+// TruncationReason returns why traversal stopped early, or an empty string
+func (g *Graph) TruncationReason() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.truncationReason
+}
+
 // AddNode adds or updates a node in the graph
 func (g *Graph) AddNode(node *Node) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	if _, exists := g.nodes[node.ID]; !exists {
+		if g.filter != nil && !g.filter(node) {
+			g.rejected[node.ID] = true
+			return
+		}
+		if g.atEdgeLimit() {
+			return
+		}
+	}
 	g.nodes[node.ID] = node
 }
 
-// AddEdge adds an edge to the graph
+// AddEdge adds an edge to the graph. An edge with the same From, To and RelationType as one
+// already in the graph is not added again; the existing edge keeps its evidence unless that
+// evidence is heuristic and the new edge's is not.
 func (g *Graph) AddEdge(edge *Edge) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	if g.rejected[edge.From] || g.rejected[edge.To] {
+		return
+	}
+	// At the limit an existing edge may still gain better evidence, but nothing new is added
+	if g.atEdgeLimit() && g.index[edge.key()] == nil {
+		return
+	}
+	g.appendEdge(edge)
+}
+
+// appendEdge adds edge unless an edge with the same key exists. Callers must hold g.mu.
+func (g *Graph) appendEdge(edge *Edge) {
+	if g.index == nil {
+		g.index = make(map[edgeKey]*Edge)
+	}
+	if existing, ok := g.index[edge.key()]; ok {
+		if existing.Evidence.Heuristic && !edge.Evidence.Heuristic {
+			existing.Evidence = edge.Evidence
+		}
+		return
+	}
+	g.index[edge.key()] = edge
 	g.edges = append(g.edges, edge)
 }
 
+// RemoveNode removes a node and every edge to or from it. Removing the root clears it.
+func (g *Graph) RemoveNode(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.nodes[id]; !ok {
+		return
+	}
+	g.removeNodes(map[string]bool{id: true})
+}
+
+// PruneByType removes every node of the given types along with their edges. The root node is
+// always kept so the graph can still be rendered from it.
+func (g *Graph) PruneByType(types ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pruned := make(map[string]bool, len(types))
+	for _, t := range types {
+		pruned[t] = true
+	}
+	remove := make(map[string]bool)
+	for id, node := range g.nodes {
+		if id != g.root && pruned[node.Type] {
+			remove[id] = true
+		}
+	}
+	g.removeNodes(remove)
+}
+
+// PruneLeaves removes nodes of the given types that have no outgoing edges, adding a count of
+// them to the metadata of each node pointing at them (e.g. subnetCount). With iterative set,
+// it repeats until no matching leaves remain, so parents left without edges are pruned too.
+// The root node is always kept. It returns the number of nodes removed.
+func (g *Graph) PruneLeaves(iterative bool, types ...string) int {
+	pruned := make(map[string]bool, len(types))
+	for _, t := range types {
+		pruned[t] = true
+	}
+
+	root := g.Root()
+	removed := 0
+	for {
+		var leaves []*Node
+		for _, node := range g.SortedNodes() {
+			if node.ID != root && pruned[node.Type] && len(g.EdgesFrom(node.ID)) == 0 {
+				leaves = append(leaves, node)
+			}
+		}
+		for _, leaf := range leaves {
+			key := leafCountKey(leaf.Type)
+			for _, parent := range g.Predecessors(leaf.ID) {
+				if parent.Metadata == nil {
+					parent.Metadata = make(map[string]any)
+				}
+				count, _ := parent.MetaInt(key)
+				parent.Metadata[key] = count + 1
+			}
+			g.RemoveNode(leaf.ID)
+		}
+		removed += len(leaves)
+		if !iterative || len(leaves) == 0 {
+			return removed
+		}
+	}
+}
+
+// leafCountKey returns the metadata key counting pruned leaves of a type, lowering its leading
+// acronym: Subnet becomes subnetCount and IAMRole becomes iamRoleCount
+func leafCountKey(nodeType string) string {
+	runes := []rune(nodeType)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	// Keep the capital starting the next word, as in the R of IAMRole
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes) + "Count"
+}
+
+// removeNodes deletes the nodes in ids and the edges incident to them. Callers must hold g.mu.
+func (g *Graph) removeNodes(ids map[string]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	for id := range ids {
+		delete(g.nodes, id)
+	}
+	if ids[g.root] {
+		g.root = ""
+	}
+
+	kept := g.edges[:0]
+	for _, edge := range g.edges {
+		if ids[edge.From] || ids[edge.To] {
+			delete(g.index, edge.key())
+			continue
+		}
+		kept = append(kept, edge)
+	}
+	// Clear the tail so removed edges can be garbage collected
+	for i := len(kept); i < len(g.edges); i++ {
+		g.edges[i] = nil
+	}
+	g.edges = kept
+}
+
 // GetNode retrieves a node by ID
 func (g *Graph) GetNode(id string) (*Node, bool) {
 	g.mu.RLock()
@@ -98,6 +371,31 @@ func (g *Graph) Edges() []*Edge {
 	return edges
 }
 
+// SortedNodes returns all nodes ordered by ID
+func (g *Graph) SortedNodes() []*Node {
+	nodes := g.Nodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// SortedEdges returns all edges ordered by From, To and RelationType
+func (g *Graph) SortedEdges() []*Edge {
+	edges := g.Edges()
+	sort.SliceStable(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.RelationType < b.RelationType
+	})
+	return edges
+}
+
 // EdgesFrom returns all edges originating from a node
 func (g *Graph) EdgesFrom(nodeID string) []*Edge {
 	g.mu.RLock()
@@ -126,6 +424,166 @@ func (g *Graph) EdgesTo(nodeID string) []*Edge {
 	return result
 }
 
+// EdgesBetween returns the edges from one node to another, ordered by relation type
+func (g *Graph) EdgesBetween(from, to string) []*Edge {
+	g.mu.RLock()
+	var result []*Edge
+	for _, edge := range g.edges {
+		if edge.From == from && edge.To == to {
+			result = append(result, edge)
+		}
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RelationType < result[j].RelationType
+	})
+	return result
+}
+
+// Neighbors returns the nodes a node has outgoing edges to, once each and ordered by ID
+func (g *Graph) Neighbors(id string) []*Node {
+	return g.adjacent(id, func(edge *Edge) (string, bool) {
+		return edge.To, edge.From == id
+	})
+}
+
+// Predecessors returns the nodes with edges to a node, once each and ordered by ID
+func (g *Graph) Predecessors(id string) []*Node {
+	return g.adjacent(id, func(edge *Edge) (string, bool) {
+		return edge.From, edge.To == id
+	})
+}
+
+// adjacent collects the existing nodes at the other end of the edges selected by other
+func (g *Graph) adjacent(id string, other func(*Edge) (string, bool)) []*Node {
+	g.mu.RLock()
+	seen := make(map[string]bool)
+	var result []*Node
+	for _, edge := range g.edges {
+		otherID, ok := other(edge)
+		if !ok || seen[otherID] {
+			continue
+		}
+		seen[otherID] = true
+		if node, exists := g.nodes[otherID]; exists {
+			result = append(result, node)
+		}
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Filter returns a new graph containing only the nodes matching pred and the edges whose
+// endpoints both survive. The root node is always kept so the result can still be rendered
+// from it. Nodes and edges are shared with g, not copied.
+func (g *Graph) Filter(pred func(*Node) bool) *Graph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	filtered := New()
+	for id, node := range g.nodes {
+		if id == g.root || pred(node) {
+			filtered.nodes[id] = node
+		}
+	}
+	for _, edge := range g.edges {
+		_, fromOK := filtered.nodes[edge.From]
+		_, toOK := filtered.nodes[edge.To]
+		if fromOK && toOK {
+			filtered.appendEdge(edge)
+		}
+	}
+	filtered.root = g.root
+	filtered.truncationReason = g.truncationReason
+
+	return filtered
+}
+
+// FilterEdges returns a new graph containing every node of g and only the edges matching pred.
+// Nodes and edges are shared with g, not copied.
+func (g *Graph) FilterEdges(pred func(*Edge) bool) *Graph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	filtered := New()
+	for id, node := range g.nodes {
+		filtered.nodes[id] = node
+	}
+	for _, edge := range g.edges {
+		if pred(edge) {
+			filtered.appendEdge(edge)
+		}
+	}
+	filtered.root = g.root
+	filtered.truncationReason = g.truncationReason
+
+	return filtered
+}
+
+// ConfirmedOnly returns a new graph without heuristic edges, pruning nodes that are no longer
+// connected to the root in either direction once those edges are gone
+func (g *Graph) ConfirmedOnly() *Graph {
+	confirmed := g.FilterEdges(func(edge *Edge) bool {
+		return !edge.Evidence.Heuristic
+	})
+	root := confirmed.Root()
+	if root == "" {
+		return confirmed
+	}
+
+	connected := make(map[string]bool)
+	for _, component := range confirmed.ConnectedComponents() {
+		if !containsNode(component, root) {
+			continue
+		}
+		for _, node := range component {
+			connected[node.ID] = true
+		}
+		break
+	}
+	return confirmed.Filter(func(node *Node) bool {
+		return connected[node.ID]
+	})
+}
+
+// containsNode reports whether nodes includes the node with the given ID
+func containsNode(nodes []*Node, id string) bool {
+	for _, node := range nodes {
+		if node.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Isolated returns nodes with no incoming or outgoing edges, ordered by ID
+func (g *Graph) Isolated() []*Node {
+	g.mu.RLock()
+	connected := make(map[string]bool, len(g.nodes))
+	for _, edge := range g.edges {
+		connected[edge.From] = true
+		connected[edge.To] = true
+	}
+
+	var result []*Node
+	for id, node := range g.nodes {
+		if !connected[id] {
+			result = append(result, node)
+		}
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
 // NodeCount returns the number of nodes
 func (g *Graph) NodeCount() int {
 	g.mu.RLock()
@@ -139,3 +597,15 @@ func (g *Graph) EdgeCount() int {
 	defer g.mu.RUnlock()
 	return len(g.edges)
 }
+
+// CountByType returns the number of nodes of each type
+func (g *Graph) CountByType() map[string]int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, node := range g.nodes {
+		counts[node.Type]++
+	}
+	return counts
+}
@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -63,6 +65,40 @@ func TestAddEdge(t *testing.T) {
 	}
 }
 
+func TestAddEdgeDeduplicates(t *testing.T) {
+	g := New()
+	g.AddEdge(&Edge{From: "tg", To: "svc", RelationType: "routes-to", Evidence: Evidence{APICall: "DescribeTargetHealth"}})
+	g.AddEdge(&Edge{From: "tg", To: "svc", RelationType: "routes-to", Evidence: Evidence{APICall: "DescribeServices"}})
+
+	if g.EdgeCount() != 1 {
+		t.Fatalf("expected 1 edge after adding the same edge twice, got %d", g.EdgeCount())
+	}
+	if got := g.Edges()[0].Evidence.APICall; got != "DescribeTargetHealth" {
+		t.Errorf("expected the first edge's evidence to be kept, got %s", got)
+	}
+
+	// A different relation between the same pair is a separate edge
+	g.AddEdge(&Edge{From: "tg", To: "svc", RelationType: "health-checks"})
+	if g.EdgeCount() != 2 {
+		t.Errorf("expected 2 edges for different relation types, got %d", g.EdgeCount())
+	}
+}
+
+func TestAddEdgeUpgradesHeuristicEvidence(t *testing.T) {
+	g := New()
+	g.AddEdge(&Edge{From: "fn", To: "db", RelationType: "connects-to", Evidence: Evidence{APICall: "GetFunction", Heuristic: true}})
+	g.AddEdge(&Edge{From: "fn", To: "db", RelationType: "connects-to", Evidence: Evidence{APICall: "DescribeDBProxies"}})
+	g.AddEdge(&Edge{From: "fn", To: "db", RelationType: "connects-to", Evidence: Evidence{APICall: "GetFunction", Heuristic: true}})
+
+	edges := g.Edges()
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 edge, got %d", len(edges))
+	}
+	if edges[0].Evidence.Heuristic || edges[0].Evidence.APICall != "DescribeDBProxies" {
+		t.Errorf("expected confirmed evidence to replace heuristic evidence, got %+v", edges[0].Evidence)
+	}
+}
+
 func TestEdgesFrom(t *testing.T) {
 	g := New()
 	g.AddEdge(&Edge{From: "A", To: "B", RelationType: "uses"})
@@ -101,6 +137,60 @@ func TestEdgesTo(t *testing.T) {
 	}
 }
 
+func TestNeighborsAndPredecessors(t *testing.T) {
+	g := New()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(&Node{ID: id, Type: "Test"})
+	}
+	g.AddEdge(&Edge{From: "A", To: "C", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "A", To: "B", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "A", To: "B", RelationType: "forwards-to"})
+	g.AddEdge(&Edge{From: "D", To: "B", RelationType: "uses"})
+	// Edges to nodes missing from the graph are ignored
+	g.AddEdge(&Edge{From: "A", To: "missing", RelationType: "uses"})
+
+	ids := func(nodes []*Node) string {
+		var result []string
+		for _, node := range nodes {
+			result = append(result, node.ID)
+		}
+		return strings.Join(result, ",")
+	}
+
+	tests := []struct {
+		name string
+		got  []*Node
+		want string
+	}{
+		{"neighbors of A", g.Neighbors("A"), "B,C"},
+		{"neighbors of B", g.Neighbors("B"), ""},
+		{"predecessors of B", g.Predecessors("B"), "A,D"},
+		{"predecessors of A", g.Predecessors("A"), ""},
+		{"unknown node", g.Neighbors("unknown"), ""},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.got); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEdgesBetween(t *testing.T) {
+	g := New()
+	g.AddEdge(&Edge{From: "A", To: "B", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "A", To: "B", RelationType: "forwards-to"})
+	g.AddEdge(&Edge{From: "B", To: "A", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "A", To: "C", RelationType: "uses"})
+
+	edges := g.EdgesBetween("A", "B")
+	if len(edges) != 2 || edges[0].RelationType != "forwards-to" || edges[1].RelationType != "uses" {
+		t.Errorf("EdgesBetween(A, B) = %v, want forwards-to and uses", edges)
+	}
+	if edges := g.EdgesBetween("C", "A"); len(edges) != 0 {
+		t.Errorf("EdgesBetween(C, A) = %v, want none", edges)
+	}
+}
+
 func TestHasNode(t *testing.T) {
 	g := New()
 	g.AddNode(&Node{ID: "test-1"})
@@ -112,3 +202,495 @@ func TestHasNode(t *testing.T) {
 		t.Error("expected HasNode to return false for test-2")
 	}
 }
+
+func TestFromJSON(t *testing.T) {
+	data := []byte(`{
+		"rootId": "node-1",
+		"nodes": [
+			{"ID": "node-1", "Type": "LoadBalancer", "Name": "test-lb"},
+			{"ID": "node-2", "Type": "TargetGroup", "Name": "test-tg"}
+		],
+		"edges": [
+			{"From": "node-1", "To": "node-2", "RelationType": "forwards-to"}
+		]
+	}`)
+
+	g, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+
+	if g.NodeCount() != 2 {
+		t.Errorf("FromJSON() node count = %d, want 2", g.NodeCount())
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("FromJSON() edge count = %d, want 1", g.EdgeCount())
+	}
+	if g.Root() != "node-1" {
+		t.Errorf("FromJSON() root = %q, want node-1", g.Root())
+	}
+
+	edges := g.EdgesFrom("node-1")
+	if len(edges) != 1 || edges[0].To != "node-2" {
+		t.Errorf("FromJSON() expected edge node-1 -> node-2, got %v", edges)
+	}
+}
+
+func TestFromJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "malformed", data: `{"nodes": [`},
+		{name: "missing node ID", data: `{"nodes": [{"Type": "LoadBalancer"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromJSON([]byte(tt.data)); err == nil {
+				t.Error("FromJSON() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSortedNodesAndEdges(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "b"})
+	g.AddNode(&Node{ID: "c"})
+	g.AddNode(&Node{ID: "a"})
+	g.AddEdge(&Edge{From: "b", To: "c", RelationType: "routes-to"})
+	g.AddEdge(&Edge{From: "a", To: "c", RelationType: "triggers"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "forwards-to"})
+
+	nodes := g.SortedNodes()
+	for i, want := range []string{"a", "b", "c"} {
+		if nodes[i].ID != want {
+			t.Errorf("SortedNodes()[%d] = %s, want %s", i, nodes[i].ID, want)
+		}
+	}
+
+	edges := g.SortedEdges()
+	wantEdges := [][2]string{{"a", "b"}, {"a", "c"}, {"b", "c"}}
+	for i, want := range wantEdges {
+		if edges[i].From != want[0] || edges[i].To != want[1] {
+			t.Errorf("SortedEdges()[%d] = %s -> %s, want %s -> %s", i, edges[i].From, edges[i].To, want[0], want[1])
+		}
+	}
+}
+
+func TestIsolated(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddNode(&Node{ID: "d"})
+	g.AddNode(&Node{ID: "c"})
+	g.AddEdge(&Edge{From: "a", To: "b"})
+
+	isolated := g.Isolated()
+	if len(isolated) != 2 {
+		t.Fatalf("Isolated() returned %d nodes, want 2", len(isolated))
+	}
+	if isolated[0].ID != "c" || isolated[1].ID != "d" {
+		t.Errorf("Isolated() = [%s %s], want [c d]", isolated[0].ID, isolated[1].ID)
+	}
+}
+
+func TestSetNodeFilter(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "root", Type: "IAMRole"})
+
+	g.SetNodeFilter(func(node *Node) bool {
+		return node.Type != "IAMRole"
+	})
+
+	g.AddNode(&Node{ID: "lb", Type: "LoadBalancer"})
+	g.AddNode(&Node{ID: "role", Type: "IAMRole"})
+	g.AddEdge(&Edge{From: "root", To: "lb"})
+	g.AddEdge(&Edge{From: "lb", To: "role"})
+
+	if !g.HasNode("root") {
+		t.Error("SetNodeFilter() should not remove nodes already in the graph")
+	}
+	if g.HasNode("role") {
+		t.Error("SetNodeFilter() expected filtered node to be dropped")
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("SetNodeFilter() expected edges to dropped nodes to be skipped, got %d edges", g.EdgeCount())
+	}
+
+	g.SetNodeFilter(nil)
+	g.AddNode(&Node{ID: "role", Type: "IAMRole"})
+	if !g.HasNode("role") {
+		t.Error("SetNodeFilter(nil) should remove the filter")
+	}
+}
+
+func TestSetEdgeLimit(t *testing.T) {
+	g := New()
+	g.SetEdgeLimit(2)
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddNode(&Node{ID: "c"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses", Evidence: Evidence{Heuristic: true}})
+	g.AddEdge(&Edge{From: "a", To: "c", RelationType: "uses"})
+
+	// At the limit new edges and nodes are dropped, but an existing edge can still be confirmed
+	g.AddEdge(&Edge{From: "b", To: "c", RelationType: "uses"})
+	g.AddNode(&Node{ID: "d"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses", Evidence: Evidence{APICall: "Describe"}})
+
+	if g.EdgeCount() != 2 {
+		t.Errorf("EdgeCount() = %d, want 2", g.EdgeCount())
+	}
+	if g.HasNode("d") {
+		t.Error("AddNode() added a node past the edge limit")
+	}
+	if edges := g.EdgesBetween("a", "b"); len(edges) != 1 || edges[0].Evidence.Heuristic {
+		t.Errorf("EdgesBetween(a, b) = %+v, want the confirmed edge", edges)
+	}
+
+	g.SetEdgeLimit(0)
+	g.AddEdge(&Edge{From: "b", To: "c", RelationType: "uses"})
+	if g.EdgeCount() != 3 {
+		t.Errorf("SetEdgeLimit(0) should remove the limit, got %d edges", g.EdgeCount())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	newGraph := func() *Graph {
+		g := New()
+		g.AddNode(&Node{ID: "root", Region: "eu-west-1", Account: "111111111111"})
+		g.AddNode(&Node{ID: "east", Region: "us-east-1", Account: "111111111111"})
+		g.AddNode(&Node{ID: "west", Region: "us-west-2", Account: "222222222222"})
+		g.AddNode(&Node{ID: "east-other", Region: "us-east-1", Account: "222222222222"})
+		g.AddEdge(&Edge{From: "root", To: "east"})
+		g.AddEdge(&Edge{From: "root", To: "west"})
+		g.AddEdge(&Edge{From: "west", To: "east-other"})
+		g.AddEdge(&Edge{From: "east", To: "east-other"})
+		g.SetRoot("root")
+		return g
+	}
+
+	tests := []struct {
+		name      string
+		pred      func(*Node) bool
+		wantNodes []string
+		wantEdges int
+	}{
+		{
+			name:      "Region",
+			pred:      func(n *Node) bool { return n.Region == "us-east-1" },
+			wantNodes: []string{"east", "east-other", "root"},
+			wantEdges: 2,
+		},
+		{
+			name:      "Account",
+			pred:      func(n *Node) bool { return n.Account == "222222222222" },
+			wantNodes: []string{"east-other", "root", "west"},
+			wantEdges: 2,
+		},
+		{
+			name:      "Nothing matches keeps only the root",
+			pred:      func(*Node) bool { return false },
+			wantNodes: []string{"root"},
+			wantEdges: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGraph()
+			filtered := g.Filter(tt.pred)
+
+			var ids []string
+			for _, node := range filtered.SortedNodes() {
+				ids = append(ids, node.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantNodes, ",") {
+				t.Errorf("Filter() nodes = %v, want %v", ids, tt.wantNodes)
+			}
+			if filtered.EdgeCount() != tt.wantEdges {
+				t.Errorf("Filter() edges = %d, want %d", filtered.EdgeCount(), tt.wantEdges)
+			}
+			for _, edge := range filtered.Edges() {
+				if !filtered.HasNode(edge.From) || !filtered.HasNode(edge.To) {
+					t.Errorf("Filter() left dangling edge %s -> %s", edge.From, edge.To)
+				}
+			}
+			if filtered.Root() != "root" {
+				t.Errorf("Filter() root = %q, want root", filtered.Root())
+			}
+			if g.NodeCount() != 4 {
+				t.Errorf("Filter() modified the original graph")
+			}
+		})
+	}
+}
+
+func TestConfirmedOnly(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "lb"})
+	g.AddNode(&Node{ID: "tg"})
+	g.AddNode(&Node{ID: "instance"})
+	g.AddNode(&Node{ID: "guessed-db"})
+	g.AddNode(&Node{ID: "guessed-cache"})
+	g.AddNode(&Node{ID: "sg"})
+	g.AddEdge(&Edge{From: "lb", To: "tg", RelationType: "forwards-to", Evidence: Evidence{APICall: "DescribeListeners"}})
+	g.AddEdge(&Edge{From: "tg", To: "instance", RelationType: "routes-to", Evidence: Evidence{APICall: "DescribeTargetHealth"}})
+	g.AddEdge(&Edge{From: "instance", To: "guessed-db", RelationType: "connects-to", Evidence: Evidence{Heuristic: true}})
+	g.AddEdge(&Edge{From: "guessed-db", To: "guessed-cache", RelationType: "connects-to", Evidence: Evidence{APICall: "DescribeDBInstances"}})
+	// Confirmed edges pointing at the root keep their source
+	g.AddEdge(&Edge{From: "sg", To: "lb", RelationType: "protects", Evidence: Evidence{APICall: "DescribeLoadBalancers"}})
+	g.AddEdge(&Edge{From: "lb", To: "guessed-cache", RelationType: "connects-to", Evidence: Evidence{Heuristic: true}})
+	g.SetRoot("lb")
+
+	confirmed := g.ConfirmedOnly()
+
+	var ids []string
+	for _, node := range confirmed.SortedNodes() {
+		ids = append(ids, node.ID)
+	}
+	wantNodes := []string{"instance", "lb", "sg", "tg"}
+	if strings.Join(ids, ",") != strings.Join(wantNodes, ",") {
+		t.Errorf("ConfirmedOnly() nodes = %v, want %v", ids, wantNodes)
+	}
+	if confirmed.EdgeCount() != 3 {
+		t.Errorf("ConfirmedOnly() edges = %d, want 3", confirmed.EdgeCount())
+	}
+	for _, edge := range confirmed.Edges() {
+		if edge.Evidence.Heuristic {
+			t.Errorf("ConfirmedOnly() kept heuristic edge %s -> %s", edge.From, edge.To)
+		}
+	}
+	if confirmed.Root() != "lb" {
+		t.Errorf("ConfirmedOnly() root = %q, want lb", confirmed.Root())
+	}
+	if g.NodeCount() != 6 || g.EdgeCount() != 6 {
+		t.Errorf("ConfirmedOnly() modified the original graph")
+	}
+}
+
+func TestFilterEdges(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "b", To: "a", RelationType: "invokes"})
+	g.SetRoot("a")
+
+	filtered := g.FilterEdges(func(edge *Edge) bool { return edge.RelationType == "uses" })
+	if filtered.NodeCount() != 2 {
+		t.Errorf("FilterEdges() nodes = %d, want 2", filtered.NodeCount())
+	}
+	if filtered.EdgeCount() != 1 || filtered.Edges()[0].RelationType != "uses" {
+		t.Errorf("FilterEdges() edges = %v, want only uses", filtered.Edges())
+	}
+	if filtered.Root() != "a" {
+		t.Errorf("FilterEdges() root = %q, want a", filtered.Root())
+	}
+}
+
+func TestCountByType(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "lb", Type: "LoadBalancer"})
+	g.AddNode(&Node{ID: "tg-1", Type: "TargetGroup"})
+	g.AddNode(&Node{ID: "tg-2", Type: "TargetGroup"})
+	g.AddNode(&Node{ID: "svc", Type: "ECSService"})
+
+	counts := g.CountByType()
+	want := map[string]int{"LoadBalancer": 1, "TargetGroup": 2, "ECSService": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("CountByType() = %v, want %v", counts, want)
+	}
+	for typ, n := range want {
+		if counts[typ] != n {
+			t.Errorf("CountByType()[%s] = %d, want %d", typ, counts[typ], n)
+		}
+	}
+
+	if got := New().CountByType(); len(got) != 0 {
+		t.Errorf("CountByType() on empty graph = %v, want empty", got)
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	newGraph := func() *Graph {
+		g := New()
+		g.AddNode(&Node{ID: "lb", Type: "LoadBalancer"})
+		g.AddNode(&Node{ID: "listener", Type: "Listener"})
+		g.AddNode(&Node{ID: "tg", Type: "TargetGroup"})
+		g.AddEdge(&Edge{From: "lb", To: "listener", RelationType: "has-listener"})
+		g.AddEdge(&Edge{From: "listener", To: "tg", RelationType: "forwards-to"})
+		g.AddEdge(&Edge{From: "lb", To: "tg", RelationType: "forwards-to"})
+		g.SetRoot("lb")
+		return g
+	}
+
+	tests := []struct {
+		name      string
+		remove    string
+		wantNodes int
+		wantEdges int
+		wantRoot  string
+	}{
+		{name: "Middle node", remove: "listener", wantNodes: 2, wantEdges: 1, wantRoot: "lb"},
+		{name: "Root", remove: "lb", wantNodes: 2, wantEdges: 1, wantRoot: ""},
+		{name: "Missing node", remove: "nope", wantNodes: 3, wantEdges: 3, wantRoot: "lb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGraph()
+			g.RemoveNode(tt.remove)
+
+			if g.HasNode(tt.remove) {
+				t.Errorf("RemoveNode(%q) left the node in the graph", tt.remove)
+			}
+			if g.NodeCount() != tt.wantNodes || g.EdgeCount() != tt.wantEdges {
+				t.Errorf("RemoveNode(%q) = %d nodes, %d edges, want %d, %d",
+					tt.remove, g.NodeCount(), g.EdgeCount(), tt.wantNodes, tt.wantEdges)
+			}
+			for _, edge := range g.Edges() {
+				if !g.HasNode(edge.From) || !g.HasNode(edge.To) {
+					t.Errorf("RemoveNode(%q) left dangling edge %s -> %s", tt.remove, edge.From, edge.To)
+				}
+			}
+			if g.Root() != tt.wantRoot {
+				t.Errorf("Root() = %q, want %q", g.Root(), tt.wantRoot)
+			}
+		})
+	}
+}
+
+func TestRemoveNodeAllowsReAdd(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+
+	g.RemoveNode("b")
+	g.AddNode(&Node{ID: "b"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+
+	if g.EdgeCount() != 1 {
+		t.Errorf("EdgeCount() = %d after re-adding a removed edge, want 1", g.EdgeCount())
+	}
+}
+
+func TestPruneByType(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "svc", Type: "ECSService"})
+	g.AddNode(&Node{ID: "policy-1", Type: "ScalingPolicy"})
+	g.AddNode(&Node{ID: "policy-2", Type: "ScalingPolicy"})
+	g.AddNode(&Node{ID: "listener", Type: "Listener"})
+	g.AddNode(&Node{ID: "tg", Type: "TargetGroup"})
+	g.AddEdge(&Edge{From: "svc", To: "policy-1", RelationType: "scaled-by"})
+	g.AddEdge(&Edge{From: "svc", To: "policy-2", RelationType: "scaled-by"})
+	g.AddEdge(&Edge{From: "listener", To: "tg", RelationType: "forwards-to"})
+	g.AddEdge(&Edge{From: "svc", To: "tg", RelationType: "registers-with"})
+	g.SetRoot("svc")
+
+	g.PruneByType("ScalingPolicy", "Listener", "ECSService")
+
+	var ids []string
+	for _, node := range g.SortedNodes() {
+		ids = append(ids, node.ID)
+	}
+	if want := "svc,tg"; strings.Join(ids, ",") != want {
+		t.Errorf("PruneByType() nodes = %v, want %s (the root is kept)", ids, want)
+	}
+	if g.EdgeCount() != 1 || g.Edges()[0].RelationType != "registers-with" {
+		t.Errorf("PruneByType() edges = %v, want only registers-with", g.Edges())
+	}
+}
+
+func TestPruneLeaves(t *testing.T) {
+	build := func() *Graph {
+		g := New()
+		g.AddNode(&Node{ID: "lb", Type: "LoadBalancer"})
+		g.AddNode(&Node{ID: "subnet-1", Type: "Subnet"})
+		g.AddNode(&Node{ID: "subnet-2", Type: "Subnet"})
+		g.AddNode(&Node{ID: "sg", Type: "SecurityGroup"})
+		g.AddNode(&Node{ID: "vpc", Type: "VPC"})
+		g.AddNode(&Node{ID: "subnet-3", Type: "Subnet"})
+		g.AddEdge(&Edge{From: "lb", To: "subnet-1", RelationType: "runs-in-subnet"})
+		g.AddEdge(&Edge{From: "lb", To: "subnet-1", RelationType: "uses-subnet"})
+		g.AddEdge(&Edge{From: "lb", To: "subnet-2", RelationType: "runs-in-subnet"})
+		g.AddEdge(&Edge{From: "lb", To: "sg", RelationType: "uses-security-group"})
+		g.AddEdge(&Edge{From: "sg", To: "vpc", RelationType: "in-vpc"})
+		// subnet-3 is not a leaf, so it is kept
+		g.AddEdge(&Edge{From: "subnet-3", To: "vpc", RelationType: "in-vpc"})
+		g.AddEdge(&Edge{From: "lb", To: "subnet-3", RelationType: "runs-in-subnet"})
+		g.SetRoot("lb")
+		return g
+	}
+
+	tests := []struct {
+		name        string
+		iterative   bool
+		types       []string
+		wantRemoved int
+		wantNodes   string
+		wantMeta    map[string]any
+	}{
+		{
+			name:        "subnet leaves",
+			types:       []string{"Subnet"},
+			wantRemoved: 2,
+			wantNodes:   "lb,sg,subnet-3,vpc",
+			wantMeta:    map[string]any{"subnetCount": int64(2)},
+		},
+		{
+			name:        "single pass keeps exposed leaves",
+			types:       []string{"Subnet", "VPC", "SecurityGroup"},
+			wantRemoved: 3,
+			wantNodes:   "lb,sg,subnet-3",
+			wantMeta:    map[string]any{"subnetCount": int64(2)},
+		},
+		{
+			name:        "iterative prunes exposed leaves",
+			iterative:   true,
+			types:       []string{"Subnet", "VPC", "SecurityGroup"},
+			wantRemoved: 5,
+			wantNodes:   "lb",
+			wantMeta:    map[string]any{"subnetCount": int64(3), "securityGroupCount": int64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := build()
+			if got := g.PruneLeaves(tt.iterative, tt.types...); got != tt.wantRemoved {
+				t.Errorf("PruneLeaves() = %d, want %d", got, tt.wantRemoved)
+			}
+
+			var ids []string
+			for _, node := range g.SortedNodes() {
+				ids = append(ids, node.ID)
+			}
+			if strings.Join(ids, ",") != tt.wantNodes {
+				t.Errorf("PruneLeaves() nodes = %v, want %s", ids, tt.wantNodes)
+			}
+			lb, _ := g.GetNode("lb")
+			if !reflect.DeepEqual(lb.Metadata, tt.wantMeta) {
+				t.Errorf("root metadata = %v, want %v", lb.Metadata, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestLeafCountKey(t *testing.T) {
+	tests := map[string]string{
+		"Subnet":        "subnetCount",
+		"SecurityGroup": "securityGroupCount",
+		"IAMRole":       "iamRoleCount",
+		"EC2Instance":   "ec2InstanceCount",
+		"VPC":           "vpcCount",
+	}
+	for nodeType, want := range tests {
+		if got := leafCountKey(nodeType); got != want {
+			t.Errorf("leafCountKey(%q) = %q, want %q", nodeType, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package graph
+
+import "fmt"
+
+// Kinds of problems Validate reports
+const (
+	// IssueDanglingEdge is an edge whose From or To node is not in the graph
+	IssueDanglingEdge = "dangling-edge"
+	// IssueDuplicateEdge is a second edge with the same From, To and RelationType
+	IssueDuplicateEdge = "duplicate-edge"
+	// IssueSelfLoop is an edge from a node to itself
+	IssueSelfLoop = "self-loop"
+)
+
+// ValidationIssue is a structural problem in a graph
+type ValidationIssue struct {
+	Kind    string // One of the Issue constants
+	Edge    *Edge  // The offending edge
+	Message string // Human-readable description
+}
+
+// Validate checks the graph for edges that reference missing nodes, duplicate edges and
+// self-loops, which traversals and renderers assume never occur. Issues are ordered as
+// SortedEdges orders their edges; a graph without issues returns nil.
+func (g *Graph) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	seen := make(map[edgeKey]bool)
+	for _, edge := range g.SortedEdges() {
+		describe := fmt.Sprintf("%s -[%s]-> %s", edge.From, edge.RelationType, edge.To)
+
+		for _, id := range []string{edge.From, edge.To} {
+			if !g.HasNode(id) {
+				issues = append(issues, ValidationIssue{
+					Kind:    IssueDanglingEdge,
+					Edge:    edge,
+					Message: fmt.Sprintf("edge %s references missing node %s", describe, id),
+				})
+				// A self-loop on a missing node is reported once
+				if edge.From == edge.To {
+					break
+				}
+			}
+		}
+
+		if seen[edge.key()] {
+			issues = append(issues, ValidationIssue{
+				Kind:    IssueDuplicateEdge,
+				Edge:    edge,
+				Message: fmt.Sprintf("edge %s appears more than once", describe),
+			})
+		}
+		seen[edge.key()] = true
+
+		if edge.From == edge.To {
+			issues = append(issues, ValidationIssue{
+				Kind:    IssueSelfLoop,
+				Edge:    edge,
+				Message: fmt.Sprintf("edge %s points at its own source", describe),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddNode(&Node{ID: "c"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "a", To: "missing", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "c", To: "c", RelationType: "member-of"})
+
+	// AddEdge deduplicates, so a duplicate only appears when an edge is changed after adding
+	moved := &Edge{From: "b", To: "c", RelationType: "uses"}
+	g.AddEdge(moved)
+	moved.From, moved.To = "a", "b"
+
+	var got []string
+	for _, issue := range g.Validate() {
+		got = append(got, issue.Kind+": "+issue.Message)
+	}
+	want := []string{
+		"duplicate-edge: edge a -[uses]-> b appears more than once",
+		"dangling-edge: edge a -[uses]-> missing references missing node missing",
+		"self-loop: edge c -[member-of]-> c points at its own source",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateDanglingSelfLoop(t *testing.T) {
+	g := New()
+	g.AddEdge(&Edge{From: "gone", To: "gone", RelationType: "uses"})
+
+	var kinds []string
+	for _, issue := range g.Validate() {
+		kinds = append(kinds, issue.Kind)
+	}
+	if want := []string{IssueDanglingEdge, IssueSelfLoop}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Validate() kinds = %v, want %v", kinds, want)
+	}
+}
+
+func TestValidateCleanGraph(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: "a"})
+	g.AddNode(&Node{ID: "b"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+	g.AddEdge(&Edge{From: "a", To: "b", RelationType: "uses"})
+
+	if issues := g.Validate(); issues != nil {
+		t.Errorf("Validate() = %+v, want no issues", issues)
+	}
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// csvEdgeHeader is the header row for the CSV edge list
+var csvEdgeHeader = []string{
+	"from_id",
+	"from_type",
+	"from_name",
+	"to_id",
+	"to_type",
+	"to_name",
+	"relation",
+	"heuristic",
+	"api_call",
+}
+
+// csvNodeHeader is the header row for the CSV node list
+var csvNodeHeader = []string{
+	"id",
+	"type",
+	"name",
+	"arn",
+	"region",
+	"account",
+	"tags",
+	"metadata",
+}
+
+// RenderCSV renders the graph as a CSV edge list with one row per edge
+func RenderCSV(w io.Writer, g *graph.Graph) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvEdgeHeader); err != nil {
+		return err
+	}
+
+	for _, edge := range g.Edges() {
+		fromType, fromName := lookupNode(g, edge.From)
+		toType, toName := lookupNode(g, edge.To)
+
+		record := []string{
+			edge.From,
+			fromType,
+			fromName,
+			edge.To,
+			toType,
+			toName,
+			edge.RelationType,
+			strconv.FormatBool(edge.Evidence.Heuristic),
+			edge.Evidence.APICall,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// RenderNodesCSV renders the graph's nodes as CSV with one row per node, ordered by ID.
+// Tags are flattened to k=v pairs joined by semicolons and metadata is encoded as JSON.
+func RenderNodesCSV(w io.Writer, g *graph.Graph) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvNodeHeader); err != nil {
+		return err
+	}
+
+	for _, node := range g.SortedNodes() {
+		metadata, err := csvMetadata(node.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata for %s: %w", node.ID, err)
+		}
+
+		record := []string{
+			node.ID,
+			node.Type,
+			node.Name,
+			node.ARN,
+			node.Region,
+			node.Account,
+			csvTags(node.Tags),
+			metadata,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvTags flattens tags to k=v pairs ordered by key and joined by semicolons
+func csvTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return strings.Join(pairs, ";")
+}
+
+// csvMetadata encodes metadata as a JSON object, or an empty string when there is none
+func csvMetadata(metadata map[string]any) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// lookupNode returns the type and name of a node, or empty strings if it is not in the graph
+func lookupNode(g *graph.Graph, id string) (nodeType, name string) {
+	node, ok := g.GetNode(id)
+	if !ok {
+		return "", ""
+	}
+	return node.Type, node.Name
+}
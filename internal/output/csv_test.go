@@ -0,0 +1,138 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestRenderCSV(t *testing.T) {
+	g := graph.New()
+
+	g.AddNode(&graph.Node{
+		ID:   "node-1",
+		Type: "LoadBalancer",
+		Name: "test-lb",
+	})
+	g.AddNode(&graph.Node{
+		ID:   "node-2",
+		Type: "TargetGroup",
+		Name: "test-tg, primary",
+	})
+
+	g.AddEdge(&graph.Edge{
+		From:         "node-1",
+		To:           "node-2",
+		RelationType: "forwards-to",
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "node-2",
+		To:           "missing",
+		RelationType: "connects-to",
+		Evidence: graph.Evidence{
+			APICall:   "GetFunction",
+			Heuristic: true,
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := RenderCSV(&buf, g); err != nil {
+		t.Fatalf("RenderCSV() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("RenderCSV() produced invalid CSV: %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("RenderCSV() expected 3 rows (header + 2 edges), got %d", len(records))
+	}
+
+	wantHeader := []string{"from_id", "from_type", "from_name", "to_id", "to_type", "to_name", "relation", "heuristic", "api_call"}
+	for i, col := range wantHeader {
+		if records[0][i] != col {
+			t.Errorf("RenderCSV() header[%d] = %q, want %q", i, records[0][i], col)
+		}
+	}
+
+	wantFirst := []string{"node-1", "LoadBalancer", "test-lb", "node-2", "TargetGroup", "test-tg, primary", "forwards-to", "false", ""}
+	for i, val := range wantFirst {
+		if records[1][i] != val {
+			t.Errorf("RenderCSV() row 1 col %d = %q, want %q", i, records[1][i], val)
+		}
+	}
+
+	// Unknown endpoints resolve to empty type/name
+	if records[2][4] != "" || records[2][5] != "" {
+		t.Errorf("RenderCSV() expected empty type/name for missing node, got %q/%q", records[2][4], records[2][5])
+	}
+	if records[2][7] != "true" || records[2][8] != "GetFunction" {
+		t.Errorf("RenderCSV() heuristic/api_call = %q/%q, want true/GetFunction", records[2][7], records[2][8])
+	}
+}
+
+func TestRenderNodesCSV(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{
+		ID:      "arn:aws:lambda:us-east-1:123456789012:function:api",
+		Type:    "Lambda",
+		Name:    "api",
+		ARN:     "arn:aws:lambda:us-east-1:123456789012:function:api",
+		Region:  "us-east-1",
+		Account: "123456789012",
+		Tags:    map[string]string{"team": "payments", "env": "prod"},
+		Metadata: map[string]any{
+			"runtime": "go1.x",
+			"memory":  128,
+		},
+	})
+	g.AddNode(&graph.Node{
+		ID:   "sg-123",
+		Type: "SecurityGroup",
+		Name: "web, \"public\"",
+	})
+
+	var buf bytes.Buffer
+	if err := RenderNodesCSV(&buf, g); err != nil {
+		t.Fatalf("RenderNodesCSV() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("RenderNodesCSV() produced invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("RenderNodesCSV() expected 3 rows (header + 2 nodes), got %d", len(records))
+	}
+
+	wantHeader := []string{"id", "type", "name", "arn", "region", "account", "tags", "metadata"}
+	if len(records[0]) != len(wantHeader) {
+		t.Fatalf("RenderNodesCSV() header = %v, want %v", records[0], wantHeader)
+	}
+	for i, col := range wantHeader {
+		if records[0][i] != col {
+			t.Errorf("RenderNodesCSV() header[%d] = %q, want %q", i, records[0][i], col)
+		}
+	}
+
+	// Rows are ordered by ID; tags are sorted by key and metadata is JSON
+	lambda := records[1]
+	if lambda[1] != "Lambda" || lambda[4] != "us-east-1" || lambda[5] != "123456789012" {
+		t.Errorf("RenderNodesCSV() row 1 = %v", lambda)
+	}
+	if lambda[6] != "env=prod;team=payments" {
+		t.Errorf("RenderNodesCSV() tags = %q, want env=prod;team=payments", lambda[6])
+	}
+	if lambda[7] != `{"memory":128,"runtime":"go1.x"}` {
+		t.Errorf("RenderNodesCSV() metadata = %q", lambda[7])
+	}
+
+	// Commas and quotes survive the round trip
+	sg := records[2]
+	if sg[2] != `web, "public"` || sg[6] != "" || sg[7] != "" {
+		t.Errorf("RenderNodesCSV() row 2 = %v", sg)
+	}
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// RenderDiff renders the changes between two graphs as text
+func RenderDiff(w io.Writer, diff *graph.GraphDiff) error {
+	if diff.Empty() {
+		fmt.Fprintf(w, "No changes\n")
+		return nil
+	}
+
+	renderDiffNodes(w, "+", "Added nodes", diff.AddedNodes)
+	renderDiffNodes(w, "-", "Removed nodes", diff.RemovedNodes)
+	renderDiffNodes(w, "~", "Modified nodes", diff.ModifiedNodes)
+	renderDiffEdges(w, "+", "Added edges", diff.AddedEdges)
+	renderDiffEdges(w, "-", "Removed edges", diff.RemovedEdges)
+	renderDiffEdges(w, "~", "Changed edges", diff.ChangedEdges)
+
+	fmt.Fprintf(w, "\nSummary: +%d/-%d/~%d nodes, +%d/-%d/~%d edges\n",
+		len(diff.AddedNodes), len(diff.RemovedNodes), len(diff.ModifiedNodes),
+		len(diff.AddedEdges), len(diff.RemovedEdges), len(diff.ChangedEdges))
+	return nil
+}
+
+// DiffJSON represents the changes between two graphs in JSON format. Lists are never null so
+// consumers can iterate them without checks.
+type DiffJSON struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	AddedNodes    []*graph.Node `json:"addedNodes"`
+	RemovedNodes  []*graph.Node `json:"removedNodes"`
+	ModifiedNodes []*graph.Node `json:"modifiedNodes"`
+	AddedEdges    []*graph.Edge `json:"addedEdges"`
+	RemovedEdges  []*graph.Edge `json:"removedEdges"`
+	ChangedEdges  []*graph.Edge `json:"changedEdges"`
+	Summary       DiffSummary   `json:"summary"`
+}
+
+// DiffSummary counts the changes in each category
+type DiffSummary struct {
+	AddedNodes    int  `json:"addedNodes"`
+	RemovedNodes  int  `json:"removedNodes"`
+	ModifiedNodes int  `json:"modifiedNodes"`
+	AddedEdges    int  `json:"addedEdges"`
+	RemovedEdges  int  `json:"removedEdges"`
+	ChangedEdges  int  `json:"changedEdges"`
+	Changed       bool `json:"changed"`
+}
+
+// RenderDiffJSON renders the changes between two graphs as JSON
+func RenderDiffJSON(w io.Writer, diff *graph.GraphDiff) error {
+	output := DiffJSON{
+		SchemaVersion: SchemaVersion,
+		AddedNodes:    nonNil(diff.AddedNodes),
+		RemovedNodes:  nonNil(diff.RemovedNodes),
+		ModifiedNodes: nonNil(diff.ModifiedNodes),
+		AddedEdges:    nonNil(diff.AddedEdges),
+		RemovedEdges:  nonNil(diff.RemovedEdges),
+		ChangedEdges:  nonNil(diff.ChangedEdges),
+		Summary: DiffSummary{
+			AddedNodes:    len(diff.AddedNodes),
+			RemovedNodes:  len(diff.RemovedNodes),
+			ModifiedNodes: len(diff.ModifiedNodes),
+			AddedEdges:    len(diff.AddedEdges),
+			RemovedEdges:  len(diff.RemovedEdges),
+			ChangedEdges:  len(diff.ChangedEdges),
+			Changed:       !diff.Empty(),
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// nonNil returns items, or an empty slice if it is nil, so it encodes as [] rather than null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func renderDiffNodes(w io.Writer, marker, title string, nodes []*graph.Node) {
+	if len(nodes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(nodes))
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s %s: %s\n", marker, node.Type, nodeLabel(node))
+	}
+}
+
+func renderDiffEdges(w io.Writer, marker, title string, edges []*graph.Edge) {
+	if len(edges) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(edges))
+	for _, edge := range edges {
+		fmt.Fprintf(w, "%s %s --[%s]--> %s\n", marker, edge.From, edge.RelationType, edge.To)
+	}
+}
+
+// nodeLabel returns the node name, falling back to its ID
+func nodeLabel(node *graph.Node) string {
+	if node.Name != "" {
+		return node.Name
+	}
+	return node.ID
+}
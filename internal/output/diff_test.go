@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestRenderDiff(t *testing.T) {
+	oldGraph := graph.New()
+	oldGraph.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	oldGraph.AddNode(&graph.Node{ID: "tg-old", Type: "TargetGroup", Name: "old-tg"})
+	oldGraph.AddEdge(&graph.Edge{From: "lb", To: "tg-old", RelationType: "forwards-to"})
+
+	newGraph := graph.New()
+	newGraph.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	newGraph.AddNode(&graph.Node{ID: "tg-new", Type: "TargetGroup", Name: "new-tg"})
+	newGraph.AddEdge(&graph.Edge{From: "lb", To: "tg-new", RelationType: "forwards-to"})
+
+	diff := graph.Diff(oldGraph, newGraph)
+
+	var buf bytes.Buffer
+	if err := RenderDiff(&buf, &diff); err != nil {
+		t.Fatalf("RenderDiff() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"+ TargetGroup: new-tg",
+		"- TargetGroup: old-tg",
+		"+ lb --[forwards-to]--> tg-new",
+		"- lb --[forwards-to]--> tg-old",
+		"Summary: +1/-1/~0 nodes, +1/-1/~0 edges",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("RenderDiff() missing %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenderDiffNoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderDiff(&buf, &graph.GraphDiff{}); err != nil {
+		t.Fatalf("RenderDiff() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No changes") {
+		t.Errorf("RenderDiff() = %q, want No changes", buf.String())
+	}
+}
+
+func TestRenderDiffJSON(t *testing.T) {
+	oldGraph := graph.New()
+	oldGraph.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	oldGraph.AddNode(&graph.Node{ID: "tg-old", Type: "TargetGroup", Name: "old-tg"})
+	oldGraph.AddEdge(&graph.Edge{From: "lb", To: "tg-old", RelationType: "forwards-to"})
+
+	newGraph := graph.New()
+	newGraph.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+
+	diff := graph.Diff(oldGraph, newGraph)
+
+	var buf bytes.Buffer
+	if err := RenderDiffJSON(&buf, &diff); err != nil {
+		t.Fatalf("RenderDiffJSON() error = %v", err)
+	}
+
+	var got DiffJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("RenderDiffJSON() produced invalid JSON: %v", err)
+	}
+	if len(got.RemovedNodes) != 1 || got.RemovedNodes[0].ID != "tg-old" {
+		t.Errorf("removedNodes = %v, want [tg-old]", got.RemovedNodes)
+	}
+	if len(got.RemovedEdges) != 1 || got.RemovedEdges[0].To != "tg-old" {
+		t.Errorf("removedEdges = %v, want the edge to tg-old", got.RemovedEdges)
+	}
+	want := DiffSummary{RemovedNodes: 1, RemovedEdges: 1, Changed: true}
+	if got.Summary != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary, want)
+	}
+
+	// Empty categories are encoded as [] rather than null
+	if !strings.Contains(buf.String(), `"addedNodes": []`) {
+		t.Errorf("RenderDiffJSON() did not encode empty addedNodes as []:\n%s", buf.String())
+	}
+}
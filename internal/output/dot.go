@@ -3,45 +3,324 @@ package output
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
+// Ways of clustering nodes in DOT output
+const (
+	DOTGroupByVPC     = "vpc"
+	DOTGroupByRegion  = "region"
+	DOTGroupByAccount = "account"
+)
+
+// DOTOptions controls optional styling in the DOT output
+type DOTOptions struct {
+	// GroupBy selects what nodes are clustered by: DOTGroupByVPC (the default when empty),
+	// DOTGroupByRegion or DOTGroupByAccount
+	GroupBy string
+
+	// InternetReachable marks the IDs of nodes reachable from an internet-facing entry point,
+	// which are drawn in red
+	InternetReachable map[string]bool
+	// BundleEdges merges fan-in and fan-out edges sharing a relation type and far-end node type
+	// into one labeled trunk (see bundleEdgeLabels)
+	BundleEdges bool
+
+	// Root is the node named in the graph label; it defaults to the graph's root
+	Root string
+	// GeneratedAt, if set, is printed in the graph label
+	GeneratedAt time.Time
+
+	// NodeStyles overrides DefaultDOTNodeStyles per resource type
+	NodeStyles map[string]DOTNodeStyle
+
+	// LabelTemplate, if set, replaces the default type, name and region node label
+	// (see ParseLabelTemplate)
+	LabelTemplate *template.Template
+	// LabelTags appends the values of these tag keys to node labels, skipping tags a node lacks
+	LabelTags []string
+}
+
+// DOTNodeStyle is how nodes of one resource type are drawn. Empty fields are left to Graphviz.
+type DOTNodeStyle struct {
+	// Shape is a Graphviz node shape such as box, cylinder or hexagon
+	Shape string
+	// FillColor, if set, fills the node with a Graphviz color name or #rrggbb value
+	FillColor string
+	// Color is the outline color
+	Color string
+}
+
+// DefaultDOTNodeStyles gives each known resource type a shape and fill so large diagrams can be
+// scanned by kind: datastores are cylinders, load balancers hexagons, queues and streams cds,
+// IAM identities ovals and security groups are outlined in red. Types not listed are plain boxes.
+var DefaultDOTNodeStyles = map[string]DOTNodeStyle{
+	"LoadBalancer":            {Shape: "hexagon", FillColor: "lightblue"},
+	"Listener":                {Shape: "box", FillColor: "aliceblue"},
+	"ListenerRule":            {Shape: "note", FillColor: "aliceblue"},
+	"TargetGroup":             {Shape: "box", FillColor: "lightcyan"},
+	"IPTarget":                {Shape: "ellipse", FillColor: "gainsboro"},
+	"APIGatewayRestAPI":       {Shape: "house", FillColor: "lightblue"},
+	"APIGatewayHTTPAPI":       {Shape: "house", FillColor: "lightblue"},
+	"Route53Record":           {Shape: "tab", FillColor: "palegreen"},
+	"ECSCluster":              {Shape: "folder", FillColor: "papayawhip"},
+	"ECSService":              {Shape: "component", FillColor: "peachpuff"},
+	"ECSTask":                 {Shape: "box", FillColor: "seashell"},
+	"ECSTaskDefinition":       {Shape: "note", FillColor: "linen"},
+	"TaskDefinition":          {Shape: "note", FillColor: "linen"},
+	"ECSContainerInstance":    {Shape: "box3d", FillColor: "wheat"},
+	"CloudMapService":         {Shape: "tab", FillColor: "peachpuff"},
+	"EC2Instance":             {Shape: "box3d", FillColor: "wheat"},
+	"Instance":                {Shape: "box3d", FillColor: "wheat"},
+	"AutoScalingGroup":        {Shape: "box3d", FillColor: "burlywood"},
+	"LaunchTemplate":          {Shape: "note", FillColor: "wheat"},
+	"NetworkInterface":        {Shape: "ellipse", FillColor: "gainsboro"},
+	"Lambda":                  {Shape: "octagon", FillColor: "navajowhite"},
+	"LambdaLayer":             {Shape: "folder", FillColor: "navajowhite"},
+	"LambdaFunctionURL":       {Shape: "house", FillColor: "navajowhite"},
+	"ECRRepository":           {Shape: "folder", FillColor: "lightsteelblue"},
+	"ECRImage":                {Shape: "tab", FillColor: "lightsteelblue"},
+	"RDSInstance":             {Shape: "cylinder", FillColor: "khaki"},
+	"RDSCluster":              {Shape: "cylinder", FillColor: "khaki"},
+	"RDSGlobalCluster":        {Shape: "cylinder", FillColor: "khaki"},
+	"OpenSearchDomain":        {Shape: "cylinder", FillColor: "khaki"},
+	"EFSFileSystem":           {Shape: "cylinder", FillColor: "lightgoldenrodyellow"},
+	"EFSAccessPoint":          {Shape: "box", FillColor: "lightgoldenrodyellow"},
+	"DBSubnetGroup":           {Shape: "box", FillColor: "honeydew"},
+	"DBParameterGroup":        {Shape: "note", FillColor: "ivory"},
+	"DBClusterParameterGroup": {Shape: "note", FillColor: "ivory"},
+	"SQSQueue":                {Shape: "cds", FillColor: "thistle"},
+	"DLQ":                     {Shape: "cds", FillColor: "thistle"},
+	"DynamoDBStream":          {Shape: "cds", FillColor: "lavender"},
+	"KinesisStream":           {Shape: "cds", FillColor: "lavender"},
+	"KinesisConsumer":         {Shape: "box", FillColor: "lavender"},
+	"FirehoseDeliveryStream":  {Shape: "cds", FillColor: "lavender"},
+	"KafkaCluster":            {Shape: "cds", FillColor: "lavender"},
+	"MSKConfiguration":        {Shape: "note", FillColor: "lavender"},
+	"EventSource":             {Shape: "parallelogram", FillColor: "plum"},
+	"EventBridgeRule":         {Shape: "parallelogram", FillColor: "plum"},
+	"EventTarget":             {Shape: "parallelogram", FillColor: "plum"},
+	"EventDestination":        {Shape: "parallelogram", FillColor: "plum"},
+	"SNSTopic":                {Shape: "parallelogram", FillColor: "plum"},
+	"SESConfigurationSet":     {Shape: "component", FillColor: "plum"},
+	"CloudWatchMetrics":       {Shape: "note", FillColor: "plum"},
+	"StateMachine":            {Shape: "component", FillColor: "plum"},
+	"IAMRole":                 {Shape: "ellipse", FillColor: "mistyrose"},
+	"IAMPolicy":               {Shape: "note", FillColor: "mistyrose"},
+	"IAMPrincipal":            {Shape: "ellipse", FillColor: "mistyrose"},
+	"SecurityGroup":           {Shape: "box", FillColor: "white", Color: "red"},
+	"Subnet":                  {Shape: "box", FillColor: "honeydew"},
+	"VPC":                     {Shape: "box", FillColor: "palegreen"},
+	"ElasticIP":               {Shape: "box", FillColor: "honeydew"},
+	"NATGateway":              {Shape: "hexagon", FillColor: "honeydew"},
+	"InternetGateway":         {Shape: "hexagon", FillColor: "palegreen"},
+	"ScalingPolicy":           {Shape: "note", FillColor: "ivory"},
+	"KMSKey":                  {Shape: "box", FillColor: "gold"},
+	"SecretsManagerSecret":    {Shape: "note", FillColor: "gold"},
+	"SSMParameter":            {Shape: "note", FillColor: "lightyellow"},
+	"ACMCertificate":          {Shape: "tab", FillColor: "lightgrey"},
+	"WAFWebACL":               {Shape: "pentagon", FillColor: "salmon"},
+	"WAFRuleGroup":            {Shape: "pentagon", FillColor: "salmon"},
+	"WAFIPSet":                {Shape: "pentagon", FillColor: "salmon"},
+}
+
 // RenderDOT renders the graph in Graphviz DOT format
 func RenderDOT(w io.Writer, g *graph.Graph) error {
+	return RenderDOTWithOptions(w, g, DOTOptions{})
+}
+
+// RenderDOTWithOptions renders the graph in Graphviz DOT format, applying opts
+func RenderDOTWithOptions(w io.Writer, g *graph.Graph, opts DOTOptions) error {
+	clusters, err := dotClusters(g.Nodes(), opts.GroupBy)
+	if err != nil {
+		return err
+	}
+
 	fmt.Fprintln(w, "digraph blast_radius {")
 	fmt.Fprintln(w, "  rankdir=LR;")
 	fmt.Fprintln(w, "  node [shape=box, style=rounded];")
+	if opts.BundleEdges {
+		fmt.Fprintln(w, "  concentrate=true;")
+	}
 	fmt.Fprintln(w, "")
 
-	// Render nodes
-	for _, node := range g.Nodes() {
-		label := formatNodeLabel(node)
-		nodeID := sanitizeID(node.ID)
-		fmt.Fprintf(w, "  %s [label=\"%s\"];\n", nodeID, label)
+	// Render nodes grouped into one cluster per VPC, region or account
+	for _, cluster := range clusters {
+		fmt.Fprintf(w, "  subgraph %s {\n", cluster.id)
+		fmt.Fprintf(w, "    label=\"%s\";\n", cluster.label)
+		fmt.Fprintln(w, "    style=dashed;")
+		for _, node := range cluster.nodes {
+			fmt.Fprintf(w, "    %s [%s];\n", sanitizeID(node.ID), opts.nodeAttributes(node))
+		}
+		fmt.Fprintln(w, "  }")
 	}
 
 	fmt.Fprintln(w, "")
 
 	// Render edges
-	for _, edge := range g.Edges() {
+	edges := g.Edges()
+	var labels map[*graph.Edge]string
+	if opts.BundleEdges {
+		edges = g.SortedEdges()
+		labels = bundleEdgeLabels(g, edges)
+	}
+	for _, edge := range edges {
 		fromID := sanitizeID(edge.From)
 		toID := sanitizeID(edge.To)
-		label := edge.RelationType
+		label, bundled := labels[edge]
+		if !bundled {
+			label = edge.RelationType
+			if edge.Evidence.Heuristic {
+				label += " (heuristic)"
+			}
+		}
 
 		if edge.Evidence.Heuristic {
-			label += " (heuristic)"
 			fmt.Fprintf(w, "  %s -> %s [label=\"%s\", style=dashed];\n", fromID, toID, label)
 		} else {
 			fmt.Fprintf(w, "  %s -> %s [label=\"%s\"];\n", fromID, toID, label)
 		}
 	}
 
+	// The graph label comes last so clusters do not inherit it
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "  label=\"%s\";\n", graphLabel(g, opts))
+	fmt.Fprintln(w, "  labelloc=b;")
 	fmt.Fprintln(w, "}")
 	return nil
 }
 
+// nodeAttributes returns the DOT attribute list for a node: its label, the style of its type and
+// the internet-reachable highlight, which takes precedence over the type's outline color
+func (opts DOTOptions) nodeAttributes(node *graph.Node) string {
+	attrs := []string{fmt.Sprintf("label=\"%s\"", opts.nodeLabel(node))}
+
+	style, ok := opts.NodeStyles[node.Type]
+	if !ok {
+		style = DefaultDOTNodeStyles[node.Type]
+	}
+	if style.Shape != "" {
+		attrs = append(attrs, "shape="+style.Shape)
+	}
+	if style.FillColor != "" {
+		attrs = append(attrs, `style="filled,rounded"`, fmt.Sprintf("fillcolor=\"%s\"", style.FillColor))
+	}
+
+	if opts.InternetReachable[node.ID] {
+		attrs = append(attrs, "color=red", "fontcolor=red", "penwidth=2")
+	} else if style.Color != "" {
+		attrs = append(attrs, fmt.Sprintf("color=\"%s\"", style.Color))
+	}
+	return strings.Join(attrs, ", ")
+}
+
+// graphLabel describes what produced the graph: the root resource, node and edge counts, the
+// deepest BFS level below the root, truncation and, if set, when it was generated
+func graphLabel(g *graph.Graph, opts DOTOptions) string {
+	rootID := opts.Root
+	if rootID == "" {
+		rootID = g.Root()
+	}
+
+	var lines []string
+	if root, ok := g.GetNode(rootID); ok {
+		lines = append(lines, fmt.Sprintf("Blast radius of %s %s", root.Type, root.Name))
+	}
+
+	counts := fmt.Sprintf("%d nodes, %d edges", g.NodeCount(), g.EdgeCount())
+	if levels := g.BFS(rootID); len(levels) > 0 {
+		counts += fmt.Sprintf(", depth %d", levels[len(levels)-1].Depth)
+	}
+	lines = append(lines, counts)
+
+	if g.Truncated() {
+		lines = append(lines, fmt.Sprintf("Truncated (%s)", g.TruncationReason()))
+	}
+	if !opts.GeneratedAt.IsZero() {
+		lines = append(lines, "Generated "+opts.GeneratedAt.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(lines, "\\n")
+}
+
+// bundleEdgeLabels groups edges that fan into one node (or out of one node) with the same
+// relation type and the same node type at the other end. Graphviz only concentrates edges
+// without distinct labels, so the first edge of each group carries the count and the rest are
+// unlabeled. Edges outside any group are absent from the result.
+func bundleEdgeLabels(g *graph.Graph, edges []*graph.Edge) map[*graph.Edge]string {
+	nodeType := func(id string) string {
+		if node, ok := g.GetNode(id); ok {
+			return node.Type
+		}
+		return ""
+	}
+	bundleKey := func(direction, endpoint, otherType string, edge *graph.Edge) string {
+		return fmt.Sprintf("%s|%s|%s|%s|%t", direction, endpoint, edge.RelationType, otherType, edge.Evidence.Heuristic)
+	}
+
+	labels := make(map[*graph.Edge]string)
+	bundle := func(key func(*graph.Edge) string) {
+		groups := make(map[string][]*graph.Edge)
+		var order []string
+		for _, edge := range edges {
+			if _, done := labels[edge]; done {
+				continue
+			}
+			k := key(edge)
+			if _, ok := groups[k]; !ok {
+				order = append(order, k)
+			}
+			groups[k] = append(groups[k], edge)
+		}
+		for _, k := range order {
+			group := groups[k]
+			if len(group) < 2 {
+				continue
+			}
+			label := fmt.Sprintf("%s (x%d)", group[0].RelationType, len(group))
+			if group[0].Evidence.Heuristic {
+				label += " (heuristic)"
+			}
+			labels[group[0]] = label
+			for _, edge := range group[1:] {
+				labels[edge] = ""
+			}
+		}
+	}
+
+	// Fan-in first, so many services registering with one target group become one trunk
+	bundle(func(edge *graph.Edge) string {
+		return bundleKey("in", edge.To, nodeType(edge.From), edge)
+	})
+	bundle(func(edge *graph.Edge) string {
+		return bundleKey("out", edge.From, nodeType(edge.To), edge)
+	})
+
+	return labels
+}
+
+// nodeLabel returns the DOT label of a node from the label template, escaped for a quoted DOT
+// string with newlines as line breaks, or the default label, followed by any LabelTags on a line
+// of their own
+func (opts DOTOptions) nodeLabel(node *graph.Node) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	label, ok := customLabel(opts.LabelTemplate, node)
+	if ok {
+		label = escape.Replace(label)
+	} else {
+		label = formatNodeLabel(node)
+	}
+	if tags := labelTags(node, opts.LabelTags); tags != "" {
+		label += `\n` + escape.Replace(tags)
+	}
+	return label
+}
+
+// formatNodeLabel returns the default DOT label: type, name and, when known, region
 func formatNodeLabel(node *graph.Node) string {
 	label := fmt.Sprintf("%s\\n%s", node.Type, node.Name)
 	if node.Region != "" {
@@ -50,6 +329,93 @@ func formatNodeLabel(node *graph.Node) string {
 	return label
 }
 
+// dotCluster is a subgraph box of nodes sharing a VPC, region or account
+type dotCluster struct {
+	id    string
+	label string
+	nodes []*graph.Node
+}
+
+// dotClusters groups nodes into clusters by groupBy, ordered by cluster ID
+func dotClusters(nodes []*graph.Node, groupBy string) ([]dotCluster, error) {
+	var key func(*graph.Node) string
+	var describe func(value string, nodes []*graph.Node) (id, label string)
+	switch groupBy {
+	case "", DOTGroupByVPC:
+		key = nodeVPCID
+		describe = func(vpcID string, nodes []*graph.Node) (string, string) {
+			return clusterID(vpcID), clusterLabel(vpcID, nodes)
+		}
+	case DOTGroupByRegion, DOTGroupByAccount:
+		key = func(node *graph.Node) string { return node.Region }
+		title := "Region"
+		if groupBy == DOTGroupByAccount {
+			key = func(node *graph.Node) string { return node.Account }
+			title = "Account"
+		}
+		describe = func(value string, _ []*graph.Node) (string, string) {
+			if value == "" {
+				return "cluster_" + groupBy + "_unknown", "Unknown " + strings.ToLower(title)
+			}
+			return "cluster_" + groupBy + "_" + strings.ReplaceAll(value, "-", "_"), title + " " + value
+		}
+	default:
+		return nil, fmt.Errorf("unknown DOT grouping: %s (must be vpc, region, or account)", groupBy)
+	}
+
+	groups := make(map[string][]*graph.Node)
+	for _, node := range nodes {
+		value := key(node)
+		groups[value] = append(groups[value], node)
+	}
+
+	clusters := make([]dotCluster, 0, len(groups))
+	for value, members := range groups {
+		id, label := describe(value, members)
+		clusters = append(clusters, dotCluster{id: id, label: label, nodes: members})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].id < clusters[j].id
+	})
+	return clusters, nil
+}
+
+// nodeVPCID returns the VPC ID recorded in a node's metadata, if any
+func nodeVPCID(node *graph.Node) string {
+	vpcID, _ := node.MetaString("vpcId")
+	return vpcID
+}
+
+func clusterID(vpcID string) string {
+	if vpcID == "" {
+		return "cluster_no_vpc"
+	}
+	return "cluster_" + strings.ReplaceAll(vpcID, "-", "_")
+}
+
+func clusterLabel(vpcID string, nodes []*graph.Node) string {
+	if vpcID == "" {
+		return "No VPC"
+	}
+
+	label := "VPC " + vpcID
+
+	// A VPC lives in a single account and region; use the first node that records them
+	for _, node := range nodes {
+		var scope []string
+		if node.Account != "" {
+			scope = append(scope, node.Account)
+		}
+		if node.Region != "" {
+			scope = append(scope, node.Region)
+		}
+		if len(scope) > 0 {
+			return label + "\\n" + strings.Join(scope, " / ")
+		}
+	}
+	return label
+}
+
 func sanitizeID(id string) string {
 	// Replace characters that are invalid in DOT identifiers
 	id = strings.ReplaceAll(id, ":", "_")
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
@@ -107,3 +108,314 @@ func TestRenderDOTHeuristic(t *testing.T) {
 		t.Error("RenderDOT() heuristic edge should have (heuristic) label")
 	}
 }
+
+func TestRenderDOTVPCClusters(t *testing.T) {
+	g := graph.New()
+
+	vpcID := "vpc-0abc123"
+	g.AddNode(&graph.Node{
+		ID:       "lb-1",
+		Type:     "LoadBalancer",
+		Name:     "test-lb",
+		Region:   "us-east-1",
+		Account:  "123456789012",
+		Metadata: map[string]any{"vpcId": "vpc-0abc123"},
+	})
+	g.AddNode(&graph.Node{
+		ID:       "subnet-group-1",
+		Type:     "DBSubnetGroup",
+		Name:     "db-subnets",
+		Metadata: map[string]any{"vpcId": &vpcID},
+	})
+	g.AddNode(&graph.Node{
+		ID:   "role-1",
+		Type: "IAMRole",
+		Name: "task-role",
+	})
+
+	var buf bytes.Buffer
+	if err := RenderDOT(&buf, g); err != nil {
+		t.Fatalf("RenderDOT() error = %v", err)
+	}
+
+	output := buf.String()
+
+	expectedStrings := []string{
+		"subgraph cluster_vpc_0abc123 {",
+		`label="VPC vpc-0abc123\n123456789012 / us-east-1";`,
+		"subgraph cluster_no_vpc {",
+		`label="No VPC";`,
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("RenderDOT() output missing expected string: %q\nGot:\n%s", expected, output)
+		}
+	}
+
+	// Both VPC-scoped nodes share a single cluster
+	if n := strings.Count(output, "subgraph cluster_vpc_0abc123"); n != 1 {
+		t.Errorf("expected 1 cluster for vpc-0abc123, got %d", n)
+	}
+
+	noVPC := output[strings.Index(output, "subgraph cluster_no_vpc"):]
+	if !strings.Contains(noVPC[:strings.Index(noVPC, "}")], "task-role") {
+		t.Errorf("expected IAM role in the no-VPC cluster\nGot:\n%s", output)
+	}
+}
+
+func TestRenderDOTInternetReachable(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "alb", Type: "LoadBalancer", Name: "public-alb"})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "private-db"})
+	g.AddEdge(&graph.Edge{From: "alb", To: "db", RelationType: "connects-to"})
+
+	var buf bytes.Buffer
+	err := RenderDOTWithOptions(&buf, g, DOTOptions{InternetReachable: map[string]bool{"alb": true}})
+	if err != nil {
+		t.Fatalf("RenderDOTWithOptions() error = %v", err)
+	}
+	output := buf.String()
+
+	if !strings.Contains(output, `"alb" [label="LoadBalancer\npublic-alb", shape=hexagon, style="filled,rounded", fillcolor="lightblue", color=red, fontcolor=red, penwidth=2];`) {
+		t.Errorf("expected internet-reachable node in red, got:\n%s", output)
+	}
+	if !strings.Contains(output, `"db" [label="RDSInstance\nprivate-db", shape=cylinder, style="filled,rounded", fillcolor="khaki"];`) {
+		t.Errorf("expected private node without highlight, got:\n%s", output)
+	}
+}
+
+func TestRenderDOTBundleEdges(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "api-tg"})
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "api-alb"})
+	for _, id := range []string{"svc-a", "svc-b", "svc-c"} {
+		g.AddNode(&graph.Node{ID: id, Type: "ECSService", Name: id})
+		g.AddEdge(&graph.Edge{From: id, To: "tg", RelationType: "registered-with"})
+	}
+	g.AddEdge(&graph.Edge{From: "lb", To: "tg", RelationType: "forwards-to"})
+
+	tests := []struct {
+		name    string
+		bundle  bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "Bundled",
+			bundle: true,
+			want: []string{
+				"concentrate=true;",
+				`"svc_a" -> "tg" [label="registered-with (x3)"];`,
+				`"svc_b" -> "tg" [label=""];`,
+				`"svc_c" -> "tg" [label=""];`,
+				`"lb" -> "tg" [label="forwards-to"];`,
+			},
+		},
+		{
+			name:    "Not bundled",
+			bundle:  false,
+			want:    []string{`"svc_b" -> "tg" [label="registered-with"];`},
+			notWant: []string{"concentrate=true;", "(x3)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderDOTWithOptions(&buf, g, DOTOptions{BundleEdges: tt.bundle}); err != nil {
+				t.Fatalf("RenderDOTWithOptions() error = %v", err)
+			}
+			output := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected %q in output, got:\n%s", want, output)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(output, notWant) {
+					t.Errorf("unexpected %q in output, got:\n%s", notWant, output)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDOTGroupBy(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "lb", Region: "us-east-1", Account: "111111111111"})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "db", Region: "eu-west-1", Account: "222222222222"})
+	g.AddNode(&graph.Node{ID: "fn", Type: "Lambda", Name: "fn", Region: "us-east-1", Account: "222222222222"})
+	g.AddNode(&graph.Node{ID: "role", Type: "IAMRole", Name: "role"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "fn", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "fn", To: "db", RelationType: "connects-to"})
+
+	tests := []struct {
+		groupBy string
+		// want maps each cluster ID to its label and the nodes it must contain
+		want map[string]struct {
+			label string
+			nodes []string
+		}
+	}{
+		{
+			groupBy: DOTGroupByRegion,
+			want: map[string]struct {
+				label string
+				nodes []string
+			}{
+				"cluster_region_us_east_1": {label: "Region us-east-1", nodes: []string{"lb", "fn"}},
+				"cluster_region_eu_west_1": {label: "Region eu-west-1", nodes: []string{"db"}},
+				"cluster_region_unknown":   {label: "Unknown region", nodes: []string{"role"}},
+			},
+		},
+		{
+			groupBy: DOTGroupByAccount,
+			want: map[string]struct {
+				label string
+				nodes []string
+			}{
+				"cluster_account_111111111111": {label: "Account 111111111111", nodes: []string{"lb"}},
+				"cluster_account_222222222222": {label: "Account 222222222222", nodes: []string{"db", "fn"}},
+				"cluster_account_unknown":      {label: "Unknown account", nodes: []string{"role"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderDOTWithOptions(&buf, g, DOTOptions{GroupBy: tt.groupBy}); err != nil {
+				t.Fatalf("RenderDOTWithOptions() error = %v", err)
+			}
+			output := buf.String()
+
+			if got := strings.Count(output, "subgraph cluster_"); got != len(tt.want) {
+				t.Errorf("expected %d clusters, got %d:\n%s", len(tt.want), got, output)
+			}
+			for id, cluster := range tt.want {
+				start := strings.Index(output, "subgraph "+id+" {")
+				if start < 0 {
+					t.Errorf("expected cluster %s, got:\n%s", id, output)
+					continue
+				}
+				body := output[start : start+strings.Index(output[start:], "  }")]
+				if !strings.Contains(body, `label="`+cluster.label+`";`) {
+					t.Errorf("cluster %s missing label %q:\n%s", id, cluster.label, body)
+				}
+				for _, node := range cluster.nodes {
+					if !strings.Contains(body, `"`+node+`" [label=`) {
+						t.Errorf("expected %s in cluster %s:\n%s", node, id, body)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDOTGroupByUnknown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderDOTWithOptions(&buf, graph.New(), DOTOptions{GroupBy: "team"}); err == nil {
+		t.Error("RenderDOTWithOptions() expected an error for an unknown grouping")
+	}
+}
+
+func TestRenderDOTGraphLabel(t *testing.T) {
+	tests := []struct {
+		name      string
+		truncated bool
+		opts      DOTOptions
+		want      string
+	}{
+		{
+			name: "Graph root",
+			want: `label="Blast radius of LoadBalancer web\n3 nodes, 2 edges, depth 2";`,
+		},
+		{
+			name: "Explicit root and timestamp",
+			opts: DOTOptions{Root: "tg", GeneratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+			want: `label="Blast radius of TargetGroup web-tg\n3 nodes, 2 edges, depth 1\nGenerated 2024-05-01T12:00:00Z";`,
+		},
+		{
+			name:      "Truncated",
+			truncated: true,
+			want:      `label="Blast radius of LoadBalancer web\n3 nodes, 2 edges, depth 2\nTruncated (max-nodes reached)";`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.New()
+			g.AddNode(&graph.Node{ID: "alb", Type: "LoadBalancer", Name: "web"})
+			g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "web-tg"})
+			g.AddNode(&graph.Node{ID: "i-1", Type: "EC2Instance", Name: "i-1"})
+			g.AddEdge(&graph.Edge{From: "alb", To: "tg", RelationType: "forwards-to"})
+			g.AddEdge(&graph.Edge{From: "tg", To: "i-1", RelationType: "routes-to-target"})
+			g.SetRoot("alb")
+			if tt.truncated {
+				g.MarkTruncated("max-nodes reached")
+			}
+
+			var buf bytes.Buffer
+			if err := RenderDOTWithOptions(&buf, g, tt.opts); err != nil {
+				t.Fatalf("RenderDOTWithOptions() error = %v", err)
+			}
+			out := buf.String()
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("expected %s in output:\n%s", tt.want, out)
+			}
+			if !strings.Contains(out, "  labelloc=b;\n}") {
+				t.Errorf("expected labelloc=b at the end of the graph:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestRenderDOTNodeStyles(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "orders"})
+	g.AddNode(&graph.Node{ID: "sg", Type: "SecurityGroup", Name: "db-sg"})
+	g.AddNode(&graph.Node{ID: "fn", Type: "Lambda", Name: "worker"})
+	g.AddNode(&graph.Node{ID: "other", Type: "Widget", Name: "thing"})
+
+	tests := []struct {
+		name string
+		opts DOTOptions
+		want []string
+	}{
+		{
+			name: "Defaults",
+			want: []string{
+				`"db" [label="RDSInstance\norders", shape=cylinder, style="filled,rounded", fillcolor="khaki"];`,
+				`"sg" [label="SecurityGroup\ndb-sg", shape=box, style="filled,rounded", fillcolor="white", color="red"];`,
+				`"fn" [label="Lambda\nworker", shape=octagon, style="filled,rounded", fillcolor="navajowhite"];`,
+				`"other" [label="Widget\nthing"];`,
+			},
+		},
+		{
+			name: "Overrides",
+			opts: DOTOptions{NodeStyles: map[string]DOTNodeStyle{
+				"Lambda": {Shape: "hexagon", FillColor: "orange"},
+				"Widget": {Shape: "star"},
+			}},
+			want: []string{
+				`"db" [label="RDSInstance\norders", shape=cylinder, style="filled,rounded", fillcolor="khaki"];`,
+				`"fn" [label="Lambda\nworker", shape=hexagon, style="filled,rounded", fillcolor="orange"];`,
+				`"other" [label="Widget\nthing", shape=star];`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderDOTWithOptions(&buf, g, tt.opts); err != nil {
+				t.Fatalf("RenderDOTWithOptions() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected %s in output:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// RenderEdgeEvidence prints each edge with its endpoints and the full evidence behind it: the
+// API call, whether it was inferred, and every field sorted by name
+func RenderEdgeEvidence(w io.Writer, g *graph.Graph, edges []*graph.Edge) error {
+	for i, edge := range edges {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s --[%s]--> %s\n", explainNodeLabel(g, edge.From), edge.RelationType, explainNodeLabel(g, edge.To))
+		fmt.Fprintf(w, "  From:      %s\n", edge.From)
+		fmt.Fprintf(w, "  To:        %s\n", edge.To)
+
+		apiCall := edge.Evidence.APICall
+		if apiCall == "" {
+			apiCall = "unknown"
+		}
+		fmt.Fprintf(w, "  API call:  %s\n", apiCall)
+		if edge.Evidence.Heuristic {
+			fmt.Fprintln(w, "  Heuristic: yes (inferred, not confirmed by the API)")
+		} else {
+			fmt.Fprintln(w, "  Heuristic: no")
+		}
+
+		if len(edge.Evidence.Fields) == 0 {
+			fmt.Fprintln(w, "  Fields:    none")
+			continue
+		}
+		keys := make([]string, 0, len(edge.Evidence.Fields))
+		for k := range edge.Evidence.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w, "  Fields:")
+		for _, k := range keys {
+			fmt.Fprintf(w, "    %s: %s\n", k, formatEvidenceValue(edge.Evidence.Fields[k]))
+		}
+	}
+	return nil
+}
+
+// explainNodeLabel returns "Type Name" for a node, or its ID if the graph lacks it
+func explainNodeLabel(g *graph.Graph, id string) string {
+	node, ok := g.GetNode(id)
+	if !ok {
+		return id
+	}
+	name := node.Name
+	if name == "" {
+		name = node.ID
+	}
+	return node.Type + " " + name
+}
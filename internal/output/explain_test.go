@@ -0,0 +1,55 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestRenderEdgeEvidence(t *testing.T) {
+	port := int32(443)
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "my-alb"})
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "api-tg"})
+	g.AddEdge(&graph.Edge{
+		From:         "lb",
+		To:           "tg",
+		RelationType: "forwards-to",
+		Evidence: graph.Evidence{
+			APICall: "DescribeListeners",
+			Fields:  map[string]any{"Port": &port, "ListenerArn": "arn:listener"},
+		},
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "lb",
+		To:           "tg",
+		RelationType: "uses",
+		Evidence:     graph.Evidence{Heuristic: true},
+	})
+
+	var buf bytes.Buffer
+	if err := RenderEdgeEvidence(&buf, g, g.EdgesBetween("lb", "tg")); err != nil {
+		t.Fatalf("RenderEdgeEvidence() error = %v", err)
+	}
+
+	want := `LoadBalancer my-alb --[forwards-to]--> TargetGroup api-tg
+  From:      lb
+  To:        tg
+  API call:  DescribeListeners
+  Heuristic: no
+  Fields:
+    ListenerArn: arn:listener
+    Port: 443
+
+LoadBalancer my-alb --[uses]--> TargetGroup api-tg
+  From:      lb
+  To:        tg
+  API call:  unknown
+  Heuristic: yes (inferred, not confirmed by the API)
+  Fields:    none
+`
+	if got := buf.String(); got != want {
+		t.Errorf("RenderEdgeEvidence() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -2,25 +2,238 @@ package output
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"sort"
 
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
+// SchemaVersion identifies the layout of the JSON output so consumers can detect format changes
+const SchemaVersion = "1"
+
 // GraphJSON represents the graph in JSON format
 type GraphJSON struct {
-	Nodes []*graph.Node `json:"nodes"`
-	Edges []*graph.Edge `json:"edges"`
+	SchemaVersion string        `json:"schemaVersion"`
+	Nodes         []*graph.Node `json:"nodes"`
+	Edges         []*graph.Edge `json:"edges"`
+	Isolated      []string      `json:"isolated,omitempty"` // IDs of nodes with no edges
+	Summary       GraphSummary  `json:"summary"`
+
+	Truncated        bool   `json:"truncated"`
+	TruncationReason string `json:"truncationReason,omitempty"`
+}
+
+// GraphSummary counts the nodes and edges in the graph
+type GraphSummary struct {
+	Nodes  int            `json:"nodes"`
+	Edges  int            `json:"edges"`
+	ByType map[string]int `json:"byType"`
+	// Components holds the size of each connected component, largest first
+	Components []int `json:"components"`
 }
 
-// RenderJSON renders the graph as JSON
+// RenderJSON renders the graph as JSON with nodes and edges in a stable order
 func RenderJSON(w io.Writer, g *graph.Graph) error {
+	output := newGraphJSON(g)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// componentSizes returns the size of each connected component of g, largest first
+func componentSizes(g *graph.Graph) []int {
+	components := g.ConnectedComponents()
+	sizes := make([]int, len(components))
+	for i, component := range components {
+		sizes[i] = len(component)
+	}
+	return sizes
+}
+
+// newGraphJSON builds the JSON representation using the graph's sorted accessors
+func newGraphJSON(g *graph.Graph) GraphJSON {
 	output := GraphJSON{
-		Nodes: g.Nodes(),
-		Edges: g.Edges(),
+		SchemaVersion: SchemaVersion,
+		Nodes:         g.SortedNodes(),
+		Edges:         g.SortedEdges(),
+		Summary: GraphSummary{
+			Nodes:      g.NodeCount(),
+			Edges:      g.EdgeCount(),
+			ByType:     g.CountByType(),
+			Components: componentSizes(g),
+		},
+
+		Truncated:        g.Truncated(),
+		TruncationReason: g.TruncationReason(),
+	}
+	for _, node := range g.Isolated() {
+		output.Isolated = append(output.Isolated, node.ID)
+	}
+	return output
+}
+
+// LevelsJSON represents the graph as the BFS levels the tree renderer shows
+type LevelsJSON struct {
+	SchemaVersion string      `json:"schemaVersion"`
+	StartID       string      `json:"startId"`
+	Levels        []LevelJSON `json:"levels"`
+
+	Truncated        bool   `json:"truncated"`
+	TruncationReason string `json:"truncationReason,omitempty"`
+}
+
+// LevelJSON holds the nodes first reached at one BFS depth
+type LevelJSON struct {
+	Depth int             `json:"depth"`
+	Nodes []LevelNodeJSON `json:"nodes"`
+}
+
+// LevelNodeJSON is a node together with the edge it was reached by. The start node has no
+// incoming edge, so its reachedFrom and relation are omitted.
+type LevelNodeJSON struct {
+	*graph.Node
+	ReachedFrom string `json:"reachedFrom,omitempty"`
+	Relation    string `json:"relation,omitempty"`
+}
+
+// RenderJSONLevels renders the graph as JSON grouped into BFS levels from startID
+func RenderJSONLevels(w io.Writer, g *graph.Graph, startID string) error {
+	levels := g.BFS(startID)
+	if len(levels) == 0 {
+		return fmt.Errorf("starting node not found: %s", startID)
+	}
+
+	output := LevelsJSON{
+		SchemaVersion: SchemaVersion,
+		StartID:       startID,
+		Levels:        make([]LevelJSON, 0, len(levels)),
+
+		Truncated:        g.Truncated(),
+		TruncationReason: g.TruncationReason(),
+	}
+
+	reachedBy := bfsParentEdges(g, levels)
+	for _, level := range levels {
+		levelJSON := LevelJSON{Depth: level.Depth, Nodes: make([]LevelNodeJSON, 0, len(level.Nodes))}
+		for _, node := range level.Nodes {
+			entry := LevelNodeJSON{Node: node}
+			if edge := reachedBy[node.ID]; edge != nil {
+				entry.ReachedFrom = edge.From
+				entry.Relation = edge.RelationType
+			}
+			levelJSON.Nodes = append(levelJSON.Nodes, entry)
+		}
+		output.Levels = append(output.Levels, levelJSON)
 	}
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
 }
+
+// bfsParentEdges returns, for each node past the start, the edge from the level above that
+// reached it. BFS follows outgoing edges, so every such node has one.
+func bfsParentEdges(g *graph.Graph, levels []graph.BFSLevel) map[string]*graph.Edge {
+	reachedBy := make(map[string]*graph.Edge)
+	previous := make(map[string]bool)
+	for _, level := range levels {
+		current := make(map[string]bool, len(level.Nodes))
+		for _, node := range level.Nodes {
+			for _, edge := range sortedEdgesTo(g, node.ID) {
+				if previous[edge.From] {
+					reachedBy[node.ID] = edge
+					break
+				}
+			}
+			current[node.ID] = true
+		}
+		previous = current
+	}
+	return reachedBy
+}
+
+// TreeJSON represents the graph as dependencies nested beneath the start node
+type TreeJSON struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	Root          *TreeNodeJSON `json:"root"`
+
+	Truncated        bool   `json:"truncated"`
+	TruncationReason string `json:"truncationReason,omitempty"`
+}
+
+// TreeNodeJSON is a node with the nodes it depends on nested in dependencies, each carrying the
+// relation of the edge to it. A node is emitted in full once, beneath the parent BFS first
+// reached it from; every other edge to it, including those closing a cycle, becomes a reference
+// holding only its ID in ref. Leaves and references have no dependencies.
+type TreeNodeJSON struct {
+	*graph.Node
+	Relation     string          `json:"relation,omitempty"`
+	Ref          string          `json:"ref,omitempty"`
+	Dependencies []*TreeNodeJSON `json:"dependencies,omitempty"`
+}
+
+// RenderJSONTree renders the graph as JSON nested from startID, placing each node at the BFS
+// depth it was first reached as the levels output does
+func RenderJSONTree(w io.Writer, g *graph.Graph, startID string) error {
+	levels := g.BFS(startID)
+	if len(levels) == 0 {
+		return fmt.Errorf("starting node not found: %s", startID)
+	}
+
+	root := levels[0].Nodes[0]
+	output := TreeJSON{
+		SchemaVersion: SchemaVersion,
+		Root:          newTreeNodeJSON(g, root, "", bfsParentEdges(g, levels)),
+
+		Truncated:        g.Truncated(),
+		TruncationReason: g.TruncationReason(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// newTreeNodeJSON nests the targets of a node's outgoing edges beneath it, in full for those
+// the node is the BFS parent of and as references otherwise
+func newTreeNodeJSON(g *graph.Graph, node *graph.Node, relation string, reachedBy map[string]*graph.Edge) *TreeNodeJSON {
+	entry := &TreeNodeJSON{Node: node, Relation: relation}
+	for _, edge := range sortedEdgesFrom(g, node.ID) {
+		child, ok := g.GetNode(edge.To)
+		if !ok {
+			continue
+		}
+		if reachedBy[child.ID] != edge {
+			entry.Dependencies = append(entry.Dependencies, &TreeNodeJSON{Relation: edge.RelationType, Ref: child.ID})
+			continue
+		}
+		entry.Dependencies = append(entry.Dependencies, newTreeNodeJSON(g, child, edge.RelationType, reachedBy))
+	}
+	return entry
+}
+
+// sortedEdgesFrom returns a node's outgoing edges, ordered by target and relation
+func sortedEdgesFrom(g *graph.Graph, nodeID string) []*graph.Edge {
+	edges := g.EdgesFrom(nodeID)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].RelationType < edges[j].RelationType
+	})
+	return edges
+}
+
+// sortedEdgesTo returns the edges pointing to a node, ordered by source and relation
+func sortedEdgesTo(g *graph.Graph, nodeID string) []*graph.Edge {
+	edges := g.EdgesTo(nodeID)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].RelationType < edges[j].RelationType
+	})
+	return edges
+}
@@ -3,6 +3,7 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/pfrederiksen/blast-radius/internal/graph"
@@ -61,6 +62,10 @@ func TestRenderJSON(t *testing.T) {
 		t.Fatalf("RenderJSON() produced invalid JSON: %v", err)
 	}
 
+	if result.SchemaVersion != SchemaVersion {
+		t.Errorf("RenderJSON() schemaVersion = %q, want %q", result.SchemaVersion, SchemaVersion)
+	}
+
 	// Verify structure
 	if len(result.Nodes) != 2 {
 		t.Errorf("RenderJSON() expected 2 nodes, got %d", len(result.Nodes))
@@ -80,3 +85,240 @@ func TestRenderJSON(t *testing.T) {
 		t.Errorf("RenderJSON() edge RelationType = %v, want forwards-to", result.Edges[0].RelationType)
 	}
 }
+
+func TestRenderJSONDeterministic(t *testing.T) {
+	g := graph.New()
+
+	for _, id := range []string{"node-c", "node-a", "node-e", "node-b", "node-d"} {
+		g.AddNode(&graph.Node{ID: id, Type: "TargetGroup", Name: id})
+	}
+	g.AddEdge(&graph.Edge{From: "node-c", To: "node-e", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "node-a", To: "node-d", RelationType: "triggers"})
+	g.AddEdge(&graph.Edge{From: "node-a", To: "node-b", RelationType: "routes-to"})
+	g.AddEdge(&graph.Edge{From: "node-a", To: "node-b", RelationType: "forwards-to"})
+
+	var first, second bytes.Buffer
+	if err := RenderJSON(&first, g); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+	if err := RenderJSON(&second, g); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("RenderJSON() output differs between runs:\nfirst:\n%s\nsecond:\n%s", first.String(), second.String())
+	}
+
+	var result GraphJSON
+	if err := json.Unmarshal(first.Bytes(), &result); err != nil {
+		t.Fatalf("RenderJSON() produced invalid JSON: %v", err)
+	}
+
+	for i := 1; i < len(result.Nodes); i++ {
+		if result.Nodes[i-1].ID > result.Nodes[i].ID {
+			t.Errorf("RenderJSON() nodes not sorted: %s before %s", result.Nodes[i-1].ID, result.Nodes[i].ID)
+		}
+	}
+
+	wantEdges := []string{"node-a>node-b:forwards-to", "node-a>node-b:routes-to", "node-a>node-d:triggers", "node-c>node-e:forwards-to"}
+	for i, want := range wantEdges {
+		edge := result.Edges[i]
+		if got := edge.From + ">" + edge.To + ":" + edge.RelationType; got != want {
+			t.Errorf("RenderJSON() edge[%d] = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestRenderJSONIsolated(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer"})
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup"})
+	g.AddNode(&graph.Node{ID: "orphan", Type: "TargetGroup"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "tg", RelationType: "forwards-to"})
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, g); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var result GraphJSON
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("RenderJSON() produced invalid JSON: %v", err)
+	}
+
+	if len(result.Isolated) != 1 || result.Isolated[0] != "orphan" {
+		t.Errorf("RenderJSON() isolated = %v, want [orphan]", result.Isolated)
+	}
+}
+
+func TestRenderJSONTruncated(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer"})
+	g.MarkTruncated("max-nodes reached")
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, g); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var result GraphJSON
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("RenderJSON() produced invalid JSON: %v", err)
+	}
+
+	if !result.Truncated || result.TruncationReason != "max-nodes reached" {
+		t.Errorf("RenderJSON() truncated = %v, reason = %q", result.Truncated, result.TruncationReason)
+	}
+}
+
+func TestRenderJSONSummary(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer"})
+	g.AddNode(&graph.Node{ID: "tg-1", Type: "TargetGroup"})
+	g.AddNode(&graph.Node{ID: "tg-2", Type: "TargetGroup"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "tg-1", RelationType: "forwards-to"})
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, g); err != nil {
+		t.Fatalf("RenderJSON() error = %v", err)
+	}
+
+	var result GraphJSON
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("RenderJSON() produced invalid JSON: %v", err)
+	}
+
+	if result.Summary.Nodes != 3 || result.Summary.Edges != 1 {
+		t.Errorf("RenderJSON() summary = %d nodes, %d edges, want 3 and 1", result.Summary.Nodes, result.Summary.Edges)
+	}
+	if result.Summary.ByType["LoadBalancer"] != 1 || result.Summary.ByType["TargetGroup"] != 2 {
+		t.Errorf("RenderJSON() summary byType = %v", result.Summary.ByType)
+	}
+	if len(result.Summary.Components) != 2 || result.Summary.Components[0] != 2 || result.Summary.Components[1] != 1 {
+		t.Errorf("RenderJSON() summary components = %v, want [2 1]", result.Summary.Components)
+	}
+}
+
+func TestRenderJSONLevels(t *testing.T) {
+	// Same shape as the BFS fixture, plus an edge back to the start:
+	//     A
+	//    / \
+	//   B   C
+	//   |
+	//   D -> A
+	g := graph.New()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(&graph.Node{ID: id, Type: "Test", Name: "Node " + id})
+	}
+	g.AddEdge(&graph.Edge{From: "A", To: "B", RelationType: "uses"})
+	g.AddEdge(&graph.Edge{From: "A", To: "C", RelationType: "calls"})
+	g.AddEdge(&graph.Edge{From: "B", To: "D", RelationType: "reads"})
+	g.AddEdge(&graph.Edge{From: "D", To: "A", RelationType: "notifies"})
+
+	var buf bytes.Buffer
+	if err := RenderJSONLevels(&buf, g, "A"); err != nil {
+		t.Fatalf("RenderJSONLevels() error = %v", err)
+	}
+
+	var got LevelsJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("RenderJSONLevels() produced invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.StartID != "A" {
+		t.Errorf("startId = %q, want A", got.StartID)
+	}
+
+	type reached struct{ id, from, relation string }
+	want := [][]reached{
+		{{id: "A"}},
+		{{id: "B", from: "A", relation: "uses"}, {id: "C", from: "A", relation: "calls"}},
+		{{id: "D", from: "B", relation: "reads"}},
+	}
+	if len(got.Levels) != len(want) {
+		t.Fatalf("levels = %d, want %d\n%s", len(got.Levels), len(want), buf.String())
+	}
+	for depth, wantNodes := range want {
+		level := got.Levels[depth]
+		if level.Depth != depth {
+			t.Errorf("levels[%d].depth = %d", depth, level.Depth)
+		}
+		if len(level.Nodes) != len(wantNodes) {
+			t.Fatalf("levels[%d] has %d nodes, want %d", depth, len(level.Nodes), len(wantNodes))
+		}
+		for i, w := range wantNodes {
+			node := level.Nodes[i]
+			if node.Node == nil || node.ID != w.id || node.ReachedFrom != w.from || node.Relation != w.relation {
+				t.Errorf("levels[%d].nodes[%d] = %+v, want %+v", depth, i, node, w)
+			}
+		}
+	}
+}
+
+func TestRenderJSONTree(t *testing.T) {
+	//     A
+	//    / \
+	//   B   C
+	//    \ /
+	//     D -> A
+	g := graph.New()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(&graph.Node{ID: id, Type: "Test", Name: "Node " + id})
+	}
+	g.AddEdge(&graph.Edge{From: "A", To: "B", RelationType: "uses"})
+	g.AddEdge(&graph.Edge{From: "A", To: "C", RelationType: "calls"})
+	g.AddEdge(&graph.Edge{From: "B", To: "D", RelationType: "reads"})
+	g.AddEdge(&graph.Edge{From: "C", To: "D", RelationType: "writes"})
+	g.AddEdge(&graph.Edge{From: "D", To: "A", RelationType: "notifies"})
+	g.MarkTruncated("max-depth reached")
+
+	var buf bytes.Buffer
+	if err := RenderJSONTree(&buf, g, "A"); err != nil {
+		t.Fatalf("RenderJSONTree() error = %v", err)
+	}
+
+	var got TreeJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("RenderJSONTree() produced invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.SchemaVersion != SchemaVersion || !got.Truncated || got.TruncationReason != "max-depth reached" {
+		t.Errorf("header = %q, %v, %q", got.SchemaVersion, got.Truncated, got.TruncationReason)
+	}
+	if got.Root == nil || got.Root.Node == nil || got.Root.Name != "Node A" {
+		t.Fatalf("root = %+v, want node A\n%s", got.Root, buf.String())
+	}
+
+	// D is shared by B and C and closes a cycle back to A, so both are expanded only once
+	want := "A(uses B(reads D(notifies ^A)), calls C(writes ^D))"
+	if describe := describeTreeJSON(got.Root); describe != want {
+		t.Errorf("tree = %s, want %s", describe, want)
+	}
+}
+
+// describeTreeJSON writes a tree as ID(relation child, ...), with references as ^ID
+func describeTreeJSON(node *TreeNodeJSON) string {
+	if node.Ref != "" {
+		return "^" + node.Ref
+	}
+	if len(node.Dependencies) == 0 {
+		return node.ID
+	}
+	children := make([]string, 0, len(node.Dependencies))
+	for _, child := range node.Dependencies {
+		children = append(children, child.Relation+" "+describeTreeJSON(child))
+	}
+	return node.ID + "(" + strings.Join(children, ", ") + ")"
+}
+
+func TestRenderJSONTreeMissingStart(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderJSONTree(&buf, graph.New(), "missing"); err == nil {
+		t.Error("RenderJSONTree() expected an error for a missing start node")
+	}
+}
+
+func TestRenderJSONLevelsMissingStart(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderJSONLevels(&buf, graph.New(), "missing"); err == nil {
+		t.Error("RenderJSONLevels() expected an error for a missing start node")
+	}
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// Record kinds emitted by RenderJSONL
+const (
+	JSONLKindNode = "node"
+	JSONLKindEdge = "edge"
+)
+
+// jsonlNode is a node record in JSON Lines output
+type jsonlNode struct {
+	Kind string `json:"kind"`
+	*graph.Node
+}
+
+// jsonlEdge is an edge record in JSON Lines output
+type jsonlEdge struct {
+	Kind string `json:"kind"`
+	*graph.Edge
+}
+
+// RenderJSONL renders the graph as JSON Lines: one compact object per node, then one per edge.
+// Nodes are ordered by ID and edges by endpoints and relation, so the same graph always
+// produces the same lines.
+func RenderJSONL(w io.Writer, g *graph.Graph) error {
+	encoder := json.NewEncoder(w)
+
+	for _, node := range g.SortedNodes() {
+		if err := encoder.Encode(jsonlNode{Kind: JSONLKindNode, Node: node}); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range g.SortedEdges() {
+		if err := encoder.Encode(jsonlEdge{Kind: JSONLKindEdge, Edge: edge}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
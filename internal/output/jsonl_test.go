@@ -0,0 +1,126 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestRenderJSONL(t *testing.T) {
+	g := graph.New()
+
+	g.AddNode(&graph.Node{
+		ID:   "node-1",
+		Type: "LoadBalancer",
+		Name: "test-lb",
+		Metadata: map[string]any{
+			"dnsName": "test-lb.elb.amazonaws.com",
+		},
+	})
+	g.AddNode(&graph.Node{
+		ID:   "node-2",
+		Type: "TargetGroup",
+		Name: "test-tg",
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "node-1",
+		To:           "node-2",
+		RelationType: "forwards-to",
+		Evidence: graph.Evidence{
+			APICall: "DescribeTargetGroups",
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := RenderJSONL(&buf, g); err != nil {
+		t.Fatalf("RenderJSONL() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("RenderJSONL() expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	kinds := map[string]int{}
+	for i, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("RenderJSONL() line %d is not valid JSON: %v\n%s", i, err, line)
+		}
+
+		kind, _ := record["kind"].(string)
+		kinds[kind]++
+
+		switch kind {
+		case JSONLKindNode:
+			if record["ID"] == "" || record["Type"] == "" {
+				t.Errorf("node record missing ID/Type: %s", line)
+			}
+		case JSONLKindEdge:
+			if record["From"] != "node-1" || record["To"] != "node-2" || record["RelationType"] != "forwards-to" {
+				t.Errorf("unexpected edge record: %s", line)
+			}
+		default:
+			t.Errorf("unexpected record kind %q: %s", kind, line)
+		}
+	}
+
+	if kinds[JSONLKindNode] != 2 || kinds[JSONLKindEdge] != 1 {
+		t.Errorf("RenderJSONL() expected 2 nodes and 1 edge, got %v", kinds)
+	}
+
+	// Nodes are emitted before edges
+	if !strings.Contains(lines[2], `"kind":"edge"`) {
+		t.Errorf("RenderJSONL() expected edge record last, got %s", lines[2])
+	}
+}
+
+func TestRenderJSONLDeterministic(t *testing.T) {
+	g := graph.New()
+	for _, id := range []string{"c", "a", "d", "b"} {
+		g.AddNode(&graph.Node{ID: id, Type: "Test"})
+	}
+	g.AddEdge(&graph.Edge{From: "c", To: "d", RelationType: "uses"})
+	g.AddEdge(&graph.Edge{From: "a", To: "c", RelationType: "uses"})
+	g.AddEdge(&graph.Edge{From: "a", To: "b", RelationType: "uses"})
+
+	var first bytes.Buffer
+	if err := RenderJSONL(&first, g); err != nil {
+		t.Fatalf("RenderJSONL() error = %v", err)
+	}
+
+	var order []string
+	for _, line := range strings.Split(strings.TrimSpace(first.String()), "\n") {
+		var record struct {
+			Kind string `json:"kind"`
+			ID   string
+			From string
+			To   string
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("RenderJSONL() line is not valid JSON: %v\n%s", err, line)
+		}
+		if record.Kind == JSONLKindNode {
+			order = append(order, record.ID)
+		} else {
+			order = append(order, record.From+">"+record.To)
+		}
+	}
+	want := "a,b,c,d,a>b,a>c,c>d"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("RenderJSONL() order = %s, want %s", got, want)
+	}
+
+	for i := 0; i < 5; i++ {
+		var again bytes.Buffer
+		if err := RenderJSONL(&again, g); err != nil {
+			t.Fatalf("RenderJSONL() error = %v", err)
+		}
+		if again.String() != first.String() {
+			t.Fatalf("RenderJSONL() output changed between runs:\n%s\nvs\n%s", first.String(), again.String())
+		}
+	}
+}
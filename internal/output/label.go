@@ -0,0 +1,59 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// labelFuncs are the functions available to node label templates
+var labelFuncs = template.FuncMap{
+	// meta returns a metadata value formatted as in tree output, or "" when it is missing
+	"meta": func(node *graph.Node, key string) string {
+		value, _ := metadataValue(node, key)
+		return value
+	},
+}
+
+// ParseLabelTemplate parses a text/template for node labels, executed with the *graph.Node as
+// its data: {{.Type}}, {{.Name}}, {{.ID}}, {{.ARN}}, {{.Region}}, {{.Account}} and
+// {{meta . "key"}} for a metadata value. The template is executed once against an empty node so
+// references to fields that do not exist fail here rather than while rendering.
+func ParseLabelTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("label").Funcs(labelFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse label template: %w", err)
+	}
+	if execErr := tmpl.Execute(io.Discard, &graph.Node{}); execErr != nil {
+		return nil, fmt.Errorf("failed to execute label template: %w", execErr)
+	}
+	return tmpl, nil
+}
+
+// labelTags returns the node's values for keys as "key=value" pairs in the order given, joined
+// by ", ". Keys the node has no tag for are left out.
+func labelTags(node *graph.Node, keys []string) string {
+	var pairs []string
+	for _, key := range keys {
+		if value, ok := node.Tags[key]; ok {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// customLabel executes tmpl for node. It reports false when tmpl is nil or fails, so renderers
+// fall back to their own label.
+func customLabel(tmpl *template.Template, node *graph.Node) (string, bool) {
+	if tmpl == nil {
+		return "", false
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, node); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestParseLabelTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{name: "fields", text: "{{.Name}} ({{.Type}})"},
+		{name: "metadata", text: `{{.Name}}{{with meta . "engine"}} [{{.}}]{{end}}`},
+		{name: "bad syntax", text: "{{.Name", wantErr: "failed to parse label template"},
+		{name: "unknown field", text: "{{.Nmae}}", wantErr: "can't evaluate field Nmae"},
+		{name: "unknown function", text: "{{upper .Name}}", wantErr: `function "upper" not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLabelTemplate(tt.text)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseLabelTemplate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseLabelTemplate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderWithLabelTemplate(t *testing.T) {
+	tmpl, err := ParseLabelTemplate("{{.Name}} ({{.Type}})")
+	if err != nil {
+		t.Fatalf("ParseLabelTemplate() error = %v", err)
+	}
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "svc", Type: "ECSService", Name: "api", Region: "us-east-1"})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "main", Region: "us-east-1"})
+	g.AddEdge(&graph.Edge{From: "svc", To: "db", RelationType: "connects-to"})
+
+	tests := []struct {
+		name   string
+		render func(*bytes.Buffer) error
+		want   []string
+	}{
+		{
+			name: "tree levels",
+			render: func(buf *bytes.Buffer) error {
+				return RenderTreeWithOptions(buf, g, "svc", TreeOptions{LabelTemplate: tmpl})
+			},
+			want: []string{"└─ api (ECSService)\n", "└─ main (RDSInstance) [connects-to]\n"},
+		},
+		{
+			name: "tree nested",
+			render: func(buf *bytes.Buffer) error {
+				return RenderTreeWithOptions(buf, g, "svc", TreeOptions{Style: TreeStyleNested, LabelTemplate: tmpl})
+			},
+			want: []string{"\napi (ECSService)\n", "└─ main (RDSInstance) [connects-to]\n"},
+		},
+		{
+			name: "dot",
+			render: func(buf *bytes.Buffer) error {
+				return RenderDOTWithOptions(buf, g, DOTOptions{LabelTemplate: tmpl})
+			},
+			want: []string{`label="api (ECSService)"`, `label="main (RDSInstance)"`},
+		},
+		{
+			name: "plantuml",
+			render: func(buf *bytes.Buffer) error {
+				return RenderPlantUMLWithOptions(buf, g, PlantUMLOptions{LabelTemplate: tmpl})
+			},
+			want: []string{`component "api (ECSService)" as n_svc <<ECSService>>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(&buf); err != nil {
+				t.Fatalf("render error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDOTLabelTemplateEscaping(t *testing.T) {
+	tmpl, err := ParseLabelTemplate("{{.Type}}\n\"{{.Name}}\"{{with meta . \"engine\"}}\n{{.}}{{end}}")
+	if err != nil {
+		t.Fatalf("ParseLabelTemplate() error = %v", err)
+	}
+	node := &graph.Node{ID: "db", Type: "RDSInstance", Name: "main", Metadata: map[string]any{"engine": "postgres"}}
+
+	want := `RDSInstance\n\"main\"\npostgres`
+	if got := (DOTOptions{LabelTemplate: tmpl}).nodeLabel(node); got != want {
+		t.Errorf("nodeLabel() = %q, want %q", got, want)
+	}
+	if got := (DOTOptions{}).nodeLabel(node); got != `RDSInstance\nmain` {
+		t.Errorf("default nodeLabel() = %q, want the type and name", got)
+	}
+}
+
+func TestRenderWithLabelTags(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "svc", Type: "ECSService", Name: "api", Tags: map[string]string{"owner": "alice", "team": "payments", "env": "prod"}})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "main", Tags: map[string]string{"owner": `bob "b"`}})
+	g.AddNode(&graph.Node{ID: "sg", Type: "SecurityGroup", Name: "sg-1"})
+	g.AddEdge(&graph.Edge{From: "svc", To: "db", RelationType: "connects-to"})
+	g.AddEdge(&graph.Edge{From: "svc", To: "sg", RelationType: "uses-security-group"})
+	tags := []string{"team", "owner"}
+
+	var tree bytes.Buffer
+	if err := RenderTreeWithOptions(&tree, g, "svc", TreeOptions{LabelTags: tags}); err != nil {
+		t.Fatalf("RenderTreeWithOptions() error = %v", err)
+	}
+	for _, want := range []string{
+		"└─ ECSService: api (team=payments, owner=alice)\n",
+		`├─ RDSInstance: main (owner=bob "b") [connects-to]`,
+		"└─ SecurityGroup: sg-1 [uses-security-group]\n",
+	} {
+		if !strings.Contains(tree.String(), want) {
+			t.Errorf("tree output missing %q:\n%s", want, tree.String())
+		}
+	}
+
+	var dot bytes.Buffer
+	if err := RenderDOTWithOptions(&dot, g, DOTOptions{LabelTags: tags}); err != nil {
+		t.Fatalf("RenderDOTWithOptions() error = %v", err)
+	}
+	for _, want := range []string{
+		`label="ECSService\napi\nteam=payments, owner=alice"`,
+		`label="RDSInstance\nmain\nowner=bob \"b\""`,
+		`label="SecurityGroup\nsg-1"`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("dot output missing %q:\n%s", want, dot.String())
+		}
+	}
+}
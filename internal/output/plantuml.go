@@ -0,0 +1,112 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// plantUMLInvalidAlias matches characters that are not allowed in PlantUML aliases
+var plantUMLInvalidAlias = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// PlantUMLOptions controls optional details in the PlantUML output
+type PlantUMLOptions struct {
+	// LabelTemplate, if set, replaces the node name as each component's label
+	// (see ParseLabelTemplate)
+	LabelTemplate *template.Template
+}
+
+// RenderPlantUML renders the graph as a PlantUML component diagram, grouping nodes
+// into one package per region
+func RenderPlantUML(w io.Writer, g *graph.Graph) error {
+	return RenderPlantUMLWithOptions(w, g, PlantUMLOptions{})
+}
+
+// RenderPlantUMLWithOptions renders the graph as a PlantUML component diagram, applying opts
+func RenderPlantUMLWithOptions(w io.Writer, g *graph.Graph, opts PlantUMLOptions) error {
+	nodes := g.SortedNodes()
+	aliases := plantUMLAliases(nodes)
+
+	fmt.Fprintln(w, "@startuml blast_radius")
+	fmt.Fprintln(w, "left to right direction")
+	fmt.Fprintln(w, "skinparam componentStyle rectangle")
+	fmt.Fprintln(w, "")
+
+	regions := make(map[string][]*graph.Node)
+	for _, node := range nodes {
+		regions[node.Region] = append(regions[node.Region], node)
+	}
+	regionNames := make([]string, 0, len(regions))
+	for region := range regions {
+		regionNames = append(regionNames, region)
+	}
+	sort.Strings(regionNames)
+
+	// Nodes without a region (e.g. IAM roles) are declared outside any package
+	for _, region := range regionNames {
+		indent := ""
+		if region != "" {
+			fmt.Fprintf(w, "package \"%s\" {\n", plantUMLEscape(region))
+			indent = "  "
+		}
+		for _, node := range regions[region] {
+			label, ok := customLabel(opts.LabelTemplate, node)
+			if !ok {
+				label = node.Name
+			}
+			label = strings.ReplaceAll(label, "\n", `\n`)
+			fmt.Fprintf(w, "%scomponent \"%s\" as %s <<%s>>\n",
+				indent, plantUMLEscape(label), aliases[node.ID], plantUMLEscape(node.Type))
+		}
+		if region != "" {
+			fmt.Fprintln(w, "}")
+		}
+	}
+
+	fmt.Fprintln(w, "")
+
+	// Render edges; heuristic edges are dotted
+	for _, edge := range g.SortedEdges() {
+		fromAlias, fromOK := aliases[edge.From]
+		toAlias, toOK := aliases[edge.To]
+		if !fromOK || !toOK {
+			continue
+		}
+
+		if edge.Evidence.Heuristic {
+			fmt.Fprintf(w, "%s ..> %s : %s (heuristic)\n", fromAlias, toAlias, edge.RelationType)
+		} else {
+			fmt.Fprintf(w, "%s --> %s : %s\n", fromAlias, toAlias, edge.RelationType)
+		}
+	}
+
+	fmt.Fprintln(w, "@enduml")
+	return nil
+}
+
+// plantUMLAliases assigns each node a stable alias derived from its ID. Nodes are expected in
+// sorted order so that IDs sanitizing to the same alias are numbered deterministically.
+func plantUMLAliases(nodes []*graph.Node) map[string]string {
+	aliases := make(map[string]string, len(nodes))
+	used := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		base := "n_" + plantUMLInvalidAlias.ReplaceAllString(node.ID, "_")
+		alias := base
+		for i := 2; used[alias]; i++ {
+			alias = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[alias] = true
+		aliases[node.ID] = alias
+	}
+	return aliases
+}
+
+// plantUMLEscape makes a value safe to use inside a quoted PlantUML string or stereotype
+func plantUMLEscape(s string) string {
+	return strings.NewReplacer(`"`, `'`, "<", "(", ">", ")").Replace(s)
+}
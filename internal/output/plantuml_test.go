@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestRenderPlantUML(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/abc", Type: "LoadBalancer", Name: "my-alb", Region: "us-east-1"})
+	g.AddNode(&graph.Node{ID: "tg-1", Type: "TargetGroup", Name: "my-tg", Region: "us-east-1"})
+	g.AddNode(&graph.Node{ID: "arn:aws:iam::123456789012:role/app", Type: "IAMRole", Name: "app"})
+	g.AddEdge(&graph.Edge{
+		From:         "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/abc",
+		To:           "tg-1",
+		RelationType: "forwards-to",
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "tg-1",
+		To:           "arn:aws:iam::123456789012:role/app",
+		RelationType: "assumes",
+		Evidence:     graph.Evidence{Heuristic: true},
+	})
+
+	var buf bytes.Buffer
+	if err := RenderPlantUML(&buf, g); err != nil {
+		t.Fatalf("RenderPlantUML() error = %v", err)
+	}
+	output := buf.String()
+
+	want := []string{
+		"@startuml",
+		"package \"us-east-1\" {",
+		"  component \"my-alb\" as n_arn_aws_elasticloadbalancing_us_east_1_123456789012_loadbalancer_app_my_alb_abc <<LoadBalancer>>",
+		"  component \"my-tg\" as n_tg_1 <<TargetGroup>>",
+		"\ncomponent \"app\" as n_arn_aws_iam__123456789012_role_app <<IAMRole>>",
+		"n_arn_aws_elasticloadbalancing_us_east_1_123456789012_loadbalancer_app_my_alb_abc --> n_tg_1 : forwards-to",
+		"n_tg_1 ..> n_arn_aws_iam__123456789012_role_app : assumes (heuristic)",
+		"@enduml",
+	}
+	for _, line := range want {
+		if !strings.Contains(output, line) {
+			t.Errorf("RenderPlantUML() missing %q:\n%s", line, output)
+		}
+	}
+}
+
+func TestPlantUMLAliasesCollision(t *testing.T) {
+	nodes := []*graph.Node{{ID: "a-b"}, {ID: "a/b"}, {ID: "a:b"}}
+	aliases := plantUMLAliases(nodes)
+
+	want := map[string]string{"a-b": "n_a_b", "a/b": "n_a_b_2", "a:b": "n_a_b_3"}
+	for id, alias := range want {
+		if aliases[id] != alias {
+			t.Errorf("plantUMLAliases()[%q] = %q, want %q", id, aliases[id], alias)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// Snapshot is a saved discovery result that can be re-rendered without calling AWS
+type Snapshot struct {
+	GraphJSON
+	RootID    string    `json:"rootId"`
+	Resource  string    `json:"resource"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WriteSnapshot saves the full graph along with the identifier it was discovered from
+func WriteSnapshot(w io.Writer, g *graph.Graph, resource string) error {
+	snapshot := Snapshot{
+		GraphJSON: newGraphJSON(g),
+		RootID:    g.Root(),
+		Resource:  resource,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot loads a snapshot written by WriteSnapshot and rebuilds its graph
+func ReadSnapshot(r io.Reader) (*Snapshot, *graph.Graph, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	g, err := graph.FromJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &snapshot, g, nil
+}
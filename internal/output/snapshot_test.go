@@ -0,0 +1,76 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{
+		ID:      "node-1",
+		Type:    "LoadBalancer",
+		Name:    "test-lb",
+		Account: "123456789012",
+		Region:  "us-east-1",
+	})
+	g.AddNode(&graph.Node{
+		ID:   "node-2",
+		Type: "TargetGroup",
+		Name: "test-tg",
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "node-1",
+		To:           "node-2",
+		RelationType: "forwards-to",
+		Evidence: graph.Evidence{
+			APICall: "DescribeListeners",
+		},
+	})
+	g.SetRoot("node-1")
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, g, "test-lb"); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	snapshot, restored, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+
+	if snapshot.Resource != "test-lb" {
+		t.Errorf("ReadSnapshot() resource = %q, want test-lb", snapshot.Resource)
+	}
+	if snapshot.CreatedAt.IsZero() {
+		t.Error("ReadSnapshot() expected createdAt to be set")
+	}
+	if restored.Root() != "node-1" {
+		t.Errorf("ReadSnapshot() root = %q, want node-1", restored.Root())
+	}
+	if restored.NodeCount() != 2 || restored.EdgeCount() != 1 {
+		t.Errorf("ReadSnapshot() got %d nodes, %d edges, want 2, 1", restored.NodeCount(), restored.EdgeCount())
+	}
+
+	node, ok := restored.GetNode("node-1")
+	if !ok {
+		t.Fatal("ReadSnapshot() missing node-1")
+	}
+	if node.Account != "123456789012" || node.Region != "us-east-1" {
+		t.Errorf("ReadSnapshot() node-1 = %+v, want account and region preserved", node)
+	}
+
+	// Restored graph renders the same tree as the original
+	var want, got bytes.Buffer
+	if err := RenderTree(&want, g, "node-1"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	if err := RenderTree(&got, restored, restored.Root()); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	if want.String() != got.String() {
+		t.Errorf("restored tree differs:\nwant:\n%s\ngot:\n%s", want.String(), got.String())
+	}
+}
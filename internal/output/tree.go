@@ -3,12 +3,59 @@ package output
 import (
 	"fmt"
 	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/pfrederiksen/blast-radius/internal/graph"
 )
 
+// Tree output styles
+const (
+	// TreeStyleLevels groups nodes into flat BFS depth levels
+	TreeStyleLevels = "levels"
+	// TreeStyleNested prints each node indented under the parent it was reached from
+	TreeStyleNested = "nested"
+)
+
+// TreeOptions controls optional details in the tree output
+type TreeOptions struct {
+	// ShowEvidence prints the API call and fields behind each node's incoming edge
+	ShowEvidence bool
+	// Style selects levels (default) or nested output
+	Style string
+	// InternetReachable marks the IDs of nodes reachable from an internet-facing entry point
+	InternetReachable map[string]bool
+	// LabelTemplate, if set, replaces the default "Type: Name" node label (see ParseLabelTemplate)
+	LabelTemplate *template.Template
+	// LabelTags appends the values of these tag keys to node labels, skipping tags a node lacks
+	LabelTags []string
+}
+
+// internetReachableMarker is appended to nodes flagged in TreeOptions.InternetReachable
+const internetReachableMarker = " ⚠ internet-reachable"
+
 // RenderTree renders the graph as a tree structure
 func RenderTree(w io.Writer, g *graph.Graph, startID string) error {
+	return RenderTreeWithOptions(w, g, startID, TreeOptions{})
+}
+
+// RenderTreeWithOptions renders the graph as a tree structure, applying opts
+func RenderTreeWithOptions(w io.Writer, g *graph.Graph, startID string, opts TreeOptions) error {
+	switch opts.Style {
+	case "", TreeStyleLevels:
+		return renderLevelTree(w, g, startID, opts)
+	case TreeStyleNested:
+		return renderNestedTree(w, g, startID, opts)
+	default:
+		return fmt.Errorf("unknown tree style: %s (must be levels or nested)", opts.Style)
+	}
+}
+
+// renderLevelTree prints nodes grouped by BFS depth from the start node
+func renderLevelTree(w io.Writer, g *graph.Graph, startID string, opts TreeOptions) error {
 	levels := g.BFS(startID)
 	if len(levels) == 0 {
 		return fmt.Errorf("starting node not found: %s", startID)
@@ -38,26 +85,271 @@ func RenderTree(w io.Writer, g *graph.Graph, startID string) error {
 				relType = fmt.Sprintf(" [%s]", edges[0].RelationType)
 			}
 
-			fmt.Fprintf(w, "%s %s: %s%s\n",
+			fmt.Fprintf(w, "%s %s%s%s\n",
 				prefix,
-				node.Type,
-				node.Name,
-				relType)
+				opts.nodeLabel(node),
+				relType,
+				opts.nodeMarkers(node))
 
 			// Show ARN if different from name
 			if node.ARN != "" && node.ARN != node.ID {
 				fmt.Fprintf(w, "   ARN: %s\n", node.ARN)
 			}
 
-			// Show metadata if present
-			if len(node.Metadata) > 0 {
-				for k, v := range node.Metadata {
-					fmt.Fprintf(w, "   %s: %v\n", k, v)
-				}
+			if opts.ShowEvidence && len(edges) > 0 {
+				renderEvidence(w, edges[0], "")
 			}
+
+			renderMetadata(w, node, "")
 		}
 	}
 
-	fmt.Fprintf(w, "\nSummary: %d nodes, %d edges\n", g.NodeCount(), g.EdgeCount())
+	renderTreeSummary(w, g)
 	return nil
 }
+
+// renderNestedTree prints a depth-first tree from the start node, each node indented under the
+// parent it was reached from. Nodes reached again through another path or a cycle are marked
+// (ref) and not expanded a second time.
+func renderNestedTree(w io.Writer, g *graph.Graph, startID string, opts TreeOptions) error {
+	root, ok := g.GetNode(startID)
+	if !ok {
+		return fmt.Errorf("starting node not found: %s", startID)
+	}
+
+	fmt.Fprintf(w, "\n%s%s\n", opts.nodeLabel(root), opts.nodeMarkers(root))
+	renderNodeDetails(w, root, nil, "", opts)
+
+	visited := map[string]bool{root.ID: true}
+	renderNestedChildren(w, g, root.ID, "", visited, opts)
+
+	renderTreeSummary(w, g)
+	return nil
+}
+
+// renderNestedChildren prints the targets of a node's outgoing edges beneath it
+func renderNestedChildren(w io.Writer, g *graph.Graph, nodeID, indent string, visited map[string]bool, opts TreeOptions) {
+	edges := g.EdgesFrom(nodeID)
+	for i, edge := range edges {
+		child, ok := g.GetNode(edge.To)
+		if !ok {
+			continue
+		}
+
+		branch, childIndent := "└─", indent+"   "
+		if i < len(edges)-1 {
+			branch, childIndent = "├─", indent+"│  "
+		}
+
+		if visited[child.ID] {
+			fmt.Fprintf(w, "%s%s %s [%s] (ref)%s\n", indent, branch, opts.nodeLabel(child), edge.RelationType, opts.nodeMarkers(child))
+			continue
+		}
+		visited[child.ID] = true
+
+		fmt.Fprintf(w, "%s%s %s [%s]%s\n", indent, branch, opts.nodeLabel(child), edge.RelationType, opts.nodeMarkers(child))
+		renderNodeDetails(w, child, edge, childIndent, opts)
+		renderNestedChildren(w, g, child.ID, childIndent, visited, opts)
+	}
+}
+
+// nodeLabel returns how a node is named on its line, "Type: Name" unless a label template is set.
+// LabelTags follow in parentheses, apart from the bracketed relation. Template output is kept
+// to one line so the tree stays aligned.
+func (opts TreeOptions) nodeLabel(node *graph.Node) string {
+	label, ok := customLabel(opts.LabelTemplate, node)
+	if ok {
+		label = strings.ReplaceAll(label, "\n", " ")
+	} else {
+		label = node.Type + ": " + node.Name
+	}
+	if tags := labelTags(node, opts.LabelTags); tags != "" {
+		label += " (" + strings.ReplaceAll(tags, "\n", " ") + ")"
+	}
+	return label
+}
+
+// nodeMarkers returns the warnings appended to a node's line: unhealthy targets, then exposure
+func (opts TreeOptions) nodeMarkers(node *graph.Node) string {
+	return unhealthyMarker(node) + opts.exposureMarker(node.ID)
+}
+
+// unhealthyMarker returns a warning with the number of unhealthy targets recorded on a target
+// group, or an empty string
+func unhealthyMarker(node *graph.Node) string {
+	unhealthy, _ := node.MetaInt("unhealthyTargets")
+	if unhealthy <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" ⚠ %d unhealthy", unhealthy)
+}
+
+// exposureMarker returns the internet-reachable marker for flagged nodes, or an empty string
+func (opts TreeOptions) exposureMarker(nodeID string) string {
+	if opts.InternetReachable[nodeID] {
+		return internetReachableMarker
+	}
+	return ""
+}
+
+// renderNodeDetails prints a node's ARN, the evidence of the edge it was reached by and its
+// metadata, each line prefixed with indent
+func renderNodeDetails(w io.Writer, node *graph.Node, edge *graph.Edge, indent string, opts TreeOptions) {
+	if node.ARN != "" && node.ARN != node.ID {
+		fmt.Fprintf(w, "%s   ARN: %s\n", indent, node.ARN)
+	}
+
+	if opts.ShowEvidence && edge != nil {
+		renderEvidence(w, edge, indent)
+	}
+
+	renderMetadata(w, node, indent)
+}
+
+// renderMetadata prints a node's metadata sorted by key, skipping empty values
+func renderMetadata(w io.Writer, node *graph.Node, indent string) {
+	keys := make([]string, 0, len(node.Metadata))
+	for k := range node.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if value, ok := metadataValue(node, k); ok {
+			fmt.Fprintf(w, "%s   %s: %s\n", indent, k, value)
+		}
+	}
+}
+
+// metadataValue formats one metadata value for display, dereferencing pointers. It reports false
+// for nil and empty values.
+func metadataValue(node *graph.Node, key string) (string, bool) {
+	if s, ok := node.MetaString(key); ok {
+		return s, s != ""
+	}
+	if i, ok := node.MetaInt(key); ok {
+		return strconv.FormatInt(i, 10), true
+	}
+	if b, ok := node.MetaBool(key); ok {
+		return strconv.FormatBool(b), true
+	}
+
+	v := node.Metadata[key]
+	if v == nil {
+		return "", false
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", false
+		}
+		v = rv.Elem().Interface()
+	}
+	return fmt.Sprintf("%v", v), true
+}
+
+// renderTreeSummary prints node and edge counts with truncation and isolated node warnings
+func renderTreeSummary(w io.Writer, g *graph.Graph) {
+	fmt.Fprintf(w, "\nSummary: %d nodes, %d edges\n", g.NodeCount(), g.EdgeCount())
+	renderTypeCounts(w, g.CountByType())
+
+	if sizes := componentSizes(g); len(sizes) > 1 {
+		parts := make([]string, len(sizes))
+		for i, size := range sizes {
+			parts[i] = fmt.Sprint(size)
+		}
+		fmt.Fprintf(w, "%d disconnected components (sizes %s)\n", len(sizes), strings.Join(parts, ", "))
+	}
+
+	if total, priced := estimatedMonthlyCost(g); priced > 0 {
+		fmt.Fprintf(w, "Estimated monthly cost: $%.2f across %d of %d resources (approximate, see costBasis)\n", total, priced, g.NodeCount())
+	}
+
+	if g.Truncated() {
+		fmt.Fprintf(w, "⚠ results truncated (%s)\n", g.TruncationReason())
+	}
+
+	if isolated := g.Isolated(); len(isolated) > 0 && g.NodeCount() > 1 {
+		fmt.Fprintf(w, "Warning: %d isolated node(s) with no edges:\n", len(isolated))
+		for _, node := range isolated {
+			fmt.Fprintf(w, "   %s: %s\n", node.Type, node.Name)
+		}
+	}
+}
+
+// costMetadataKey is the metadata key --with-cost records estimates under, cost.MetadataKey
+const costMetadataKey = "estimatedMonthlyUsd"
+
+// estimatedMonthlyCost sums the monthly cost estimates recorded on the nodes of g and counts
+// the nodes priced
+func estimatedMonthlyCost(g *graph.Graph) (float64, int) {
+	total, priced := 0.0, 0
+	for _, node := range g.Nodes() {
+		if usd, ok := node.MetaFloat(costMetadataKey); ok {
+			total += usd
+			priced++
+		}
+	}
+	return total, priced
+}
+
+// typeCountBarWidth is the width of the bar drawn for the most common node type
+const typeCountBarWidth = 30
+
+// renderTypeCounts prints a histogram of node counts by type, most common first
+func renderTypeCounts(w io.Writer, counts map[string]int) {
+	types := make([]string, 0, len(counts))
+	maxCount, nameWidth := 0, 0
+	for typ, n := range counts {
+		types = append(types, typ)
+		maxCount = max(maxCount, n)
+		nameWidth = max(nameWidth, len(typ))
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	countWidth := len(fmt.Sprint(maxCount))
+	for _, typ := range types {
+		bar := max(1, counts[typ]*typeCountBarWidth/maxCount)
+		fmt.Fprintf(w, "   %-*s %*d %s\n", nameWidth, typ, countWidth, counts[typ], strings.Repeat("█", bar))
+	}
+}
+
+// renderEvidence prints how an edge was discovered, flagging inferred relationships
+func renderEvidence(w io.Writer, edge *graph.Edge, indent string) {
+	source := edge.Evidence.APICall
+	if source == "" {
+		source = "unknown"
+	}
+	if edge.Evidence.Heuristic {
+		fmt.Fprintf(w, "%s   Evidence: %s [HEURISTIC - inferred, not confirmed]\n", indent, source)
+	} else {
+		fmt.Fprintf(w, "%s   Evidence: %s\n", indent, source)
+	}
+
+	keys := make([]string, 0, len(edge.Evidence.Fields))
+	for k := range edge.Evidence.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s     %s: %s\n", indent, k, formatEvidenceValue(edge.Evidence.Fields[k]))
+	}
+}
+
+// formatEvidenceValue prints pointer fields by value rather than address
+func formatEvidenceValue(v any) string {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "<nil>"
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%+v", rv.Interface())
+}
@@ -94,3 +94,327 @@ func TestRenderTreeNonexistentStart(t *testing.T) {
 		t.Error("RenderTree() expected error for nonexistent start node, got nil")
 	}
 }
+
+func TestRenderTreeIsolatedWarning(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "test-lb"})
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "test-tg"})
+	g.AddNode(&graph.Node{ID: "orphan", Type: "TargetGroup", Name: "orphan-tg"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "tg", RelationType: "forwards-to"})
+
+	var buf bytes.Buffer
+	if err := RenderTree(&buf, g, "lb"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Warning: 1 isolated node(s)") {
+		t.Errorf("RenderTree() missing isolated warning:\n%s", output)
+	}
+	if !strings.Contains(output, "TargetGroup: orphan-tg") {
+		t.Errorf("RenderTree() missing isolated node name:\n%s", output)
+	}
+	if !strings.Contains(output, "2 disconnected components (sizes 2, 1)") {
+		t.Errorf("RenderTree() missing component summary:\n%s", output)
+	}
+}
+
+func TestRenderTreeShowEvidence(t *testing.T) {
+	g := graph.New()
+	secretARN := "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-AbCdEf"
+	listenerARN := "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/test-alb/abc123/def456"
+
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "test-lb"})
+	g.AddNode(&graph.Node{ID: "listener", Type: "Listener", Name: "HTTPS:443"})
+	g.AddNode(&graph.Node{ID: "secret", Type: "SecretsManagerSecret", Name: "db"})
+	g.AddEdge(&graph.Edge{
+		From:         "lb",
+		To:           "listener",
+		RelationType: "has-listener",
+		Evidence: graph.Evidence{
+			APICall: "DescribeListeners",
+			Fields: map[string]any{
+				"ListenerArn": &listenerARN,
+				"Port":        443,
+			},
+		},
+	})
+	g.AddEdge(&graph.Edge{
+		From:         "listener",
+		To:           "secret",
+		RelationType: "reads-secret",
+		Evidence: graph.Evidence{
+			APICall:   "GetFunction",
+			Fields:    map[string]any{"EnvironmentVariable": secretARN},
+			Heuristic: true,
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := RenderTreeWithOptions(&buf, g, "lb", TreeOptions{ShowEvidence: true}); err != nil {
+		t.Fatalf("RenderTreeWithOptions() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"Evidence: DescribeListeners\n",
+		"ListenerArn: " + listenerARN,
+		"Port: 443",
+		"Evidence: GetFunction [HEURISTIC",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("RenderTreeWithOptions() missing %q in output:\n%s", want, output)
+		}
+	}
+
+	// Evidence is hidden by default
+	buf.Reset()
+	if err := RenderTree(&buf, g, "lb"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Evidence:") {
+		t.Errorf("RenderTree() should not show evidence by default:\n%s", buf.String())
+	}
+}
+
+func TestRenderTreeTruncated(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "test-lb"})
+	g.MarkTruncated("max-nodes reached")
+
+	var buf bytes.Buffer
+	if err := RenderTree(&buf, g, "lb"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "⚠ results truncated (max-nodes reached)") {
+		t.Errorf("RenderTree() missing truncation warning:\n%s", buf.String())
+	}
+}
+
+func TestRenderTreeNested(t *testing.T) {
+	// Diamond: lb -> listener-a/listener-b -> tg, plus a cycle back to lb
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "test-lb"})
+	g.AddNode(&graph.Node{ID: "a", Type: "Listener", Name: "HTTP:80"})
+	g.AddNode(&graph.Node{ID: "b", Type: "Listener", Name: "HTTPS:443"})
+	g.AddNode(&graph.Node{ID: "tg", Type: "TargetGroup", Name: "test-tg"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "a", RelationType: "has-listener"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "b", RelationType: "has-listener"})
+	g.AddEdge(&graph.Edge{From: "a", To: "tg", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "b", To: "tg", RelationType: "routes-to"})
+	g.AddEdge(&graph.Edge{From: "tg", To: "lb", RelationType: "attached-to"})
+
+	var buf bytes.Buffer
+	if err := RenderTreeWithOptions(&buf, g, "lb", TreeOptions{Style: TreeStyleNested}); err != nil {
+		t.Fatalf("RenderTreeWithOptions() error = %v", err)
+	}
+
+	want := []string{
+		"LoadBalancer: test-lb\n",
+		"├─ Listener: HTTP:80 [has-listener]\n",
+		"│  └─ TargetGroup: test-tg [forwards-to]\n",
+		"│     └─ LoadBalancer: test-lb [attached-to] (ref)\n",
+		"└─ Listener: HTTPS:443 [has-listener]\n",
+		"   └─ TargetGroup: test-tg [routes-to] (ref)\n",
+	}
+	output := buf.String()
+	for _, line := range want {
+		if !strings.Contains(output, line) {
+			t.Errorf("RenderTreeWithOptions() missing line %q:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "[Level") {
+		t.Errorf("RenderTreeWithOptions() nested output should not contain levels:\n%s", output)
+	}
+}
+
+func TestRenderTreeUnknownStyle(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "test-lb"})
+
+	var buf bytes.Buffer
+	if err := RenderTreeWithOptions(&buf, g, "lb", TreeOptions{Style: "sideways"}); err == nil {
+		t.Error("RenderTreeWithOptions() expected error for unknown style")
+	}
+}
+
+func TestRenderTreeInternetReachable(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "alb", Type: "LoadBalancer", Name: "public-alb"})
+	g.AddNode(&graph.Node{ID: "svc", Type: "ECSService", Name: "web"})
+	g.AddNode(&graph.Node{ID: "role", Type: "IAMRole", Name: "task-role"})
+	g.AddEdge(&graph.Edge{From: "alb", To: "svc", RelationType: "routes-to-target"})
+	g.AddEdge(&graph.Edge{From: "role", To: "svc", RelationType: "assumed-by"})
+
+	reachable := map[string]bool{"alb": true, "svc": true}
+
+	for _, style := range []string{TreeStyleLevels, TreeStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := RenderTreeWithOptions(&buf, g, "alb", TreeOptions{Style: style, InternetReachable: reachable})
+			if err != nil {
+				t.Fatalf("RenderTreeWithOptions() error = %v", err)
+			}
+
+			for _, line := range strings.Split(buf.String(), "\n") {
+				flagged := strings.Contains(line, "⚠ internet-reachable")
+				switch {
+				case strings.Contains(line, "public-alb"), strings.Contains(line, "ECSService: web"):
+					if !flagged {
+						t.Errorf("expected %q to be flagged", line)
+					}
+				case flagged:
+					t.Errorf("unexpected flag on %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTreeUnhealthyTargets(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "listener", Type: "Listener", Name: "https"})
+	g.AddNode(&graph.Node{ID: "tg-bad", Type: "TargetGroup", Name: "api",
+		Metadata: map[string]any{"healthyTargets": 1, "unhealthyTargets": 2, "unhealthy": true}})
+	// Loaded from a snapshot, counts decode as float64
+	g.AddNode(&graph.Node{ID: "tg-snap", Type: "TargetGroup", Name: "worker",
+		Metadata: map[string]any{"unhealthyTargets": float64(1)}})
+	g.AddNode(&graph.Node{ID: "tg-ok", Type: "TargetGroup", Name: "web",
+		Metadata: map[string]any{"healthyTargets": 3, "unhealthyTargets": 0}})
+	g.AddEdge(&graph.Edge{From: "listener", To: "tg-bad", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "listener", To: "tg-snap", RelationType: "forwards-to"})
+	g.AddEdge(&graph.Edge{From: "listener", To: "tg-ok", RelationType: "forwards-to"})
+
+	for _, style := range []string{TreeStyleLevels, TreeStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderTreeWithOptions(&buf, g, "listener", TreeOptions{Style: style}); err != nil {
+				t.Fatalf("RenderTreeWithOptions() error = %v", err)
+			}
+			out := buf.String()
+
+			for _, want := range []string{"TargetGroup: api [forwards-to] ⚠ 2 unhealthy", "TargetGroup: worker [forwards-to] ⚠ 1 unhealthy"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "web [forwards-to] ⚠") {
+				t.Errorf("healthy target group flagged:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestRenderTreeMetadata(t *testing.T) {
+	engine := "postgres"
+	port := int32(5432)
+	var missing *string
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "svc", Type: "ECSService", Name: "api"})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "main",
+		Metadata: map[string]any{
+			"engine":        &engine,
+			"port":          &port,
+			"multiAZ":       true,
+			"storageGB":     float64(100),
+			"endpoint":      missing,
+			"kmsKeyId":      "",
+			"parameterList": nil,
+		}})
+	g.AddEdge(&graph.Edge{From: "svc", To: "db", RelationType: "connects-to"})
+
+	for _, style := range []string{TreeStyleLevels, TreeStyleNested} {
+		t.Run(style, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderTreeWithOptions(&buf, g, "svc", TreeOptions{Style: style}); err != nil {
+				t.Fatalf("RenderTreeWithOptions() error = %v", err)
+			}
+			out := buf.String()
+
+			want := "   engine: postgres\n"
+			if style == TreeStyleNested {
+				want = "      engine: postgres\n"
+			}
+			for _, line := range []string{want, "multiAZ: true\n", "port: 5432\n", "storageGB: 100\n"} {
+				if !strings.Contains(out, line) {
+					t.Errorf("expected output to contain %q, got:\n%s", line, out)
+				}
+			}
+			for _, key := range []string{"endpoint", "kmsKeyId", "parameterList", "0x"} {
+				if strings.Contains(out, key) {
+					t.Errorf("expected empty value %q to be skipped, got:\n%s", key, out)
+				}
+			}
+			// Keys are sorted in both styles
+			if strings.Index(out, "engine:") > strings.Index(out, "storageGB:") {
+				t.Errorf("metadata not sorted by key:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestRenderTreeTypeCounts(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "lb", Type: "LoadBalancer", Name: "lb"})
+	for _, id := range []string{"tg-1", "tg-2"} {
+		g.AddNode(&graph.Node{ID: id, Type: "TargetGroup", Name: id})
+		g.AddEdge(&graph.Edge{From: "lb", To: id, RelationType: "forwards-to"})
+	}
+	g.AddNode(&graph.Node{ID: "sg", Type: "SecurityGroup", Name: "sg"})
+	g.AddEdge(&graph.Edge{From: "lb", To: "sg", RelationType: "uses-security-group"})
+
+	var buf bytes.Buffer
+	if err := RenderTree(&buf, g, "lb"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	output := buf.String()
+
+	summary := output[strings.Index(output, "Summary:"):]
+	want := []string{
+		"   TargetGroup   2 " + strings.Repeat("█", 30),
+		"   LoadBalancer  1 " + strings.Repeat("█", 15),
+		"   SecurityGroup 1 " + strings.Repeat("█", 15),
+	}
+	// Most common types come first, ties ordered by name
+	last := -1
+	for _, line := range want {
+		idx := strings.Index(summary, line+"\n")
+		if idx < 0 {
+			t.Errorf("expected summary line %q, got:\n%s", line, summary)
+			continue
+		}
+		if idx < last {
+			t.Errorf("summary line %q out of order", line)
+		}
+		last = idx
+	}
+}
+
+func TestRenderTreeCostSummary(t *testing.T) {
+	g := graph.New()
+	g.AddNode(&graph.Node{ID: "svc", Type: "ECSService", Name: "api", Metadata: map[string]any{"estimatedMonthlyUsd": 30.42}})
+	g.AddNode(&graph.Node{ID: "db", Type: "RDSInstance", Name: "main", Metadata: map[string]any{"estimatedMonthlyUsd": 49.64}})
+	g.AddNode(&graph.Node{ID: "sg", Type: "SecurityGroup", Name: "web"})
+	g.AddEdge(&graph.Edge{From: "svc", To: "db", RelationType: "connects-to"})
+	g.AddEdge(&graph.Edge{From: "svc", To: "sg", RelationType: "uses"})
+
+	var buf bytes.Buffer
+	if err := RenderTree(&buf, g, "svc"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	want := "Estimated monthly cost: $80.06 across 2 of 3 resources (approximate, see costBasis)\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	g.RemoveNode("svc")
+	g.RemoveNode("db")
+	if err := RenderTree(&buf, g, "sg"); err != nil {
+		t.Fatalf("RenderTree() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "Estimated monthly cost") {
+		t.Errorf("expected no cost line without estimates, got:\n%s", buf.String())
+	}
+}
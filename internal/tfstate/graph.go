@@ -0,0 +1,106 @@
+package tfstate
+
+import (
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+	"github.com/pfrederiksen/blast-radius/internal/graph"
+)
+
+// RelationDependency is the relation of edges built from the dependencies Terraform records
+const RelationDependency = "dependency"
+
+// SeedFunc builds the node for a resource, keyed as discovery keys it, or returns nil when the
+// resource has no ARN or ID. Discoverer.SeedNode is one.
+type SeedFunc func(resourceType, arn, id, name string) *graph.Node
+
+// Graph builds a graph of the resources of a state and the dependencies between them without
+// any AWS calls. Resources are keyed by seed so the graph can be compared with a discovered one;
+// those without an ARN or ID, such as resources a plan has yet to create, are keyed by address,
+// and types without a node type become AWSResource nodes. The root is the first resource, in
+// address order, that nothing depends on.
+func Graph(resources []Resource, seed SeedFunc) *graph.Graph {
+	g := graph.New()
+
+	nodeIDs := make(map[string]string, len(resources))
+	for i := range resources {
+		resource := &resources[i]
+		node := resourceNode(resource, seed)
+		if existing, ok := g.GetNode(node.ID); ok {
+			// Several resources can share an ID; the first keeps the node
+			nodeIDs[resource.Address] = existing.ID
+			continue
+		}
+		g.AddNode(node)
+		nodeIDs[resource.Address] = node.ID
+	}
+
+	dependedOn := make(map[string]bool)
+	for i := range resources {
+		resource := &resources[i]
+		from := nodeIDs[resource.Address]
+		for _, dependency := range resource.DependsOn {
+			for j := range resources {
+				if !matchesAddress(resources[j].Address, dependency) {
+					continue
+				}
+				to := nodeIDs[resources[j].Address]
+				if to == from {
+					continue
+				}
+				g.AddEdge(&graph.Edge{
+					From:         from,
+					To:           to,
+					RelationType: RelationDependency,
+					Evidence: graph.Evidence{
+						APICall: "TerraformState",
+						Fields: map[string]any{
+							"address":   resource.Address,
+							"dependsOn": dependency,
+						},
+					},
+				})
+				dependedOn[to] = true
+			}
+		}
+	}
+
+	for i := range resources {
+		if id := nodeIDs[resources[i].Address]; !dependedOn[id] {
+			g.SetRoot(id)
+			break
+		}
+	}
+	return g
+}
+
+// resourceNode builds the node for one resource, recording its Terraform address and type
+func resourceNode(resource *Resource, seed SeedFunc) *graph.Node {
+	nodeType, name := resource.NodeType, resource.Name
+	if nodeType == "" {
+		nodeType, name = discover.ResourceTypeAWSResource, resource.Address
+	}
+
+	node := seed(nodeType, resource.ARN, resource.ID, name)
+	if node == nil {
+		node = &graph.Node{
+			ID:       resource.Address,
+			Type:     nodeType,
+			Name:     resource.Address,
+			Metadata: make(map[string]any),
+		}
+	}
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]any)
+	}
+	node.Metadata["terraformAddress"] = resource.Address
+	node.Metadata["terraformType"] = resource.Type
+	return node
+}
+
+// matchesAddress reports whether a resource instance address belongs to the resource block at
+// dependency, which has no index key
+func matchesAddress(address, dependency string) bool {
+	rest, ok := strings.CutPrefix(address, dependency)
+	return ok && (rest == "" || strings.HasPrefix(rest, "["))
+}
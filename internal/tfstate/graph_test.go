@@ -0,0 +1,81 @@
+package tfstate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/awsx"
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+func TestGraph(t *testing.T) {
+	resources, err := Parse(strings.NewReader(sampleState))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	// A planned resource without an ARN or ID yet
+	resources = append(resources, Resource{Address: "aws_sqs_queue.new", Type: "aws_sqs_queue", NodeType: discover.ResourceTypeSQSQueue})
+
+	seed := discover.New(&awsx.Clients{}, &discover.Options{}).SeedNode
+	g := Graph(resources, seed)
+
+	if g.NodeCount() != len(resources) {
+		t.Errorf("NodeCount() = %d, want %d", g.NodeCount(), len(resources))
+	}
+
+	const lambdaARN = "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	lambda, ok := g.GetNode(lambdaARN)
+	if !ok || lambda.Type != discover.ResourceTypeLambda || lambda.Metadata["terraformAddress"] != "aws_lambda_function.handler" {
+		t.Fatalf("Lambda node = %+v, want it keyed by ARN with its address", lambda)
+	}
+
+	// The dependency on the replica block reaches both instances; data sources are skipped
+	var targets []string
+	for _, node := range g.Neighbors(lambdaARN) {
+		targets = append(targets, node.ID)
+	}
+	want := "arn:aws:rds:us-east-1:123456789012:db:orders-0,arn:aws:rds:us-east-1:123456789012:db:orders-1,role-20240101"
+	if got := strings.Join(targets, ","); got != want {
+		t.Errorf("Neighbors(lambda) = %s, want %s", got, want)
+	}
+	for _, edge := range g.EdgesFrom(lambdaARN) {
+		if edge.RelationType != RelationDependency {
+			t.Errorf("edge %s -> %s relation = %s, want %s", edge.From, edge.To, edge.RelationType, RelationDependency)
+		}
+	}
+
+	attachment, _ := g.GetNode("role-20240101")
+	if attachment == nil || attachment.Type != discover.ResourceTypeAWSResource || attachment.Name != "aws_iam_role_policy_attachment.logs" {
+		t.Errorf("attachment node = %+v, want an AWSResource named by address", attachment)
+	}
+	if sg, ok := g.GetNode("SecurityGroup:123456789012:us-east-1:sg-1"); !ok || sg.Type != discover.ResourceTypeSecurityGroup {
+		t.Errorf("security group node = %+v, want it keyed by its scoped ID", sg)
+	}
+	if queue, ok := g.GetNode("aws_sqs_queue.new"); !ok || queue.Type != discover.ResourceTypeSQSQueue {
+		t.Errorf("planned queue node = %+v, want it keyed by address", queue)
+	}
+
+	// The ECS service comes first by address and nothing depends on it
+	if root := g.Root(); root != "arn:aws:ecs:us-east-1:123456789012:service/prod/api" {
+		t.Errorf("Root() = %s, want the ECS service", root)
+	}
+}
+
+func TestMatchesAddress(t *testing.T) {
+	tests := []struct {
+		address    string
+		dependency string
+		want       bool
+	}{
+		{"aws_subnet.private", "aws_subnet.private", true},
+		{"aws_subnet.private[0]", "aws_subnet.private", true},
+		{`module.net.aws_subnet.private["a"]`, "module.net.aws_subnet.private", true},
+		{"aws_subnet.private_b", "aws_subnet.private", false},
+		{"module.net.aws_subnet.private", "aws_subnet.private", false},
+	}
+	for _, tt := range tests {
+		if got := matchesAddress(tt.address, tt.dependency); got != tt.want {
+			t.Errorf("matchesAddress(%q, %q) = %v, want %v", tt.address, tt.dependency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package tfstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+// Resource is one instance of a managed AWS resource in a state
+type Resource struct {
+	// Address is the resource's address in the configuration, e.g. module.api.aws_lambda_function.handler["eu"]
+	Address string
+	// Type is the Terraform resource type, e.g. aws_lambda_function
+	Type string
+	// NodeType is the graph node type for Type, or empty when it has none
+	NodeType string
+	ARN      string
+	ID       string
+	Name     string
+	// DependsOn holds the addresses of the resources Terraform recorded this one depends on,
+	// without index keys, e.g. module.network.aws_subnet.private
+	DependsOn []string
+}
+
+// nodeTypes maps Terraform resource types to graph node types
+var nodeTypes = map[string]string{
+	"aws_lb":                               discover.ResourceTypeLoadBalancer,
+	"aws_alb":                              discover.ResourceTypeLoadBalancer,
+	"aws_lb_listener":                      discover.ResourceTypeListener,
+	"aws_alb_listener":                     discover.ResourceTypeListener,
+	"aws_lb_listener_rule":                 discover.ResourceTypeListenerRule,
+	"aws_alb_listener_rule":                discover.ResourceTypeListenerRule,
+	"aws_lb_target_group":                  discover.ResourceTypeTargetGroup,
+	"aws_alb_target_group":                 discover.ResourceTypeTargetGroup,
+	"aws_ecs_cluster":                      discover.ResourceTypeECSCluster,
+	"aws_ecs_service":                      discover.ResourceTypeECSService,
+	"aws_ecs_task_definition":              discover.ResourceTypeECSTaskDefinition,
+	"aws_service_discovery_service":        discover.ResourceTypeCloudMapService,
+	"aws_lambda_function":                  discover.ResourceTypeLambda,
+	"aws_lambda_layer_version":             discover.ResourceTypeLambdaLayer,
+	"aws_db_instance":                      discover.ResourceTypeRDSInstance,
+	"aws_rds_cluster":                      discover.ResourceTypeRDSCluster,
+	"aws_rds_global_cluster":               discover.ResourceTypeRDSGlobalCluster,
+	"aws_db_subnet_group":                  discover.ResourceTypeDBSubnetGroup,
+	"aws_api_gateway_rest_api":             discover.ResourceTypeAPIGatewayRestAPI,
+	"aws_apigatewayv2_api":                 discover.ResourceTypeAPIGatewayHTTPAPI,
+	"aws_cloudwatch_event_rule":            discover.ResourceTypeEventBridgeRule,
+	"aws_kms_key":                          discover.ResourceTypeKMSKey,
+	"aws_secretsmanager_secret":            discover.ResourceTypeSecretsManagerSecret,
+	"aws_ssm_parameter":                    discover.ResourceTypeSSMParameter,
+	"aws_acm_certificate":                  discover.ResourceTypeACMCertificate,
+	"aws_iam_role":                         discover.ResourceTypeIAMRole,
+	"aws_iam_policy":                       discover.ResourceTypeIAMPolicy,
+	"aws_wafv2_web_acl":                    discover.ResourceTypeWAFWebACL,
+	"aws_wafv2_rule_group":                 discover.ResourceTypeWAFRuleGroup,
+	"aws_wafv2_ip_set":                     discover.ResourceTypeWAFIPSet,
+	"aws_ecr_repository":                   discover.ResourceTypeECRRepository,
+	"aws_efs_file_system":                  discover.ResourceTypeEFSFileSystem,
+	"aws_efs_access_point":                 discover.ResourceTypeEFSAccessPoint,
+	"aws_kinesis_stream":                   discover.ResourceTypeKinesisStream,
+	"aws_kinesis_firehose_delivery_stream": discover.ResourceTypeFirehoseDeliveryStream,
+	"aws_sqs_queue":                        discover.ResourceTypeSQSQueue,
+	"aws_sns_topic":                        discover.ResourceTypeSNSTopic,
+	"aws_s3_bucket":                        discover.ResourceTypeS3Bucket,
+	"aws_sfn_state_machine":                discover.ResourceTypeStateMachine,
+	"aws_opensearch_domain":                discover.ResourceTypeOpenSearchDomain,
+	"aws_elasticsearch_domain":             discover.ResourceTypeOpenSearchDomain,
+	"aws_msk_cluster":                      discover.ResourceTypeKafkaCluster,
+	"aws_ses_configuration_set":            discover.ResourceTypeSESConfigurationSet,
+	"aws_sesv2_configuration_set":          discover.ResourceTypeSESConfigurationSet,
+	"aws_security_group":                   discover.ResourceTypeSecurityGroup,
+	"aws_subnet":                           discover.ResourceTypeSubnet,
+	"aws_vpc":                              discover.ResourceTypeVPC,
+	"aws_nat_gateway":                      discover.ResourceTypeNATGateway,
+	"aws_eip":                              discover.ResourceTypeElasticIP,
+	"aws_internet_gateway":                 discover.ResourceTypeInternetGateway,
+	"aws_instance":                         discover.ResourceTypeEC2Instance,
+	"aws_autoscaling_group":                discover.ResourceTypeAutoScalingGroup,
+	"aws_launch_template":                  discover.ResourceTypeLaunchTemplate,
+}
+
+// NodeType returns the graph node type for a Terraform resource type
+func NodeType(terraformType string) (string, bool) {
+	nodeType, ok := nodeTypes[terraformType]
+	return nodeType, ok
+}
+
+// state is the subset of a terraform.tfstate (format version 4) or `terraform show -json`
+// document, for a state or a saved plan, that is read
+type state struct {
+	Version       int             `json:"version"`
+	Resources     []stateResource `json:"resources"`
+	Values        *showValues     `json:"values"`
+	PlannedValues *showValues     `json:"planned_values"`
+	PriorState    *struct {
+		Values *showValues `json:"values"`
+	} `json:"prior_state"`
+}
+
+// showValues holds the root module of `terraform show -json` output
+type showValues struct {
+	RootModule showModule `json:"root_module"`
+}
+
+// stateResource is a resource block of a terraform.tfstate; resources in modules are listed
+// alongside root resources with their module address
+type stateResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Instances []struct {
+		IndexKey     any            `json:"index_key"`
+		Attributes   map[string]any `json:"attributes"`
+		Dependencies []string       `json:"dependencies"`
+	} `json:"instances"`
+}
+
+// showModule is a module of `terraform show -json` output, with its child modules nested
+type showModule struct {
+	Resources []struct {
+		Address   string         `json:"address"`
+		Mode      string         `json:"mode"`
+		Type      string         `json:"type"`
+		Values    map[string]any `json:"values"`
+		DependsOn []string       `json:"depends_on"`
+	} `json:"resources"`
+	ChildModules []showModule `json:"child_modules"`
+}
+
+// Load reads the managed AWS resources of the state file at path
+func Load(path string) ([]Resource, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is supplied by the user via --from-tfstate or import-tfstate
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads the managed AWS resources of a terraform.tfstate or `terraform show -json`
+// document, sorted by address. For a saved plan, the planned resources are read, with the
+// dependencies recorded in the prior state. Data sources and resources of other providers are
+// skipped.
+func Parse(r io.Reader) ([]Resource, error) {
+	var doc state
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	var resources []Resource
+	switch {
+	case doc.PlannedValues != nil:
+		resources = showResources(&doc.PlannedValues.RootModule)
+		if doc.PriorState != nil && doc.PriorState.Values != nil {
+			addPriorDependencies(resources, showResources(&doc.PriorState.Values.RootModule))
+		}
+	case doc.Values != nil:
+		resources = showResources(&doc.Values.RootModule)
+	case doc.Version == 4:
+		resources = stateResources(doc.Resources)
+	default:
+		return nil, fmt.Errorf("unsupported state file version %d (want 4, or terraform show -json output)", doc.Version)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Address < resources[j].Address
+	})
+	return resources, nil
+}
+
+// stateResources flattens the instances of terraform.tfstate resource blocks
+func stateResources(blocks []stateResource) []Resource {
+	var resources []Resource
+	for i := range blocks {
+		block := &blocks[i]
+		if !managedAWS(block.Mode, block.Type) {
+			continue
+		}
+
+		address := block.Type + "." + block.Name
+		if block.Module != "" {
+			address = block.Module + "." + address
+		}
+		for _, instance := range block.Instances {
+			resource := newResource(address+indexSuffix(instance.IndexKey), block.Type, instance.Attributes)
+			resource.DependsOn = instance.Dependencies
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// showResources collects the resources of a `terraform show -json` module and its children
+func showResources(module *showModule) []Resource {
+	var resources []Resource
+	for i := range module.Resources {
+		resource := &module.Resources[i]
+		if managedAWS(resource.Mode, resource.Type) {
+			r := newResource(resource.Address, resource.Type, resource.Values)
+			r.DependsOn = resource.DependsOn
+			resources = append(resources, r)
+		}
+	}
+	for i := range module.ChildModules {
+		resources = append(resources, showResources(&module.ChildModules[i])...)
+	}
+	return resources
+}
+
+// addPriorDependencies copies the dependencies of resources already in the prior state onto
+// the planned resources, since planned values do not record them
+func addPriorDependencies(planned, prior []Resource) {
+	dependencies := make(map[string][]string, len(prior))
+	for i := range prior {
+		dependencies[prior[i].Address] = prior[i].DependsOn
+	}
+	for i := range planned {
+		if planned[i].DependsOn == nil {
+			planned[i].DependsOn = dependencies[planned[i].Address]
+		}
+	}
+}
+
+// managedAWS reports whether a resource is managed (not a data source) by the AWS provider
+func managedAWS(mode, terraformType string) bool {
+	return mode == "managed" && strings.HasPrefix(terraformType, "aws_")
+}
+
+// newResource builds a Resource from an instance's attributes
+func newResource(address, terraformType string, attributes map[string]any) Resource {
+	resource := Resource{
+		Address: address,
+		Type:    terraformType,
+		ARN:     stringAttribute(attributes, "arn"),
+		ID:      stringAttribute(attributes, "id"),
+		Name:    stringAttribute(attributes, "name"),
+	}
+	resource.NodeType, _ = NodeType(terraformType)
+
+	// Some resources, such as ECS services, only carry their ARN as the ID
+	if resource.ARN == "" && strings.HasPrefix(resource.ID, "arn:") {
+		resource.ARN = resource.ID
+	}
+	return resource
+}
+
+// indexSuffix formats a count or for_each key as it appears in an address
+func indexSuffix(key any) string {
+	switch k := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return fmt.Sprintf("[%q]", k)
+	case float64:
+		return fmt.Sprintf("[%d]", int(k))
+	default:
+		return fmt.Sprintf("[%v]", k)
+	}
+}
+
+// stringAttribute returns a string attribute, or empty when it is missing or not a string
+func stringAttribute(attributes map[string]any, key string) string {
+	value, _ := attributes[key].(string)
+	return value
+}
@@ -0,0 +1,202 @@
+package tfstate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pfrederiksen/blast-radius/internal/discover"
+)
+
+const sampleState = `{
+  "version": 4,
+  "terraform_version": "1.9.5",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_lambda_function",
+      "name": "handler",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {"attributes": {"arn": "arn:aws:lambda:us-east-1:123456789012:function:orders", "id": "orders", "function_name": "orders"},
+         "dependencies": ["aws_iam_role_policy_attachment.logs", "data.aws_iam_policy_document.assume", "module.db.aws_db_instance.replica"]}
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_iam_policy_document",
+      "name": "assume",
+      "instances": [{"attributes": {"id": "123"}}]
+    },
+    {
+      "module": "module.db",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "replica",
+      "instances": [
+        {"index_key": 0, "attributes": {"arn": "arn:aws:rds:us-east-1:123456789012:db:orders-0", "id": "db-ABC"}},
+        {"index_key": 1, "attributes": {"arn": "arn:aws:rds:us-east-1:123456789012:db:orders-1", "id": "db-DEF"}}
+      ]
+    },
+    {
+      "module": "module.network.module.sg",
+      "mode": "managed",
+      "type": "aws_security_group",
+      "name": "this",
+      "instances": [
+        {"index_key": "web", "attributes": {"arn": "arn:aws:ec2:us-east-1:123456789012:security-group/sg-1", "id": "sg-1", "name": "web"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_ecs_service",
+      "name": "api",
+      "instances": [
+        {"attributes": {"id": "arn:aws:ecs:us-east-1:123456789012:service/prod/api", "name": "api"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_iam_role_policy_attachment",
+      "name": "logs",
+      "instances": [{"attributes": {"id": "role-20240101"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "random_id",
+      "name": "suffix",
+      "instances": [{"attributes": {"id": "abcd"}}]
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	resources, err := Parse(strings.NewReader(sampleState))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Resource{
+		{Address: "aws_ecs_service.api", Type: "aws_ecs_service", NodeType: discover.ResourceTypeECSService,
+			ARN: "arn:aws:ecs:us-east-1:123456789012:service/prod/api", ID: "arn:aws:ecs:us-east-1:123456789012:service/prod/api", Name: "api"},
+		{Address: "aws_iam_role_policy_attachment.logs", Type: "aws_iam_role_policy_attachment", ID: "role-20240101"},
+		{Address: "aws_lambda_function.handler", Type: "aws_lambda_function", NodeType: discover.ResourceTypeLambda,
+			ARN: "arn:aws:lambda:us-east-1:123456789012:function:orders", ID: "orders",
+			DependsOn: []string{"aws_iam_role_policy_attachment.logs", "data.aws_iam_policy_document.assume", "module.db.aws_db_instance.replica"}},
+		{Address: "module.db.aws_db_instance.replica[0]", Type: "aws_db_instance", NodeType: discover.ResourceTypeRDSInstance,
+			ARN: "arn:aws:rds:us-east-1:123456789012:db:orders-0", ID: "db-ABC"},
+		{Address: "module.db.aws_db_instance.replica[1]", Type: "aws_db_instance", NodeType: discover.ResourceTypeRDSInstance,
+			ARN: "arn:aws:rds:us-east-1:123456789012:db:orders-1", ID: "db-DEF"},
+		{Address: `module.network.module.sg.aws_security_group.this["web"]`, Type: "aws_security_group", NodeType: discover.ResourceTypeSecurityGroup,
+			ARN: "arn:aws:ec2:us-east-1:123456789012:security-group/sg-1", ID: "sg-1", Name: "web"},
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("Parse() returned %d resources, want %d: %+v", len(resources), len(want), resources)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(resources[i], want[i]) {
+			t.Errorf("Parse()[%d] = %+v, want %+v", i, resources[i], want[i])
+		}
+	}
+}
+
+func TestParseShowJSON(t *testing.T) {
+	const show = `{
+  "format_version": "1.0",
+  "values": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_kms_key.main", "mode": "managed", "type": "aws_kms_key", "name": "main",
+         "values": {"arn": "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", "id": "1234abcd-12ab-34cd-56ef-1234567890ab"}}
+      ],
+      "child_modules": [
+        {
+          "address": "module.queue",
+          "resources": [
+            {"address": "module.queue.aws_sqs_queue.jobs", "mode": "managed", "type": "aws_sqs_queue", "name": "jobs",
+             "values": {"arn": "arn:aws:sqs:us-east-1:123456789012:jobs", "id": "https://sqs.us-east-1.amazonaws.com/123456789012/jobs", "name": "jobs"}},
+            {"address": "module.queue.data.aws_caller_identity.current", "mode": "data", "type": "aws_caller_identity", "name": "current",
+             "values": {"id": "123456789012"}}
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+	resources, err := Parse(strings.NewReader(show))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("Parse() returned %d resources, want 2: %+v", len(resources), resources)
+	}
+	if resources[0].Address != "aws_kms_key.main" || resources[0].NodeType != discover.ResourceTypeKMSKey {
+		t.Errorf("Parse()[0] = %+v, want the KMS key", resources[0])
+	}
+	if resources[1].Address != "module.queue.aws_sqs_queue.jobs" || resources[1].NodeType != discover.ResourceTypeSQSQueue {
+		t.Errorf("Parse()[1] = %+v, want the nested SQS queue", resources[1])
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	const plan = `{
+  "format_version": "1.2",
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_ecs_service.api", "mode": "managed", "type": "aws_ecs_service", "name": "api",
+         "values": {"id": "arn:aws:ecs:us-east-1:123456789012:service/prod/api", "name": "api"}},
+        {"address": "aws_sqs_queue.jobs", "mode": "managed", "type": "aws_sqs_queue", "name": "jobs",
+         "values": {"name": "jobs"}}
+      ]
+    }
+  },
+  "prior_state": {
+    "values": {
+      "root_module": {
+        "resources": [
+          {"address": "aws_ecs_service.api", "mode": "managed", "type": "aws_ecs_service", "name": "api",
+           "values": {"id": "arn:aws:ecs:us-east-1:123456789012:service/prod/api"},
+           "depends_on": ["aws_sqs_queue.jobs"]}
+        ]
+      }
+    }
+  }
+}`
+
+	resources, err := Parse(strings.NewReader(plan))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("Parse() returned %d resources, want 2: %+v", len(resources), resources)
+	}
+	if !reflect.DeepEqual(resources[0].DependsOn, []string{"aws_sqs_queue.jobs"}) {
+		t.Errorf("Parse()[0].DependsOn = %v, want the prior state's dependencies", resources[0].DependsOn)
+	}
+	// The queue is yet to be created, so it has no ARN or dependencies
+	if resources[1].Address != "aws_sqs_queue.jobs" || resources[1].ARN != "" || resources[1].DependsOn != nil {
+		t.Errorf("Parse()[1] = %+v, want the planned queue", resources[1])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Invalid JSON", input: `{"version": 4,`, want: "failed to parse state file"},
+		{name: "Legacy state version", input: `{"version": 3, "modules": []}`, want: "unsupported state file version 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}